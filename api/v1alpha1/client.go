@@ -0,0 +1,182 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewScheme returns a scheme with the core Kubernetes types and the DWS types registered
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	if err := AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	return scheme, nil
+}
+
+// NewClient returns a client for the DWS resources. Integrators such as WLM plugins can use
+// it with the typed clients below rather than building an unstructured client.
+func NewClient(config *rest.Config) (client.Client, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+// NewCache returns an informer cache for the DWS resources. The cache must be started
+// before its informers deliver events.
+func NewCache(config *rest.Config, options cache.Options) (cache.Cache, error) {
+	if options.Scheme == nil {
+		scheme, err := NewScheme()
+		if err != nil {
+			return nil, err
+		}
+
+		options.Scheme = scheme
+	}
+
+	return cache.New(config, options)
+}
+
+// StripManagedFields is a cache transform that drops the managed fields of the cached
+// objects. On a large system they can be most of the memory the informers use, and the
+// controllers don't read them. Use it as cache.Options.DefaultTransform.
+func StripManagedFields(obj interface{}) (interface{}, error) {
+	if accessor, ok := obj.(metav1.Object); ok {
+		accessor.SetManagedFields(nil)
+	}
+
+	return obj, nil
+}
+
+// TypedClient gets, lists, and watches a single DWS resource type without type assertions.
+// It can read from a cached client or, for lists that shouldn't be cached, an API reader.
+// +kubebuilder:object:generate=false
+type TypedClient[T any, PT interface {
+	*T
+	client.Object
+}, L any, PL interface {
+	*L
+	client.ObjectList
+}] struct {
+	client client.Reader
+	items  func(*L) []T
+}
+
+// Get returns the resource with the key
+func (c *TypedClient[T, PT, L, PL]) Get(ctx context.Context, key client.ObjectKey) (*T, error) {
+	obj := PT(new(T))
+	if err := c.client.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+
+	return (*T)(obj), nil
+}
+
+// List returns the resources matching the options
+func (c *TypedClient[T, PT, L, PL]) List(ctx context.Context, opts ...client.ListOption) ([]T, error) {
+	list := PL(new(L))
+	if err := c.client.List(ctx, list, opts...); err != nil {
+		return nil, err
+	}
+
+	return c.items((*L)(list)), nil
+}
+
+// ListPages lists the resources matching the options from the API server pageSize at a time,
+// calling visit with each page, so a list of tens of thousands of resources isn't held in
+// memory at once. The list isn't paged if pageSize is 0. The cache doesn't page lists, so
+// use a client that reads from the API server.
+func (c *TypedClient[T, PT, L, PL]) ListPages(ctx context.Context, pageSize int64, visit func([]T) error, opts ...client.ListOption) error {
+	continueToken := ""
+	for {
+		list := PL(new(L))
+		pageOpts := append([]client.ListOption{}, opts...)
+		if pageSize > 0 {
+			pageOpts = append(pageOpts, client.Limit(pageSize), client.Continue(continueToken))
+		}
+
+		if err := c.client.List(ctx, list, pageOpts...); err != nil {
+			return err
+		}
+
+		if err := visit(c.items((*L)(list))); err != nil {
+			return err
+		}
+
+		continueToken = list.GetContinue()
+		if pageSize <= 0 || continueToken == "" {
+			return nil
+		}
+	}
+}
+
+// AddEventHandler registers handler with the informer for the resource type in informers.
+// The objects passed to the handler are of type *T.
+func (c *TypedClient[T, PT, L, PL]) AddEventHandler(ctx context.Context, informers cache.Cache, handler toolscache.ResourceEventHandler) error {
+	informer, err := informers.GetInformer(ctx, PT(new(T)))
+	if err != nil {
+		return err
+	}
+
+	informer.AddEventHandler(handler)
+
+	return nil
+}
+
+// ClientMounts returns a typed client for ClientMount resources
+func ClientMounts(c client.Reader) *TypedClient[ClientMount, *ClientMount, ClientMountList, *ClientMountList] {
+	return &TypedClient[ClientMount, *ClientMount, ClientMountList, *ClientMountList]{
+		client: c,
+		items:  func(list *ClientMountList) []ClientMount { return list.Items },
+	}
+}
+
+// Storages returns a typed client for Storage resources
+func Storages(c client.Reader) *TypedClient[Storage, *Storage, StorageList, *StorageList] {
+	return &TypedClient[Storage, *Storage, StorageList, *StorageList]{
+		client: c,
+		items:  func(list *StorageList) []Storage { return list.Items },
+	}
+}
+
+// DWDirectiveRules returns a typed client for DWDirectiveRule resources
+func DWDirectiveRules(c client.Reader) *TypedClient[DWDirectiveRule, *DWDirectiveRule, DWDirectiveRuleList, *DWDirectiveRuleList] {
+	return &TypedClient[DWDirectiveRule, *DWDirectiveRule, DWDirectiveRuleList, *DWDirectiveRuleList]{
+		client: c,
+		items:  func(list *DWDirectiveRuleList) []DWDirectiveRule { return list.Items },
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// storageClient returns canned Storage resources
+type storageClient struct {
+	client.Client
+	storages []Storage
+}
+
+func (c *storageClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	for _, storage := range c.storages {
+		if storage.Name == key.Name && storage.Namespace == key.Namespace {
+			*obj.(*Storage) = storage
+		}
+	}
+
+	return nil
+}
+
+// List returns the storages a page at a time when a limit is given. The continue token is the
+// index of the next storage.
+func (c *storageClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	options := client.ListOptions{}
+	options.ApplyOptions(opts)
+
+	start := 0
+	if options.Continue != "" {
+		start, _ = strconv.Atoi(options.Continue)
+	}
+
+	end := len(c.storages)
+	if options.Limit > 0 && start+int(options.Limit) < end {
+		end = start + int(options.Limit)
+		list.(*StorageList).Continue = strconv.Itoa(end)
+	}
+
+	list.(*StorageList).Items = c.storages[start:end]
+	return nil
+}
+
+func TestTypedClient(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &storageClient{storages: []Storage{{}, {}}}
+	c.storages[0].Name, c.storages[0].Namespace = "rabbit-0", "default"
+	c.storages[1].Name, c.storages[1].Namespace = "rabbit-1", "default"
+
+	storage, err := Storages(c).Get(context.TODO(), client.ObjectKey{Name: "rabbit-1", Namespace: "default"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(storage.Name).To(Equal("rabbit-1"))
+
+	storages, err := Storages(c).List(context.TODO())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(storages).To(HaveLen(2))
+}
+
+func TestTypedClientListPages(t *testing.T) {
+	g := NewWithT(t)
+
+	c := &storageClient{storages: make([]Storage, 5)}
+	for i := range c.storages {
+		c.storages[i].Name = "rabbit-" + strconv.Itoa(i)
+	}
+
+	for _, pageSize := range []int64{0, 2, 5, 10} {
+		pages, names := 0, []string{}
+		err := Storages(c).ListPages(context.TODO(), pageSize, func(storages []Storage) error {
+			pages++
+			for _, storage := range storages {
+				names = append(names, storage.Name)
+			}
+			return nil
+		})
+
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names).To(Equal([]string{"rabbit-0", "rabbit-1", "rabbit-2", "rabbit-3", "rabbit-4"}))
+		if pageSize == 2 {
+			g.Expect(pages).To(Equal(3))
+		} else {
+			g.Expect(pages).To(Equal(1))
+		}
+	}
+
+	// An error from the visit stops the list
+	pages := 0
+	err := Storages(c).ListPages(context.TODO(), 1, func(storages []Storage) error {
+		pages++
+		return errors.New("stop")
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(pages).To(Equal(1))
+}
+
+func TestTypedClientStripManagedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	storage := &Storage{}
+	storage.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "dws"}})
+
+	obj, err := StripManagedFields(storage)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(obj).To(BeIdenticalTo(storage))
+	g.Expect(storage.GetManagedFields()).To(BeNil())
+
+	// Objects without metadata, such as a deleted final state, are passed through
+	g.Expect(StripManagedFields("tombstone")).To(Equal("tombstone"))
+}
+
+func TestNewScheme(t *testing.T) {
+	g := NewWithT(t)
+
+	scheme, err := NewScheme()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(scheme.Recognizes(GroupVersion.WithKind("ClientMount"))).To(BeTrue())
+	g.Expect(scheme.Recognizes(GroupVersion.WithKind("DWDirectiveRule"))).To(BeTrue())
+}
+
+func TestNodeOSInfo(t *testing.T) {
+	g := NewWithT(t)
+
+	var info *NodeOSInfo
+	g.Expect(info.MissingKernelModules("gfs2")).To(BeEmpty())
+
+	info = &NodeOSInfo{KernelModules: []string{"gfs2", "xfs"}}
+	g.Expect(info.MissingKernelModules("gfs2")).To(Equal([]string{"dlm"}))
+	g.Expect(info.MissingKernelModules("xfs")).To(BeEmpty())
+	g.Expect(info.MissingKernelModules("tmpfs")).To(BeEmpty())
+}
+
+func TestFatalCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	conditions := []metav1.Condition{}
+	SetFatalCondition(&conditions, 1, "ClientMount default/compute-0", NewResourceError("retrying", nil))
+	g.Expect(meta.IsStatusConditionFalse(conditions, ConditionFatal)).To(BeTrue())
+
+	SetFatalCondition(&conditions, 1, "ClientMount default/compute-0", NewResourceError("bad device", nil).WithUserMessage("mount failed").WithFatal())
+	condition := meta.FindStatusCondition(conditions, ConditionFatal)
+	g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(condition.Message).To(Equal("ClientMount default/compute-0: mount failed"))
+
+	// The condition stays true once it's set
+	SetFatalCondition(&conditions, 2, "ClientMount default/compute-1", nil)
+	g.Expect(meta.IsStatusConditionTrue(conditions, ConditionFatal)).To(BeTrue())
+
+	status := ResourceError{Error: NewResourceError("retrying", nil)}
+	g.Expect(status.FatalError()).To(BeNil())
+	status.Error.WithFatal()
+	g.Expect(status.FatalError()).To(Equal(status.Error))
+}
+
+func TestResourceErrorRepeat(t *testing.T) {
+	g := NewWithT(t)
+
+	first := metav1.Unix(100, 0)
+	err := NewResourceError("mount failed", nil)
+	g.Expect(err.Repeat(nil, first)).To(BeFalse())
+	g.Expect(err.Count).To(Equal(1))
+
+	second := metav1.Unix(110, 0)
+	repeated := NewResourceError("mount failed", nil)
+	g.Expect(repeated.Repeat(err, second)).To(BeTrue())
+	g.Expect(repeated.Count).To(Equal(2))
+	g.Expect(repeated.FirstSeen.Equal(&first)).To(BeTrue())
+	g.Expect(repeated.LastSeen.Equal(&second)).To(BeTrue())
+
+	different := NewResourceError("unmount failed", nil)
+	g.Expect(different.Repeat(repeated, second)).To(BeFalse())
+	g.Expect(different.Count).To(Equal(1))
+	g.Expect(different.FirstSeen.Equal(&second)).To(BeTrue())
+}
+
+func TestClientMountProfileApply(t *testing.T) {
+	g := NewWithT(t)
+
+	profile := &ClientMountProfile{Spec: ClientMountProfileSpec{Options: "noatime,flock"}}
+	g.Expect(profile.Apply(ClientMountInfo{Options: "ro"}).Options).To(Equal("noatime,flock,ro"))
+	g.Expect(profile.Apply(ClientMountInfo{}).Options).To(Equal("noatime,flock"))
+
+	profile.Spec.Options = ""
+	g.Expect(profile.Apply(ClientMountInfo{Options: "ro"}).Options).To(Equal("ro"))
+}
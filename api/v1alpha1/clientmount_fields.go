@@ -0,0 +1,250 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var (
+	// commandNamePattern matches the names, addresses, and IDs passed to the mount commands
+	// (e.g., a volume group, an RBD image, or a list of Lustre NIDs)
+	commandNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.+@:%\[\]][A-Za-z0-9_.+@:%\[\],=-]*$`)
+
+	// commandPathPattern matches the absolute paths passed to the mount commands
+	commandPathPattern = regexp.MustCompile(`^/[A-Za-z0-9_.+@:%,=~/-]*$`)
+)
+
+// shellCharacters are refused in the free-form fields. The commands are run without a
+// shell, so they're only refused because no legitimate value uses them.
+const shellCharacters = ";&|$`<>\\"
+
+// commandField is a field of a mount that's passed to a command on the node
+type commandField struct {
+	path  *field.Path
+	value string
+	check func(string) error
+}
+
+// checkCommandName checks a name, address, or ID
+func checkCommandName(value string) error {
+	if !commandNamePattern.MatchString(value) {
+		return fmt.Errorf("must match %s", commandNamePattern)
+	}
+
+	return nil
+}
+
+// checkCommandPath checks a clean absolute path. The pattern allows '.' in names, so a path
+// with a '..' element or anything else filepath.Clean would change is refused separately.
+func checkCommandPath(value string) error {
+	if !commandPathPattern.MatchString(value) {
+		return fmt.Errorf("must be an absolute path matching %s", commandPathPattern)
+	}
+
+	return checkCleanPath(value)
+}
+
+// checkCommandSubPath checks a clean path relative to another path
+func checkCommandSubPath(value string) error {
+	if strings.HasPrefix(value, "/") || !commandPathPattern.MatchString("/"+value) {
+		return fmt.Errorf("must be a relative path matching %s", commandPathPattern)
+	}
+
+	return checkCleanPath(value)
+}
+
+// checkCleanPath checks that a path has no '..' elements and is unchanged by filepath.Clean
+func checkCleanPath(value string) error {
+	for _, element := range strings.Split(value, "/") {
+		if element == ".." {
+			return fmt.Errorf("must not contain '..'")
+		}
+	}
+
+	if filepath.Clean(value) != value {
+		return fmt.Errorf("must be a clean path (%s)", filepath.Clean(value))
+	}
+
+	return nil
+}
+
+// checkCommandText checks a free-form value such as a file system label, which may have
+// spaces. It can't be taken for a command option.
+func checkCommandText(value string) error {
+	if strings.HasPrefix(value, "-") {
+		return fmt.Errorf("must not start with '-'")
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) || strings.ContainsRune(shellCharacters, r) {
+			return fmt.Errorf("must not contain control characters or any of '%s'", shellCharacters)
+		}
+	}
+
+	return nil
+}
+
+// checkCommandOptions checks a comma separated list of mount options. Options may have
+// quoted values (e.g., an SELinux context), but not spaces.
+func checkCommandOptions(value string) error {
+	if strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("must not contain spaces")
+	}
+
+	return checkCommandText(value)
+}
+
+// checkFormatOptions checks the mkfs arguments, which are split into words at spaces
+func checkFormatOptions(value string) error {
+	for _, word := range strings.Fields(value) {
+		if err := checkCommandText(strings.TrimLeft(word, "-")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commandFields returns the fields of the mount that are passed to the commands on the node
+func (m *ClientMountInfo) commandFields(path *field.Path) []commandField {
+	fields := []commandField{
+		{path.Child("mountPath"), m.MountPath, checkCommandPath},
+		{path.Child("options"), m.Options, checkCommandOptions},
+	}
+
+	if m.Format != nil {
+		fields = append(fields, commandField{path.Child("format", "options"), m.Format.Options, checkFormatOptions})
+	}
+
+	if m.MountNamespace != nil {
+		fields = append(fields, commandField{path.Child("mountNamespace", "path"), m.MountNamespace.Path, checkCommandPath})
+	}
+
+	device := path.Child("device")
+	if lustre := m.Device.Lustre; lustre != nil {
+		fields = append(fields,
+			commandField{device.Child("lustre", "fileSystemName"), lustre.FileSystemName, checkCommandName},
+			commandField{device.Child("lustre", "mgsAddresses"), lustre.MgsAddresses, checkCommandName},
+		)
+
+		for i, node := range lustre.MgsNodes {
+			for j, nid := range node.NIDs {
+				fields = append(fields, commandField{device.Child("lustre", "mgsNodes").Index(i).Child("nids").Index(j), nid, checkCommandName})
+			}
+		}
+
+		if lustre.Squash != nil {
+			fields = append(fields, commandField{device.Child("lustre", "squash", "nodemap"), lustre.Squash.Nodemap, checkCommandName})
+		}
+	}
+
+	if lvm := m.Device.LVM; lvm != nil {
+		fields = append(fields,
+			commandField{device.Child("lvm", "volumeGroup"), lvm.VolumeGroup, checkCommandName},
+			commandField{device.Child("lvm", "logicalVolume"), lvm.LogicalVolume, checkCommandName},
+		)
+	}
+
+	if tmpfs := m.Device.Tmpfs; tmpfs != nil {
+		fields = append(fields, commandField{device.Child("tmpfs", "size"), tmpfs.Size, checkCommandName})
+	}
+
+	if swapFile := m.Device.SwapFile; swapFile != nil {
+		fields = append(fields, commandField{device.Child("swapFile", "path"), swapFile.Path, checkCommandPath})
+	}
+
+	if multipath := m.Device.Multipath; multipath != nil {
+		fields = append(fields,
+			commandField{device.Child("multipath", "wwid"), multipath.WWID, checkCommandName},
+			commandField{device.Child("multipath", "alias"), multipath.Alias, checkCommandName},
+		)
+	}
+
+	if nfs := m.Device.NFS; nfs != nil {
+		fields = append(fields,
+			commandField{device.Child("nfs", "server"), nfs.Server, checkCommandName},
+			commandField{device.Child("nfs", "exportPath"), nfs.ExportPath, checkCommandPath},
+			commandField{device.Child("nfs", "version"), nfs.Version, checkCommandName},
+		)
+	}
+
+	if block := m.Device.Block; block != nil {
+		fields = append(fields,
+			commandField{device.Child("block", "path"), block.Path, checkCommandPath},
+			commandField{device.Child("block", "uuid"), block.UUID, checkCommandName},
+			commandField{device.Child("block", "label"), block.Label, checkCommandText},
+			commandField{device.Child("block", "wwn"), block.WWN, checkCommandName},
+		)
+	}
+
+	if cephfs := m.Device.CephFS; cephfs != nil {
+		for i, monitor := range cephfs.Monitors {
+			fields = append(fields, commandField{device.Child("cephfs", "monitors").Index(i), monitor, checkCommandName})
+		}
+
+		fields = append(fields,
+			commandField{device.Child("cephfs", "path"), cephfs.Path, checkCommandPath},
+			commandField{device.Child("cephfs", "user"), cephfs.User, checkCommandName},
+			commandField{device.Child("cephfs", "fileSystem"), cephfs.FileSystem, checkCommandName},
+		)
+	}
+
+	if rbd := m.Device.RBD; rbd != nil {
+		fields = append(fields,
+			commandField{device.Child("rbd", "pool"), rbd.Pool, checkCommandName},
+			commandField{device.Child("rbd", "image"), rbd.Image, checkCommandName},
+			commandField{device.Child("rbd", "user"), rbd.User, checkCommandName},
+		)
+	}
+
+	if none := m.Device.None; none != nil {
+		fields = append(fields,
+			commandField{device.Child("none", "mountPoint"), none.MountPoint, checkCommandPath},
+			commandField{device.Child("none", "fileSystemType"), string(none.FileSystemType), checkCommandName},
+			commandField{device.Child("none", "subPath"), none.SubPath, checkCommandSubPath},
+		)
+	}
+
+	return fields
+}
+
+// ValidateCommandFields checks the fields of the mount that are passed to the commands on
+// the node against strict patterns. The commands aren't run through a shell, but a value
+// that could be taken for an option or that no real mount would use is refused.
+func (m *ClientMountInfo) ValidateCommandFields(path *field.Path) error {
+	for _, f := range m.commandFields(path) {
+		if f.value == "" {
+			continue
+		}
+
+		if err := f.check(f.value); err != nil {
+			return field.Invalid(f.path, f.value, err.Error())
+		}
+	}
+
+	return nil
+}
@@ -20,19 +20,115 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+	"github.com/HewlettPackard/dws/utils/status"
 	"github.com/HewlettPackard/dws/utils/updater"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// ClientMountLustreMgsNode defines a single Lustre MGS node by the LNet NIDs it can be reached at
+type ClientMountLustreMgsNode struct {
+	// LNet NIDs of the MGS node of the form [address]@[lnet] (e.g., "10.1.1.1@o2ib")
+	// +kubebuilder:validation:MinItems:=1
+	NIDs []string `json:"nids"`
+}
+
 // ClientMountDeviceLustre defines the lustre device information for mounting
 type ClientMountDeviceLustre struct {
 	// Lustre fsname
 	FileSystemName string `json:"fileSystemName"`
 
-	// List of mgsAddresses of the form [address]@[lnet]
-	MgsAddresses string `json:"mgsAddresses"`
+	// List of mgsAddresses of the form [address]@[lnet]. Ignored if MgsNodes is set.
+	MgsAddresses string `json:"mgsAddresses,omitempty"`
+
+	// MGS nodes in failover order. The first node is the primary MGS and the rest are
+	// failover nodes.
+	MgsNodes []ClientMountLustreMgsNode `json:"mgsNodes,omitempty"`
+
+	// Squash maps the users of the mount to other IDs. Multi-tenant systems use it to
+	// configure squashing per job at mount time. Nothing is squashed by the client if nil.
+	Squash *ClientMountLustreSquash `json:"squash,omitempty"`
+}
+
+// ClientMountLustreSquashMode selects the users of a Lustre mount whose IDs are squashed
+// +kubebuilder:validation:Enum=root;all
+type ClientMountLustreSquashMode string
+
+const (
+	// ClientMountLustreSquashRoot squashes only root
+	ClientMountLustreSquashRoot ClientMountLustreSquashMode = "root"
+
+	// ClientMountLustreSquashAll squashes every user
+	ClientMountLustreSquashAll ClientMountLustreSquashMode = "all"
+)
+
+// maxSquashID is the largest ID a user can be squashed to. The next ID is -1 as an unsigned
+// 32 bit integer, which means no ID.
+const maxSquashID = 4294967294
+
+// ClientMountLustreSquash defines the UID and GID squashing of a Lustre mount. The squash
+// options are passed to the Lustre client at mount time, and the nodemap of the file
+// system on the servers must allow them.
+type ClientMountLustreSquash struct {
+	// Mode is whether only root or every user is squashed
+	// +kubebuilder:default:=root
+	Mode ClientMountLustreSquashMode `json:"mode,omitempty"`
+
+	// UID the squashed users are mapped to
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=4294967294
+	UID int64 `json:"uid"`
+
+	// GID the squashed users are mapped to
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=4294967294
+	GID int64 `json:"gid"`
+
+	// Nodemap is the name of the nodemap on the servers the client expects to be a member
+	// of. It's a hint for the servers and the site's mount helper; the squash options are
+	// used without it if empty.
+	Nodemap string `json:"nodemap,omitempty"`
+}
+
+// squashOptionKeys are the mount options composed from ClientMountLustreSquash
+var squashOptionKeys = []string{"squash_uid", "squash_gid", "root_squash", "all_squash", "nodemap"}
+
+// Validate checks the squash IDs and mode
+func (s *ClientMountLustreSquash) Validate() error {
+	if s.Mode != "" && s.Mode != ClientMountLustreSquashRoot && s.Mode != ClientMountLustreSquashAll {
+		return fmt.Errorf("mode must be root or all")
+	}
+
+	if s.UID < 1 || s.UID > maxSquashID || s.GID < 1 || s.GID > maxSquashID {
+		return fmt.Errorf("uid and gid must be between 1 and %d", maxSquashID)
+	}
+
+	return nil
+}
+
+// Options returns the Lustre client mount options for the squash
+func (s *ClientMountLustreSquash) Options() []string {
+	options := []string{fmt.Sprintf("squash_uid=%d", s.UID), fmt.Sprintf("squash_gid=%d", s.GID)}
+
+	if s.Mode == ClientMountLustreSquashAll {
+		options = append(options, "all_squash")
+	} else {
+		options = append(options, "root_squash")
+	}
+
+	if s.Nodemap != "" {
+		options = append(options, "nodemap="+s.Nodemap)
+	}
+
+	return options
 }
 
 // ClientMountNVMeDesc uniquely describes an NVMe namespace
@@ -55,6 +151,22 @@ const (
 	ClientMountLVMDeviceTypeNVMe ClientMountLVMDeviceType = "nvme"
 )
 
+// ClientMountLVMActivationMode specifies how the VG is activated on the client
+type ClientMountLVMActivationMode string
+
+const (
+	// ClientMountLVMActivationModeExclusive activates the VG exclusively through the lock
+	// manager so no other host can activate it at the same time
+	ClientMountLVMActivationModeExclusive ClientMountLVMActivationMode = "exclusive"
+
+	// ClientMountLVMActivationModeShared activates the VG through the lock manager so it can
+	// be active on multiple hosts at once (e.g., for gfs2)
+	ClientMountLVMActivationModeShared ClientMountLVMActivationMode = "shared"
+
+	// ClientMountLVMActivationModeLocal activates the VG without the lock manager
+	ClientMountLVMActivationModeLocal ClientMountLVMActivationMode = "local"
+)
+
 // ClientMountDeviceLVM defines an LVM device by the VG/LV pair and optionally
 // the drives that are the PVs.
 type ClientMountDeviceLVM struct {
@@ -70,6 +182,25 @@ type ClientMountDeviceLVM struct {
 
 	// LVM logical volume name
 	LogicalVolume string `json:"logicalVolume,omitempty"`
+
+	// ActivationMode specifies how the VG is activated. If it isn't set, the VG is activated
+	// in shared mode for a gfs2 mount and in local mode for any other mount type.
+	// +kubebuilder:validation:Enum=exclusive;shared;local
+	ActivationMode ClientMountLVMActivationMode `json:"activationMode,omitempty"`
+}
+
+// ActivationModeFor returns the activation mode to use for the VG when it's mounted with
+// the mount type
+func (l *ClientMountDeviceLVM) ActivationModeFor(mountType FileSystemType) ClientMountLVMActivationMode {
+	if l.ActivationMode != "" {
+		return l.ActivationMode
+	}
+
+	if mountType == FileSystemTypeGFS2 {
+		return ClientMountLVMActivationModeShared
+	}
+
+	return ClientMountLVMActivationModeLocal
 }
 
 // ClientMountDeviceReference is an reference to a different Kubernetes object
@@ -82,6 +213,201 @@ type ClientMountDeviceReference struct {
 	Data int `json:"data,omitempty"`
 }
 
+// ClientMountDeviceTmpfs defines the tmpfs device information for mounting
+type ClientMountDeviceTmpfs struct {
+	// Size limit of the tmpfs as accepted by the tmpfs "size" mount option (e.g., "4g" or "50%").
+	// The kernel default is used if empty.
+	Size string `json:"size,omitempty"`
+}
+
+// ClientMountDeviceSwapFile defines a swap file created on the client
+type ClientMountDeviceSwapFile struct {
+	// Path of the swap file on the client
+	Path string `json:"path"`
+
+	// Size of the swap file in bytes
+	// +kubebuilder:validation:Minimum:=1
+	Size int64 `json:"size"`
+}
+
+// ClientMountDeviceMultipath defines a device-mapper multipath device. The device is found
+// by its WWID or by its alias under /dev/mapper.
+type ClientMountDeviceMultipath struct {
+	// WWID of the multipath device as reported by multipathd
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._:\-]+$`
+	WWID string `json:"wwid,omitempty"`
+
+	// Alias of the multipath map. Used to find the map when WWID is empty.
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._:\-]+$`
+	Alias string `json:"alias,omitempty"`
+
+	// ManageMap has the client add the multipath map with multipathd before mounting and
+	// remove it after unmounting
+	ManageMap bool `json:"manageMap,omitempty"`
+
+	// MinPaths is the number of active paths the map must have before it's mounted. The
+	// mount is retried until enough paths are active.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:default:=1
+	MinPaths int `json:"minPaths,omitempty"`
+}
+
+// ClientMountDeviceNFS defines an NFS export to mount
+type ClientMountDeviceNFS struct {
+	// Server is the host name or address of the NFS server
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._:\-\[\]]+$`
+	Server string `json:"server"`
+
+	// ExportPath is the path of the export on the server
+	// +kubebuilder:validation:Pattern:=`^/`
+	ExportPath string `json:"exportPath"`
+
+	// Version is the NFS protocol version passed in the "vers" mount option (e.g., "4.2").
+	// The client and server negotiate the version if empty.
+	// +kubebuilder:validation:Pattern:=`^[0-9]+(\.[0-9]+)?$`
+	Version string `json:"version,omitempty"`
+
+	// Automount has the client write an autofs direct map entry for the mount rather than
+	// mounting it. The export is mounted by autofs on first access. The client must be
+	// configured with a directory for the map entries.
+	Automount bool `json:"automount,omitempty"`
+}
+
+// Device returns the device string for the NFS mount command (e.g., "nfs1:/exports/data")
+func (n *ClientMountDeviceNFS) Device() string {
+	return n.Server + ":" + n.ExportPath
+}
+
+// ClientMountDeviceCephFS defines a CephFS file system to mount. The key of the user is
+// usually passed with a SecretRef that sets the "secret" or "secretfile" option.
+type ClientMountDeviceCephFS struct {
+	// Monitors are the addresses of the Ceph monitors (e.g., "10.0.0.1:6789")
+	// +kubebuilder:validation:MinItems=1
+	Monitors []string `json:"monitors"`
+
+	// Path in the file system to mount. Defaults to the root of the file system.
+	// +kubebuilder:validation:Pattern:=`^/`
+	Path string `json:"path,omitempty"`
+
+	// User is the Ceph user passed in the "name" mount option. The client's default user
+	// is used if empty.
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._\-]+$`
+	User string `json:"user,omitempty"`
+
+	// FileSystem is the name of the CephFS file system passed in the "fs" mount option. The
+	// default file system of the cluster is mounted if empty.
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._\-]+$`
+	FileSystem string `json:"fileSystem,omitempty"`
+}
+
+// Device returns the device string for the CephFS mount command (e.g., "10.0.0.1:6789,10.0.0.2:6789:/volumes/data")
+func (c *ClientMountDeviceCephFS) Device() string {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return strings.Join(c.Monitors, ",") + ":" + path
+}
+
+// ClientMountDeviceRBD defines a Ceph RBD image that the client maps to a block device
+// before mounting and unmaps after unmounting. The client finds the monitors and the key
+// of the user in its Ceph configuration.
+type ClientMountDeviceRBD struct {
+	// Pool of the image
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._\-]+$`
+	Pool string `json:"pool"`
+
+	// Image is the name of the RBD image
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._\-]+$`
+	Image string `json:"image"`
+
+	// User is the Ceph user the image is mapped with. The client's default user is used
+	// if empty.
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._\-]+$`
+	User string `json:"user,omitempty"`
+}
+
+// ImageSpec returns the image in the pool/image form taken by the rbd command
+func (r *ClientMountDeviceRBD) ImageSpec() string {
+	return r.Pool + "/" + r.Image
+}
+
+// ClientMountDeviceNone defines a file system that's mounted on the client outside of DWS,
+// such as a site-managed global Lustre file system. The client doesn't mount or unmount the
+// file system itself. It checks that the file system is mounted and bind mounts a
+// subdirectory of it at the mount path.
+type ClientMountDeviceNone struct {
+	// MountPoint is where the file system is mounted on the client (e.g., "/lus/global")
+	// +kubebuilder:validation:Pattern:=`^/`
+	MountPoint string `json:"mountPoint"`
+
+	// FileSystemType is the type the file system must be mounted as (e.g., "lustre"). Any
+	// type is accepted if empty.
+	FileSystemType FileSystemType `json:"fileSystemType,omitempty"`
+
+	// SubPath is the directory in the file system, relative to the mount point, that's bind
+	// mounted. The whole file system is bind mounted if empty.
+	SubPath string `json:"subPath,omitempty"`
+}
+
+// Device returns the path that's bind mounted at the mount path
+func (n *ClientMountDeviceNone) Device() string {
+	return filepath.Join(n.MountPoint, n.SubPath)
+}
+
+// Validate checks that the subdirectory is within the mount point
+func (n *ClientMountDeviceNone) Validate() error {
+	if n.SubPath == "" {
+		return nil
+	}
+
+	subPath := filepath.Clean(n.SubPath)
+	if filepath.IsAbs(n.SubPath) || subPath == ".." || strings.HasPrefix(subPath, "../") {
+		return fmt.Errorf("subPath must be a relative path within the mount point")
+	}
+
+	return nil
+}
+
+// ClientMountDeviceBlock defines a block device by a path or by a persistent identifier.
+// The /dev names of disks can change across reboots, so the identifiers are preferred.
+// Exactly one of the fields must be set. A device found by UUID or label already has a
+// file system, so only a device found by path or WWN can be formatted.
+type ClientMountDeviceBlock struct {
+	// Path of the device (e.g., /dev/sdb or /dev/disk/by-path/...)
+	// +kubebuilder:validation:Pattern:=`^/dev/`
+	Path string `json:"path,omitempty"`
+
+	// UUID of the file system on the device as reported by blkid
+	// +kubebuilder:validation:Pattern:=`^[A-Fa-f0-9-]+$`
+	UUID string `json:"uuid,omitempty"`
+
+	// Label of the file system on the device as reported by blkid
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9._:\-]+$`
+	Label string `json:"label,omitempty"`
+
+	// WWN of the disk, as found in the /dev/disk/by-id/wwn-* links (e.g., 0x5000c500a1b2c3d4)
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9.:\-]+$`
+	WWN string `json:"wwn,omitempty"`
+}
+
+// Validate checks that exactly one of the device identifiers is set
+func (b *ClientMountDeviceBlock) Validate() error {
+	set := 0
+	for _, id := range []string{b.Path, b.UUID, b.Label, b.WWN} {
+		if id != "" {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("exactly one of path, uuid, label, and wwn must be set")
+	}
+
+	return nil
+}
+
 // ClientMountDeviceType specifies the go type for device type
 type ClientMountDeviceType string
 
@@ -96,11 +422,39 @@ const (
 	// a separate Kubernetes resource. The clientmountd (or another controller doing the mounts)
 	// must know how to interpret the resource to extract the device information.
 	ClientMountDeviceTypeReference ClientMountDeviceType = "reference"
+
+	// ClientMountDeviceTypeTmpfs is used to define the device as a memory backed tmpfs
+	ClientMountDeviceTypeTmpfs ClientMountDeviceType = "tmpfs"
+
+	// ClientMountDeviceTypeSwapFile is used to define the device as a swap file that is
+	// created on the client
+	ClientMountDeviceTypeSwapFile ClientMountDeviceType = "swapfile"
+
+	// ClientMountDeviceTypeMultipath is used to define the device as a device-mapper
+	// multipath device
+	ClientMountDeviceTypeMultipath ClientMountDeviceType = "multipath"
+
+	// ClientMountDeviceTypeNFS is used to define the device as an NFS export
+	ClientMountDeviceTypeNFS ClientMountDeviceType = "nfs"
+
+	// ClientMountDeviceTypeBlock is used to define the device as a block device found by
+	// its path, file system UUID or label, or disk WWN
+	ClientMountDeviceTypeBlock ClientMountDeviceType = "block"
+
+	// ClientMountDeviceTypeCephFS is used to define the device as a CephFS file system
+	ClientMountDeviceTypeCephFS ClientMountDeviceType = "cephfs"
+
+	// ClientMountDeviceTypeRBD is used to define the device as a Ceph RBD image
+	ClientMountDeviceTypeRBD ClientMountDeviceType = "rbd"
+
+	// ClientMountDeviceTypeNone is used when the file system is already mounted on the
+	// client and a subdirectory of it is bind mounted
+	ClientMountDeviceTypeNone ClientMountDeviceType = "none"
 )
 
 // ClientMountDevice defines the device to mount
 type ClientMountDevice struct {
-	// +kubebuilder:validation:Enum=lustre;lvm;reference
+	// +kubebuilder:validation:Enum=lustre;lvm;reference;tmpfs;swapfile;multipath;nfs;block;cephfs;rbd;none
 	Type ClientMountDeviceType `json:"type"`
 
 	// Lustre specific device information
@@ -109,12 +463,57 @@ type ClientMountDevice struct {
 	// LVM logical volume specific device information
 	LVM *ClientMountDeviceLVM `json:"lvm,omitempty"`
 
+	// Tmpfs specific device information
+	Tmpfs *ClientMountDeviceTmpfs `json:"tmpfs,omitempty"`
+
+	// Swap file specific device information
+	SwapFile *ClientMountDeviceSwapFile `json:"swapFile,omitempty"`
+
+	// Multipath specific device information
+	Multipath *ClientMountDeviceMultipath `json:"multipath,omitempty"`
+
+	// NFS specific device information
+	NFS *ClientMountDeviceNFS `json:"nfs,omitempty"`
+
+	// Block device specific device information
+	Block *ClientMountDeviceBlock `json:"block,omitempty"`
+
+	// CephFS specific device information
+	CephFS *ClientMountDeviceCephFS `json:"cephfs,omitempty"`
+
+	// Ceph RBD specific device information
+	RBD *ClientMountDeviceRBD `json:"rbd,omitempty"`
+
+	// Information about the file system that's already mounted for a "none" device
+	None *ClientMountDeviceNone `json:"none,omitempty"`
+
 	DeviceReference *ClientMountDeviceReference `json:"deviceReference,omitempty"`
 }
 
+// ClientMountCreateOptions defines how the mount target is created and cleaned up
+type ClientMountCreateOptions struct {
+	// Mode is the octal permission mode of the mount target (e.g., "0755"). Defaults to
+	// "0755" for a directory and "0644" for a file.
+	// +kubebuilder:validation:Pattern:=`^0?[0-7]{3}$`
+	Mode string `json:"mode,omitempty"`
+
+	// PreserveOnUnmount leaves the mount target in place after unmounting
+	PreserveOnUnmount bool `json:"preserveOnUnmount,omitempty"`
+
+	// RecursiveCleanup removes the mount target and everything under it after unmounting.
+	// Without this, only an empty directory is removed.
+	RecursiveCleanup bool `json:"recursiveCleanup,omitempty"`
+}
+
+// ClientMountFormat defines how a block device is formatted before it's first mounted
+type ClientMountFormat struct {
+	// Options are extra arguments passed to mkfs (e.g., "-m 0" for ext4)
+	Options string `json:"options,omitempty"`
+}
+
 // ClientMountInfo defines a single mount
 type ClientMountInfo struct {
-	// Client path for mount target
+	// Client path for mount target. Not used for swap since swap space is activated rather than mounted.
 	MountPath string `json:"mountPath"`
 
 	// Options for the file system mount
@@ -124,15 +523,198 @@ type ClientMountInfo struct {
 	Device ClientMountDevice `json:"device"`
 
 	// mount type
-	// +kubebuilder:validation:Enum=lustre;xfs;gfs2;none
-	Type string `json:"type"`
+	Type FileSystemType `json:"type"`
+
+	// Format asks the client to create an xfs or ext4 file system on an LVM or multipath
+	// device before mounting it. Only a blank device is formatted. A device that already
+	// has a file system of the same type is mounted as is, and a device with any other
+	// signature is an error.
+	Format *ClientMountFormat `json:"format,omitempty"`
+
+	// TargetType determines whether the mount target is a file, a directory, or an existing
+	// device node
+	TargetType TargetType `json:"targetType"`
+
+	// Options for creating and cleaning up the mount target
+	CreateOptions *ClientMountCreateOptions `json:"createOptions,omitempty"`
 
-	// TargetType determines whether the mount target is a file or a directory
-	// +kubebuilder:validation:Enum=file;directory
-	TargetType string `json:"targetType"`
+	// Order sets the order the mounts are mounted in. Mounts with a lower order are mounted
+	// first, and mounts with the same order are mounted in the order they're listed. The
+	// mounts are unmounted in the reverse order.
+	// +optional
+	Order int `json:"order,omitempty"`
+
+	// DependsOn lists the mount paths of the other mounts in this ClientMount that must be
+	// mounted before this one (e.g., the file system under a bind mount). A mount isn't
+	// attempted until its dependencies are mounted, and its dependencies aren't unmounted
+	// until it's unmounted. DependsOn takes precedence over Order.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
 
 	// Compute is the name of the compute node which shares this mount if present. Empty if not shared.
 	Compute string `json:"compute,omitempty"`
+
+	// EnvName is the job environment variable that's set to the mount path once the mount
+	// is ready (e.g., DW_JOB_scratch). JobEnvName derives it from the #DW directive. The
+	// variable is published in status.env and in the status of the Workflow named by the
+	// workflow labels.
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z_][A-Za-z0-9_]*$`
+	EnvName string `json:"envName,omitempty"`
+
+	// Propagation sets the mount propagation of the mount point after it's mounted. The
+	// propagation is left as the node default if it's empty.
+	// +optional
+	Propagation ClientMountPropagation `json:"propagation,omitempty"`
+
+	// MountNamespace is the mount namespace the mount is made in. The mount is made in the
+	// mount namespace of the daemon if it's empty. This lets the client service compute
+	// environments where jobs run in their own mount namespace (e.g., containers).
+	// +optional
+	MountNamespace *ClientMountNamespace `json:"mountNamespace,omitempty"`
+
+	// Profile is the name of the ClientMountProfile with the site settings for the mount.
+	// The options of the profile are added before the options of the mount. The mount
+	// isn't attempted until the profile exists.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// SecretRef names a Secret with the credentials for the mount. The daemon adds the
+	// values from the Secret to the mount options when it runs the mount command.
+	// +optional
+	SecretRef *ClientMountSecretRef `json:"secretRef,omitempty"`
+}
+
+// ClientMountSecretRef names a Secret and the mount options set from its keys
+type ClientMountSecretRef struct {
+	// Name of the Secret. The Secret must be in the namespace of the ClientMount.
+	Name string `json:"name"`
+
+	// Options are the mount options set from the keys of the Secret
+	// +kubebuilder:validation:MinItems=1
+	Options []ClientMountSecretOption `json:"options"`
+}
+
+// ClientMountSecretOption sets a mount option from a key of a Secret
+type ClientMountSecretOption struct {
+	// Option is the name of the mount option (e.g., "secret" or "secretfile" for CephFS)
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9_.-]+$`
+	Option string `json:"option"`
+
+	// Key in the Secret with the value of the option
+	Key string `json:"key"`
+
+	// File writes the value to a file only readable by root and sets the option to the
+	// path of the file. This keeps the value off the command line of the mount.
+	// +optional
+	File bool `json:"file,omitempty"`
+}
+
+// ClientMountPropagation is the propagation type of a mount point
+// +kubebuilder:validation:Enum=private;shared;slave
+type ClientMountPropagation string
+
+// ClientMountPropagation string constants
+const (
+	ClientMountPropagationPrivate ClientMountPropagation = "private"
+	ClientMountPropagationShared  ClientMountPropagation = "shared"
+	ClientMountPropagationSlave   ClientMountPropagation = "slave"
+)
+
+// ClientMountNamespace identifies a mount namespace on the client. Exactly one of PID and
+// Path is set. The mount, unmount, and mount target commands are run in the namespace, so
+// the namespace must have the mount, umount, mkdir, touch, chmod, rmdir, and rm commands.
+type ClientMountNamespace struct {
+	// PID is a process in the mount namespace. The process must be running in the PID
+	// namespace of the client.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PID int `json:"pid,omitempty"`
+
+	// Path is a file that refers to the mount namespace (e.g., a bind mount of
+	// /proc/<pid>/ns/mnt made by the container runtime). A daemon with a privileged helper
+	// only accepts a file in a directory under /run whose name ends in "ns" (e.g.,
+	// /run/mntns/<name>) with a process still in the namespace.
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// NamespacePath returns the path of the namespace file for the mount namespace
+func (ns *ClientMountNamespace) NamespacePath() string {
+	if ns.Path != "" {
+		return ns.Path
+	}
+
+	return fmt.Sprintf("/proc/%d/ns/mnt", ns.PID)
+}
+
+// Validate checks that exactly one of PID and Path is set and that Path is absolute
+func (ns *ClientMountNamespace) Validate() error {
+	if (ns.PID == 0) == (ns.Path == "") {
+		return fmt.Errorf("exactly one of pid and path must be set")
+	}
+
+	if ns.Path != "" && !filepath.IsAbs(ns.Path) {
+		return fmt.Errorf("path '%s' is not absolute", ns.Path)
+	}
+
+	return nil
+}
+
+// FileSystemType is the type of file system mounted by a ClientMountInfo
+// +kubebuilder:validation:Enum=lustre;xfs;ext4;gfs2;swap;tmpfs;nfs;ceph;none
+type FileSystemType string
+
+// FileSystemType string constants
+const (
+	FileSystemTypeLustre FileSystemType = "lustre"
+	FileSystemTypeXFS    FileSystemType = "xfs"
+	FileSystemTypeExt4   FileSystemType = "ext4"
+	FileSystemTypeGFS2   FileSystemType = "gfs2"
+	FileSystemTypeSwap   FileSystemType = "swap"
+	FileSystemTypeTmpfs  FileSystemType = "tmpfs"
+	FileSystemTypeNFS    FileSystemType = "nfs"
+	FileSystemTypeCeph   FileSystemType = "ceph"
+	FileSystemTypeNone   FileSystemType = "none"
+)
+
+// IsSwap returns whether the file system is swap space, which is activated rather than
+// mounted and has no mount target
+func (t FileSystemType) IsSwap() bool {
+	return t == FileSystemTypeSwap
+}
+
+// IsShared returns whether the file system can be mounted by more than one node at a time
+func (t FileSystemType) IsShared() bool {
+	return t == FileSystemTypeLustre || t == FileSystemTypeGFS2 || t == FileSystemTypeNFS || t == FileSystemTypeCeph
+}
+
+// IsFormattable returns whether the client can create the file system on a blank device
+func (t FileSystemType) IsFormattable() bool {
+	return t == FileSystemTypeXFS || t == FileSystemTypeExt4
+}
+
+// TargetType is the type of the mount target of a ClientMountInfo
+// +kubebuilder:validation:Enum=file;directory;device
+type TargetType string
+
+// TargetType string constants
+const (
+	// TargetTypeDirectory is a directory that's created before mounting
+	TargetTypeDirectory TargetType = "directory"
+
+	// TargetTypeFile is a file that's created before mounting, typically for a bind mount
+	// of a single file
+	TargetTypeFile TargetType = "file"
+
+	// TargetTypeDevice is a device node that already exists. It's neither created nor
+	// removed by the client.
+	TargetTypeDevice TargetType = "device"
+)
+
+// IsCreated returns whether the client creates the mount target before mounting and
+// removes it after unmounting
+func (t TargetType) IsCreated() bool {
+	return t != TargetTypeDevice
 }
 
 // ClientMountState specifies the go type for MountState
@@ -156,16 +738,223 @@ type ClientMountSpec struct {
 	// List of mounts to create on this client
 	// +kubebuilder:validation:MinItems=1
 	Mounts []ClientMountInfo `json:"mounts"`
+
+	// DryRun asks the client to report the commands it would run to reach the desired state
+	// in status.dryRunCommands without running them. None of the mounts are ready while
+	// DryRun is set. Deleting the resource always unmounts.
+	// +kubebuilder:default:=false
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Suspended pauses reconciling the ClientMount. The mounts and their status are left as
+	// they are until it's cleared, and the Suspended condition is set. A suspended
+	// ClientMount that's deleted isn't unmounted until it's resumed.
+	// +kubebuilder:default:=false
+	Suspended bool `json:"suspended,omitempty"`
+
+	// ExpiresAt is the time after which the mounts are unmounted even if the creator never
+	// asks for it, so a crashed WLM can't strand the mounts on the node. The desired state
+	// is set to unmounted when the time passes. The mounts never expire if empty.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// Hurry asks the client to unmount as fast as it can rather than gracefully. The mounts
+	// are detached with a lazy unmount, so a busy mount doesn't hold up the teardown. It's
+	// set on the workflow's ClientMounts when the workflow is torn down in a hurry.
+	// +kubebuilder:default:=false
+	Hurry bool `json:"hurry,omitempty"`
+}
+
+// ExclusiveDevice returns an identifier for the device of a mount that may only be mounted
+// read-write on one node at a time, or "" if the mount can be shared. The file systems in
+// IsShared are safe to mount on many nodes, and so is any file system mounted read-only.
+// Only identifiers that name the same device on every node are returned, so block devices
+// found by path or label aren't checked.
+func (m *ClientMountInfo) ExclusiveDevice() string {
+	if m.Type.IsShared() || m.Type == FileSystemTypeNone || m.Type == FileSystemTypeTmpfs {
+		return ""
+	}
+
+	for _, option := range splitMountOptions(m.Options) {
+		if option == "ro" {
+			return ""
+		}
+	}
+
+	switch m.Device.Type {
+	case ClientMountDeviceTypeLVM:
+		if lvm := m.Device.LVM; lvm != nil && lvm.VolumeGroup != "" && lvm.LogicalVolume != "" {
+			return "lvm:" + lvm.VolumeGroup + "/" + lvm.LogicalVolume
+		}
+	case ClientMountDeviceTypeMultipath:
+		if multipath := m.Device.Multipath; multipath != nil && multipath.WWID != "" {
+			return "wwid:" + multipath.WWID
+		}
+	case ClientMountDeviceTypeRBD:
+		if rbd := m.Device.RBD; rbd != nil && rbd.Pool != "" && rbd.Image != "" {
+			return "rbd:" + rbd.ImageSpec()
+		}
+	case ClientMountDeviceTypeBlock:
+		if block := m.Device.Block; block != nil {
+			if block.UUID != "" {
+				return "uuid:" + block.UUID
+			}
+
+			if block.WWN != "" {
+				return "wwn:" + block.WWN
+			}
+		}
+	}
+
+	return ""
+}
+
+// MountOrder returns the indexes of the mounts in the order they're mounted. The order
+// respects DependsOn first, then Order, then the position in the list. An error is returned
+// if a dependency doesn't name another mount or the dependencies form a cycle.
+func (s *ClientMountSpec) MountOrder() ([]int, error) {
+	indexes := map[string]int{}
+	for i, mount := range s.Mounts {
+		indexes[mount.MountPath] = i
+	}
+
+	// dependents[i] are the mounts that depend on mount i
+	dependents := make([][]int, len(s.Mounts))
+	waiting := make([]int, len(s.Mounts))
+	for i, mount := range s.Mounts {
+		for _, dependency := range mount.DependsOn {
+			j, found := indexes[dependency]
+			if !found || j == i {
+				return nil, fmt.Errorf("mount '%s' depends on unknown mount '%s'", mount.MountPath, dependency)
+			}
+
+			dependents[j] = append(dependents[j], i)
+			waiting[i]++
+		}
+	}
+
+	order := make([]int, 0, len(s.Mounts))
+	done := make([]bool, len(s.Mounts))
+	for len(order) < len(s.Mounts) {
+		next := -1
+		for i, mount := range s.Mounts {
+			if done[i] || waiting[i] != 0 {
+				continue
+			}
+
+			if next == -1 || mount.Order < s.Mounts[next].Order {
+				next = i
+			}
+		}
+
+		if next == -1 {
+			return nil, fmt.Errorf("mount dependencies form a cycle")
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			waiting[dependent]--
+		}
+	}
+
+	return order, nil
 }
 
 // ClientMountInfoStatus is the status for a single mount point
 type ClientMountInfoStatus struct {
+	// MountPath is the mount path of the mount in the spec this status is for. The status
+	// of a mount is kept by its mount path when the mounts in the spec are edited.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+
 	// Current state
 	// +kubebuilder:validation:Enum=mounted;unmounted
 	State ClientMountState `json:"state"`
 
 	// Ready indicates whether status.state has been achieved
 	Ready bool `json:"ready"`
+
+	// MountStarted is the time the client started moving the mount to status.state. This
+	// is reset whenever the desired state changes, so it times unmounts as well as mounts.
+	// +optional
+	MountStarted *metav1.MicroTime `json:"mountStarted,omitempty"`
+
+	// MountCompleted is the time the mount reached status.state. It's cleared while the
+	// mount isn't ready.
+	// +optional
+	MountCompleted *metav1.MicroTime `json:"mountCompleted,omitempty"`
+
+	// Conditions of the mount. The Degraded condition is set when the client probes the
+	// mounted file system.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Plan is how the client mounts the file system, as resolved on the node. It's kept
+	// after the mount, so it records what was mounted.
+	// +optional
+	Plan *ClientMountPlan `json:"plan,omitempty"`
+
+	// Usage is the space and inodes used on the mounted file system, reported periodically
+	// if the client is configured to. It's cleared when the file system is unmounted.
+	// +optional
+	Usage *ClientMountUsage `json:"usage,omitempty"`
+}
+
+// ClientMountUsage is the space and inodes used on a mounted file system, as reported by
+// statfs on the client
+type ClientMountUsage struct {
+	// TotalBytes is the size of the file system
+	TotalBytes int64 `json:"totalBytes"`
+
+	// UsedBytes is the space in use
+	UsedBytes int64 `json:"usedBytes"`
+
+	// AvailableBytes is the space unprivileged users can still write. It can be less than
+	// the unused space when part of the file system is reserved for root.
+	AvailableBytes int64 `json:"availableBytes"`
+
+	// TotalInodes is the number of inodes. It's 0 for a file system without a fixed number.
+	TotalInodes int64 `json:"totalInodes"`
+
+	// FreeInodes is the number of inodes not in use
+	FreeInodes int64 `json:"freeInodes"`
+
+	// LastReported is when the usage was read
+	LastReported metav1.Time `json:"lastReported"`
+}
+
+// PercentUsed returns the percentage of the space that's used, rounded up, counting the
+// space reserved for root as used. It's 0 for a file system without a size.
+func (u *ClientMountUsage) PercentUsed() int {
+	if u.TotalBytes <= 0 {
+		return 0
+	}
+
+	unavailable := u.TotalBytes - u.AvailableBytes
+	return int((unavailable*100 + u.TotalBytes - 1) / u.TotalBytes)
+}
+
+// ClientMountPlan is how the client mounts a file system, resolved from the spec on the node
+type ClientMountPlan struct {
+	// Order is the position of the mount in the order the mounts are mounted in, starting
+	// at 0. The mounts are unmounted in the reverse order.
+	Order int `json:"order"`
+
+	// Device is the device the file system is mounted from, resolved on the node (e.g.,
+	// the path of the LVM logical volume or the list of Lustre MGS NIDs). It's set when
+	// the client mounts the file system, so it's empty for a mount that was already there.
+	// +optional
+	Device string `json:"device,omitempty"`
+
+	// Options is the full set of options the file system is mounted with, including the
+	// options from the device, the profile, and the Secret. The values from the Secret are
+	// replaced.
+	// +optional
+	Options string `json:"options,omitempty"`
+}
+
+// IsDegraded returns whether the mount is mounted but its file system doesn't respond
+func (s *ClientMountInfoStatus) IsDegraded() bool {
+	return meta.IsStatusConditionTrue(s.Conditions, ConditionDegraded)
 }
 
 // ClientMountStatus defines the observed state of ClientMount
@@ -173,12 +962,219 @@ type ClientMountStatus struct {
 	// List of mount statuses
 	Mounts []ClientMountInfoStatus `json:"mounts"`
 
+	// Number of mounts that have achieved their desired state
+	ReadyCount int `json:"readyCount"`
+
+	// MountStarted is the earliest time any of the mounts started moving to their state
+	// +optional
+	MountStarted *metav1.MicroTime `json:"mountStarted,omitempty"`
+
+	// MountCompleted is the time the last of the mounts reached its state. It's only set
+	// once all the mounts are ready.
+	// +optional
+	MountCompleted *metav1.MicroTime `json:"mountCompleted,omitempty"`
+
 	// Error information
 	ResourceError `json:",inline"`
+
+	// DryRunCommands are the commands the client would run to reach the desired state when
+	// spec.dryRun is set, in the order they would be run
+	// +optional
+	DryRunCommands []string `json:"dryRunCommands,omitempty"`
+
+	// Env holds the job environment variables of the mounts that are ready, keyed by
+	// spec.mounts[].envName
+	// +optional
+	Env map[string]string `json:"env,omitempty"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// maxSummaryErrors is the number of ClientMount errors kept in a ClientMountSummary, so
+// the status of a large job stays small
+const maxSummaryErrors = 8
+
+// ClientMountSummary is the readiness of a group of ClientMounts, such as the ClientMounts
+// of a workflow
+type ClientMountSummary struct {
+	// Total is the number of ClientMounts
+	Total int `json:"total"`
+
+	// Ready is the number of ClientMounts with every mount in its desired state
+	Ready int `json:"ready"`
+
+	// AllReady is true if every ClientMount is ready
+	AllReady bool `json:"allReady"`
+
+	// Errors are the errors of the ClientMounts that aren't ready, sorted by ClientMount.
+	// Only the first few errors are kept.
+	// +optional
+	Errors []ClientMountSummaryError `json:"errors,omitempty"`
+}
+
+// ClientMountSummaryError is the error of a ClientMount in a ClientMountSummary
+type ClientMountSummaryError struct {
+	// ClientMount is the namespace/name of the ClientMount
+	ClientMount string `json:"clientMount"`
+
+	// Error is the error from the status of the ClientMount
+	Error *ResourceErrorInfo `json:"error"`
+}
+
+// SummarizeClientMounts returns the readiness of the ClientMounts, or nil if there aren't
+// any
+func SummarizeClientMounts(clientMounts []ClientMount) *ClientMountSummary {
+	if len(clientMounts) == 0 {
+		return nil
+	}
+
+	summary := &ClientMountSummary{Total: len(clientMounts)}
+	for i := range clientMounts {
+		clientMount := &clientMounts[i]
+		if clientMount.IsReady() {
+			summary.Ready++
+		} else if clientMount.Status.Error != nil {
+			summary.Errors = append(summary.Errors, ClientMountSummaryError{
+				ClientMount: clientMount.Namespace + "/" + clientMount.Name,
+				Error:       clientMount.Status.Error.DeepCopy(),
+			})
+		}
+	}
+
+	summary.AllReady = summary.Ready == summary.Total
+
+	sort.Slice(summary.Errors, func(i, j int) bool { return summary.Errors[i].ClientMount < summary.Errors[j].ClientMount })
+	if len(summary.Errors) > maxSummaryErrors {
+		summary.Errors = summary.Errors[:maxSummaryErrors]
+	}
+
+	return summary
+}
+
+// UpdateReadyCount sets ReadyCount to the number of mount statuses that are ready
+func (s *ClientMountStatus) UpdateReadyCount() {
+	states := make([]status.State, len(s.Mounts))
+	for i, mount := range s.Mounts {
+		states[i] = status.FromReady(mount.Ready, status.Starting)
+	}
+
+	s.ReadyCount = status.Summarize(states, status.Policy{}).Ready
+}
+
+// UpdateTiming sets the start and completion times of the mounts and the roll-up times for
+// the ClientMount. A mount without a start time is started now, and a mount that became
+// ready is completed now. Clear MountStarted when changing the state of a mount to time
+// the new state.
+func (s *ClientMountStatus) UpdateTiming() {
+	now := metav1.NowMicro()
+
+	s.MountStarted = nil
+	s.MountCompleted = nil
+	completed := len(s.Mounts) != 0
+
+	for i := range s.Mounts {
+		mount := &s.Mounts[i]
+
+		if mount.MountStarted == nil {
+			mount.MountStarted = now.DeepCopy()
+		}
+
+		if !mount.Ready {
+			mount.MountCompleted = nil
+		} else if mount.MountCompleted == nil {
+			mount.MountCompleted = now.DeepCopy()
+		}
+
+		if s.MountStarted == nil || mount.MountStarted.Before(s.MountStarted) {
+			s.MountStarted = mount.MountStarted.DeepCopy()
+		}
+
+		if mount.MountCompleted == nil {
+			completed = false
+		} else if s.MountCompleted == nil || s.MountCompleted.Before(mount.MountCompleted) {
+			s.MountCompleted = mount.MountCompleted.DeepCopy()
+		}
+	}
+
+	if !completed {
+		s.MountCompleted = nil
+	}
+}
+
+// IsReady returns whether every mount has reached the desired state
+func (c *ClientMount) IsReady() bool {
+	ready := len(c.Status.Mounts) == len(c.Spec.Mounts)
+	for _, mount := range c.Status.Mounts {
+		if mount.State != c.Spec.DesiredState || !mount.Ready {
+			ready = false
+		}
+	}
+
+	return ready
+}
+
+// UpdateConditions sets the status conditions. The ClientMount is ready once every mount
+// has reached the desired state.
+func (c *ClientMount) UpdateConditions() {
+	SetReadyConditions(&c.Status.Conditions, c.Generation, c.IsReady(), c.Status.Error)
+	SetSuspendedCondition(&c.Status.Conditions, c.Generation, c.Spec.Suspended)
+}
+
+// UpdateEnv sets the job environment variables in the status for the mounts that are mounted
+// and ready
+func (c *ClientMount) UpdateEnv() {
+	env := map[string]string{}
+	for i, mount := range c.Spec.Mounts {
+		if mount.EnvName == "" || i >= len(c.Status.Mounts) {
+			continue
+		}
+
+		if c.Status.Mounts[i].State == ClientMountStateMounted && c.Status.Mounts[i].Ready {
+			env[mount.EnvName] = mount.MountPath
+		}
+	}
+
+	if len(env) == 0 {
+		env = nil
+	}
+
+	c.Status.Env = env
+}
+
+// JobEnvName returns the name of the job environment variable for the storage created by a
+// #DW directive. It's DW_JOB_[name] for "jobdw" and DW_PERSISTENT_[name] for "persistentdw".
+func JobEnvName(directive string) (string, error) {
+	args, err := dwdparse.BuildArgsMap(directive)
+	if err != nil {
+		return "", err
+	}
+
+	name, found := args["name"]
+	if !found {
+		return "", fmt.Errorf("directive '%s' has no name", directive)
+	}
+
+	switch args["command"] {
+	case "jobdw":
+		return "DW_JOB_" + name, nil
+	case "persistentdw":
+		return "DW_PERSISTENT_" + name, nil
+	}
+
+	return "", fmt.Errorf("directive command '%s' does not provide job storage", args["command"])
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="DESIREDSTATE",type="string",JSONPath=".spec.desiredState",description="The desired state"
+//+kubebuilder:printcolumn:name="READY",type="integer",JSONPath=".status.readyCount",description="Number of mounts that have achieved the desired state"
+//+kubebuilder:printcolumn:name="SUSPENDED",type="boolean",JSONPath=".spec.suspended",description="Whether reconciling is suspended",priority=1
+//+kubebuilder:printcolumn:name="ERROR",type="string",JSONPath=".status.error.debugMessage",description="Error message"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 
 // ClientMount is the Schema for the clientmounts API
 type ClientMount struct {
@@ -0,0 +1,266 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+func TestClientMountUpdateTiming(t *testing.T) {
+	g := NewWithT(t)
+
+	status := ClientMountStatus{Mounts: []ClientMountInfoStatus{
+		{State: ClientMountStateMounted, Ready: true},
+		{State: ClientMountStateMounted, Ready: false},
+	}}
+
+	status.UpdateTiming()
+	g.Expect(status.Mounts[0].MountStarted).ToNot(BeNil())
+	g.Expect(status.Mounts[0].MountCompleted).ToNot(BeNil())
+	g.Expect(status.Mounts[1].MountStarted).ToNot(BeNil())
+	g.Expect(status.Mounts[1].MountCompleted).To(BeNil())
+	g.Expect(status.MountStarted).ToNot(BeNil())
+	g.Expect(status.MountCompleted).To(BeNil())
+
+	completed := status.Mounts[0].MountCompleted.DeepCopy()
+
+	status.Mounts[1].Ready = true
+	status.UpdateTiming()
+	g.Expect(status.Mounts[0].MountCompleted).To(Equal(completed))
+	g.Expect(status.Mounts[1].MountCompleted).ToNot(BeNil())
+	g.Expect(status.MountCompleted).To(Equal(status.Mounts[1].MountCompleted))
+
+	// Changing the state restarts the timing
+	for i := range status.Mounts {
+		status.Mounts[i].State = ClientMountStateUnmounted
+		status.Mounts[i].Ready = false
+		status.Mounts[i].MountStarted = nil
+	}
+
+	status.UpdateTiming()
+	g.Expect(status.Mounts[0].MountCompleted).To(BeNil())
+	g.Expect(status.MountCompleted).To(BeNil())
+	g.Expect(status.MountStarted.Before(completed)).To(BeFalse())
+}
+
+func TestClientMountOrder(t *testing.T) {
+	g := NewWithT(t)
+
+	spec := ClientMountSpec{Mounts: []ClientMountInfo{
+		{MountPath: "/mnt/bind", DependsOn: []string{"/mnt/xfs"}},
+		{MountPath: "/mnt/late", Order: 10},
+		{MountPath: "/mnt/xfs", Order: 5},
+		{MountPath: "/mnt/first"},
+	}}
+
+	order, err := spec.MountOrder()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(order).To(Equal([]int{3, 2, 0, 1}))
+
+	spec.Mounts[2].DependsOn = []string{"/mnt/bind"}
+	_, err = spec.MountOrder()
+	g.Expect(err).To(MatchError(ContainSubstring("cycle")))
+
+	spec.Mounts[2].DependsOn = []string{"/mnt/missing"}
+	_, err = spec.MountOrder()
+	g.Expect(err).To(MatchError(ContainSubstring("unknown mount")))
+}
+
+func TestClientMountLVMActivationMode(t *testing.T) {
+	g := NewWithT(t)
+
+	lvm := &ClientMountDeviceLVM{}
+	g.Expect(lvm.ActivationModeFor("gfs2")).To(Equal(ClientMountLVMActivationModeShared))
+	g.Expect(lvm.ActivationModeFor("xfs")).To(Equal(ClientMountLVMActivationModeLocal))
+
+	lvm.ActivationMode = ClientMountLVMActivationModeExclusive
+	g.Expect(lvm.ActivationModeFor("gfs2")).To(Equal(ClientMountLVMActivationModeExclusive))
+}
+
+func TestClientMountSuspendedCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	clientMount := &ClientMount{Spec: ClientMountSpec{Suspended: true}}
+	clientMount.UpdateConditions()
+	g.Expect(meta.IsStatusConditionTrue(clientMount.Status.Conditions, ConditionSuspended)).To(BeTrue())
+
+	clientMount.Spec.Suspended = false
+	clientMount.UpdateConditions()
+	g.Expect(meta.IsStatusConditionFalse(clientMount.Status.Conditions, ConditionSuspended)).To(BeTrue())
+}
+
+func TestClientMountNamespace(t *testing.T) {
+	g := NewWithT(t)
+
+	ns := &ClientMountNamespace{PID: 1234}
+	g.Expect(ns.Validate()).To(Succeed())
+	g.Expect(ns.NamespacePath()).To(Equal("/proc/1234/ns/mnt"))
+
+	ns = &ClientMountNamespace{Path: "/run/job/ns/mnt"}
+	g.Expect(ns.Validate()).To(Succeed())
+	g.Expect(ns.NamespacePath()).To(Equal("/run/job/ns/mnt"))
+
+	g.Expect((&ClientMountNamespace{}).Validate()).ToNot(Succeed())
+	g.Expect((&ClientMountNamespace{PID: 1, Path: "/run/ns"}).Validate()).ToNot(Succeed())
+	g.Expect((&ClientMountNamespace{Path: "ns/mnt"}).Validate()).ToNot(Succeed())
+}
+
+func TestFileSystemAndTargetTypes(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(FileSystemTypeSwap.IsSwap()).To(BeTrue())
+	g.Expect(FileSystemTypeXFS.IsSwap()).To(BeFalse())
+	g.Expect(FileSystemTypeGFS2.IsShared()).To(BeTrue())
+	g.Expect(FileSystemTypeExt4.IsShared()).To(BeFalse())
+	g.Expect(FileSystemTypeExt4.IsFormattable()).To(BeTrue())
+	g.Expect(FileSystemTypeLustre.IsFormattable()).To(BeFalse())
+
+	g.Expect(TargetTypeDirectory.IsCreated()).To(BeTrue())
+	g.Expect(TargetTypeFile.IsCreated()).To(BeTrue())
+	g.Expect(TargetTypeDevice.IsCreated()).To(BeFalse())
+}
+
+func TestClientMountDeviceNFS(t *testing.T) {
+	g := NewWithT(t)
+
+	nfs := &ClientMountDeviceNFS{Server: "nfs1", ExportPath: "/exports/data", Version: "4.2"}
+	g.Expect(nfs.Device()).To(Equal("nfs1:/exports/data"))
+	g.Expect(FileSystemTypeNFS.IsShared()).To(BeTrue())
+	g.Expect(FileSystemTypeNFS.IsFormattable()).To(BeFalse())
+}
+
+func TestClientMountDeviceCeph(t *testing.T) {
+	g := NewWithT(t)
+
+	cephfs := &ClientMountDeviceCephFS{Monitors: []string{"10.0.0.1:6789", "10.0.0.2:6789"}}
+	g.Expect(cephfs.Device()).To(Equal("10.0.0.1:6789,10.0.0.2:6789:/"))
+	cephfs.Path = "/volumes/data"
+	g.Expect(cephfs.Device()).To(Equal("10.0.0.1:6789,10.0.0.2:6789:/volumes/data"))
+	g.Expect(FileSystemTypeCeph.IsShared()).To(BeTrue())
+
+	mount := ClientMountInfo{Type: FileSystemTypeXFS, Device: ClientMountDevice{Type: ClientMountDeviceTypeRBD, RBD: &ClientMountDeviceRBD{Pool: "dws", Image: "job-1"}}}
+	g.Expect(mount.ExclusiveDevice()).To(Equal("rbd:dws/job-1"))
+	mount.Options = "ro"
+	g.Expect(mount.ExclusiveDevice()).To(BeEmpty())
+}
+
+func TestClientMountDeviceBlock(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect((&ClientMountDeviceBlock{UUID: "3f1c2b6e-8d2a-4a4b-9e61-0c7b5f1d2e3a"}).Validate()).To(Succeed())
+	g.Expect((&ClientMountDeviceBlock{WWN: "0x5000c500a1b2c3d4"}).Validate()).To(Succeed())
+	g.Expect((&ClientMountDeviceBlock{}).Validate()).ToNot(Succeed())
+	g.Expect((&ClientMountDeviceBlock{Path: "/dev/sdb", Label: "scratch"}).Validate()).ToNot(Succeed())
+}
+
+func TestClientMountDeviceNone(t *testing.T) {
+	g := NewWithT(t)
+
+	none := &ClientMountDeviceNone{MountPoint: "/lus/global"}
+	g.Expect(none.Device()).To(Equal("/lus/global"))
+	g.Expect(none.Validate()).To(Succeed())
+
+	none.SubPath = "projects/job-1/"
+	g.Expect(none.Device()).To(Equal("/lus/global/projects/job-1"))
+	g.Expect(none.Validate()).To(Succeed())
+
+	for _, subPath := range []string{"/projects", "..", "../other", "projects/../../other"} {
+		none.SubPath = subPath
+		g.Expect(none.Validate()).ToNot(Succeed(), "subPath %s", subPath)
+	}
+}
+
+func TestClientMountLustreSquash(t *testing.T) {
+	g := NewWithT(t)
+
+	squash := &ClientMountLustreSquash{UID: 99, GID: 100}
+	g.Expect(squash.Validate()).To(Succeed())
+	g.Expect(squash.Options()).To(Equal([]string{"squash_uid=99", "squash_gid=100", "root_squash"}))
+
+	squash.Mode = ClientMountLustreSquashAll
+	squash.Nodemap = "tenant-a"
+	g.Expect(squash.Options()).To(Equal([]string{"squash_uid=99", "squash_gid=100", "all_squash", "nodemap=tenant-a"}))
+
+	for _, invalid := range []ClientMountLustreSquash{
+		{UID: 0, GID: 100},
+		{UID: 99, GID: maxSquashID + 1},
+		{UID: 99, GID: 100, Mode: "some"},
+	} {
+		g.Expect(invalid.Validate()).ToNot(Succeed(), "squash %+v", invalid)
+	}
+}
+
+func TestClientMountUsage(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect((&ClientMountUsage{}).PercentUsed()).To(Equal(0))
+	g.Expect((&ClientMountUsage{TotalBytes: 1000, UsedBytes: 500, AvailableBytes: 500}).PercentUsed()).To(Equal(50))
+	g.Expect((&ClientMountUsage{TotalBytes: 1000, UsedBytes: 901, AvailableBytes: 99}).PercentUsed()).To(Equal(91))
+
+	// The space reserved for root counts as used
+	g.Expect((&ClientMountUsage{TotalBytes: 1000, UsedBytes: 900, AvailableBytes: 50}).PercentUsed()).To(Equal(95))
+}
+
+func TestClientMountDegradedCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	status := ClientMountInfoStatus{State: ClientMountStateMounted, Ready: true}
+	g.Expect(status.IsDegraded()).To(BeFalse())
+
+	SetDegradedCondition(&status.Conditions, 1, fmt.Errorf("statfs of '/mnt/lus' did not return within 10s"))
+	g.Expect(status.IsDegraded()).To(BeTrue())
+
+	SetDegradedCondition(&status.Conditions, 1, nil)
+	g.Expect(status.IsDegraded()).To(BeFalse())
+}
+
+func TestClientMountSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(SummarizeClientMounts(nil)).To(BeNil())
+
+	clientMounts := make([]ClientMount, 3)
+	for i := range clientMounts {
+		clientMounts[i].Name = fmt.Sprintf("compute-%d", i)
+		clientMounts[i].Namespace = clientMounts[i].Name
+		clientMounts[i].Spec.DesiredState = ClientMountStateMounted
+		clientMounts[i].Spec.Mounts = []ClientMountInfo{{MountPath: "/mnt/scratch"}}
+		clientMounts[i].Status.Mounts = []ClientMountInfoStatus{{State: ClientMountStateMounted, Ready: true}}
+	}
+
+	summary := SummarizeClientMounts(clientMounts)
+	g.Expect(summary.Total).To(Equal(3))
+	g.Expect(summary.Ready).To(Equal(3))
+	g.Expect(summary.AllReady).To(BeTrue())
+
+	clientMounts[2].Status.Mounts[0].Ready = false
+	clientMounts[2].Status.Error = NewResourceError("mount failed", nil)
+	clientMounts[1].Status.Mounts[0].State = ClientMountStateUnmounted
+
+	summary = SummarizeClientMounts(clientMounts)
+	g.Expect(summary.Ready).To(Equal(1))
+	g.Expect(summary.AllReady).To(BeFalse())
+	g.Expect(summary.Errors).To(HaveLen(1))
+	g.Expect(summary.Errors[0].ClientMount).To(Equal("compute-2/compute-2"))
+}
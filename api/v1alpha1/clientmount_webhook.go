@@ -0,0 +1,303 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=mountoptionpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountnodes,verbs=get;list;watch
+
+// log is for logging in this package.
+var clientmountlog = logf.Log.WithName("clientmount-resource")
+
+// SetupWebhookWithManager connects the webhook with the manager
+func (cm *ClientMount) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	c = mgr.GetClient()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(cm).
+		Complete()
+}
+
+// DefaultMountOptions are the mount options filled in for a mount of each file system type
+// that doesn't specify any options
+var DefaultMountOptions = map[FileSystemType]string{
+	FileSystemTypeXFS:   "noatime",
+	FileSystemTypeExt4:  "noatime",
+	FileSystemTypeGFS2:  "noatime",
+	FileSystemTypeTmpfs: "nodev,nosuid",
+}
+
+//+kubebuilder:webhook:path=/mutate-dws-cray-hpe-com-v1alpha1-clientmount,mutating=true,failurePolicy=fail,sideEffects=None,groups=dws.cray.hpe.com,resources=clientmounts,verbs=create;update,versions=v1alpha1,name=mclientmount.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Defaulter = &ClientMount{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type. The
+// node defaults to the namespace, since each node has its own namespace. Each mount's target
+// type defaults to a directory, its options default to those for its file system type, and
+// its mount path and the paths it depends on are cleaned and made absolute.
+func (cm *ClientMount) Default() {
+	if cm.Spec.Node == "" {
+		cm.Spec.Node = cm.Namespace
+	}
+
+	for i := range cm.Spec.Mounts {
+		mount := &cm.Spec.Mounts[i]
+
+		if mount.TargetType == "" {
+			mount.TargetType = TargetTypeDirectory
+		}
+
+		if mount.Options == "" {
+			mount.Options = DefaultMountOptions[mount.Type]
+		}
+
+		if mount.MountPath != "" {
+			mount.MountPath = filepath.Clean("/" + mount.MountPath)
+		}
+
+		for j, dependency := range mount.DependsOn {
+			mount.DependsOn[j] = filepath.Clean("/" + dependency)
+		}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-dws-cray-hpe-com-v1alpha1-clientmount,mutating=false,failurePolicy=fail,sideEffects=None,groups=dws.cray.hpe.com,resources=clientmounts,verbs=create;update,versions=v1alpha1,name=vclientmount.kb.io,admissionReviewVersions={v1,v1beta1}
+
+var _ webhook.Validator = &ClientMount{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (cm *ClientMount) ValidateCreate() error {
+	return cm.validateMounts()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// The mounts are only checked if the mounts changed, so a policy change doesn't block
+// updates (e.g., removing the finalizer) to ClientMounts that already exist.
+func (cm *ClientMount) ValidateUpdate(old runtime.Object) error {
+	oldClientMount, ok := old.(*ClientMount)
+	if !ok {
+		err := fmt.Errorf("invalid ClientMount resource")
+		clientmountlog.Error(err, "old runtime.Object is not a ClientMount resource")
+
+		return err
+	}
+
+	if reflect.DeepEqual(cm.Spec.Mounts, oldClientMount.Spec.Mounts) {
+		// A ClientMount that's mounted again must not take a device another node has
+		// mounted in the meantime
+		if cm.Spec.DesiredState == ClientMountStateMounted && oldClientMount.Spec.DesiredState != ClientMountStateMounted {
+			return cm.validateExclusiveDevices(context.TODO(), c)
+		}
+
+		return nil
+	}
+
+	return cm.validateMounts()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (cm *ClientMount) ValidateDelete() error {
+	return nil
+}
+
+func (cm *ClientMount) validateMounts() error {
+	if err := cm.validateMountPaths(); err != nil {
+		return err
+	}
+
+	if _, err := cm.Spec.MountOrder(); err != nil {
+		return field.Invalid(field.NewPath("spec").Child("mounts"), len(cm.Spec.Mounts), err.Error())
+	}
+
+	for i, mount := range cm.Spec.Mounts {
+		if err := mount.ValidateCommandFields(field.NewPath("spec").Child("mounts").Index(i)); err != nil {
+			return err
+		}
+
+		if mount.MountNamespace != nil {
+			if err := mount.MountNamespace.Validate(); err != nil {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("mountNamespace"), *mount.MountNamespace, err.Error())
+			}
+		}
+
+		if mount.Device.Type == ClientMountDeviceTypeLustre && mount.Device.Lustre != nil && mount.Device.Lustre.Squash != nil {
+			squash := mount.Device.Lustre.Squash
+			if err := squash.Validate(); err != nil {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("lustre").Child("squash"), *squash, err.Error())
+			}
+
+			for _, option := range splitMountOptions(mount.Options) {
+				if matchMountOption(option, squashOptionKeys) {
+					return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("options"), mount.Options, fmt.Sprintf("option '%s' conflicts with the squash settings of the Lustre device", option))
+				}
+			}
+		}
+
+		if mount.Device.Type == ClientMountDeviceTypeBlock {
+			if mount.Device.Block == nil {
+				return field.Required(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("block"), "block device information is required")
+			}
+
+			if err := mount.Device.Block.Validate(); err != nil {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("block"), *mount.Device.Block, err.Error())
+			}
+		}
+
+		if mount.Device.Type == ClientMountDeviceTypeCephFS {
+			if mount.Device.CephFS == nil {
+				return field.Required(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("cephfs"), "CephFS device information is required")
+			}
+
+			if mount.Type != FileSystemTypeCeph {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("type"), mount.Type, "a CephFS device must be mounted as type ceph")
+			}
+		}
+
+		if mount.Device.Type == ClientMountDeviceTypeRBD {
+			if mount.Device.RBD == nil {
+				return field.Required(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("rbd"), "RBD device information is required")
+			}
+
+			if mount.Type.IsShared() {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("type"), mount.Type, "an RBD image must be mounted with a local file system type")
+			}
+		}
+
+		if mount.Device.Type == ClientMountDeviceTypeNone {
+			if mount.Device.None == nil {
+				return field.Required(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("none"), "the mount point of the file system is required")
+			}
+
+			if err := mount.Device.None.Validate(); err != nil {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("device").Child("none"), *mount.Device.None, err.Error())
+			}
+
+			if mount.Type != FileSystemTypeNone {
+				return field.Invalid(field.NewPath("spec").Child("mounts").Index(i).Child("type"), mount.Type, "a none device is bind mounted as type none")
+			}
+		}
+	}
+
+	if err := cm.validateNodeCapabilities(context.TODO(), c); err != nil {
+		return err
+	}
+
+	if err := cm.validateExclusiveDevices(context.TODO(), c); err != nil {
+		return err
+	}
+
+	rules, err := ListMountOptionRules(context.TODO(), c)
+	if err != nil {
+		return err
+	}
+
+	return ValidateMountOptions(cm.Spec.Mounts, rules)
+}
+
+// validateMountPaths checks that no two mounts have the same mount path. The status of each
+// mount and the dependencies between the mounts are found by mount path.
+func (cm *ClientMount) validateMountPaths() error {
+	seen := map[string]bool{}
+	for i, mount := range cm.Spec.Mounts {
+		if seen[mount.MountPath] {
+			return field.Duplicate(field.NewPath("spec").Child("mounts").Index(i).Child("mountPath"), mount.MountPath)
+		}
+		seen[mount.MountPath] = true
+	}
+
+	return nil
+}
+
+// validateExclusiveDevices rejects a mount of a device that can only be mounted read-write
+// on one node at a time (see ExclusiveDevice) if a ClientMount for another node already
+// mounts it. Concurrent mounts of a non-shared file system such as xfs corrupt it.
+// ClientMounts created at the same moment can still both be accepted, since each is checked
+// against the ClientMounts that exist when it's admitted.
+func (cm *ClientMount) validateExclusiveDevices(ctx context.Context, c client.Reader) error {
+	if cm.Spec.DesiredState != ClientMountStateMounted {
+		return nil
+	}
+
+	devices := map[string]int{}
+	for i := range cm.Spec.Mounts {
+		if device := cm.Spec.Mounts[i].ExclusiveDevice(); device != "" {
+			devices[device] = i
+		}
+	}
+
+	if len(devices) == 0 {
+		return nil
+	}
+
+	clientMounts := &ClientMountList{}
+	if err := c.List(ctx, clientMounts); err != nil {
+		return err
+	}
+
+	for _, other := range clientMounts.Items {
+		if other.Spec.Node == cm.Spec.Node || other.Spec.DesiredState != ClientMountStateMounted || !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		for j := range other.Spec.Mounts {
+			if i, found := devices[other.Spec.Mounts[j].ExclusiveDevice()]; found {
+				return field.Forbidden(field.NewPath("spec").Child("mounts").Index(i).Child("device"),
+					fmt.Sprintf("device is already mounted read-write on node %s by ClientMount %s/%s", other.Spec.Node, other.Namespace, other.Name))
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateNodeCapabilities checks the mounts against the capabilities the node's
+// mount-daemon reported in its ClientMountNode. The mounts aren't checked if the
+// mount-daemon hasn't reported its capabilities.
+func (cm *ClientMount) validateNodeCapabilities(ctx context.Context, c client.Reader) error {
+	node := &ClientMountNode{}
+	if err := c.Get(ctx, client.ObjectKey{Name: cm.Spec.Node, Namespace: cm.Namespace}, node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if node.Status.LastReported == nil {
+		return nil
+	}
+
+	for i, mount := range cm.Spec.Mounts {
+		if err := node.Status.Capabilities.Supports(mount); err != nil {
+			return field.Invalid(field.NewPath("spec").Child("mounts").Index(i), mount.MountPath,
+				fmt.Sprintf("node %s mount-daemon version %s: %v", cm.Spec.Node, node.Status.DaemonVersion, err))
+		}
+	}
+
+	return nil
+}
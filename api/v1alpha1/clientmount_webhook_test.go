@@ -0,0 +1,258 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestClientMountDefault(t *testing.T) {
+	g := NewWithT(t)
+
+	clientMount := &ClientMount{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "rabbit-0"},
+		Spec: ClientMountSpec{Mounts: []ClientMountInfo{
+			{MountPath: "mnt/xfs/", Type: "xfs"},
+			{MountPath: "/mnt//bind", Type: "none", Options: "bind", TargetType: "file", DependsOn: []string{"mnt/xfs"}},
+			{Type: "swap"},
+		}},
+	}
+
+	clientMount.Default()
+
+	g.Expect(clientMount.Spec.Node).To(Equal("rabbit-0"))
+
+	mounts := clientMount.Spec.Mounts
+	g.Expect(mounts[0].MountPath).To(Equal("/mnt/xfs"))
+	g.Expect(mounts[0].Options).To(Equal("noatime"))
+	g.Expect(mounts[0].TargetType).To(Equal(TargetTypeDirectory))
+
+	g.Expect(mounts[1].MountPath).To(Equal("/mnt/bind"))
+	g.Expect(mounts[1].Options).To(Equal("bind"))
+	g.Expect(mounts[1].TargetType).To(Equal(TargetTypeFile))
+	g.Expect(mounts[1].DependsOn).To(Equal([]string{"/mnt/xfs"}))
+
+	g.Expect(mounts[2].MountPath).To(BeEmpty())
+	g.Expect(mounts[2].Options).To(BeEmpty())
+}
+
+// nodeReader returns a canned ClientMountNode
+type nodeReader struct {
+	client.Reader
+	node *ClientMountNode
+}
+
+func (r *nodeReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if r.node == nil || r.node.Name != key.Name || r.node.Namespace != key.Namespace {
+		return apierrors.NewNotFound(schema.GroupResource{Group: GroupVersion.Group, Resource: "clientmountnodes"}, key.Name)
+	}
+
+	*obj.(*ClientMountNode) = *r.node
+	return nil
+}
+
+func TestClientMountNodeCapabilities(t *testing.T) {
+	g := NewWithT(t)
+
+	clientMount := &ClientMount{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "compute-0"},
+		Spec: ClientMountSpec{Node: "compute-0", Mounts: []ClientMountInfo{
+			{MountPath: "/mnt/nfs", Type: FileSystemTypeNFS, Device: ClientMountDevice{Type: ClientMountDeviceTypeNFS}},
+		}},
+	}
+
+	// A node that hasn't reported its capabilities isn't checked
+	reader := &nodeReader{}
+	g.Expect(clientMount.validateNodeCapabilities(context.TODO(), reader)).To(Succeed())
+
+	reader.node = &ClientMountNode{ObjectMeta: metav1.ObjectMeta{Name: "compute-0", Namespace: "compute-0"}}
+	g.Expect(clientMount.validateNodeCapabilities(context.TODO(), reader)).To(Succeed())
+
+	now := metav1.Now()
+	reader.node.Status = ClientMountNodeStatus{
+		DaemonVersion: "0.0.1",
+		Capabilities: ClientMountCapabilities{
+			DeviceTypes:     []ClientMountDeviceType{ClientMountDeviceTypeLVM},
+			FileSystemTypes: []FileSystemType{FileSystemTypeXFS, FileSystemTypeNFS},
+		},
+		LastReported: &now,
+	}
+	err := clientMount.validateNodeCapabilities(context.TODO(), reader)
+	g.Expect(err).To(MatchError(ContainSubstring("device type 'nfs' is not supported")))
+
+	reader.node.Status.Capabilities.DeviceTypes = append(reader.node.Status.Capabilities.DeviceTypes, ClientMountDeviceTypeNFS)
+	g.Expect(clientMount.validateNodeCapabilities(context.TODO(), reader)).To(Succeed())
+
+	// A mount-daemon that doesn't list a feature would ignore it
+	reader.node.Status.Capabilities.DeviceTypes = append(reader.node.Status.Capabilities.DeviceTypes, ClientMountDeviceTypeLustre)
+	reader.node.Status.Capabilities.FileSystemTypes = append(reader.node.Status.Capabilities.FileSystemTypes, FileSystemTypeLustre)
+	clientMount.Spec.Mounts = append(clientMount.Spec.Mounts, ClientMountInfo{MountPath: "/mnt/lus", Type: FileSystemTypeLustre, Device: ClientMountDevice{
+		Type:   ClientMountDeviceTypeLustre,
+		Lustre: &ClientMountDeviceLustre{FileSystemName: "lus", Squash: &ClientMountLustreSquash{UID: 99, GID: 99}},
+	}})
+	err = clientMount.validateNodeCapabilities(context.TODO(), reader)
+	g.Expect(err).To(MatchError(ContainSubstring("feature 'lustreSquash' is not supported")))
+
+	reader.node.Status.Capabilities.Features = []ClientMountFeature{ClientMountFeatureLustreSquash}
+	g.Expect(clientMount.validateNodeCapabilities(context.TODO(), reader)).To(Succeed())
+}
+
+func TestClientMountDuplicateMountPaths(t *testing.T) {
+	g := NewWithT(t)
+
+	clientMount := &ClientMount{Spec: ClientMountSpec{Mounts: []ClientMountInfo{
+		{MountPath: "/mnt/a"},
+		{MountPath: "/mnt/b"},
+	}}}
+	g.Expect(clientMount.validateMountPaths()).To(Succeed())
+
+	clientMount.Spec.Mounts = append(clientMount.Spec.Mounts, ClientMountInfo{MountPath: "/mnt/a"})
+	err := clientMount.validateMountPaths()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.(*field.Error).Type).To(Equal(field.ErrorTypeDuplicate))
+	g.Expect(err.(*field.Error).Field).To(Equal("spec.mounts[2].mountPath"))
+}
+
+// clientMountLister returns canned ClientMounts
+type clientMountLister struct {
+	client.Reader
+	clientMounts []ClientMount
+}
+
+func (r *clientMountLister) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	list.(*ClientMountList).Items = r.clientMounts
+	return nil
+}
+
+func TestClientMountExclusiveDevices(t *testing.T) {
+	g := NewWithT(t)
+
+	newClientMount := func(node string, options string) ClientMount {
+		return ClientMount{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: node},
+			Spec: ClientMountSpec{Node: node, DesiredState: ClientMountStateMounted, Mounts: []ClientMountInfo{{
+				MountPath: "/mnt/xfs",
+				Type:      FileSystemTypeXFS,
+				Options:   options,
+				Device: ClientMountDevice{
+					Type: ClientMountDeviceTypeLVM,
+					LVM:  &ClientMountDeviceLVM{VolumeGroup: "vg", LogicalVolume: "lv"},
+				},
+			}}},
+		}
+	}
+
+	reader := &clientMountLister{clientMounts: []ClientMount{newClientMount("compute-0", "noatime")}}
+
+	clientMount := newClientMount("compute-1", "noatime")
+	g.Expect(clientMount.validateExclusiveDevices(context.TODO(), reader)).To(MatchError(ContainSubstring("already mounted read-write on node compute-0")))
+
+	// Read-only mounts can be shared
+	clientMount = newClientMount("compute-1", "ro,noatime")
+	g.Expect(clientMount.validateExclusiveDevices(context.TODO(), reader)).To(Succeed())
+
+	// The same node may mount the device more than once
+	clientMount = newClientMount("compute-0", "noatime")
+	g.Expect(clientMount.validateExclusiveDevices(context.TODO(), reader)).To(Succeed())
+
+	reader.clientMounts[0].Spec.DesiredState = ClientMountStateUnmounted
+	clientMount = newClientMount("compute-1", "noatime")
+	g.Expect(clientMount.validateExclusiveDevices(context.TODO(), reader)).To(Succeed())
+
+	// Shared file systems can be mounted on many nodes
+	reader.clientMounts[0] = newClientMount("compute-0", "")
+	reader.clientMounts[0].Spec.Mounts[0].Type = FileSystemTypeGFS2
+	clientMount.Spec.Mounts[0].Type = FileSystemTypeGFS2
+	g.Expect(clientMount.validateExclusiveDevices(context.TODO(), reader)).To(Succeed())
+}
+
+func TestClientMountCommandFields(t *testing.T) {
+	g := NewWithT(t)
+
+	path := field.NewPath("spec").Child("mounts").Index(0)
+
+	valid := []ClientMountInfo{
+		{MountPath: "/mnt/lus", Options: "flock,user_xattr", Device: ClientMountDevice{Lustre: &ClientMountDeviceLustre{FileSystemName: "lus", MgsAddresses: "10.1.1.1@o2ib,10.1.1.2@o2ib:10.1.1.3@tcp"}}},
+		{MountPath: "/mnt/tenant", Device: ClientMountDevice{Lustre: &ClientMountDeviceLustre{FileSystemName: "lus", MgsAddresses: "10.1.1.1@o2ib", Squash: &ClientMountLustreSquash{UID: 99, GID: 99, Nodemap: "tenant-a"}}}},
+		{MountPath: "/mnt/xfs", Options: `context="system_u:object_r:container_file_t:s0:c1,c2"`, Format: &ClientMountFormat{Options: "-m crc=1 -K"}, Device: ClientMountDevice{LVM: &ClientMountDeviceLVM{VolumeGroup: "vg-0_a", LogicalVolume: "lv.0+1"}}},
+		{MountPath: "/mnt/nfs", Options: "sec=krb5:krb5i,addr=fe80::1%eth0", Device: ClientMountDevice{NFS: &ClientMountDeviceNFS{Server: "[fe80::1]", ExportPath: "/export/home", Version: "4.2"}}},
+		{MountPath: "/mnt/data", Device: ClientMountDevice{Block: &ClientMountDeviceBlock{Label: "scratch data"}}},
+		{MountPath: "/mnt/ceph", Device: ClientMountDevice{CephFS: &ClientMountDeviceCephFS{Monitors: []string{"10.0.0.1:6789"}, Path: "/volumes/a", User: "client.a"}}},
+		{MountPath: "/mnt/job", Device: ClientMountDevice{None: &ClientMountDeviceNone{MountPoint: "/lus/global", FileSystemType: FileSystemTypeLustre, SubPath: "projects/job-1"}}},
+		{MountPath: "/mnt/ns", MountNamespace: &ClientMountNamespace{Path: "/run/mntns/job-1"}},
+		{MountPath: "/mnt/pid", MountNamespace: &ClientMountNamespace{PID: 100}},
+	}
+
+	for _, mount := range valid {
+		g.Expect(mount.ValidateCommandFields(path)).To(Succeed(), "mount %s", mount.MountPath)
+	}
+
+	invalid := map[string]ClientMountInfo{
+		"spec.mounts[0].mountPath":                    {MountPath: "/mnt/a; rm -rf /"},
+		"spec.mounts[0].options":                      {MountPath: "/mnt/a", Options: "ro $(reboot)"},
+		"spec.mounts[0].format.options":               {MountPath: "/mnt/a", Format: &ClientMountFormat{Options: "-K; reboot"}},
+		"spec.mounts[0].device.lvm.volumeGroup":       {MountPath: "/mnt/a", Device: ClientMountDevice{LVM: &ClientMountDeviceLVM{VolumeGroup: "--config=x"}}},
+		"spec.mounts[0].device.rbd.image":             {MountPath: "/mnt/a", Device: ClientMountDevice{RBD: &ClientMountDeviceRBD{Pool: "rbd", Image: "a|b"}}},
+		"spec.mounts[0].device.none.subPath":          {MountPath: "/mnt/a", Device: ClientMountDevice{None: &ClientMountDeviceNone{MountPoint: "/lus/global", SubPath: "/etc"}}},
+		"spec.mounts[0].device.block.label":           {MountPath: "/mnt/a", Device: ClientMountDevice{Block: &ClientMountDeviceBlock{Label: "-U"}}},
+		"spec.mounts[0].device.lustre.mgsAddresses":   {MountPath: "/mnt/a", Device: ClientMountDevice{Lustre: &ClientMountDeviceLustre{FileSystemName: "lus", MgsAddresses: "10.1.1.1@tcp\nreboot"}}},
+		"spec.mounts[0].mountNamespace.path":          {MountPath: "/mnt/a", MountNamespace: &ClientMountNamespace{Path: "/run/ns --target=1"}},
+		"spec.mounts[0].device.lustre.squash.nodemap": {MountPath: "/mnt/a", Device: ClientMountDevice{Lustre: &ClientMountDeviceLustre{FileSystemName: "lus", Squash: &ClientMountLustreSquash{UID: 99, GID: 99, Nodemap: "--all"}}}},
+	}
+
+	// A '..' element or anything else filepath.Clean would change is refused in each path field
+	unclean := []struct {
+		field string
+		mount ClientMountInfo
+	}{
+		{"spec.mounts[0].mountPath", ClientMountInfo{MountPath: "/mnt/../etc/shadow"}},
+		{"spec.mounts[0].device.swapFile.path", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{SwapFile: &ClientMountDeviceSwapFile{Path: "/mnt/../etc/swap"}}}},
+		{"spec.mounts[0].device.nfs.exportPath", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{NFS: &ClientMountDeviceNFS{Server: "nfs-0", ExportPath: "/export/../etc"}}}},
+		{"spec.mounts[0].device.block.path", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{Block: &ClientMountDeviceBlock{Path: "/dev/../etc/shadow"}}}},
+		{"spec.mounts[0].device.cephfs.path", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{CephFS: &ClientMountDeviceCephFS{Monitors: []string{"10.0.0.1:6789"}, Path: "/volumes/../.."}}}},
+		{"spec.mounts[0].device.none.mountPoint", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{None: &ClientMountDeviceNone{MountPoint: "/lus/global/.."}}}},
+		{"spec.mounts[0].device.none.subPath", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{None: &ClientMountDeviceNone{MountPoint: "/lus/global", SubPath: "projects/../../../root"}}}},
+		{"spec.mounts[0].device.none.subPath", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{None: &ClientMountDeviceNone{MountPoint: "/lus/global", SubPath: ".."}}}},
+		{"spec.mounts[0].device.swapFile.path", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{SwapFile: &ClientMountDeviceSwapFile{Path: "/mnt/./swap"}}}},
+		{"spec.mounts[0].device.nfs.exportPath", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{NFS: &ClientMountDeviceNFS{Server: "nfs-0", ExportPath: "/export/home/"}}}},
+		{"spec.mounts[0].device.none.mountPoint", ClientMountInfo{MountPath: "/mnt/a", Device: ClientMountDevice{None: &ClientMountDeviceNone{MountPoint: "/lus//global"}}}},
+		{"spec.mounts[0].mountNamespace.path", ClientMountInfo{MountPath: "/mnt/a", MountNamespace: &ClientMountNamespace{Path: "/run/mntns/../../etc/shadow"}}},
+	}
+
+	for _, test := range unclean {
+		err := test.mount.ValidateCommandFields(path)
+		g.Expect(err).To(HaveOccurred(), "field %s", test.field)
+		g.Expect(err.(*field.Error).Field).To(Equal(test.field))
+	}
+
+	for fieldPath, mount := range invalid {
+		err := mount.ValidateCommandFields(path)
+		g.Expect(err).To(HaveOccurred(), "field %s", fieldPath)
+		g.Expect(err.(*field.Error).Field).To(Equal(fieldPath))
+	}
+}
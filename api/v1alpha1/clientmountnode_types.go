@@ -0,0 +1,118 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClientMountCapabilities lists the parts of the ClientMount spec a mount-daemon supports
+type ClientMountCapabilities struct {
+	// DeviceTypes is the list of device types the mount-daemon can mount
+	DeviceTypes []ClientMountDeviceType `json:"deviceTypes,omitempty"`
+
+	// FileSystemTypes is the list of file system types the mount-daemon can mount
+	FileSystemTypes []FileSystemType `json:"fileSystemTypes,omitempty"`
+
+	// Features is the list of optional parts of the device specs the mount-daemon handles.
+	// A mount-daemon that doesn't list a feature would ignore it rather than fail.
+	Features []ClientMountFeature `json:"features,omitempty"`
+}
+
+// ClientMountFeature is an optional part of a device spec a mount-daemon may handle
+type ClientMountFeature string
+
+const (
+	// ClientMountFeatureLustreSquash is the squashing of the users of a Lustre mount
+	ClientMountFeatureLustreSquash ClientMountFeature = "lustreSquash"
+)
+
+// Supports returns an error describing the first part of the mount the capabilities don't
+// include, or nil if the mount is supported
+func (c *ClientMountCapabilities) Supports(mount ClientMountInfo) error {
+	if !containsValue(c.DeviceTypes, mount.Device.Type) {
+		return fmt.Errorf("device type '%s' is not supported", mount.Device.Type)
+	}
+
+	if !containsValue(c.FileSystemTypes, mount.Type) {
+		return fmt.Errorf("file system type '%s' is not supported", mount.Type)
+	}
+
+	if mount.Device.Lustre != nil && mount.Device.Lustre.Squash != nil && !containsValue(c.Features, ClientMountFeatureLustreSquash) {
+		return fmt.Errorf("feature '%s' is not supported", ClientMountFeatureLustreSquash)
+	}
+
+	return nil
+}
+
+func containsValue[T comparable](values []T, value T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientMountNodeStatus is the information the mount-daemon on a node reports about itself
+type ClientMountNodeStatus struct {
+	// DaemonVersion is the version of the mount-daemon running on the node
+	DaemonVersion string `json:"daemonVersion,omitempty"`
+
+	// Capabilities lists the parts of the ClientMount spec the mount-daemon supports
+	Capabilities ClientMountCapabilities `json:"capabilities,omitempty"`
+
+	// LastReported is when the mount-daemon last reported the information
+	LastReported *metav1.Time `json:"lastReported,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="VERSION",type="string",JSONPath=".status.daemonVersion",description="Version of the mount-daemon"
+//+kubebuilder:printcolumn:name="REPORTED",type="date",JSONPath=".status.lastReported",description="When the mount-daemon last reported"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClientMountNode is the Schema for the clientmountnodes API. The mount-daemon on each node
+// creates one with the node's name in the node's namespace and reports its version and
+// capabilities in the status. The ClientMount webhook rejects mounts the node's mount-daemon
+// doesn't support, so a ClientMount using a new feature doesn't fail silently on a node that
+// hasn't been upgraded yet. ClientMounts for a node without a ClientMountNode aren't checked.
+type ClientMountNode struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status ClientMountNodeStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClientMountNodeList contains a list of ClientMountNode
+type ClientMountNodeList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClientMountNode `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClientMountNode{}, &ClientMountNodeList{})
+}
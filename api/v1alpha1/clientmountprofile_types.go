@@ -0,0 +1,98 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClientMountProfileSpec defines the mount settings shared by the mounts that use the profile
+type ClientMountProfileSpec struct {
+	// Options are mount options added before the options of a mount that uses the profile.
+	// The mount commands use the last value of an option that's given more than once, so
+	// the options of the mount take precedence.
+	// +optional
+	Options string `json:"options,omitempty"`
+
+	// CommandTimeout is the time a host command for a mount that uses the profile may run
+	// before it's killed. The client's command timeout is used if it's empty.
+	// +optional
+	CommandTimeout *metav1.Duration `json:"commandTimeout,omitempty"`
+
+	// RetryDelay is the delay before retrying a ClientMount with a mount that uses the
+	// profile after a failure. The longest retry delay of the profiles used by the
+	// ClientMount and the client's retry delay is used.
+	// +optional
+	RetryDelay *metav1.Duration `json:"retryDelay,omitempty"`
+
+	// HookSet is the subdirectory of the client's hook directory that holds the hooks run
+	// for a mount that uses the profile. The hooks at the top of the hook directory are
+	// run if it's empty.
+	// +kubebuilder:validation:Pattern:=`^[A-Za-z0-9][A-Za-z0-9._-]*$`
+	// +optional
+	HookSet string `json:"hookSet,omitempty"`
+
+	// SkipHooks stops any hooks from being run for a mount that uses the profile
+	// +optional
+	SkipHooks bool `json:"skipHooks,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// ClientMountProfile is the Schema for the clientmountprofiles API. A ClientMountProfile
+// names a set of mount options, timeouts, and hook settings that mounts refer to by name,
+// so a site tunes the mounts of a file system in one place rather than in every
+// ClientMount.
+type ClientMountProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClientMountProfileSpec `json:"spec,omitempty"`
+}
+
+// Apply returns a copy of the mount with the options of the profile added
+func (p *ClientMountProfile) Apply(clientMountInfo ClientMountInfo) ClientMountInfo {
+	options := []string{}
+	for _, o := range []string{p.Spec.Options, clientMountInfo.Options} {
+		if o = strings.Trim(o, ", "); o != "" {
+			options = append(options, o)
+		}
+	}
+
+	clientMountInfo.Options = strings.Join(options, ",")
+
+	return clientMountInfo
+}
+
+//+kubebuilder:object:root=true
+
+// ClientMountProfileList contains a list of ClientMountProfile
+type ClientMountProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClientMountProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClientMountProfile{}, &ClientMountProfileList{})
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types used in the Conditions list of the DWS status types. Consumers can
+// check them with meta.IsStatusConditionTrue.
+const (
+	// ConditionReady is true when the resource has reached its desired state
+	ConditionReady = "Ready"
+
+	// ConditionProgressing is true while the resource is working towards its desired state
+	ConditionProgressing = "Progressing"
+
+	// ConditionError is true when the resource has an error
+	ConditionError = "Error"
+
+	// ConditionSuspended is true when reconciling the resource is paused
+	ConditionSuspended = "Suspended"
+
+	// ConditionFatal is true when a resource that the workflow depends on has a fatal
+	// error. By convention a controller marks an error fatal (ResourceErrorInfo.WithFatal)
+	// only when retrying can't help. A fatal error in a resource with the workflow labels
+	// (AddWorkflowLabels) is escalated to the workflow so the WLM can end the job rather
+	// than wait for a resource that will never be ready.
+	ConditionFatal = "Fatal"
+
+	// ConditionDegraded is true when a mounted file system doesn't respond, such as a
+	// Lustre mount that has lost its servers. The mount is still in the mount table, so
+	// it's still reported as mounted.
+	ConditionDegraded = "Degraded"
+)
+
+// Condition reasons
+const (
+	ConditionReasonReady       = "Ready"
+	ConditionReasonNotReady    = "NotReady"
+	ConditionReasonProgressing = "Progressing"
+	ConditionReasonNoError     = "NoError"
+	ConditionReasonRecoverable = "RecoverableError"
+	ConditionReasonFatal       = "FatalError"
+	ConditionReasonSuspended   = "Suspended"
+	ConditionReasonResumed     = "Resumed"
+	ConditionReasonResponding  = "Responding"
+	ConditionReasonStale       = "Stale"
+)
+
+// maxConditionMessageLength is the maximum length of a metav1.Condition message
+const maxConditionMessageLength = 32768
+
+// SetReadyConditions sets the Ready, Progressing, and Error conditions given whether the
+// resource is ready and its current error, if any. A resource is progressing until it's
+// ready or has a fatal error. The transition time of a condition only changes when its
+// status changes.
+func SetReadyConditions(conditions *[]metav1.Condition, generation int64, ready bool, resourceError *ResourceErrorInfo) {
+	readyCondition := metav1.Condition{
+		Type:               ConditionReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonNotReady,
+		ObservedGeneration: generation,
+	}
+
+	progressingCondition := metav1.Condition{
+		Type:               ConditionProgressing,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonReady,
+		ObservedGeneration: generation,
+	}
+
+	errorCondition := metav1.Condition{
+		Type:               ConditionError,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonNoError,
+		ObservedGeneration: generation,
+	}
+
+	if ready {
+		readyCondition.Status = metav1.ConditionTrue
+		readyCondition.Reason = ConditionReasonReady
+	} else if resourceError == nil || resourceError.Recoverable {
+		progressingCondition.Status = metav1.ConditionTrue
+		progressingCondition.Reason = ConditionReasonProgressing
+	}
+
+	if resourceError != nil {
+		errorCondition.Status = metav1.ConditionTrue
+		errorCondition.Reason = ConditionReasonRecoverable
+		if !resourceError.Recoverable {
+			errorCondition.Reason = ConditionReasonFatal
+			progressingCondition.Reason = ConditionReasonFatal
+		}
+
+		errorCondition.Message = resourceError.UserMessage
+		if errorCondition.Message == "" {
+			errorCondition.Message = resourceError.DebugMessage
+		}
+
+		// The API server rejects longer condition messages
+		if len(errorCondition.Message) > maxConditionMessageLength {
+			errorCondition.Message = errorCondition.Message[:maxConditionMessageLength]
+		}
+	}
+
+	meta.SetStatusCondition(conditions, readyCondition)
+	meta.SetStatusCondition(conditions, progressingCondition)
+	meta.SetStatusCondition(conditions, errorCondition)
+}
+
+// SetFatalCondition sets the Fatal condition for a fatal error reported by source (e.g.,
+// "ClientMount default/compute-0"). The condition is left alone if the error is nil or
+// recoverable, so once it's true it stays true. A fatal error isn't retried, so the
+// resource that reported it isn't expected to recover.
+func SetFatalCondition(conditions *[]metav1.Condition, generation int64, source string, resourceError *ResourceErrorInfo) {
+	if meta.IsStatusConditionTrue(*conditions, ConditionFatal) {
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionFatal,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonNoError,
+		ObservedGeneration: generation,
+	}
+
+	if resourceError != nil && !resourceError.Recoverable {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ConditionReasonFatal
+
+		message := resourceError.UserMessage
+		if message == "" {
+			message = resourceError.DebugMessage
+		}
+
+		condition.Message = source + ": " + message
+		if len(condition.Message) > maxConditionMessageLength {
+			condition.Message = condition.Message[:maxConditionMessageLength]
+		}
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// SetSuspendedCondition sets the Suspended condition given whether reconciling the resource
+// is paused
+func SetSuspendedCondition(conditions *[]metav1.Condition, generation int64, suspended bool) {
+	condition := metav1.Condition{
+		Type:               ConditionSuspended,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonResumed,
+		ObservedGeneration: generation,
+	}
+
+	if suspended {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ConditionReasonSuspended
+		condition.Message = "Reconciling is suspended"
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// SetDegradedCondition sets the Degraded condition given the error from probing the mounted
+// file system, if any
+func SetDegradedCondition(conditions *[]metav1.Condition, generation int64, probeError error) {
+	condition := metav1.Condition{
+		Type:               ConditionDegraded,
+		Status:             metav1.ConditionFalse,
+		Reason:             ConditionReasonResponding,
+		ObservedGeneration: generation,
+	}
+
+	if probeError != nil {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = ConditionReasonStale
+		condition.Message = probeError.Error()
+		if len(condition.Message) > maxConditionMessageLength {
+			condition.Message = condition.Message[:maxConditionMessageLength]
+		}
+	}
+
+	meta.SetStatusCondition(conditions, condition)
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// NodeCordonAnnotation cordons a compute node when set on the node's namespace. The
+	// value is the reason for the maintenance. The client mount daemon on a cordoned node
+	// refuses new mounts but still unmounts, so the node drains of DWS mounts as the
+	// ClientMounts are unmounted or deleted. For example:
+	//
+	//	kubectl annotate namespace compute-01 dws.cray.hpe.com/cordon="replace DIMM"
+	NodeCordonAnnotation = "dws.cray.hpe.com/cordon"
+)
+
+// IsNodeCordoned returns whether the namespace of a compute node is cordoned and the reason
+// given for the cordon
+func IsNodeCordoned(namespace *corev1.Namespace) (bool, string) {
+	reason, found := namespace.GetAnnotations()[NodeCordonAnnotation]
+	return found, reason
+}
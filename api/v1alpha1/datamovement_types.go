@@ -0,0 +1,188 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+	"github.com/HewlettPackard/dws/utils/updater"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DataMovementOperation is the #DW command that requested the data movement
+type DataMovementOperation string
+
+const (
+	// DataMovementStageIn copies data into the job storage before the job runs
+	DataMovementStageIn DataMovementOperation = "stage_in"
+
+	// DataMovementStageOut copies data out of the job storage after the job runs
+	DataMovementStageOut DataMovementOperation = "stage_out"
+)
+
+// DataMovementState is the progress of the data movement
+type DataMovementState string
+
+const (
+	// The data movement hasn't been started by the data mover yet
+	DataMovementPending DataMovementState = "pending"
+
+	// The data mover is copying the data
+	DataMovementRunning DataMovementState = "running"
+
+	// The data movement has completed. The Ready status and error information record
+	// whether it was successful.
+	DataMovementFinished DataMovementState = "finished"
+)
+
+// DataMovementSpec defines the desired state of DataMovement
+type DataMovementSpec struct {
+	// Operation is the #DW command that requested the data movement
+	// +kubebuilder:validation:Enum:=stage_in;stage_out
+	Operation DataMovementOperation `json:"operation"`
+
+	// Type of the source: a single file, a directory, or a file containing a list of
+	// source and destination pairs
+	// +kubebuilder:validation:Enum:=file;directory;list
+	Type string `json:"type"`
+
+	// Source path of the data. For stage_out this is usually in the job storage (i.e., $DW_JOB_name).
+	Source string `json:"source"`
+
+	// Destination path of the data. For stage_in this is usually in the job storage (i.e., $DW_JOB_name).
+	Destination string `json:"destination"`
+
+	// Profile names the data mover configuration to use. The meaning of the profile is
+	// left to the data mover.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// DWDirective is a copy of the #DW directive that requested the data movement
+	// +optional
+	DWDirective string `json:"dwDirective,omitempty"`
+}
+
+// DataMovementStatus defines the observed state of DataMovement
+type DataMovementStatus struct {
+	// Current state of the data movement
+	// +kubebuilder:validation:Enum:=pending;running;finished
+	State DataMovementState `json:"state,omitempty"`
+
+	// Ready is set when the data movement finished successfully
+	Ready bool `json:"ready"`
+
+	// Total number of bytes to move, if known by the data mover
+	BytesTotal int64 `json:"bytesTotal,omitempty"`
+
+	// Number of bytes moved so far
+	BytesTransferred int64 `json:"bytesTransferred,omitempty"`
+
+	// Time the data mover started moving the data
+	StartTime *metav1.MicroTime `json:"startTime,omitempty"`
+
+	// Time the data movement finished
+	EndTime *metav1.MicroTime `json:"endTime,omitempty"`
+
+	// Error information
+	ResourceError `json:",inline"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="OPERATION",type="string",JSONPath=".spec.operation",description="stage_in or stage_out"
+//+kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state",description="Current state"
+//+kubebuilder:printcolumn:name="READY",type="boolean",JSONPath=".status.ready",description="True if the data movement finished successfully"
+//+kubebuilder:printcolumn:name="ERROR",type="string",JSONPath=".status.error.debugMessage",description="Error message",priority=1
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DataMovement is the Schema for the datamovements API. It represents the data movement
+// requested by a #DW stage_in or stage_out directive.
+type DataMovement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DataMovementSpec   `json:"spec,omitempty"`
+	Status DataMovementStatus `json:"status,omitempty"`
+}
+
+func (dm *DataMovement) GetStatus() updater.Status[*DataMovementStatus] {
+	return &dm.Status
+}
+
+//+kubebuilder:object:root=true
+
+// DataMovementList contains a list of DataMovement
+type DataMovementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DataMovement `json:"items"`
+}
+
+// GetObjectList returns a list of DataMovement references.
+func (d *DataMovementList) GetObjectList() []client.Object {
+	objectList := []client.Object{}
+
+	for i := range d.Items {
+		objectList = append(objectList, &d.Items[i])
+	}
+
+	return objectList
+}
+
+// NewDataMovementSpec builds the spec for a #DW stage_in or stage_out directive
+func NewDataMovementSpec(directive string) (DataMovementSpec, error) {
+	argsMap, err := dwdparse.BuildArgsMap(directive)
+	if err != nil {
+		return DataMovementSpec{}, err
+	}
+
+	operation := DataMovementOperation(argsMap["command"])
+	if operation != DataMovementStageIn && operation != DataMovementStageOut {
+		return DataMovementSpec{}, fmt.Errorf("directive '%s' is not a data movement directive", argsMap["command"])
+	}
+
+	for _, key := range []string{"type", "source", "destination"} {
+		if argsMap[key] == "" {
+			return DataMovementSpec{}, fmt.Errorf("data movement directive is missing '%s'", key)
+		}
+	}
+
+	return DataMovementSpec{
+		Operation:   operation,
+		Type:        argsMap["type"],
+		Source:      argsMap["source"],
+		Destination: argsMap["destination"],
+		Profile:     argsMap["profile"],
+		DWDirective: directive,
+	}, nil
+}
+
+func init() {
+	SchemeBuilder.Register(&DataMovement{}, &DataMovementList{})
+}
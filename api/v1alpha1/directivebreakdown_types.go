@@ -20,6 +20,9 @@
 package v1alpha1
 
 import (
+	"fmt"
+
+	"github.com/HewlettPackard/dws/utils/dwdparse"
 	"github.com/HewlettPackard/dws/utils/updater"
 
 	corev1 "k8s.io/api/core/v1"
@@ -145,8 +148,70 @@ type DirectiveBreakdownSpec struct {
 	UserID uint32 `json:"userID"`
 }
 
+// DirectiveRequirements is the storage requirement of a jobdw, create_persistent, or
+// persistentdw directive, parsed so consumers don't have to parse the directive themselves
+type DirectiveRequirements struct {
+	// Command is the directive's command (e.g., "jobdw")
+	Command string `json:"command"`
+
+	// Name is the name of the storage the directive creates or uses
+	Name string `json:"name"`
+
+	// FileSystemType is the file system the directive creates. Empty for persistentdw,
+	// since the file system already exists.
+	FileSystemType string `json:"fileSystemType,omitempty"`
+
+	// Capacity is the number of bytes requested. Zero for persistentdw.
+	Capacity int64 `json:"capacity,omitempty"`
+
+	// Lifetime is the duration of the storage
+	// +kubebuilder:validation:Enum=job;persistent
+	Lifetime string `json:"lifetime"`
+}
+
+// NewDirectiveRequirements parses the storage requirement from a jobdw, create_persistent, or
+// persistentdw directive. A nil requirement is returned for the other directives, which don't
+// use any storage.
+func NewDirectiveRequirements(directive string) (*DirectiveRequirements, error) {
+	argsMap, err := dwdparse.BuildArgsMap(directive)
+	if err != nil {
+		return nil, err
+	}
+
+	requirements := &DirectiveRequirements{
+		Command: argsMap["command"],
+		Name:    argsMap["name"],
+	}
+
+	switch requirements.Command {
+	case "jobdw", "create_persistent":
+		requirements.FileSystemType = argsMap["type"]
+		requirements.Lifetime = DirectiveLifetimeJob
+		if requirements.Command == "create_persistent" {
+			requirements.Lifetime = DirectiveLifetimePersistent
+		}
+
+		if requirements.Capacity, err = dwdparse.ParseCapacity(argsMap["capacity"]); err != nil {
+			return nil, err
+		}
+	case "persistentdw":
+		requirements.Lifetime = DirectiveLifetimePersistent
+	default:
+		return nil, nil
+	}
+
+	if requirements.Name == "" {
+		return nil, fmt.Errorf("%s directive is missing 'name'", requirements.Command)
+	}
+
+	return requirements, nil
+}
+
 // DirectiveBreakdownStatus defines the storage information WLM needs to select NNF Nodes and request storage from the selected nodes
 type DirectiveBreakdownStatus struct {
+	// Requirements is the storage requirement parsed from the directive
+	Requirements *DirectiveRequirements `json:"requirements,omitempty"`
+
 	// Storage is the storage breakdown for the directive
 	Storage *StorageBreakdown `json:"storage,omitempty"`
 
@@ -158,6 +223,12 @@ type DirectiveBreakdownStatus struct {
 
 	// Error information
 	ResourceError `json:",inline"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
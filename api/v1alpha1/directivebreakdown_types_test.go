@@ -0,0 +1,58 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestNewDirectiveRequirements(t *testing.T) {
+	g := NewWithT(t)
+
+	requirements, err := NewDirectiveRequirements("#DW jobdw type=xfs capacity=10GiB name=scratch")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requirements).To(Equal(&DirectiveRequirements{
+		Command:        "jobdw",
+		Name:           "scratch",
+		FileSystemType: "xfs",
+		Capacity:       10 * 1024 * 1024 * 1024,
+		Lifetime:       DirectiveLifetimeJob,
+	}))
+
+	requirements, err = NewDirectiveRequirements("#DW persistentdw name=shared")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requirements).To(Equal(&DirectiveRequirements{
+		Command:  "persistentdw",
+		Name:     "shared",
+		Lifetime: DirectiveLifetimePersistent,
+	}))
+
+	requirements, err = NewDirectiveRequirements("#DW copy_in source=/a destination=/b")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(requirements).To(BeNil())
+
+	_, err = NewDirectiveRequirements("#DW jobdw type=xfs capacity=lots name=scratch")
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = NewDirectiveRequirements("#DW persistentdw")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+)
+
+const (
+	// RuleSetNameLabel is the name of the rule set a DWDirectiveRule provides. Multiple
+	// versions of a rule set share the same name. Defaults to the name of the DWDirectiveRule.
+	RuleSetNameLabel = "dws.cray.hpe.com/ruleset.name"
+
+	// RuleSetVersionLabel is the dotted numeric version of the rule set (e.g., "1.2.0")
+	RuleSetVersionLabel = "dws.cray.hpe.com/ruleset.version"
+
+	// RuleSetOverridesLabel is the name of the rule set a DWDirectiveRule overrides. Site
+	// specific rules use this to extend the vendor rules without modifying them.
+	RuleSetOverridesLabel = "dws.cray.hpe.com/ruleset.overrides"
+
+	// DefaultRuleSetVersion is the version given to a rule set without a version label
+	DefaultRuleSetVersion = "1.0.0"
+)
+
+// GetRuleSet returns the rule set described by the DWDirectiveRule. Rules without a
+// driver label are given the rule set name as their driver label.
+func (r *DWDirectiveRule) GetRuleSet() dwdparse.RuleSet {
+	labels := r.GetLabels()
+
+	ruleSet := dwdparse.RuleSet{
+		Name:      labels[RuleSetNameLabel],
+		Version:   labels[RuleSetVersionLabel],
+		Overrides: labels[RuleSetOverridesLabel],
+	}
+
+	if ruleSet.Name == "" {
+		ruleSet.Name = r.Name
+	}
+
+	if ruleSet.Version == "" {
+		ruleSet.Version = DefaultRuleSetVersion
+	}
+
+	driverLabel := ruleSet.Name
+	if ruleSet.Overrides != "" {
+		driverLabel = ruleSet.Overrides
+	}
+
+	for _, rule := range r.Spec {
+		if rule.DriverLabel == "" {
+			rule.DriverLabel = driverLabel
+		}
+		ruleSet.Rules = append(ruleSet.Rules, *rule.DeepCopy())
+	}
+
+	return ruleSet
+}
+
+// ListRuleSets returns the applicable rule sets in a namespace. Only the highest version of
+// each rule set is returned, with the rules from any overriding rule sets merged in.
+func ListRuleSets(ctx context.Context, c client.Reader, namespace string) ([]dwdparse.RuleSet, error) {
+	ruleSetList := &DWDirectiveRuleList{}
+	if err := c.List(ctx, ruleSetList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	ruleSets := []dwdparse.RuleSet{}
+	for i := range ruleSetList.Items {
+		ruleSets = append(ruleSets, ruleSetList.Items[i].GetRuleSet())
+	}
+
+	return dwdparse.ApplicableRuleSets(ruleSets)
+}
+
+// RuleSetCache caches the applicable rule sets of each namespace for a time to live, so
+// the rule sets aren't listed and merged for every directive that's validated. A nil
+// RuleSetCache doesn't cache, and lists the rule sets each time.
+// +kubebuilder:object:generate=false
+type RuleSetCache struct {
+	ttl time.Duration
+
+	lock    sync.Mutex
+	entries map[string]ruleSetCacheEntry
+}
+
+type ruleSetCacheEntry struct {
+	ruleSets []dwdparse.RuleSet
+	expires  time.Time
+}
+
+// NewRuleSetCache returns a RuleSetCache that keeps the rule sets for ttl
+func NewRuleSetCache(ttl time.Duration) *RuleSetCache {
+	return &RuleSetCache{
+		ttl:     ttl,
+		entries: map[string]ruleSetCacheEntry{},
+	}
+}
+
+// ListRuleSets returns the applicable rule sets in a namespace, using the cached rule sets
+// if they haven't expired
+func (r *RuleSetCache) ListRuleSets(ctx context.Context, c client.Reader, namespace string) ([]dwdparse.RuleSet, error) {
+	if r == nil {
+		return ListRuleSets(ctx, c, namespace)
+	}
+
+	r.lock.Lock()
+	entry, found := r.entries[namespace]
+	r.lock.Unlock()
+
+	if found && time.Now().Before(entry.expires) {
+		return entry.ruleSets, nil
+	}
+
+	ruleSets, err := ListRuleSets(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	r.lock.Lock()
+	r.entries[namespace] = ruleSetCacheEntry{ruleSets: ruleSets, expires: time.Now().Add(r.ttl)}
+	r.lock.Unlock()
+
+	return ruleSets, nil
+}
+
+// Invalidate drops the cached rule sets for a namespace
+func (r *RuleSetCache) Invalidate(namespace string) {
+	if r == nil {
+		return
+	}
+
+	r.lock.Lock()
+	delete(r.entries, namespace)
+	r.lock.Unlock()
+}
+
+// DirectiveRules returns the rules that directives are validated against: the rules from the
+// applicable rule sets merged over the rules for the commands registered with dwdparse, so
+// the rule sets can override a registered command
+func DirectiveRules(ruleSets []dwdparse.RuleSet) []dwdparse.DWDirectiveRuleSpec {
+	rules := []dwdparse.DWDirectiveRuleSpec{}
+	for _, ruleSet := range ruleSets {
+		rules = append(rules, ruleSet.Rules...)
+	}
+
+	return dwdparse.MergeRules(dwdparse.RegisteredRules(), rules)
+}
+
+// ValidateDWDirectives validates a job's directives against the applicable rule sets in a
+// namespace the same way the Workflow webhook does. All the invalid directives are reported.
+func (r *RuleSetCache) ValidateDWDirectives(ctx context.Context, c client.Reader, namespace string, directives []string) error {
+	ruleSets, err := r.ListRuleSets(ctx, c, namespace)
+	if err != nil {
+		return err
+	}
+
+	rules := DirectiveRules(ruleSets)
+
+	allErrs := field.ErrorList{}
+	for i, err := range dwdparse.ValidateDirectives(rules, directives) {
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("dwDirectives").Index(i), directives[i], err.Error()))
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+)
+
+// ruleClient returns a canned DWDirectiveRule and counts the lists
+type ruleClient struct {
+	client.Reader
+	rule  DWDirectiveRule
+	lists int
+}
+
+func (c *ruleClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	list.(*DWDirectiveRuleList).Items = []DWDirectiveRule{c.rule}
+	return nil
+}
+
+func newRuleClient() *ruleClient {
+	c := &ruleClient{}
+	c.rule.Name = "default"
+	c.rule.Spec = []dwdparse.DWDirectiveRuleSpec{
+		{Command: "jobdw", RuleDefs: []dwdparse.DWDirectiveRuleDef{
+			{Key: "type", Type: "string", Pattern: "^(xfs|gfs2)$", IsRequired: true, IsValueRequired: true},
+			{Key: "capacity", Type: "string", IsRequired: true, IsValueRequired: true},
+			{Key: "name", Type: "string", IsRequired: true, IsValueRequired: true},
+		}},
+	}
+
+	return c
+}
+
+func TestRuleSetCache(t *testing.T) {
+	g := NewWithT(t)
+
+	c := newRuleClient()
+	cache := NewRuleSetCache(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		ruleSets, err := cache.ListRuleSets(context.TODO(), c, "default")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(ruleSets).To(HaveLen(1))
+	}
+	g.Expect(c.lists).To(Equal(1))
+
+	cache.Invalidate("default")
+	_, err := cache.ListRuleSets(context.TODO(), c, "default")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.lists).To(Equal(2))
+
+	// A nil cache lists every time
+	var noCache *RuleSetCache
+	_, err = noCache.ListRuleSets(context.TODO(), c, "default")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.lists).To(Equal(3))
+}
+
+func TestValidateDWDirectives(t *testing.T) {
+	g := NewWithT(t)
+
+	c := newRuleClient()
+	cache := NewRuleSetCache(time.Hour)
+
+	g.Expect(cache.ValidateDWDirectives(context.TODO(), c, "default", []string{
+		"#DW jobdw type=xfs capacity=10GiB name=scratch",
+	})).To(Succeed())
+
+	err := cache.ValidateDWDirectives(context.TODO(), c, "default", []string{
+		"#DW jobdw type=xfs capacity=10GiB name=scratch",
+		"#DW jobdw type=zfs capacity=10GiB name=bad",
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("spec.dwDirectives[1]"))
+}
+
+func TestRuleSetCacheRegisteredCommands(t *testing.T) {
+	g := NewWithT(t)
+
+	dwdparse.RegisterCommand(dwdparse.DWDirectiveRuleSpec{Command: "test_api_registered", DriverLabel: "default", RuleDefs: []dwdparse.DWDirectiveRuleDef{
+		{Key: "name", Type: "string", IsRequired: true, IsValueRequired: true},
+	}})
+
+	cache := NewRuleSetCache(time.Minute)
+	c := newRuleClient()
+
+	// The registered commands are validated along with the commands of the rule sets
+	g.Expect(cache.ValidateDWDirectives(context.TODO(), c, "default", []string{
+		"#DW jobdw type=xfs capacity=1GiB name=a",
+		"#DW test_api_registered name=b",
+	})).To(Succeed())
+
+	g.Expect(cache.ValidateDWDirectives(context.TODO(), c, "default", []string{"#DW test_api_registered"})).ToNot(Succeed())
+
+	// A rule set rule for the same command and driver label overrides the registered rule. The
+	// driver label of a rule set rule defaults to the rule set name.
+	c.rule.Spec = append(c.rule.Spec, dwdparse.DWDirectiveRuleSpec{Command: "test_api_registered", RuleDefs: []dwdparse.DWDirectiveRuleDef{
+		{Key: "name", Type: "string"},
+	}})
+	cache.Invalidate("default")
+	g.Expect(cache.ValidateDWDirectives(context.TODO(), c, "default", []string{"#DW test_api_registered"})).To(Succeed())
+}
+
+// emptyRuleClient lists no DWDirectiveRules
+type emptyRuleClient struct {
+	client.Reader
+}
+
+func (c *emptyRuleClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	list.(*DWDirectiveRuleList).Items = nil
+	return nil
+}
+
+func TestRuleSetCacheRegisteredCommandsOnly(t *testing.T) {
+	g := NewWithT(t)
+
+	dwdparse.RegisterCommand(dwdparse.DWDirectiveRuleSpec{Command: "test_api_registered_only", DriverLabel: "plugin", RuleDefs: []dwdparse.DWDirectiveRuleDef{
+		{Key: "name", Type: "string", IsRequired: true, IsValueRequired: true},
+	}})
+
+	// The registered commands are enough without a rule set in the namespace
+	ruleList := &RuleList{}
+	g.Expect(ruleList.ReadRules(context.TODO(), &emptyRuleClient{})).To(Succeed())
+
+	commands := []string{}
+	for _, rule := range ruleList.GetRuleList() {
+		commands = append(commands, rule.Command)
+	}
+	g.Expect(commands).To(ContainElement("test_api_registered_only"))
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultMountOptionRules are used when there are no MountOptionPolicy resources. The
+// ClientMount controllers do the mounting, so "noauto" is never useful, and gfs2 doesn't
+// support "user_xattr".
+var DefaultMountOptionRules = []MountOptionRule{
+	{Type: "*", Denied: []string{"noauto"}},
+	{Type: "gfs2", Denied: []string{"user_xattr"}},
+}
+
+// ListMountOptionRules returns the rules from all the MountOptionPolicy resources, or
+// DefaultMountOptionRules if there aren't any
+func ListMountOptionRules(ctx context.Context, c client.Reader) ([]MountOptionRule, error) {
+	policies := &MountOptionPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return nil, err
+	}
+
+	if len(policies.Items) == 0 {
+		return DefaultMountOptionRules, nil
+	}
+
+	rules := []MountOptionRule{}
+	for _, policy := range policies.Items {
+		rules = append(rules, policy.Spec.Rules...)
+	}
+
+	return rules, nil
+}
+
+// splitMountOptions splits a comma separated mount option string
+func splitMountOptions(options string) []string {
+	split := []string{}
+	for _, option := range strings.Split(options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			split = append(split, option)
+		}
+	}
+
+	return split
+}
+
+// matchMountOption returns true if the option matches one of the entries
+func matchMountOption(option string, entries []string) bool {
+	key, _, _ := strings.Cut(option, "=")
+
+	for _, entry := range entries {
+		if entry == option || entry == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateMountOptions checks the options of each mount against the rules for its file
+// system type. All the invalid options are reported.
+func ValidateMountOptions(mounts []ClientMountInfo, rules []MountOptionRule) error {
+	allErrs := field.ErrorList{}
+	mountsPath := field.NewPath("spec").Child("mounts")
+
+	for i, mount := range mounts {
+		for _, option := range splitMountOptions(mount.Options) {
+			for _, rule := range rules {
+				if rule.Type != "*" && rule.Type != string(mount.Type) {
+					continue
+				}
+
+				if matchMountOption(option, rule.Denied) {
+					allErrs = append(allErrs, field.Invalid(mountsPath.Index(i).Child("options"), option,
+						fmt.Sprintf("option is not allowed for file system type '%s'", mount.Type)))
+					break
+				}
+
+				if len(rule.Allowed) != 0 && !matchMountOption(option, rule.Allowed) {
+					allErrs = append(allErrs, field.Invalid(mountsPath.Index(i).Child("options"), option,
+						fmt.Sprintf("option is not in the allowed list for file system type '%s'", mount.Type)))
+					break
+				}
+			}
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateMountOptions(t *testing.T) {
+	rules := []MountOptionRule{
+		{Type: "*", Denied: []string{"noauto"}},
+		{Type: "gfs2", Denied: []string{"user_xattr"}},
+		{Type: "xfs", Allowed: []string{"noatime", "logbsize", "inode64"}},
+		{Type: "ext4", Denied: []string{"data=journal"}},
+	}
+
+	tests := []struct {
+		mount ClientMountInfo
+		valid bool
+	}{
+		{ClientMountInfo{Type: "gfs2", Options: "rw,noatime"}, true},
+		{ClientMountInfo{Type: "gfs2", Options: "rw,user_xattr"}, false},
+		{ClientMountInfo{Type: "lustre", Options: "noauto"}, false},
+		{ClientMountInfo{Type: "xfs", Options: "noatime, logbsize=256k"}, true},
+		{ClientMountInfo{Type: "xfs", Options: "noatime,nobarrier"}, false},
+		{ClientMountInfo{Type: "ext4", Options: "data=ordered"}, true},
+		{ClientMountInfo{Type: "ext4", Options: "data=journal"}, false},
+		{ClientMountInfo{Type: "tmpfs", Options: ""}, true},
+	}
+
+	for _, test := range tests {
+		err := ValidateMountOptions([]ClientMountInfo{test.mount}, rules)
+		if test.valid {
+			NewWithT(t).Expect(err).ToNot(HaveOccurred(), "%s: %s", test.mount.Type, test.mount.Options)
+		} else {
+			NewWithT(t).Expect(err).To(HaveOccurred(), "%s: %s", test.mount.Type, test.mount.Options)
+		}
+	}
+}
+
+func TestValidateMountOptionsReportsAll(t *testing.T) {
+	g := NewWithT(t)
+
+	mounts := []ClientMountInfo{
+		{Type: "gfs2", Options: "user_xattr,noauto"},
+		{Type: "xfs", Options: "noauto"},
+	}
+
+	err := ValidateMountOptions(mounts, DefaultMountOptionRules)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("spec.mounts[0].options"))
+	g.Expect(err.Error()).To(ContainSubstring("spec.mounts[1].options"))
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MountOptionRule restricts the mount options that ClientMounts may use for a file system type
+type MountOptionRule struct {
+	// Type is the file system type the rule applies to, or "*" for all types
+	Type string `json:"type"`
+
+	// Allowed is the list of options that may be used. If empty, any option that isn't
+	// denied may be used. An entry without a value (e.g., "flock") matches the option with
+	// any value, and an entry with a value (e.g., "data=ordered") matches only that value.
+	Allowed []string `json:"allowed,omitempty"`
+
+	// Denied is the list of options that may not be used. Entries are matched the same way
+	// as Allowed.
+	Denied []string `json:"denied,omitempty"`
+}
+
+// MountOptionPolicySpec defines the mount option rules
+type MountOptionPolicySpec struct {
+	// Rules are applied to the options of every mount in a ClientMount. All the rules
+	// matching the file system type of the mount must pass.
+	Rules []MountOptionRule `json:"rules,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+
+// MountOptionPolicy is the Schema for the mountoptionpolicies API. The rules from all the
+// MountOptionPolicy resources are checked by the ClientMount webhook. If there are no
+// MountOptionPolicy resources, DefaultMountOptionRules are used.
+type MountOptionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MountOptionPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MountOptionPolicyList contains a list of MountOptionPolicy
+type MountOptionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MountOptionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MountOptionPolicy{}, &MountOptionPolicyList{})
+}
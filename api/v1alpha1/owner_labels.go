@@ -139,6 +139,82 @@ func InheritParentLabels(child metav1.Object, owner metav1.Object) {
 	child.SetLabels(labels)
 }
 
+// InheritLabels copies the workflow and persistent storage labels from a parent resource to a
+// child resource. Unlike InheritParentLabels, only the standard DWS labels are copied, so
+// drivers can propagate the workflow association without picking up unrelated labels.
+func InheritLabels(child metav1.Object, parent metav1.Object) {
+	labels := child.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	for _, key := range []string{WorkflowNameLabel, WorkflowNamespaceLabel, PersistentStorageNameLabel, PersistentStorageNamespaceLabel} {
+		if value, exists := parent.GetLabels()[key]; exists {
+			labels[key] = value
+		}
+	}
+
+	child.SetLabels(labels)
+}
+
+// OwnerFromLabels returns the kind and name of the owner recorded in the owner labels of a
+// resource. The returned bool is false if the resource doesn't have the owner labels.
+func OwnerFromLabels(obj metav1.Object) (string, types.NamespacedName, bool) {
+	labels := obj.GetLabels()
+
+	kind, exists := labels[OwnerKindLabel]
+	if !exists {
+		return "", types.NamespacedName{}, false
+	}
+
+	name, exists := labels[OwnerNameLabel]
+	if !exists {
+		return "", types.NamespacedName{}, false
+	}
+
+	namespace, exists := labels[OwnerNamespaceLabel]
+	if !exists {
+		return "", types.NamespacedName{}, false
+	}
+
+	return kind, types.NamespacedName{Name: name, Namespace: namespace}, true
+}
+
+// WorkflowFromLabels returns the name of the workflow recorded in the workflow labels of a
+// resource. The returned bool is false if the resource doesn't have the workflow name label.
+func WorkflowFromLabels(obj metav1.Object) (types.NamespacedName, bool) {
+	labels := obj.GetLabels()
+
+	name, exists := labels[WorkflowNameLabel]
+	if !exists {
+		return types.NamespacedName{}, false
+	}
+
+	return types.NamespacedName{Name: name, Namespace: labels[WorkflowNamespaceLabel]}, true
+}
+
+// MatchingWorkflowName returns the MatchingLabels to match the workflow labels for a workflow
+// that isn't available as an object
+func MatchingWorkflowName(workflow types.NamespacedName) client.MatchingLabels {
+	return client.MatchingLabels(map[string]string{
+		WorkflowNameLabel:      workflow.Name,
+		WorkflowNamespaceLabel: workflow.Namespace,
+	})
+}
+
+// MatchingOwnerKind returns the MatchingLabels to match all the resources owned by any
+// resource of the kind
+func MatchingOwnerKind(kind string) client.MatchingLabels {
+	return client.MatchingLabels(map[string]string{
+		OwnerKindLabel: kind,
+	})
+}
+
+// HasOwnerLabels returns a ListOption that matches the resources with any owner labels
+func HasOwnerLabels() client.HasLabels {
+	return client.HasLabels{OwnerKindLabel, OwnerNameLabel, OwnerNamespaceLabel}
+}
+
 // DeleteStatus provides information about the status of DeleteChildren* operation
 // +kubebuilder:object:generate=false
 type DeleteStatus struct {
@@ -263,22 +339,10 @@ func DeleteChildren(ctx context.Context, c client.Client, childObjectLists []Obj
 }
 
 func OwnerLabelMapFunc(o client.Object) []reconcile.Request {
-	labels := o.GetLabels()
-
-	ownerName, exists := labels[OwnerNameLabel]
-	if exists == false {
+	_, owner, exists := OwnerFromLabels(o)
+	if !exists {
 		return []reconcile.Request{}
 	}
 
-	ownerNamespace, exists := labels[OwnerNamespaceLabel]
-	if exists == false {
-		return []reconcile.Request{}
-	}
-
-	return []reconcile.Request{
-		{NamespacedName: types.NamespacedName{
-			Name:      ownerName,
-			Namespace: ownerNamespace,
-		}},
-	}
+	return []reconcile.Request{{NamespacedName: owner}}
 }
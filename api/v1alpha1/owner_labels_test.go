@@ -0,0 +1,66 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestOwnerLabels(t *testing.T) {
+	g := NewWithT(t)
+
+	workflow := &Workflow{ObjectMeta: metav1.ObjectMeta{Name: "wf", Namespace: "default"}}
+	parent := &ClientMount{ObjectMeta: metav1.ObjectMeta{
+		Name:      "parent",
+		Namespace: "rabbit-0",
+		Labels:    map[string]string{"other": "label"},
+	}}
+	AddWorkflowLabels(parent, workflow)
+
+	child := &ClientMount{}
+	_, _, found := OwnerFromLabels(child)
+	g.Expect(found).To(BeFalse())
+	_, found = WorkflowFromLabels(child)
+	g.Expect(found).To(BeFalse())
+
+	AddOwnerLabels(child, parent)
+	InheritLabels(child, parent)
+
+	kind, owner, found := OwnerFromLabels(child)
+	g.Expect(found).To(BeTrue())
+	g.Expect(kind).To(Equal("ClientMount"))
+	g.Expect(owner).To(Equal(types.NamespacedName{Name: "parent", Namespace: "rabbit-0"}))
+
+	key, found := WorkflowFromLabels(child)
+	g.Expect(found).To(BeTrue())
+	g.Expect(key).To(Equal(types.NamespacedName{Name: "wf", Namespace: "default"}))
+	g.Expect(child.GetLabels()).ToNot(HaveKey("other"))
+
+	g.Expect(MatchingWorkflowName(key)).To(Equal(MatchingWorkflow(workflow)))
+	g.Expect(MatchingOwnerKind("ClientMount")).To(HaveKeyWithValue(OwnerKindLabel, "ClientMount"))
+
+	RemoveOwnerLabels(child)
+	_, _, found = OwnerFromLabels(child)
+	g.Expect(found).To(BeFalse())
+}
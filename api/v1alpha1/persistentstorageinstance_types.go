@@ -86,6 +86,12 @@ type PersistentStorageInstanceStatus struct {
 
 	// Error information
 	ResourceError `json:",inline"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
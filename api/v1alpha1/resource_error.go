@@ -19,6 +19,10 @@
 
 package v1alpha1
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 type ResourceErrorInfo struct {
 	// Optional user facing message if the error is relevant to an end user
 	UserMessage string `json:"userMessage,omitempty"`
@@ -28,6 +32,15 @@ type ResourceErrorInfo struct {
 
 	// Indication if the error is likely recoverable or not
 	Recoverable bool `json:"recoverable"`
+
+	// Number of consecutive times the same error occurred
+	Count int `json:"count,omitempty"`
+
+	// Time the error first occurred
+	FirstSeen *metav1.Time `json:"firstSeen,omitempty"`
+
+	// Time the error last occurred
+	LastSeen *metav1.Time `json:"lastSeen,omitempty"`
 }
 
 type ResourceError struct {
@@ -75,10 +88,38 @@ func (e *ResourceErrorInfo) WithUserMessage(message string) *ResourceErrorInfo {
 	return e
 }
 
+// Repeat records that the error occurred at now. If the error is the same as the previous
+// error, the count and the time it was first seen are carried over so a persistent failure
+// is summarized rather than reported as a new error each time. It returns whether the
+// error repeats the previous error.
+func (e *ResourceErrorInfo) Repeat(previous *ResourceErrorInfo, now metav1.Time) bool {
+	e.LastSeen = &now
+
+	if previous == nil || previous.FirstSeen == nil || previous.DebugMessage != e.DebugMessage ||
+		previous.UserMessage != e.UserMessage || previous.Recoverable != e.Recoverable {
+		e.Count = 1
+		e.FirstSeen = &now
+		return false
+	}
+
+	e.Count = previous.Count + 1
+	e.FirstSeen = previous.FirstSeen.DeepCopy()
+	return true
+}
+
 func (e *ResourceErrorInfo) Error() string {
 	return e.DebugMessage
 }
 
+// FatalError returns the error if it's fatal, otherwise nil
+func (e *ResourceError) FatalError() *ResourceErrorInfo {
+	if e.Error == nil || e.Error.Recoverable {
+		return nil
+	}
+
+	return e.Error
+}
+
 func (e *ResourceError) SetResourceError(err error) {
 	if err == nil {
 		e.Error = nil
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1alpha1
+
+const (
+	// ClientMountSkipUnmountAnnotation removes the finalizer of a deleted ClientMount without
+	// unmounting its file systems, for sites that clean up the nodes with their own tooling
+	// during disaster recovery. The mounts are left on the node. As a guard against
+	// annotating ClientMounts in bulk, the value must be the name of the ClientMount;
+	// anything else is ignored with a warning event. For example:
+	//
+	//	kubectl annotate clientmount -n compute-01 job-1 dws.cray.hpe.com/skip-unmount=job-1
+	//
+	// The annotation has no effect until the ClientMount is deleted.
+	ClientMountSkipUnmountAnnotation = "dws.cray.hpe.com/skip-unmount"
+)
+
+// SkipUnmount returns whether the skip-unmount annotation is set on the ClientMount and
+// whether its value confirms it
+func (cm *ClientMount) SkipUnmount() (requested bool, confirmed bool) {
+	value, found := cm.GetAnnotations()[ClientMountSkipUnmountAnnotation]
+	return found, found && value == cm.GetName()
+}
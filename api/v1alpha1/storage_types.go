@@ -69,6 +69,110 @@ type Node struct {
 	// Status of the node
 	// +kubebuilder:validation:Enum=Starting;Ready;Disabled;NotPresent;Offline;Failed
 	Status string `json:"status,omitempty"`
+
+	// OSInfo is the operating system information reported by the mount-daemon on a
+	// compute node
+	OSInfo *NodeOSInfo `json:"osInfo,omitempty"`
+
+	// Accessibility is the compute node's view of whether it can reach the storage its
+	// ClientMounts use, reported by the mount-daemon's storage reporter
+	Accessibility *NodeStorageAccessibility `json:"accessibility,omitempty"`
+}
+
+// NodeStorageTargetType is the kind of storage target a compute node checks
+type NodeStorageTargetType string
+
+const (
+	// NodeStorageTargetLustreMGS is a Lustre MGS NID that's checked with an LNet ping
+	NodeStorageTargetLustreMGS NodeStorageTargetType = "LustreMGS"
+
+	// NodeStorageTargetLVMVolumeGroup is an LVM volume group whose physical volumes must
+	// be visible on the node
+	NodeStorageTargetLVMVolumeGroup NodeStorageTargetType = "LVMVolumeGroup"
+)
+
+// NodeStorageTarget is a storage target a compute node checked
+type NodeStorageTarget struct {
+	// Type is the kind of target
+	// +kubebuilder:validation:Enum=LustreMGS;LVMVolumeGroup
+	Type NodeStorageTargetType `json:"type"`
+
+	// Name is the NID or the volume group name
+	Name string `json:"name"`
+
+	// Reachable is true if the node could reach the target
+	Reachable bool `json:"reachable"`
+
+	// Message describes why the target couldn't be reached
+	Message string `json:"message,omitempty"`
+}
+
+// NodeStorageAccessibility is what a compute node found when it checked the storage targets
+// of its ClientMounts
+type NodeStorageAccessibility struct {
+	// Status is Ready if every target could be reached and Degraded if any couldn't
+	// +kubebuilder:validation:Enum=Ready;Degraded
+	Status string `json:"status"`
+
+	// Targets are the targets that were checked
+	Targets []NodeStorageTarget `json:"targets,omitempty"`
+
+	// LastReported is when the mount-daemon last checked the targets
+	LastReported *metav1.Time `json:"lastReported,omitempty"`
+}
+
+// NodeOSInfo describes the software on a node that determines which file systems it
+// can mount
+type NodeOSInfo struct {
+	// KernelVersion is the release of the running kernel (e.g., "4.18.0-372.el8.x86_64")
+	KernelVersion string `json:"kernelVersion,omitempty"`
+
+	// LustreVersion is the version of the Lustre client, or empty if it isn't installed
+	LustreVersion string `json:"lustreVersion,omitempty"`
+
+	// LVMVersion is the version of the LVM tools, or empty if they aren't installed
+	LVMVersion string `json:"lvmVersion,omitempty"`
+
+	// KernelModules is the list of loaded kernel modules that file systems depend on
+	KernelModules []string `json:"kernelModules,omitempty"`
+
+	// LastReported is when the mount-daemon last reported the information
+	LastReported *metav1.Time `json:"lastReported,omitempty"`
+}
+
+// FileSystemKernelModules are the kernel modules a node must have loaded to mount each
+// file system type
+var FileSystemKernelModules = map[FileSystemType][]string{
+	FileSystemTypeCeph:   {"ceph"},
+	FileSystemTypeGFS2:   {"gfs2", "dlm"},
+	FileSystemTypeLustre: {"lustre"},
+	FileSystemTypeXFS:    {"xfs"},
+}
+
+// MissingKernelModules returns the kernel modules the node needs to mount the file system
+// type but doesn't have loaded. Nothing is missing if the node hasn't reported its
+// information.
+func (i *NodeOSInfo) MissingKernelModules(fsType FileSystemType) []string {
+	if i == nil {
+		return nil
+	}
+
+	missing := []string{}
+	for _, module := range FileSystemKernelModules[fsType] {
+		found := false
+		for _, loaded := range i.KernelModules {
+			if loaded == module {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, module)
+		}
+	}
+
+	return missing
 }
 
 // StorageAccess contains nodes and the protocol that may access the storage
@@ -104,13 +208,27 @@ type StorageData struct {
 	// +kubebuilder:default:=0
 	Capacity int64 `json:"capacity"`
 
+	// Allocated is the number of bytes currently allocated from this storage as
+	// reported by the driver
+	Allocated int64 `json:"allocated,omitempty"`
+
 	// Status is the overall status of the storage
 	// +kubebuilder:validation:Enum=Starting;Ready;Disabled;NotPresent;Offline;Failed
 	Status string `json:"status,omitempty"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // Storage is the Schema for the storages API
 // +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".data.type",description="Type of storage"
+// +kubebuilder:printcolumn:name="CAPACITY",type="integer",JSONPath=".data.capacity",description="Number of bytes the storage provides"
+// +kubebuilder:printcolumn:name="STATUS",type="string",JSONPath=".data.status",description="Overall status of the storage"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 type Storage struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -21,8 +21,24 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+const (
+	// StoragePoolReady indicates at least one Storage resource in the pool is ready
+	StoragePoolReady = "Ready"
+
+	// StoragePoolUnavailable indicates no Storage resource in the pool is ready
+	StoragePoolUnavailable = "Unavailable"
 )
 
+// StoragePoolLabel returns the label key used to add a Storage resource to the named
+// StoragePool. A Storage resource is a member of the pool when the label is set to "true".
+func StoragePoolLabel(poolName string) string {
+	return StoragePoolLabelPrefix + poolName
+}
+
 // StoragePoolSpec defines the desired state of StoragePool
 type StoragePoolSpec struct {
 	PoolID      string `json:"poolID"`
@@ -32,13 +48,45 @@ type StoragePoolSpec struct {
 	Free        int    `json:"free"`
 }
 
-// StoragePoolStatus defines the observed state of StoragePool
+// StoragePoolStatus defines the observed state of StoragePool. The capacity is aggregated
+// from the Storage resources in the pool's namespace labeled with StoragePoolLabel(name).
 type StoragePoolStatus struct {
+	// State is Ready if any Storage resource in the pool is ready, otherwise Unavailable
 	State string `json:"state"`
+
+	// StorageCount is the number of Storage resources in the pool
+	StorageCount int `json:"storageCount"`
+
+	// ReadyStorageCount is the number of Storage resources in the pool that are ready
+	ReadyStorageCount int `json:"readyStorageCount"`
+
+	// Capacity is the total number of bytes provided by the ready Storage resources
+	Capacity int64 `json:"capacity"`
+
+	// Allocated is the number of bytes allocated from the ready Storage resources
+	Allocated int64 `json:"allocated"`
+
+	// Allocatable is the number of bytes that can still be allocated from the ready
+	// Storage resources
+	Allocatable int64 `json:"allocatable"`
+
+	// LargestAllocatable is the largest number of bytes that can be allocated from a
+	// single Storage resource in the pool
+	LargestAllocatable int64 `json:"largestAllocatable"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="STATE",type="string",JSONPath=".status.state",description="State of the storage pool"
+//+kubebuilder:printcolumn:name="STORAGE",type="integer",JSONPath=".status.readyStorageCount",description="Number of ready Storage resources in the pool"
+//+kubebuilder:printcolumn:name="ALLOCATABLE",type="integer",JSONPath=".status.allocatable",description="Number of bytes that can be allocated from the pool"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
 
 // StoragePool is the Schema for the storagepools API
 type StoragePool struct {
@@ -49,6 +97,10 @@ type StoragePool struct {
 	Status StoragePoolStatus `json:"status,omitempty"`
 }
 
+func (sp *StoragePool) GetStatus() updater.Status[*StoragePoolStatus] {
+	return &sp.Status
+}
+
 //+kubebuilder:object:root=true
 
 // StoragePoolList contains a list of StoragePool
@@ -21,6 +21,8 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/HewlettPackard/dws/utils/updater"
 )
 
 // SystemConfigurationComputeNode describes a compute node in the system
@@ -82,6 +84,10 @@ type SystemConfiguration struct {
 	Status SystemConfigurationStatus `json:"status,omitempty"`
 }
 
+func (s *SystemConfiguration) GetStatus() updater.Status[*SystemConfigurationStatus] {
+	return &s.Status
+}
+
 //+kubebuilder:object:root=true
 
 // SystemConfigurationList contains a list of SystemConfiguration
@@ -187,6 +187,11 @@ type WorkflowStatus struct {
 	// Reference to Computes
 	Computes corev1.ObjectReference `json:"computes,omitempty"`
 
+	// ClientMounts is the readiness of the ClientMounts with the workflow labels, and the
+	// errors of the ones that aren't ready. It's empty if the workflow has no ClientMounts.
+	// +optional
+	ClientMounts *ClientMountSummary `json:"clientMounts,omitempty"`
+
 	// Time of the most recent desiredState change
 	DesiredStateChange *metav1.MicroTime `json:"desiredStateChange,omitempty"`
 
@@ -195,6 +200,18 @@ type WorkflowStatus struct {
 
 	// Duration of the last state change
 	ElapsedTimeLastState string `json:"elapsedTimeLastState,omitempty"`
+
+	// DirectiveWarnings are the changes made to the #DW directives when the Workflow was
+	// created, such as a capacity rounded up to the allocation granularity of its rule or a
+	// deprecated argument name replaced. Each warning names the index of its directive.
+	// +optional
+	DirectiveWarnings []string `json:"directiveWarnings,omitempty"`
+
+	// Conditions are the standard Ready, Progressing, and Error conditions
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -25,6 +25,7 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -43,9 +44,17 @@ var workflowlog = logf.Log.WithName("workflow-resource")
 
 var c client.Client
 
+// WorkflowRuleSetCacheTTL is how long the Workflow webhook caches the rule sets. It must be
+// set before SetupWebhookWithManager is called.
+var WorkflowRuleSetCacheTTL = 5 * time.Second
+
+// workflowRuleSets caches the rule sets used by the Workflow webhook
+var workflowRuleSets *RuleSetCache
+
 // SetupWebhookWithManager connects the webhook with the manager
 func (w *Workflow) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	c = mgr.GetClient()
+	workflowRuleSets = NewRuleSetCache(WorkflowRuleSetCacheTTL)
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(w).
 		Complete()
@@ -59,7 +68,16 @@ var _ webhook.Defaulter = &Workflow{}
 func (w *Workflow) Default() {
 	workflowlog.Info("default", "name", w.Name)
 
-	_ = checkDirectives(w, &MutatingRuleParser{})
+	ruleParser := &MutatingRuleParser{RuleList{cache: workflowRuleSets}}
+	_ = checkDirectives(context.TODO(), w, ruleParser)
+
+	// The directives can't change after the Workflow is created, so the argument aliases are
+	// only replaced, and the capacities rounded, on creation. ValidateCreate rejects the
+	// capacities that can't be rounded.
+	if w.Generation == 0 {
+		w.replaceDirectiveAliases(ruleParser.GetRuleList())
+		_ = w.roundDirectiveCapacities(ruleParser.GetRuleList())
+	}
 
 	if w.Status.Env == nil {
 		w.Status.Env = make(map[string]string)
@@ -90,7 +108,14 @@ func (w *Workflow) ValidateCreate() error {
 		return field.Forbidden(field.NewPath("Status").Child("State"), "the status state may not be set")
 	}
 
-	return checkDirectives(w, &ValidatingRuleParser{})
+	ruleParser := &ValidatingRuleParser{RuleList{cache: workflowRuleSets}}
+	if err := checkDirectives(context.TODO(), w, ruleParser); err != nil {
+		return err
+	}
+
+	// The defaulting webhook can't fail, so a rule that kept it from rounding a capacity is
+	// reported here
+	return w.DeepCopy().roundDirectiveCapacities(ruleParser.GetRuleList())
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
@@ -211,13 +236,13 @@ func validateWorkflowImmutable(newWorkflow *Workflow, oldWorkflow *Workflow) err
 	return nil
 }
 
-func checkDirectives(workflow *Workflow, ruleParser RuleParser) error {
+func checkDirectives(ctx context.Context, workflow *Workflow, ruleParser RuleParser) error {
 	// Ok if we don't have any DW directives, stop parsing.
 	if len(workflow.Spec.DWDirectives) == 0 {
 		return nil
 	}
 
-	err := ruleParser.ReadRules()
+	err := ruleParser.ReadRules(ctx, c)
 	if err != nil {
 		return err
 	}
@@ -248,13 +273,66 @@ func checkDirectives(workflow *Workflow, ruleParser RuleParser) error {
 		}
 	}
 
+	// Each directive is valid, so check how they relate to each other
+	if violations := dwdparse.ValidateDirectiveSet(workflow.Spec.DWDirectives); len(violations) != 0 {
+		workflowlog.Info("dwDirective validation failed", "Error", violations)
+		return violations
+	}
+
 	return nil
 }
 
+// replaceDirectiveAliases replaces the argument aliases in the directives with the current
+// argument names, so the drivers only see the current names. A directive that repeats an
+// argument by its alias is left for checkDirectives to reject.
+func (w *Workflow) replaceDirectiveAliases(rules []dwdparse.DWDirectiveRuleSpec) {
+	for i := range w.Spec.DWDirectives {
+		for _, rule := range rules {
+			directive, warnings, err := dwdparse.ReplaceAliases(rule, w.Spec.DWDirectives[i])
+			if err != nil {
+				continue
+			}
+
+			w.addDirectiveWarnings(i, warnings)
+			w.Spec.DWDirectives[i] = directive
+		}
+	}
+}
+
+// roundDirectiveCapacities rounds the capacities in the directives up to the allocation
+// granularity of the rules, so the Workflow shows the capacity that will be allocated. The
+// directives that can't be rounded are left as they are and returned as errors.
+func (w *Workflow) roundDirectiveCapacities(rules []dwdparse.DWDirectiveRuleSpec) error {
+	allErrs := field.ErrorList{}
+	for i := range w.Spec.DWDirectives {
+		for _, rule := range rules {
+			directive, warnings, err := dwdparse.RoundCapacities(rule, w.Spec.DWDirectives[i])
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("dwDirectives").Index(i), w.Spec.DWDirectives[i], err.Error()))
+				continue
+			}
+
+			w.addDirectiveWarnings(i, warnings)
+			w.Spec.DWDirectives[i] = directive
+		}
+	}
+
+	return allErrs.ToAggregate()
+}
+
+// addDirectiveWarnings records the warnings for a directive in the status, where the user
+// can see them
+func (w *Workflow) addDirectiveWarnings(index int, warnings dwdparse.Warnings) {
+	for _, warning := range warnings {
+		workflowlog.Info("dwDirective warning", "name", w.Name, "directive", index, "warning", warning)
+		w.Status.DirectiveWarnings = append(w.Status.DirectiveWarnings, fmt.Sprintf("directive %d: %s", index, warning))
+	}
+}
+
 // RuleParser defines the interface a rule parser must provide
 // +kubebuilder:object:generate=false
 type RuleParser interface {
-	ReadRules() error
+	ReadRules(ctx context.Context, c client.Reader) error
 	GetRuleList() []dwdparse.DWDirectiveRuleSpec
 	MatchedDirective(*Workflow, string, int, string)
 }
@@ -263,34 +341,28 @@ type RuleParser interface {
 // +kubebuilder:object:generate=false
 type RuleList struct {
 	rules []dwdparse.DWDirectiveRuleSpec
+
+	// cache is used to read the rule sets if set
+	cache *RuleSetCache
 }
 
-// ReadRules imports the RulesList into usable go structures.
-func (r *RuleList) ReadRules() error {
-	ruleSetList := &DWDirectiveRuleList{}
-	ns := client.InNamespace(os.Getenv("POD_NAMESPACE"))
-	listOpts := []client.ListOption{
-		// Use all the DWDirectiveRules in the namespace we're running in
-		ns,
-	}
+// ReadRules imports the RulesList into usable go structures. The rules come from the
+// applicable DWDirectiveRule rule sets in the namespace we're running in, along with the
+// commands registered with dwdparse.
+func (r *RuleList) ReadRules(ctx context.Context, c client.Reader) error {
+	ns := os.Getenv("POD_NAMESPACE")
 
-	if err := c.List(context.TODO(), ruleSetList, listOpts...); err != nil {
+	ruleSets, err := r.cache.ListRuleSets(ctx, c, ns)
+	if err != nil {
 		return err
 	}
 
-	if len(ruleSetList.Items) == 0 {
+	// A namespace without rule sets is fine if the drivers have registered their commands
+	if len(ruleSets) == 0 && len(dwdparse.RegisteredCommands()) == 0 {
 		return fmt.Errorf("unable to find ruleset in namespace: %s", ns)
 	}
 
-	r.rules = []dwdparse.DWDirectiveRuleSpec{}
-	for _, ruleSet := range ruleSetList.Items {
-		for _, rule := range ruleSet.Spec {
-			if rule.DriverLabel == "" {
-				rule.DriverLabel = ruleSet.Name
-			}
-			r.rules = append(r.rules, rule)
-		}
-	}
+	r.rules = DirectiveRules(ruleSets)
 
 	return nil
 }
@@ -22,11 +22,14 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"testing"
 
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/HewlettPackard/dws/utils/dwdparse"
 )
 
 // These tests are written in BDD-style using Ginkgo framework. Refer to
@@ -136,3 +139,39 @@ var _ = Describe("Workflow Webhook", func() {
 		)
 	})
 })
+
+func TestWorkflowDirectiveWarnings(t *testing.T) {
+	g := NewWithT(t)
+
+	rules := []dwdparse.DWDirectiveRuleSpec{
+		{Command: "jobdw", RuleDefs: []dwdparse.DWDirectiveRuleDef{
+			{Key: "capacity", Type: "string", RoundUpTo: "192GiB"},
+			{Key: "name", Alias: "label", Type: "string"},
+		}},
+	}
+
+	workflow := &Workflow{Spec: WorkflowSpec{DWDirectives: []string{
+		"#DW jobdw capacity=192GiB name=a",
+		"#DW jobdw capacity=100GiB label=b",
+	}}}
+
+	// The changes to the directives are recorded in the status
+	workflow.replaceDirectiveAliases(rules)
+	g.Expect(workflow.roundDirectiveCapacities(rules)).To(Succeed())
+	g.Expect(workflow.Spec.DWDirectives).To(Equal([]string{
+		"#DW jobdw capacity=192GiB name=a",
+		"#DW jobdw capacity=192GiB name=b",
+	}))
+	g.Expect(workflow.Status.DirectiveWarnings).To(Equal([]string{
+		"directive 1: argument 'label' is replaced by 'name'",
+		"directive 1: argument 'capacity' is rounded up from 100GiB to 192GiB",
+	}))
+
+	// A rule that can't round the capacity is reported rather than skipped
+	rules[0].RuleDefs[0].RoundUpTo = "192"
+	workflow = &Workflow{Spec: WorkflowSpec{DWDirectives: []string{"#DW jobdw capacity=100GiB name=a"}}}
+	err := workflow.roundDirectiveCapacities(rules)
+	g.Expect(err).To(MatchError(ContainSubstring("spec.dwDirectives[0]")))
+	g.Expect(err).To(MatchError(ContainSubstring("invalid roundUpTo '192'")))
+	g.Expect(workflow.Spec.DWDirectives[0]).To(Equal("#DW jobdw capacity=100GiB name=a"))
+}
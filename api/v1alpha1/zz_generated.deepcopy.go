@@ -26,7 +26,8 @@ package v1alpha1
 
 import (
 	"github.com/HewlettPackard/dws/utils/dwdparse"
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -97,19 +98,104 @@ func (in *ClientMount) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountCapabilities) DeepCopyInto(out *ClientMountCapabilities) {
+	*out = *in
+	if in.DeviceTypes != nil {
+		in, out := &in.DeviceTypes, &out.DeviceTypes
+		*out = make([]ClientMountDeviceType, len(*in))
+		copy(*out, *in)
+	}
+	if in.FileSystemTypes != nil {
+		in, out := &in.FileSystemTypes, &out.FileSystemTypes
+		*out = make([]FileSystemType, len(*in))
+		copy(*out, *in)
+	}
+	if in.Features != nil {
+		in, out := &in.Features, &out.Features
+		*out = make([]ClientMountFeature, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountCapabilities.
+func (in *ClientMountCapabilities) DeepCopy() *ClientMountCapabilities {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountCapabilities)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountCreateOptions) DeepCopyInto(out *ClientMountCreateOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountCreateOptions.
+func (in *ClientMountCreateOptions) DeepCopy() *ClientMountCreateOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountCreateOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountDevice) DeepCopyInto(out *ClientMountDevice) {
 	*out = *in
 	if in.Lustre != nil {
 		in, out := &in.Lustre, &out.Lustre
 		*out = new(ClientMountDeviceLustre)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.LVM != nil {
 		in, out := &in.LVM, &out.LVM
 		*out = new(ClientMountDeviceLVM)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Tmpfs != nil {
+		in, out := &in.Tmpfs, &out.Tmpfs
+		*out = new(ClientMountDeviceTmpfs)
+		**out = **in
+	}
+	if in.SwapFile != nil {
+		in, out := &in.SwapFile, &out.SwapFile
+		*out = new(ClientMountDeviceSwapFile)
+		**out = **in
+	}
+	if in.Multipath != nil {
+		in, out := &in.Multipath, &out.Multipath
+		*out = new(ClientMountDeviceMultipath)
+		**out = **in
+	}
+	if in.NFS != nil {
+		in, out := &in.NFS, &out.NFS
+		*out = new(ClientMountDeviceNFS)
+		**out = **in
+	}
+	if in.Block != nil {
+		in, out := &in.Block, &out.Block
+		*out = new(ClientMountDeviceBlock)
+		**out = **in
+	}
+	if in.CephFS != nil {
+		in, out := &in.CephFS, &out.CephFS
+		*out = new(ClientMountDeviceCephFS)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RBD != nil {
+		in, out := &in.RBD, &out.RBD
+		*out = new(ClientMountDeviceRBD)
+		**out = **in
+	}
+	if in.None != nil {
+		in, out := &in.None, &out.None
+		*out = new(ClientMountDeviceNone)
+		**out = **in
+	}
 	if in.DeviceReference != nil {
 		in, out := &in.DeviceReference, &out.DeviceReference
 		*out = new(ClientMountDeviceReference)
@@ -127,6 +213,41 @@ func (in *ClientMountDevice) DeepCopy() *ClientMountDevice {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceBlock) DeepCopyInto(out *ClientMountDeviceBlock) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceBlock.
+func (in *ClientMountDeviceBlock) DeepCopy() *ClientMountDeviceBlock {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceBlock)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceCephFS) DeepCopyInto(out *ClientMountDeviceCephFS) {
+	*out = *in
+	if in.Monitors != nil {
+		in, out := &in.Monitors, &out.Monitors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceCephFS.
+func (in *ClientMountDeviceCephFS) DeepCopy() *ClientMountDeviceCephFS {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceCephFS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountDeviceLVM) DeepCopyInto(out *ClientMountDeviceLVM) {
 	*out = *in
@@ -150,6 +271,18 @@ func (in *ClientMountDeviceLVM) DeepCopy() *ClientMountDeviceLVM {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountDeviceLustre) DeepCopyInto(out *ClientMountDeviceLustre) {
 	*out = *in
+	if in.MgsNodes != nil {
+		in, out := &in.MgsNodes, &out.MgsNodes
+		*out = make([]ClientMountLustreMgsNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Squash != nil {
+		in, out := &in.Squash, &out.Squash
+		*out = new(ClientMountLustreSquash)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceLustre.
@@ -162,6 +295,66 @@ func (in *ClientMountDeviceLustre) DeepCopy() *ClientMountDeviceLustre {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceMultipath) DeepCopyInto(out *ClientMountDeviceMultipath) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceMultipath.
+func (in *ClientMountDeviceMultipath) DeepCopy() *ClientMountDeviceMultipath {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceMultipath)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceNFS) DeepCopyInto(out *ClientMountDeviceNFS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceNFS.
+func (in *ClientMountDeviceNFS) DeepCopy() *ClientMountDeviceNFS {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceNFS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceNone) DeepCopyInto(out *ClientMountDeviceNone) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceNone.
+func (in *ClientMountDeviceNone) DeepCopy() *ClientMountDeviceNone {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceNone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceRBD) DeepCopyInto(out *ClientMountDeviceRBD) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceRBD.
+func (in *ClientMountDeviceRBD) DeepCopy() *ClientMountDeviceRBD {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceRBD)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountDeviceReference) DeepCopyInto(out *ClientMountDeviceReference) {
 	*out = *in
@@ -178,10 +371,80 @@ func (in *ClientMountDeviceReference) DeepCopy() *ClientMountDeviceReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceSwapFile) DeepCopyInto(out *ClientMountDeviceSwapFile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceSwapFile.
+func (in *ClientMountDeviceSwapFile) DeepCopy() *ClientMountDeviceSwapFile {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceSwapFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountDeviceTmpfs) DeepCopyInto(out *ClientMountDeviceTmpfs) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountDeviceTmpfs.
+func (in *ClientMountDeviceTmpfs) DeepCopy() *ClientMountDeviceTmpfs {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountDeviceTmpfs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountFormat) DeepCopyInto(out *ClientMountFormat) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountFormat.
+func (in *ClientMountFormat) DeepCopy() *ClientMountFormat {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountFormat)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountInfo) DeepCopyInto(out *ClientMountInfo) {
 	*out = *in
 	in.Device.DeepCopyInto(&out.Device)
+	if in.Format != nil {
+		in, out := &in.Format, &out.Format
+		*out = new(ClientMountFormat)
+		**out = **in
+	}
+	if in.CreateOptions != nil {
+		in, out := &in.CreateOptions, &out.CreateOptions
+		*out = new(ClientMountCreateOptions)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MountNamespace != nil {
+		in, out := &in.MountNamespace, &out.MountNamespace
+		*out = new(ClientMountNamespace)
+		**out = **in
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(ClientMountSecretRef)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountInfo.
@@ -197,6 +460,31 @@ func (in *ClientMountInfo) DeepCopy() *ClientMountInfo {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountInfoStatus) DeepCopyInto(out *ClientMountInfoStatus) {
 	*out = *in
+	if in.MountStarted != nil {
+		in, out := &in.MountStarted, &out.MountStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.MountCompleted != nil {
+		in, out := &in.MountCompleted, &out.MountCompleted
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(ClientMountPlan)
+		**out = **in
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(ClientMountUsage)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountInfoStatus.
@@ -241,6 +529,41 @@ func (in *ClientMountList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountLustreMgsNode) DeepCopyInto(out *ClientMountLustreMgsNode) {
+	*out = *in
+	if in.NIDs != nil {
+		in, out := &in.NIDs, &out.NIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountLustreMgsNode.
+func (in *ClientMountLustreMgsNode) DeepCopy() *ClientMountLustreMgsNode {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountLustreMgsNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountLustreSquash) DeepCopyInto(out *ClientMountLustreSquash) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountLustreSquash.
+func (in *ClientMountLustreSquash) DeepCopy() *ClientMountLustreSquash {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountLustreSquash)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountNVMeDesc) DeepCopyInto(out *ClientMountNVMeDesc) {
 	*out = *in
@@ -256,6 +579,232 @@ func (in *ClientMountNVMeDesc) DeepCopy() *ClientMountNVMeDesc {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountNamespace) DeepCopyInto(out *ClientMountNamespace) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountNamespace.
+func (in *ClientMountNamespace) DeepCopy() *ClientMountNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountNode) DeepCopyInto(out *ClientMountNode) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountNode.
+func (in *ClientMountNode) DeepCopy() *ClientMountNode {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClientMountNode) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountNodeList) DeepCopyInto(out *ClientMountNodeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClientMountNode, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountNodeList.
+func (in *ClientMountNodeList) DeepCopy() *ClientMountNodeList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountNodeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClientMountNodeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountNodeStatus) DeepCopyInto(out *ClientMountNodeStatus) {
+	*out = *in
+	in.Capabilities.DeepCopyInto(&out.Capabilities)
+	if in.LastReported != nil {
+		in, out := &in.LastReported, &out.LastReported
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountNodeStatus.
+func (in *ClientMountNodeStatus) DeepCopy() *ClientMountNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountPlan) DeepCopyInto(out *ClientMountPlan) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountPlan.
+func (in *ClientMountPlan) DeepCopy() *ClientMountPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountProfile) DeepCopyInto(out *ClientMountProfile) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountProfile.
+func (in *ClientMountProfile) DeepCopy() *ClientMountProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClientMountProfile) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountProfileList) DeepCopyInto(out *ClientMountProfileList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClientMountProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountProfileList.
+func (in *ClientMountProfileList) DeepCopy() *ClientMountProfileList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountProfileList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClientMountProfileList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountProfileSpec) DeepCopyInto(out *ClientMountProfileSpec) {
+	*out = *in
+	if in.CommandTimeout != nil {
+		in, out := &in.CommandTimeout, &out.CommandTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RetryDelay != nil {
+		in, out := &in.RetryDelay, &out.RetryDelay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountProfileSpec.
+func (in *ClientMountProfileSpec) DeepCopy() *ClientMountProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountSecretOption) DeepCopyInto(out *ClientMountSecretOption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountSecretOption.
+func (in *ClientMountSecretOption) DeepCopy() *ClientMountSecretOption {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountSecretOption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountSecretRef) DeepCopyInto(out *ClientMountSecretRef) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]ClientMountSecretOption, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountSecretRef.
+func (in *ClientMountSecretRef) DeepCopy() *ClientMountSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientMountSpec) DeepCopyInto(out *ClientMountSpec) {
 	*out = *in
@@ -266,6 +815,10 @@ func (in *ClientMountSpec) DeepCopyInto(out *ClientMountSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountSpec.
@@ -284,9 +837,38 @@ func (in *ClientMountStatus) DeepCopyInto(out *ClientMountStatus) {
 	if in.Mounts != nil {
 		in, out := &in.Mounts, &out.Mounts
 		*out = make([]ClientMountInfoStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MountStarted != nil {
+		in, out := &in.MountStarted, &out.MountStarted
+		*out = (*in).DeepCopy()
+	}
+	if in.MountCompleted != nil {
+		in, out := &in.MountCompleted, &out.MountCompleted
+		*out = (*in).DeepCopy()
 	}
 	in.ResourceError.DeepCopyInto(&out.ResourceError)
+	if in.DryRunCommands != nil {
+		in, out := &in.DryRunCommands, &out.DryRunCommands
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountStatus.
@@ -299,6 +881,64 @@ func (in *ClientMountStatus) DeepCopy() *ClientMountStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountSummary) DeepCopyInto(out *ClientMountSummary) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]ClientMountSummaryError, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountSummary.
+func (in *ClientMountSummary) DeepCopy() *ClientMountSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountSummaryError) DeepCopyInto(out *ClientMountSummaryError) {
+	*out = *in
+	if in.Error != nil {
+		in, out := &in.Error, &out.Error
+		*out = new(ResourceErrorInfo)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountSummaryError.
+func (in *ClientMountSummaryError) DeepCopy() *ClientMountSummaryError {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountSummaryError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientMountUsage) DeepCopyInto(out *ClientMountUsage) {
+	*out = *in
+	in.LastReported.DeepCopyInto(&out.LastReported)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientMountUsage.
+func (in *ClientMountUsage) DeepCopy() *ClientMountUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientMountUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComputeBreakdown) DeepCopyInto(out *ComputeBreakdown) {
 	*out = *in
@@ -386,42 +1026,106 @@ func (in *ComputesData) DeepCopyInto(out *ComputesData) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputesData.
-func (in *ComputesData) DeepCopy() *ComputesData {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputesData.
+func (in *ComputesData) DeepCopy() *ComputesData {
+	if in == nil {
+		return nil
+	}
+	out := new(ComputesData)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComputesList) DeepCopyInto(out *ComputesList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Computes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputesList.
+func (in *ComputesList) DeepCopy() *ComputesList {
+	if in == nil {
+		return nil
+	}
+	out := new(ComputesList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ComputesList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DWDirectiveRule) DeepCopyInto(out *DWDirectiveRule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Spec != nil {
+		in, out := &in.Spec, &out.Spec
+		*out = make([]dwdparse.DWDirectiveRuleSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DWDirectiveRule.
+func (in *DWDirectiveRule) DeepCopy() *DWDirectiveRule {
 	if in == nil {
 		return nil
 	}
-	out := new(ComputesData)
+	out := new(DWDirectiveRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DWDirectiveRule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ComputesList) DeepCopyInto(out *ComputesList) {
+func (in *DWDirectiveRuleList) DeepCopyInto(out *DWDirectiveRuleList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Computes, len(*in))
+		*out = make([]DWDirectiveRule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComputesList.
-func (in *ComputesList) DeepCopy() *ComputesList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DWDirectiveRuleList.
+func (in *DWDirectiveRuleList) DeepCopy() *DWDirectiveRuleList {
 	if in == nil {
 		return nil
 	}
-	out := new(ComputesList)
+	out := new(DWDirectiveRuleList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ComputesList) DeepCopyObject() runtime.Object {
+func (in *DWDirectiveRuleList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -429,31 +1133,26 @@ func (in *ComputesList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DWDirectiveRule) DeepCopyInto(out *DWDirectiveRule) {
+func (in *DataMovement) DeepCopyInto(out *DataMovement) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	if in.Spec != nil {
-		in, out := &in.Spec, &out.Spec
-		*out = make([]dwdparse.DWDirectiveRuleSpec, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DWDirectiveRule.
-func (in *DWDirectiveRule) DeepCopy() *DWDirectiveRule {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMovement.
+func (in *DataMovement) DeepCopy() *DataMovement {
 	if in == nil {
 		return nil
 	}
-	out := new(DWDirectiveRule)
+	out := new(DataMovement)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DWDirectiveRule) DeepCopyObject() runtime.Object {
+func (in *DataMovement) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -461,37 +1160,83 @@ func (in *DWDirectiveRule) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DWDirectiveRuleList) DeepCopyInto(out *DWDirectiveRuleList) {
+func (in *DataMovementList) DeepCopyInto(out *DataMovementList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]DWDirectiveRule, len(*in))
+		*out = make([]DataMovement, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DWDirectiveRuleList.
-func (in *DWDirectiveRuleList) DeepCopy() *DWDirectiveRuleList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMovementList.
+func (in *DataMovementList) DeepCopy() *DataMovementList {
 	if in == nil {
 		return nil
 	}
-	out := new(DWDirectiveRuleList)
+	out := new(DataMovementList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DWDirectiveRuleList) DeepCopyObject() runtime.Object {
+func (in *DataMovementList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMovementSpec) DeepCopyInto(out *DataMovementSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMovementSpec.
+func (in *DataMovementSpec) DeepCopy() *DataMovementSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMovementSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataMovementStatus) DeepCopyInto(out *DataMovementStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.EndTime != nil {
+		in, out := &in.EndTime, &out.EndTime
+		*out = (*in).DeepCopy()
+	}
+	in.ResourceError.DeepCopyInto(&out.ResourceError)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataMovementStatus.
+func (in *DataMovementStatus) DeepCopy() *DataMovementStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataMovementStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DirectiveBreakdown) DeepCopyInto(out *DirectiveBreakdown) {
 	*out = *in
@@ -569,6 +1314,11 @@ func (in *DirectiveBreakdownSpec) DeepCopy() *DirectiveBreakdownSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DirectiveBreakdownStatus) DeepCopyInto(out *DirectiveBreakdownStatus) {
 	*out = *in
+	if in.Requirements != nil {
+		in, out := &in.Requirements, &out.Requirements
+		*out = new(DirectiveRequirements)
+		**out = **in
+	}
 	if in.Storage != nil {
 		in, out := &in.Storage, &out.Storage
 		*out = new(StorageBreakdown)
@@ -580,6 +1330,13 @@ func (in *DirectiveBreakdownStatus) DeepCopyInto(out *DirectiveBreakdownStatus)
 		(*in).DeepCopyInto(*out)
 	}
 	in.ResourceError.DeepCopyInto(&out.ResourceError)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectiveBreakdownStatus.
@@ -592,9 +1349,139 @@ func (in *DirectiveBreakdownStatus) DeepCopy() *DirectiveBreakdownStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectiveRequirements) DeepCopyInto(out *DirectiveRequirements) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectiveRequirements.
+func (in *DirectiveRequirements) DeepCopy() *DirectiveRequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectiveRequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MountOptionPolicy) DeepCopyInto(out *MountOptionPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MountOptionPolicy.
+func (in *MountOptionPolicy) DeepCopy() *MountOptionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MountOptionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MountOptionPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MountOptionPolicyList) DeepCopyInto(out *MountOptionPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MountOptionPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MountOptionPolicyList.
+func (in *MountOptionPolicyList) DeepCopy() *MountOptionPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(MountOptionPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MountOptionPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MountOptionPolicySpec) DeepCopyInto(out *MountOptionPolicySpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]MountOptionRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MountOptionPolicySpec.
+func (in *MountOptionPolicySpec) DeepCopy() *MountOptionPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MountOptionPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MountOptionRule) DeepCopyInto(out *MountOptionRule) {
+	*out = *in
+	if in.Allowed != nil {
+		in, out := &in.Allowed, &out.Allowed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Denied != nil {
+		in, out := &in.Denied, &out.Denied
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MountOptionRule.
+func (in *MountOptionRule) DeepCopy() *MountOptionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(MountOptionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Node) DeepCopyInto(out *Node) {
 	*out = *in
+	if in.OSInfo != nil {
+		in, out := &in.OSInfo, &out.OSInfo
+		*out = new(NodeOSInfo)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Accessibility != nil {
+		in, out := &in.Accessibility, &out.Accessibility
+		*out = new(NodeStorageAccessibility)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Node.
@@ -607,6 +1494,69 @@ func (in *Node) DeepCopy() *Node {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeOSInfo) DeepCopyInto(out *NodeOSInfo) {
+	*out = *in
+	if in.KernelModules != nil {
+		in, out := &in.KernelModules, &out.KernelModules
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastReported != nil {
+		in, out := &in.LastReported, &out.LastReported
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeOSInfo.
+func (in *NodeOSInfo) DeepCopy() *NodeOSInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeOSInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStorageAccessibility) DeepCopyInto(out *NodeStorageAccessibility) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]NodeStorageTarget, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastReported != nil {
+		in, out := &in.LastReported, &out.LastReported
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStorageAccessibility.
+func (in *NodeStorageAccessibility) DeepCopy() *NodeStorageAccessibility {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStorageAccessibility)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStorageTarget) DeepCopyInto(out *NodeStorageTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStorageTarget.
+func (in *NodeStorageTarget) DeepCopy() *NodeStorageTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStorageTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PersistentStorageInstance) DeepCopyInto(out *PersistentStorageInstance) {
 	*out = *in
@@ -671,7 +1621,7 @@ func (in *PersistentStorageInstanceSpec) DeepCopyInto(out *PersistentStorageInst
 	*out = *in
 	if in.ConsumerReferences != nil {
 		in, out := &in.ConsumerReferences, &out.ConsumerReferences
-		*out = make([]v1.ObjectReference, len(*in))
+		*out = make([]corev1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
 }
@@ -691,6 +1641,13 @@ func (in *PersistentStorageInstanceStatus) DeepCopyInto(out *PersistentStorageIn
 	*out = *in
 	out.Servers = in.Servers
 	in.ResourceError.DeepCopyInto(&out.ResourceError)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistentStorageInstanceStatus.
@@ -709,7 +1666,7 @@ func (in *ResourceError) DeepCopyInto(out *ResourceError) {
 	if in.Error != nil {
 		in, out := &in.Error, &out.Error
 		*out = new(ResourceErrorInfo)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -726,6 +1683,14 @@ func (in *ResourceError) DeepCopy() *ResourceError {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceErrorInfo) DeepCopyInto(out *ResourceErrorInfo) {
 	*out = *in
+	if in.FirstSeen != nil {
+		in, out := &in.FirstSeen, &out.FirstSeen
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSeen != nil {
+		in, out := &in.LastSeen, &out.LastSeen
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceErrorInfo.
@@ -949,12 +1914,16 @@ func (in *StorageAccess) DeepCopyInto(out *StorageAccess) {
 	if in.Servers != nil {
 		in, out := &in.Servers, &out.Servers
 		*out = make([]Node, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.Computes != nil {
 		in, out := &in.Computes, &out.Computes
 		*out = make([]Node, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -1018,6 +1987,13 @@ func (in *StorageData) DeepCopyInto(out *StorageData) {
 		}
 	}
 	in.Access.DeepCopyInto(&out.Access)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageData.
@@ -1088,7 +2064,7 @@ func (in *StoragePool) DeepCopyInto(out *StoragePool) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePool.
@@ -1159,6 +2135,13 @@ func (in *StoragePoolSpec) DeepCopy() *StoragePoolSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoragePoolStatus) DeepCopyInto(out *StoragePoolStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePoolStatus.
@@ -1439,10 +2422,15 @@ func (in *WorkflowStatus) DeepCopyInto(out *WorkflowStatus) {
 	}
 	if in.DirectiveBreakdowns != nil {
 		in, out := &in.DirectiveBreakdowns, &out.DirectiveBreakdowns
-		*out = make([]v1.ObjectReference, len(*in))
+		*out = make([]corev1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
 	out.Computes = in.Computes
+	if in.ClientMounts != nil {
+		in, out := &in.ClientMounts, &out.ClientMounts
+		*out = new(ClientMountSummary)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.DesiredStateChange != nil {
 		in, out := &in.DesiredStateChange, &out.DesiredStateChange
 		*out = (*in).DeepCopy()
@@ -1451,6 +2439,18 @@ func (in *WorkflowStatus) DeepCopyInto(out *WorkflowStatus) {
 		in, out := &in.ReadyChange, &out.ReadyChange
 		*out = (*in).DeepCopy()
 	}
+	if in.DirectiveWarnings != nil {
+		in, out := &in.DirectiveWarnings, &out.DirectiveWarnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowStatus.
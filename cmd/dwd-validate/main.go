@@ -0,0 +1,288 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// dwd-validate checks the #DW directives in a batch script against the directive rules
+// without access to the cluster. The rules are read from files exported from the cluster
+// (e.g., "kubectl get dwdirectiverules -o yaml"), so users can lint their job scripts on a
+// login node before submitting them.
+//
+// Usage: dwd-validate -rules <file> [-rules <file>...] [-datawarp] [script]
+//
+//	dwd-validate -rules <file> [-rules <file>...] -schema
+//
+// The script is read from stdin if it isn't given or is "-". The exit status is 1 if any
+// directive is invalid and 2 for any other error. With -schema, the JSON Schema of the
+// directive arguments is printed instead, for editors and other tools. With -datawarp, the
+// script may use the Cray DataWarp directive syntax. The directives are translated before
+// they're checked, and the translations are printed so the script can be updated.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+)
+
+// fileList is a flag that can be given more than once
+type fileList []string
+
+func (f *fileList) String() string { return strings.Join(*f, ",") }
+
+func (f *fileList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	var ruleFiles fileList
+	flag.Var(&ruleFiles, "rules", "File of DWDirectiveRule resources in YAML or JSON. May be given more than once.")
+	printSchema := flag.Bool("schema", false, "Print the JSON Schema of the directive arguments and exit")
+	dataWarp := flag.Bool("datawarp", false, "Translate Cray DataWarp directives before checking them")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -rules <file> [-schema | [-datawarp] script]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(ruleFiles) == 0 || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rules, err := loadRules(ruleFiles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if *printSchema {
+		if err := writeSchema(os.Stdout, rules); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		return
+	}
+
+	scriptName := flag.Arg(0)
+	script := io.Reader(os.Stdin)
+	if scriptName != "" && scriptName != "-" {
+		file, err := os.Open(scriptName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer file.Close()
+
+		script = file
+	} else {
+		scriptName = "<stdin>"
+	}
+
+	extract := dwdparse.ExtractDirectives
+	if *dataWarp {
+		extract = dwdparse.ExtractDataWarpDirectives
+	}
+
+	directives, err := extract(script)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	text := make([]string, len(directives))
+	for i := range directives {
+		text[i] = directives[i].Directive
+	}
+
+	invalid := false
+	errs := make([]error, len(text))
+	if *dataWarp {
+		var warnings []dwdparse.Warnings
+		text, warnings, errs = dwdparse.TranslateDataWarp(text)
+		for i := range text {
+			for _, warning := range warnings[i] {
+				fmt.Printf("%s:%d: warning: %s\n", scriptName, directives[i].Line, warning)
+			}
+
+			if errs[i] == nil && text[i] != directives[i].Directive {
+				fmt.Printf("%s:%d: translated to '%s'\n", scriptName, directives[i].Line, text[i])
+			}
+		}
+	}
+
+	validateErrs, validateWarnings := dwdparse.ValidateDirectivesWithOptions(rules, text, dwdparse.ParseOptions{})
+	for i, err := range validateErrs {
+		if errs[i] != nil {
+			err = errs[i]
+		}
+
+		if err != nil {
+			fmt.Printf("%s:%d: %v\n", scriptName, directives[i].Line, err)
+			invalid = true
+		}
+
+		for _, warning := range validateWarnings[i] {
+			fmt.Printf("%s:%d: warning: %s\n", scriptName, directives[i].Line, warning)
+		}
+	}
+
+	// The directives are related to each other under their current argument names
+	for i := range text {
+		for _, rule := range rules {
+			if directive, _, err := dwdparse.ReplaceAliases(rule, text[i]); err == nil {
+				text[i] = directive
+			}
+		}
+	}
+
+	for _, violation := range dwdparse.ValidateDirectiveSet(text) {
+		fmt.Printf("%s:%d: %s\n", scriptName, directives[violation.Index].Line, violation.Message)
+		invalid = true
+	}
+
+	if invalid {
+		os.Exit(1)
+	}
+}
+
+// loadRules returns the rules from the applicable rule sets in the files, chosen the same way
+// as in the cluster
+func loadRules(files []string) ([]dwdparse.DWDirectiveRuleSpec, error) {
+	ruleSets := []dwdparse.RuleSet{}
+
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+
+		directiveRules, err := decodeDirectiveRules(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		for i := range directiveRules {
+			ruleSets = append(ruleSets, directiveRules[i].GetRuleSet())
+		}
+	}
+
+	ruleSets, err := dwdparse.ApplicableRuleSets(ruleSets)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ruleSets) == 0 {
+		return nil, errors.New("no DWDirectiveRule resources found in the rules files")
+	}
+
+	rules := []dwdparse.DWDirectiveRuleSpec{}
+	for _, ruleSet := range ruleSets {
+		rules = append(rules, ruleSet.Rules...)
+	}
+
+	return rules, nil
+}
+
+// writeSchema writes the JSON Schema of the rules' directive arguments
+func writeSchema(w io.Writer, rules []dwdparse.DWDirectiveRuleSpec) error {
+	schema, err := dwdparse.RulesSchema(rules)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+// decodeDirectiveRules decodes the DWDirectiveRule resources in a stream of YAML or JSON
+// documents. Lists of resources, as output by kubectl, are also accepted.
+func decodeDirectiveRules(r io.Reader) ([]dwsv1alpha1.DWDirectiveRule, error) {
+	directiveRules := []dwsv1alpha1.DWDirectiveRule{}
+
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		document := json.RawMessage{}
+		if err := decoder.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				return directiveRules, nil
+			}
+
+			return nil, err
+		}
+
+		decoded, err := decodeDirectiveRuleDocument(document)
+		if err != nil {
+			return nil, err
+		}
+
+		directiveRules = append(directiveRules, decoded...)
+	}
+}
+
+func decodeDirectiveRuleDocument(document json.RawMessage) ([]dwsv1alpha1.DWDirectiveRule, error) {
+	if len(document) == 0 || string(document) == "null" {
+		return nil, nil
+	}
+
+	header := struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}{}
+
+	if err := json.Unmarshal(document, &header); err != nil {
+		return nil, err
+	}
+
+	switch header.Kind {
+	case "DWDirectiveRule":
+		directiveRule := dwsv1alpha1.DWDirectiveRule{}
+		if err := json.Unmarshal(document, &directiveRule); err != nil {
+			return nil, err
+		}
+
+		return []dwsv1alpha1.DWDirectiveRule{directiveRule}, nil
+	case "DWDirectiveRuleList", "List":
+		directiveRules := []dwsv1alpha1.DWDirectiveRule{}
+		for _, item := range header.Items {
+			decoded, err := decodeDirectiveRuleDocument(item)
+			if err != nil {
+				return nil, err
+			}
+
+			directiveRules = append(directiveRules, decoded...)
+		}
+
+		return directiveRules, nil
+	}
+
+	// Other resources in the file are ignored
+	return nil, nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// CacheOptions shrink the informer cache on systems with tens of thousands of ClientMounts.
+// The zero value caches every object as it is.
+type CacheOptions struct {
+	// StripManagedFields drops the managed fields of the cached objects
+	StripManagedFields bool
+
+	// ClientMountSelector is a label selector for the ClientMounts that are cached. The
+	// controllers don't see the ClientMounts it doesn't select, so it's for a replica that
+	// only handles some of the ClientMounts.
+	ClientMountSelector string
+}
+
+// NewCacheFunc returns the function the manager creates its cache with. It returns nil,
+// which is the default cache, if no options are set.
+func (o CacheOptions) NewCacheFunc() (cache.NewCacheFunc, error) {
+	if o == (CacheOptions{}) {
+		return nil, nil
+	}
+
+	options := cache.Options{}
+	if o.StripManagedFields {
+		options.DefaultTransform = dwsv1alpha1.StripManagedFields
+	}
+
+	if o.ClientMountSelector != "" {
+		selector, err := labels.Parse(o.ClientMountSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ClientMount selector '%s': %w", o.ClientMountSelector, err)
+		}
+
+		options.SelectorsByObject = cache.SelectorsByObject{&dwsv1alpha1.ClientMount{}: {Label: selector}}
+	}
+
+	return cache.BuilderWithOptions(options), nil
+}
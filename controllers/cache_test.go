@@ -0,0 +1,43 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache Options Test", func() {
+
+	It("Uses the default cache without options", func() {
+		Expect(CacheOptions{}.NewCacheFunc()).To(BeNil())
+	})
+
+	It("Builds a cache for the options", func() {
+		newCache, err := CacheOptions{StripManagedFields: true, ClientMountSelector: "dws.cray.hpe.com/shard=a"}.NewCacheFunc()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(newCache).ToNot(BeNil())
+	})
+
+	It("Rejects an invalid ClientMount selector", func() {
+		_, err := CacheOptions{ClientMountSelector: "a in (b"}.NewCacheFunc()
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -27,30 +27,45 @@ import (
 	"github.com/go-logr/logr"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/clientmount"
 	"github.com/HewlettPackard/dws/utils/updater"
 )
 
-// ClientMountReconciler reconciles a ClientMount object
+// ClientMountReconciler stands in for the mount-daemon in environments without compute
+// nodes (e.g., kind). It marks the mounts of a ClientMount ready without mounting anything.
+// A ClientMount for a node whose mount-daemon has reported its ClientMountNode is left to
+// the daemon, and the mounts of a dry-run ClientMount are never ready, the same as with
+// the daemon.
 type ClientMountReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// Shard limits the controller to the ClientMounts in the namespaces of one shard
+	Shard Shard
+
+	// Recorder emits the warning events for ClientMounts released without unmounting
+	Recorder record.EventRecorder
 }
 
 const (
-	// finalizerClientMount defines the key used for the finalizer
-	finalizerClientMount = "dws.cray.hpe.com/client_mount"
+	// fieldManagerClientMount is the field manager that owns the status fields applied by
+	// this controller
+	fieldManagerClientMount = "dws-clientmount-controller"
 )
 
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountnodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -63,57 +78,29 @@ func (r *ClientMountReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Create a status updater that handles the call to r.Status().Update() if any of the fields
-	// in clientMount.Status{} change
-	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.ClientMountStatus](clientMount)
-	defer func() { err = statusUpdater.CloseWithStatusUpdate(ctx, r, err) }()
-
-	// Handle cleanup if the resource is being deleted
-	if !clientMount.GetDeletionTimestamp().IsZero() {
-		if !controllerutil.ContainsFinalizer(clientMount, finalizerClientMount) {
-			return ctrl.Result{}, nil
-		}
-
-		controllerutil.RemoveFinalizer(clientMount, finalizerClientMount)
-		if err := r.Update(ctx, clientMount); err != nil {
-			return ctrl.Result{}, err
-		}
-
-		return ctrl.Result{}, nil
-	}
-
-	// Create the status section if it doesn't exist yet
-	if len(clientMount.Status.Mounts) != len(clientMount.Spec.Mounts) {
-		clientMount.Status.Mounts = make([]dwsv1alpha1.ClientMountInfoStatus, len(clientMount.Spec.Mounts))
+	// The status and finalizer belong to the mount-daemon if the node has one
+	if hasDaemon, err := r.hasMountDaemon(ctx, clientMount.Namespace); err != nil || hasDaemon {
+		return ctrl.Result{}, err
 	}
 
-	// Initialize the status section if the desired state doesn't match the status state
-	if clientMount.Status.Mounts[0].State != clientMount.Spec.DesiredState {
-		for i := 0; i < len(clientMount.Status.Mounts); i++ {
-			clientMount.Status.Mounts[i].State = clientMount.Spec.DesiredState
-			clientMount.Status.Mounts[i].Ready = false
-		}
-
-		return ctrl.Result{}, nil
-	}
-
-	// Add finalizer if it doesn't exist
-	if !controllerutil.ContainsFinalizer(clientMount, finalizerClientMount) {
-		controllerutil.AddFinalizer(clientMount, finalizerClientMount)
-		if err := r.Update(ctx, clientMount); err != nil {
-			return ctrl.Result{Requeue: true}, nil
-		}
+	// Create a status updater that applies clientMount.Status{} with server-side apply if any
+	// of the fields change
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.ClientMountStatus](clientMount)
+	defer func() { err = statusUpdater.CloseWithStatusApply(ctx, r.Client, fieldManagerClientMount, err) }()
 
-		return ctrl.Result{}, nil
-	}
+	core := &clientmount.Core{Client: r.Client, Log: r.Log.WithValues("ClientMount", req.NamespacedName), Actuator: clientmount.NoopActuator{}, Recorder: r.Recorder}
+	return core.Reconcile(ctx, clientMount)
+}
 
-	for i := range clientMount.Spec.Mounts {
-		clientMount.Status.Mounts[i].Ready = true
+// hasMountDaemon returns whether a mount-daemon has reported the ClientMountNode for the
+// node of a ClientMount namespace
+func (r *ClientMountReconciler) hasMountDaemon(ctx context.Context, namespace string) (bool, error) {
+	clientMountNode := &dwsv1alpha1.ClientMountNode{}
+	if err := r.Get(ctx, client.ObjectKey{Name: namespace, Namespace: namespace}, clientMountNode); err != nil {
+		return false, client.IgnoreNotFound(err)
 	}
 
-	clientMount.Status.Error = nil
-
-	return ctrl.Result{}, nil
+	return clientMountNode.Status.LastReported != nil, nil
 }
 
 func filterByNonRabbitNamespacePrefixForTest() predicate.Predicate {
@@ -127,9 +114,13 @@ func (r *ClientMountReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&dwsv1alpha1.ClientMount{})
 
+	if r.Shard.Sharded() {
+		builder = builder.WithEventFilter(r.Shard.Predicate())
+	}
+
 	if _, found := os.LookupEnv("NNF_TEST_ENVIRONMENT"); found {
 		builder = builder.WithEventFilter(filterByNonRabbitNamespacePrefixForTest())
 	}
 
-	return builder.Complete(r)
+	return builder.Complete(metrics.CountReconciles("ClientMount", r))
 }
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+)
+
+const (
+	// clientMountExpiryWarnedAnnotation records that the warning event was sent for the
+	// ClientMount's expiry time, so it's only sent once
+	clientMountExpiryWarnedAnnotation = "dws.cray.hpe.com/expiry-warned"
+)
+
+// ClientMountExpiryReconciler unmounts ClientMounts whose expiresAt time has passed by
+// setting their desired state to unmounted. A warning event is sent for the ClientMount
+// when it's within the warning period of expiring, and another when it expires.
+type ClientMountExpiryReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *kruntime.Scheme
+	Recorder record.EventRecorder
+
+	// WarningPeriod is how long before a ClientMount expires that the warning event is
+	// sent. No warning is sent if 0.
+	WarningPeriod time.Duration
+
+	// Shard limits the controller to the ClientMounts in the namespaces of one shard
+	Shard Shard
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ClientMountExpiryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClientMount", req.NamespacedName)
+
+	clientMount := &dwsv1alpha1.ClientMount{}
+	if err := r.Get(ctx, req.NamespacedName, clientMount); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	expiresAt := clientMount.Spec.ExpiresAt
+	if expiresAt == nil || !clientMount.GetDeletionTimestamp().IsZero() || clientMount.Spec.DesiredState == dwsv1alpha1.ClientMountStateUnmounted {
+		return ctrl.Result{}, nil
+	}
+
+	remaining := time.Until(expiresAt.Time)
+	if remaining > 0 {
+		warned := clientMount.GetAnnotations()[clientMountExpiryWarnedAnnotation] == expiresAt.UTC().Format(time.RFC3339)
+		if remaining > r.WarningPeriod || warned {
+			return ctrl.Result{RequeueAfter: remaining - r.warningPeriod(warned)}, nil
+		}
+
+		r.Recorder.Event(clientMount, corev1.EventTypeWarning, "Expiring", fmt.Sprintf("ClientMount expires at %s and will be unmounted", expiresAt.UTC().Format(time.RFC3339)))
+
+		// The expiry time is recorded rather than a flag so a new expiry time gets its own warning
+		if clientMount.Annotations == nil {
+			clientMount.Annotations = map[string]string{}
+		}
+		clientMount.Annotations[clientMountExpiryWarnedAnnotation] = expiresAt.UTC().Format(time.RFC3339)
+		if err := r.Update(ctx, clientMount); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.Info("ClientMount expired", "expiresAt", expiresAt)
+	clientMount.Spec.DesiredState = dwsv1alpha1.ClientMountStateUnmounted
+	if err := r.Update(ctx, clientMount); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	r.Recorder.Event(clientMount, corev1.EventTypeWarning, "Expired", "ClientMount expired and is being unmounted")
+
+	return ctrl.Result{}, nil
+}
+
+// warningPeriod returns how long before the expiry time the ClientMount is next checked
+func (r *ClientMountExpiryReconciler) warningPeriod(warned bool) time.Duration {
+	if warned {
+		return 0
+	}
+
+	return r.WarningPeriod
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClientMountExpiryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clientmount-expiry").
+		For(&dwsv1alpha1.ClientMount{}, builder.WithPredicates(r.Shard.Predicate())).
+		Complete(metrics.CountReconciles("ClientMountExpiry", r))
+}
@@ -0,0 +1,252 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/dwsowner"
+)
+
+const (
+	// clientMountOrphanedAnnotation records when the janitor first found the ClientMount
+	// orphaned. The ClientMount is deleted once it has been orphaned for the grace period.
+	clientMountOrphanedAnnotation = "dws.cray.hpe.com/orphaned-at"
+)
+
+// ClientMountJanitorReconciler deletes ClientMounts that have been left behind by a crashed
+// driver. A ClientMount is orphaned when the workflow, owner, or storage resource named by
+// its labels no longer exists. Owners and storage resources are only checked if their kind
+// is a DWS type; other kinds are left to the drivers that created them.
+//
+// A deleted ClientMount in a namespace that's being deleted has lost its node, so nothing is
+// left to unmount the file systems and remove the finalizer. The janitor removes the
+// finalizer once the ClientMount has been deleting for the finalizer grace period.
+type ClientMountJanitorReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *kruntime.Scheme
+
+	// OrphanGracePeriod is how long a ClientMount must be orphaned before it's deleted
+	OrphanGracePeriod time.Duration
+
+	// FinalizerGracePeriod is how long a deleted ClientMount in a deleted namespace holds
+	// its finalizer before the janitor removes it
+	FinalizerGracePeriod time.Duration
+
+	// Shard limits the janitor to the ClientMounts in the namespaces of one shard
+	Shard Shard
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=workflows,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=persistentstorageinstances,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=servers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=storages,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *ClientMountJanitorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClientMount", req.NamespacedName)
+
+	clientMount := &dwsv1alpha1.ClientMount{}
+	if err := r.Get(ctx, req.NamespacedName, clientMount); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !clientMount.GetDeletionTimestamp().IsZero() {
+		return r.releaseFinalizer(ctx, clientMount, log)
+	}
+
+	reason, err := r.orphanReason(ctx, clientMount)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	orphanedAt, found := clientMount.GetAnnotations()[clientMountOrphanedAnnotation]
+
+	// The owner may have come back (or never really left) since the last check
+	if reason == "" {
+		if found {
+			delete(clientMount.Annotations, clientMountOrphanedAnnotation)
+			if err := r.Update(ctx, clientMount); err != nil {
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if !found {
+		log.Info("ClientMount is orphaned", "reason", reason, "gracePeriod", r.OrphanGracePeriod)
+
+		if clientMount.Annotations == nil {
+			clientMount.Annotations = map[string]string{}
+		}
+		clientMount.Annotations[clientMountOrphanedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		if err := r.Update(ctx, clientMount); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+
+		return ctrl.Result{RequeueAfter: r.OrphanGracePeriod}, nil
+	}
+
+	orphanedTime, err := time.Parse(time.RFC3339, orphanedAt)
+	if err != nil {
+		// Start the grace period over if the annotation was mangled
+		delete(clientMount.Annotations, clientMountOrphanedAnnotation)
+		return ctrl.Result{}, client.IgnoreNotFound(r.Update(ctx, clientMount))
+	}
+
+	if remaining := r.OrphanGracePeriod - time.Since(orphanedTime); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.Info("Deleting orphaned ClientMount", "reason", reason)
+	if err := r.Delete(ctx, clientMount); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// releaseFinalizer removes the ClientMount finalizer from a deleted ClientMount once its
+// namespace is gone and the finalizer grace period has passed
+func (r *ClientMountJanitorReconciler) releaseFinalizer(ctx context.Context, clientMount *dwsv1alpha1.ClientMount, log logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(clientMount, dwsowner.ClientMountFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: clientMount.Namespace}, namespace); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+	} else if namespace.GetDeletionTimestamp().IsZero() {
+		// The node is still there to unmount the file systems
+		return ctrl.Result{}, nil
+	}
+
+	if remaining := r.FinalizerGracePeriod - time.Since(clientMount.GetDeletionTimestamp().Time); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	log.Info("Removing finalizer from ClientMount in deleted namespace")
+	controllerutil.RemoveFinalizer(clientMount, dwsowner.ClientMountFinalizer)
+	if err := r.Update(ctx, clientMount); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// orphanReason returns why the ClientMount is orphaned, or an empty string if it isn't
+func (r *ClientMountJanitorReconciler) orphanReason(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (string, error) {
+	labels := clientMount.GetLabels()
+
+	type reference struct {
+		kind string
+		key  types.NamespacedName
+	}
+
+	references := []reference{}
+	if workflow, found := dwsv1alpha1.WorkflowFromLabels(clientMount); found {
+		references = append(references, reference{"Workflow", workflow})
+	}
+
+	if kind, owner, found := dwsv1alpha1.OwnerFromLabels(clientMount); found {
+		references = append(references, reference{kind, owner})
+	}
+
+	references = append(references, reference{labels[dwsowner.StorageKindLabel], types.NamespacedName{Name: labels[dwsowner.StorageNameLabel], Namespace: labels[dwsowner.StorageNamespaceLabel]}})
+
+	for _, reference := range references {
+		if reference.kind == "" || reference.key.Name == "" {
+			continue
+		}
+
+		gvk := dwsv1alpha1.GroupVersion.WithKind(reference.kind)
+		if !r.Scheme.Recognizes(gvk) {
+			continue
+		}
+
+		obj, err := r.Scheme.New(gvk)
+		if err != nil {
+			return "", err
+		}
+
+		if err := r.Get(ctx, reference.key, obj.(client.Object)); err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Sprintf("%s %s no longer exists", reference.kind, reference.key), nil
+			}
+
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// workflowClientMountsMapFunc requeues the ClientMounts for a workflow so they're checked
+// when the workflow is deleted
+func (r *ClientMountJanitorReconciler) workflowClientMountsMapFunc(o client.Object) []reconcile.Request {
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := r.List(context.Background(), clientMounts, dwsv1alpha1.MatchingWorkflow(o.(*dwsv1alpha1.Workflow))); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, clientMount := range clientMounts.Items {
+		if !r.Shard.Owns(clientMount.Namespace) {
+			continue
+		}
+
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&clientMount)})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClientMountJanitorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clientmount-janitor").
+		For(&dwsv1alpha1.ClientMount{}, builder.WithPredicates(r.Shard.Predicate())).
+		Watches(&source.Kind{Type: &dwsv1alpha1.Workflow{}}, handler.EnqueueRequestsFromMapFunc(r.workflowClientMountsMapFunc)).
+		Complete(metrics.CountReconciles("ClientMountJanitor", r))
+}
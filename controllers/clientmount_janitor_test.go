@@ -0,0 +1,89 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+var _ = Describe("ClientMount Janitor Test", func() {
+
+	var clientMount *dwsv1alpha1.ClientMount
+
+	BeforeEach(func() {
+		clientMount = &dwsv1alpha1.ClientMount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: dwsv1alpha1.ClientMountSpec{
+				Node:         "compute-0",
+				DesiredState: dwsv1alpha1.ClientMountStateMounted,
+				Mounts: []dwsv1alpha1.ClientMountInfo{{
+					MountPath:  "/mnt/scratch",
+					Device:     dwsv1alpha1.ClientMountDevice{Type: dwsv1alpha1.ClientMountDeviceTypeReference},
+					Type:       "none",
+					TargetType: "directory",
+				}},
+			},
+		}
+	})
+
+	It("Deletes a ClientMount whose workflow no longer exists", func() {
+		wf := &dwsv1alpha1.Workflow{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+			},
+		}
+		dwsv1alpha1.AddWorkflowLabels(clientMount, wf)
+		Expect(k8sClient.Create(context.TODO(), clientMount)).To(Succeed())
+
+		Eventually(func(g Gomega) map[string]string {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(clientMount), clientMount)).To(Succeed())
+			return clientMount.GetAnnotations()
+		}).Should(HaveKey(clientMountOrphanedAnnotation))
+
+		Eventually(func() error {
+			return k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(clientMount), clientMount)
+		}, "10s").ShouldNot(Succeed())
+	})
+
+	It("Leaves a ClientMount without an owner alone", func() {
+		Expect(k8sClient.Create(context.TODO(), clientMount)).To(Succeed())
+
+		Consistently(func(g Gomega) map[string]string {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(clientMount), clientMount)).To(Succeed())
+			return clientMount.GetAnnotations()
+		}, "3s").ShouldNot(HaveKey(clientMountOrphanedAnnotation))
+
+		Expect(k8sClient.Delete(context.TODO(), clientMount)).To(Succeed())
+	})
+})
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+)
+
+// AddClientMountMetrics records how long the clients take to mount and unmount. The
+// ClientMounts are watched through the informers, and each mount is recorded once when its
+// completion time is first set by the client.
+func AddClientMountMetrics(ctx context.Context, informers cache.Informers) error {
+	informer, err := informers.GetInformer(ctx, &dwsv1alpha1.ClientMount{})
+	if err != nil {
+		return err
+	}
+
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldClientMount, ok := oldObj.(*dwsv1alpha1.ClientMount)
+			if !ok {
+				return
+			}
+
+			newClientMount, ok := newObj.(*dwsv1alpha1.ClientMount)
+			if !ok {
+				return
+			}
+
+			observeClientMountDurations(oldClientMount, newClientMount)
+		},
+	})
+
+	return nil
+}
+
+// observeClientMountDurations records the duration of each mount that completed between the
+// old and new versions of a ClientMount
+func observeClientMountDurations(oldClientMount, newClientMount *dwsv1alpha1.ClientMount) {
+	for i, mount := range newClientMount.Status.Mounts {
+		if mount.MountStarted == nil || mount.MountCompleted == nil || i >= len(newClientMount.Spec.Mounts) {
+			continue
+		}
+
+		if i < len(oldClientMount.Status.Mounts) && oldClientMount.Status.Mounts[i].MountCompleted != nil {
+			continue
+		}
+
+		duration := mount.MountCompleted.Sub(mount.MountStarted.Time)
+		metrics.DwsClientMountDurationSeconds.
+			WithLabelValues(string(mount.State), string(newClientMount.Spec.Mounts[i].Type)).
+			Observe(duration.Seconds())
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+const (
+	// finalizerDataMovement is held until the data mover has cancelled a running data movement
+	finalizerDataMovement = "dws.cray.hpe.com/datamovement"
+
+	// fieldManagerDataMovement is the field manager that owns the status fields applied by
+	// this controller
+	fieldManagerDataMovement = "dws-datamovement-controller"
+
+	// defaultDataMovementPollInterval is how often a running data movement is checked
+	defaultDataMovementPollInterval = 10 * time.Second
+)
+
+// DataMover is implemented by the data-mover drivers. The DataMovementReconciler calls the
+// driver to do the copy described by a DataMovement resource.
+type DataMover interface {
+	// Move starts the data movement, or checks on a data movement that's already running.
+	// The driver records its progress in the BytesTotal and BytesTransferred status fields.
+	// Move returns true once the data movement is complete. A fatal ResourceError stops the
+	// data movement; any other error is retried.
+	Move(ctx context.Context, dm *dwsv1alpha1.DataMovement) (bool, error)
+
+	// Cancel stops a running data movement when the DataMovement resource is deleted
+	Cancel(ctx context.Context, dm *dwsv1alpha1.DataMovement) error
+}
+
+// NoopDataMover is a DataMover that completes every data movement without copying any data.
+// It's used in environments that don't have a data mover (i.e., kind).
+type NoopDataMover struct{}
+
+// Move marks the data movement as complete
+func (NoopDataMover) Move(ctx context.Context, dm *dwsv1alpha1.DataMovement) (bool, error) {
+	dm.Status.BytesTransferred = dm.Status.BytesTotal
+	return true, nil
+}
+
+// Cancel does nothing since no data is ever being moved
+func (NoopDataMover) Cancel(ctx context.Context, dm *dwsv1alpha1.DataMovement) error {
+	return nil
+}
+
+// DataMovementReconciler reconciles a DataMovement object by handing it to the DataMover
+type DataMovementReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *kruntime.Scheme
+
+	// Mover is the driver that does the data movement
+	Mover DataMover
+
+	// PollInterval is how often a running data movement is checked. If zero, the
+	// default of 10 seconds is used.
+	PollInterval time.Duration
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=datamovements,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=datamovements/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=datamovements/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *DataMovementReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	log := r.Log.WithValues("DataMovement", req.NamespacedName)
+
+	dm := &dwsv1alpha1.DataMovement{}
+	if err := r.Get(ctx, req.NamespacedName, dm); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.DataMovementStatus](dm)
+	defer func() { err = statusUpdater.CloseWithStatusApply(ctx, r.Client, fieldManagerDataMovement, err) }()
+	defer func() {
+		dwsv1alpha1.SetReadyConditions(&dm.Status.Conditions, dm.Generation, dm.Status.Ready, dm.Status.Error)
+	}()
+
+	// Cancel the data movement if it's still running when the resource is deleted
+	if !dm.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(dm, finalizerDataMovement) {
+			return ctrl.Result{}, nil
+		}
+
+		if dm.Status.State == dwsv1alpha1.DataMovementRunning {
+			if err := r.Mover.Cancel(ctx, dm); err != nil {
+				dm.Status.Error = dwsv1alpha1.NewResourceError("Unable to cancel data movement", err)
+				return ctrl.Result{}, err
+			}
+
+			log.Info("Cancelled data movement")
+		}
+
+		controllerutil.RemoveFinalizer(dm, finalizerDataMovement)
+		if err := r.Update(ctx, dm); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if dm.Status.State == "" {
+		dm.Status.State = dwsv1alpha1.DataMovementPending
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	if dm.Status.State == dwsv1alpha1.DataMovementFinished {
+		return ctrl.Result{}, nil
+	}
+
+	// Add finalizer if it doesn't exist
+	if !controllerutil.ContainsFinalizer(dm, finalizerDataMovement) {
+		controllerutil.AddFinalizer(dm, finalizerDataMovement)
+		if err := r.Update(ctx, dm); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if dm.Status.State == dwsv1alpha1.DataMovementPending {
+		now := metav1.NowMicro()
+		dm.Status.StartTime = &now
+		dm.Status.State = dwsv1alpha1.DataMovementRunning
+		log.Info("Starting data movement", "operation", dm.Spec.Operation, "source", dm.Spec.Source, "destination", dm.Spec.Destination)
+	}
+
+	done, err := r.Mover.Move(ctx, dm)
+	if err != nil {
+		resourceError := dwsv1alpha1.NewResourceError("Data movement failed", err).WithUserMessage("data movement failed")
+		dm.Status.Error = resourceError
+		if !resourceError.Recoverable {
+			r.finish(dm)
+			return ctrl.Result{}, nil
+		}
+
+		return ctrl.Result{}, err
+	}
+
+	dm.Status.Error = nil
+
+	if !done {
+		pollInterval := r.PollInterval
+		if pollInterval == 0 {
+			pollInterval = defaultDataMovementPollInterval
+		}
+
+		return ctrl.Result{RequeueAfter: pollInterval}, nil
+	}
+
+	r.finish(dm)
+	dm.Status.Ready = true
+	log.Info("Finished data movement")
+
+	return ctrl.Result{}, nil
+}
+
+// finish records the end of the data movement
+func (r *DataMovementReconciler) finish(dm *dwsv1alpha1.DataMovement) {
+	now := metav1.NowMicro()
+	dm.Status.EndTime = &now
+	dm.Status.State = dwsv1alpha1.DataMovementFinished
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DataMovementReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dwsv1alpha1.DataMovement{}).
+		Complete(metrics.CountReconciles("DataMovement", r))
+}
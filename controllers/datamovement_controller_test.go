@@ -0,0 +1,74 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+var _ = Describe("DataMovement Controller Test", func() {
+
+	It("Finishes a stage_in data movement", func() {
+		spec, err := dwsv1alpha1.NewDataMovementSpec("#DW stage_in type=directory source=/lus/global/input destination=$DW_JOB_striped")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spec.Operation).To(Equal(dwsv1alpha1.DataMovementStageIn))
+
+		dm := &dwsv1alpha1.DataMovement{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: spec,
+		}
+
+		Expect(k8sClient.Create(context.TODO(), dm)).To(Succeed())
+
+		Eventually(func(g Gomega) dwsv1alpha1.DataMovementState {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(dm), dm)).To(Succeed())
+			return dm.Status.State
+		}).Should(Equal(dwsv1alpha1.DataMovementFinished))
+
+		Expect(dm.Status.Ready).To(BeTrue())
+		Expect(dm.Status.StartTime).ToNot(BeNil())
+		Expect(dm.Status.EndTime).ToNot(BeNil())
+		Expect(meta.IsStatusConditionTrue(dm.Status.Conditions, dwsv1alpha1.ConditionReady)).To(BeTrue())
+
+		Expect(k8sClient.Delete(context.TODO(), dm)).To(Succeed())
+		Eventually(func() error {
+			return k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(dm), dm)
+		}).ShouldNot(Succeed())
+	})
+
+	It("Rejects directives that don't move data", func() {
+		_, err := dwsv1alpha1.NewDataMovementSpec("#DW jobdw type=xfs capacity=10GiB name=test")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+const (
+	// lustreMetadataCapacity is the number of bytes requested for each of the Lustre
+	// MGT/MDT allocations. The capacity in the directive only applies to the OSTs.
+	lustreMetadataCapacity int64 = 1024 * 1024 * 1024
+
+	// lustreMetadataColocationKey is the exclusive colocation key shared by the Lustre
+	// MGT/MDT allocation sets
+	lustreMetadataColocationKey = "lustre-mdt"
+
+	// fieldManagerDirectiveBreakdown is the field manager that owns the status fields applied
+	// by this controller
+	fieldManagerDirectiveBreakdown = "dws-directivebreakdown-controller"
+)
+
+// DirectiveBreakdownReconciler reconciles a DirectiveBreakdown object. It provides a generic
+// breakdown of the storage required by a #DW directive for environments that don't have a
+// storage driver to do it (i.e., kind).
+type DirectiveBreakdownReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *kruntime.Scheme
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=directivebreakdowns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=directivebreakdowns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=servers,verbs=get;create;list;watch;update;patch;delete;deletecollection
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *DirectiveBreakdownReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	log := r.Log.WithValues("DirectiveBreakdown", req.NamespacedName)
+
+	dbd := &dwsv1alpha1.DirectiveBreakdown{}
+	if err := r.Get(ctx, req.NamespacedName, dbd); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.DirectiveBreakdownStatus](dbd)
+	defer func() { err = statusUpdater.CloseWithStatusApply(ctx, r.Client, fieldManagerDirectiveBreakdown, err) }()
+	defer func() {
+		dwsv1alpha1.SetReadyConditions(&dbd.Status.Conditions, dbd.Generation, dbd.Status.Ready, dbd.Status.Error)
+	}()
+
+	// The Servers resource is owned by the DirectiveBreakdown, so it's garbage
+	// collected by Kubernetes when the DirectiveBreakdown is deleted
+	if !dbd.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	argsMap, err := dwdparse.BuildArgsMap(dbd.Spec.Directive)
+	if err != nil {
+		dbd.Status.Error = dwsv1alpha1.NewResourceError("Invalid directive", err).WithUserMessage("invalid directive").WithFatal()
+		return ctrl.Result{}, nil
+	}
+
+	requirements, err := dwsv1alpha1.NewDirectiveRequirements(dbd.Spec.Directive)
+	if err != nil {
+		dbd.Status.Error = dwsv1alpha1.NewResourceError("Invalid directive", err).WithUserMessage("invalid storage request").WithFatal()
+		return ctrl.Result{}, nil
+	}
+
+	dbd.Status.Requirements = requirements
+
+	switch argsMap["command"] {
+	case "jobdw", "create_persistent":
+		storage, err := breakdownStorage(argsMap)
+		if err != nil {
+			dbd.Status.Error = dwsv1alpha1.NewResourceError("Unable to break down storage", err).WithUserMessage("invalid storage request").WithFatal()
+			return ctrl.Result{}, nil
+		}
+
+		// Memory backed file systems don't need any storage allocated
+		if storage == nil {
+			dbd.Status.Storage = nil
+			break
+		}
+
+		servers, err := r.createServers(ctx, dbd, log)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		storage.Reference = corev1.ObjectReference{
+			Kind:      reflect.TypeOf(dwsv1alpha1.Servers{}).Name(),
+			Name:      servers.Name,
+			Namespace: servers.Namespace,
+		}
+
+		dbd.Status.Storage = storage
+	default:
+		// Other directives (persistentdw, stage_in, etc.) don't request any new storage
+		dbd.Status.Storage = nil
+	}
+
+	dbd.Status.Ready = true
+	dbd.Status.Error = nil
+
+	return ctrl.Result{}, nil
+}
+
+// breakdownStorage builds the allocation sets needed to satisfy a jobdw or create_persistent directive.
+// A nil StorageBreakdown is returned for file systems that don't use any storage (i.e., tmpfs).
+func breakdownStorage(argsMap map[string]string) (*dwsv1alpha1.StorageBreakdown, error) {
+	capacity, err := dwdparse.ParseCapacity(argsMap["capacity"])
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := dwsv1alpha1.StorageLifetimeJob
+	if argsMap["command"] == "create_persistent" {
+		lifetime = dwsv1alpha1.StorageLifetimePersistent
+	}
+
+	storage := &dwsv1alpha1.StorageBreakdown{Lifetime: lifetime}
+
+	switch argsMap["type"] {
+	case "raw", "xfs", "gfs2", "swap":
+		storage.AllocationSets = []dwsv1alpha1.StorageAllocationSet{
+			{
+				AllocationStrategy: dwsv1alpha1.AllocatePerCompute,
+				MinimumCapacity:    capacity,
+				Label:              argsMap["type"],
+			},
+		}
+	case "lustre":
+		metadataLabels := []string{"mgt", "mdt"}
+		if _, found := argsMap["external_mgs"]; found {
+			metadataLabels = []string{"mdt"}
+		} else if _, found := argsMap["combined_mgtmdt"]; found {
+			metadataLabels = []string{"mgtmdt"}
+		}
+
+		// The metadata targets are spread across servers so they don't contend with each other
+		for _, label := range metadataLabels {
+			storage.AllocationSets = append(storage.AllocationSets, dwsv1alpha1.StorageAllocationSet{
+				AllocationStrategy: dwsv1alpha1.AllocateSingleServer,
+				MinimumCapacity:    lustreMetadataCapacity,
+				Label:              label,
+				Constraints: dwsv1alpha1.AllocationSetConstraints{
+					Colocation: []dwsv1alpha1.AllocationSetColocationConstraint{
+						{Type: "exclusive", Key: lustreMetadataColocationKey},
+					},
+				},
+			})
+		}
+
+		storage.AllocationSets = append(storage.AllocationSets, dwsv1alpha1.StorageAllocationSet{
+			AllocationStrategy: dwsv1alpha1.AllocateAcrossServers,
+			MinimumCapacity:    capacity,
+			Label:              "ost",
+		})
+	case "tmpfs":
+		if lifetime == dwsv1alpha1.StorageLifetimePersistent {
+			return nil, fmt.Errorf("file system type '%s' can't be persistent", argsMap["type"])
+		}
+
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported file system type '%s'", argsMap["type"])
+	}
+
+	return storage, nil
+}
+
+// createServers creates the Servers resource the WLM fills in with the storage it selects
+// for the allocation sets
+func (r *DirectiveBreakdownReconciler) createServers(ctx context.Context, dbd *dwsv1alpha1.DirectiveBreakdown, log logr.Logger) (*dwsv1alpha1.Servers, error) {
+	servers := &dwsv1alpha1.Servers{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dbd.Name,
+			Namespace: dbd.Namespace,
+		},
+	}
+
+	result, err := ctrl.CreateOrUpdate(ctx, r.Client, servers,
+		func() error {
+			dwsv1alpha1.InheritParentLabels(servers, dbd)
+			dwsv1alpha1.AddOwnerLabels(servers, dbd)
+
+			// Link the Servers to the DirectiveBreakdown
+			return ctrl.SetControllerReference(dbd, servers, r.Scheme)
+		})
+
+	if err != nil {
+		log.Error(err, "Failed to create or update Servers", "name", servers.Name)
+		return nil, err
+	}
+
+	if result == controllerutil.OperationResultCreated {
+		log.Info("Created Servers", "name", servers.Name)
+	} else if result == controllerutil.OperationResultUpdated {
+		log.Info("Updated Servers", "name", servers.Name)
+	}
+
+	return servers, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DirectiveBreakdownReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dwsv1alpha1.DirectiveBreakdown{}).
+		Owns(&dwsv1alpha1.Servers{}).
+		Complete(metrics.CountReconciles("DirectiveBreakdown", r))
+}
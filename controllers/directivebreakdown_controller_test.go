@@ -0,0 +1,144 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+var _ = Describe("DirectiveBreakdown Controller Test", func() {
+
+	var (
+		dbd *dwsv1alpha1.DirectiveBreakdown
+	)
+
+	BeforeEach(func() {
+		dbd = &dwsv1alpha1.DirectiveBreakdown{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(context.TODO(), dbd)).To(Succeed())
+	})
+
+	getReadyBreakdown := func() *dwsv1alpha1.DirectiveBreakdown {
+		Eventually(func(g Gomega) bool {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(dbd), dbd)).To(Succeed())
+			return dbd.Status.Ready
+		}).Should(BeTrue())
+
+		return dbd
+	}
+
+	It("Breaks down an xfs jobdw directive", func() {
+		dbd.Spec.Directive = "#DW jobdw type=xfs capacity=10GiB name=xfs"
+		Expect(k8sClient.Create(context.TODO(), dbd)).To(Succeed())
+
+		storage := getReadyBreakdown().Status.Storage
+		Expect(storage).NotTo(BeNil())
+		Expect(storage.Lifetime).To(Equal(dwsv1alpha1.StorageLifetimeJob))
+		Expect(storage.AllocationSets).To(HaveLen(1))
+		Expect(storage.AllocationSets[0].Label).To(Equal("xfs"))
+		Expect(storage.AllocationSets[0].AllocationStrategy).To(Equal(dwsv1alpha1.AllocatePerCompute))
+		Expect(storage.AllocationSets[0].MinimumCapacity).To(Equal(int64(10 * 1024 * 1024 * 1024)))
+
+		servers := &dwsv1alpha1.Servers{}
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: storage.Reference.Name, Namespace: storage.Reference.Namespace}, servers)).To(Succeed())
+		Expect(servers.GetLabels()).To(HaveKeyWithValue(dwsv1alpha1.OwnerNameLabel, dbd.Name))
+	})
+
+	It("Breaks down a lustre create_persistent directive", func() {
+		dbd.Spec.Directive = "#DW create_persistent type=lustre capacity=1TB name=lustre combined_mgtmdt"
+		Expect(k8sClient.Create(context.TODO(), dbd)).To(Succeed())
+
+		status := getReadyBreakdown().Status
+		Expect(status.Requirements).To(Equal(&dwsv1alpha1.DirectiveRequirements{
+			Command:        "create_persistent",
+			Name:           "lustre",
+			FileSystemType: "lustre",
+			Capacity:       1000 * 1000 * 1000 * 1000,
+			Lifetime:       dwsv1alpha1.DirectiveLifetimePersistent,
+		}))
+
+		storage := status.Storage
+		Expect(storage).NotTo(BeNil())
+		Expect(storage.Lifetime).To(Equal(dwsv1alpha1.StorageLifetimePersistent))
+		Expect(storage.AllocationSets).To(HaveLen(2))
+		Expect(storage.AllocationSets[0].Label).To(Equal("mgtmdt"))
+		Expect(storage.AllocationSets[0].Constraints.Colocation).To(ConsistOf(dwsv1alpha1.AllocationSetColocationConstraint{Type: "exclusive", Key: "lustre-mdt"}))
+		Expect(storage.AllocationSets[1].Label).To(Equal("ost"))
+		Expect(storage.AllocationSets[1].AllocationStrategy).To(Equal(dwsv1alpha1.AllocateAcrossServers))
+	})
+
+	It("Breaks down a swap jobdw directive", func() {
+		dbd.Spec.Directive = "#DW jobdw type=swap capacity=4GiB name=swap"
+		Expect(k8sClient.Create(context.TODO(), dbd)).To(Succeed())
+
+		storage := getReadyBreakdown().Status.Storage
+		Expect(storage).NotTo(BeNil())
+		Expect(storage.AllocationSets).To(HaveLen(1))
+		Expect(storage.AllocationSets[0].Label).To(Equal("swap"))
+		Expect(storage.AllocationSets[0].AllocationStrategy).To(Equal(dwsv1alpha1.AllocatePerCompute))
+	})
+
+	It("Does not request storage for a tmpfs jobdw directive", func() {
+		dbd.Spec.Directive = "#DW jobdw type=tmpfs capacity=4GiB name=tmpfs"
+		Expect(k8sClient.Create(context.TODO(), dbd)).To(Succeed())
+
+		Expect(getReadyBreakdown().Status.Storage).To(BeNil())
+	})
+
+	It("Does not request storage for a persistentdw directive", func() {
+		dbd.Spec.Directive = "#DW persistentdw name=lustre"
+		Expect(k8sClient.Create(context.TODO(), dbd)).To(Succeed())
+
+		Expect(getReadyBreakdown().Status.Storage).To(BeNil())
+		Expect(dbd.Status.Requirements.Lifetime).To(Equal(dwsv1alpha1.DirectiveLifetimePersistent))
+		Expect(meta.IsStatusConditionTrue(dbd.Status.Conditions, dwsv1alpha1.ConditionReady)).To(BeTrue())
+	})
+
+	It("Reports a fatal error condition for a persistent tmpfs directive", func() {
+		dbd.Spec.Directive = "#DW create_persistent type=tmpfs capacity=4GiB name=tmpfs"
+		Expect(k8sClient.Create(context.TODO(), dbd)).To(Succeed())
+
+		Eventually(func(g Gomega) bool {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(dbd), dbd)).To(Succeed())
+			return meta.IsStatusConditionTrue(dbd.Status.Conditions, dwsv1alpha1.ConditionError)
+		}).Should(BeTrue())
+
+		Expect(meta.IsStatusConditionFalse(dbd.Status.Conditions, dwsv1alpha1.ConditionReady)).To(BeTrue())
+		Expect(meta.FindStatusCondition(dbd.Status.Conditions, dwsv1alpha1.ConditionProgressing).Reason).To(Equal(dwsv1alpha1.ConditionReasonFatal))
+	})
+})
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/dwdparse"
+)
+
+// DWDirectiveRuleReconciler reconciles a DWDirectiveRule object. It fills in the default
+// rule set labels and validates the rule sets in the namespace so a bad rule set is
+// reported before a Workflow is rejected because of it.
+type DWDirectiveRuleReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *kruntime.Scheme
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=dwdirectiverules,verbs=get;list;watch;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *DWDirectiveRuleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("DWDirectiveRule", req.NamespacedName)
+
+	rule := &dwsv1alpha1.DWDirectiveRule{}
+	if err := r.Get(ctx, req.NamespacedName, rule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !rule.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	// Record the defaults in the labels so the rule set name and version are visible
+	// when listing the DWDirectiveRules
+	labels := rule.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	if _, found := labels[dwsv1alpha1.RuleSetOverridesLabel]; !found {
+		if labels[dwsv1alpha1.RuleSetNameLabel] == "" || labels[dwsv1alpha1.RuleSetVersionLabel] == "" {
+			ruleSet := rule.GetRuleSet()
+			labels[dwsv1alpha1.RuleSetNameLabel] = ruleSet.Name
+			labels[dwsv1alpha1.RuleSetVersionLabel] = ruleSet.Version
+			rule.SetLabels(labels)
+
+			if err := r.Update(ctx, rule); err != nil {
+				return ctrl.Result{}, client.IgnoreNotFound(err)
+			}
+
+			log.Info("Added rule set labels", "name", ruleSet.Name, "version", ruleSet.Version)
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if err := dwdparse.ValidateRuleSet(rule.GetRuleSet()); err != nil {
+		log.Error(err, "Invalid rule set")
+		return ctrl.Result{}, nil
+	}
+
+	// Check that the rule set can be combined with the other rule sets in the namespace
+	if _, err := dwsv1alpha1.ListRuleSets(ctx, r.Client, rule.Namespace); err != nil {
+		log.Error(err, "Rule sets in namespace can't be applied", "namespace", rule.Namespace)
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DWDirectiveRuleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dwsv1alpha1.DWDirectiveRule{}).
+		Complete(metrics.CountReconciles("DWDirectiveRule", r))
+}
@@ -1,19 +1,106 @@
 package metrics
 
 import (
+	"context"
+	"reflect"
+
 	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/HewlettPackard/dws/utils/updater"
 )
 
 var (
-	DwsReconcilesTotal = prometheus.NewCounter(
+	DwsReconcilesTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "dws_reconciles_total",
 			Help: "Number of total reconciles in DWS controller",
 		},
+		[]string{"controller"},
+	)
+
+	DwsReconcileErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dws_reconcile_errors_total",
+			Help: "Number of reconciles in DWS controller that returned an error",
+		},
+		[]string{"controller"},
+	)
+
+	DwsMountsReady = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dws_mounts_ready",
+			Help: "Number of mounts of a ClientMount that have reached their desired state on the node",
+		},
+		[]string{"clientmount"},
+	)
+
+	DwsStatusUpdateConflictsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dws_status_update_conflicts_total",
+			Help: "Number of status updates made by the DWS controllers that failed with a conflict",
+		},
+		[]string{"kind"},
+	)
+
+	DwsClientMountDurationSeconds = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name:       "dws_clientmount_duration_seconds",
+			Help:       "Time taken by the clients to reach the desired state of a mount",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+		[]string{"state", "type"},
+	)
+
+	DwsUpdatesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dws_updates_total",
+			Help: "Number of resource and status updates made by the DWS controllers",
+		},
+		[]string{"kind", "subresource", "result"},
 	)
 )
 
+// countUpdate is an updater.UpdateHook that counts the updates by kind and result
+func countUpdate(obj client.Object, subresource string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	kind := reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	DwsUpdatesTotal.WithLabelValues(kind, subresource, result).Inc()
+
+	if subresource == "status" && apierrors.IsConflict(err) {
+		DwsStatusUpdateConflictsTotal.WithLabelValues(kind).Inc()
+	}
+}
+
+// CountReconciles wraps a reconciler to count its reconciles, and the reconciles that
+// returned an error, under the controller name
+func CountReconciles(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		DwsReconcilesTotal.WithLabelValues(controller).Inc()
+
+		res, err := r.Reconcile(ctx, req)
+		if err != nil {
+			DwsReconcileErrorsTotal.WithLabelValues(controller).Inc()
+		}
+
+		return res, err
+	})
+}
+
 func init() {
 	metrics.Registry.MustRegister(DwsReconcilesTotal)
+	metrics.Registry.MustRegister(DwsReconcileErrorsTotal)
+	metrics.Registry.MustRegister(DwsMountsReady)
+	metrics.Registry.MustRegister(DwsStatusUpdateConflictsTotal)
+	metrics.Registry.MustRegister(DwsClientMountDurationSeconds)
+	metrics.Registry.MustRegister(DwsUpdatesTotal)
+
+	updater.AddUpdateHook(countUpdate)
 }
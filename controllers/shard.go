@@ -0,0 +1,80 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// Shard selects the namespaces handled by one of several replicas of the ClientMount
+// controllers. The namespaces are split by a hash of their name, so on systems with
+// thousands of compute namespaces each replica only reconciles its share of the
+// ClientMounts. The zero value and a count of one handle every namespace.
+type Shard struct {
+	// Index is the shard handled by this replica, from 0 to Count-1
+	Index int
+
+	// Count is the number of shards
+	Count int
+}
+
+// Validate checks that the index is within the shard count
+func (s Shard) Validate() error {
+	if s.Count < 0 || s.Index < 0 || (s.Count != 0 && s.Index >= s.Count) {
+		return fmt.Errorf("invalid shard %d of %d", s.Index, s.Count)
+	}
+
+	return nil
+}
+
+// Sharded returns whether the namespaces are split between replicas
+func (s Shard) Sharded() bool {
+	return s.Count > 1
+}
+
+// Primary returns whether this replica runs the controllers that aren't sharded. Only the
+// first shard runs them so they aren't run by every replica.
+func (s Shard) Primary() bool {
+	return s.Index == 0
+}
+
+// Owns returns whether the namespace belongs to this shard
+func (s Shard) Owns(namespace string) bool {
+	if !s.Sharded() {
+		return true
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(namespace))
+
+	return int(hash.Sum32()%uint32(s.Count)) == s.Index
+}
+
+// Predicate returns an event filter that passes the events for resources in the
+// namespaces of this shard
+func (s Shard) Predicate() predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return s.Owns(object.GetNamespace())
+	})
+}
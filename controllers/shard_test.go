@@ -0,0 +1,55 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shard Test", func() {
+
+	It("Handles every namespace when not sharded", func() {
+		Expect(Shard{}.Owns("compute-0")).To(BeTrue())
+		Expect(Shard{Index: 0, Count: 1}.Owns("compute-0")).To(BeTrue())
+	})
+
+	It("Assigns each namespace to exactly one shard", func() {
+		for i := 0; i < 100; i++ {
+			namespace := fmt.Sprintf("compute-%d", i)
+
+			owners := 0
+			for index := 0; index < 4; index++ {
+				if (Shard{Index: index, Count: 4}).Owns(namespace) {
+					owners++
+				}
+			}
+			Expect(owners).To(Equal(1), namespace)
+		}
+	})
+
+	It("Rejects an index outside the shard count", func() {
+		Expect(Shard{Index: 1, Count: 2}.Validate()).To(Succeed())
+		Expect(Shard{Index: 2, Count: 2}.Validate()).ToNot(Succeed())
+		Expect(Shard{Index: -1, Count: 2}.Validate()).ToNot(Succeed())
+	})
+})
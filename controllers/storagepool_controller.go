@@ -0,0 +1,166 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	dwsstatus "github.com/HewlettPackard/dws/utils/status"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+// fieldManagerStoragePool is the field manager that owns the status fields applied by the
+// StoragePool controller
+const fieldManagerStoragePool = "dws-storagepool-controller"
+
+// StoragePoolReconciler reconciles a StoragePool object. It aggregates the capacity of the
+// Storage resources in the pool so WLM plugins can make placement decisions from the
+// StoragePool status without listing every Storage resource.
+type StoragePoolReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *kruntime.Scheme
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=storagepools,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=storagepools/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=storages,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *StoragePoolReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	storagePool := &dwsv1alpha1.StoragePool{}
+	if err := r.Get(ctx, req.NamespacedName, storagePool); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !storagePool.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.StoragePoolStatus](storagePool)
+	defer func() { err = statusUpdater.CloseWithStatusApply(ctx, r.Client, fieldManagerStoragePool, err) }()
+
+	storageList := &dwsv1alpha1.StorageList{}
+	if err := r.List(ctx, storageList, client.InNamespace(storagePool.Namespace), client.MatchingLabels{dwsv1alpha1.StoragePoolLabel(storagePool.Name): "true"}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	storagePool.Status = aggregateStoragePool(storagePool.Status, storageList.Items)
+	dwsv1alpha1.SetReadyConditions(&storagePool.Status.Conditions, storagePool.Generation, storagePool.Status.State == dwsv1alpha1.StoragePoolReady, nil)
+
+	return ctrl.Result{}, nil
+}
+
+// aggregateStoragePool computes the status of a pool from the Storage resources in it. Only
+// Storage resources that are ready contribute capacity.
+func aggregateStoragePool(status dwsv1alpha1.StoragePoolStatus, storages []dwsv1alpha1.Storage) dwsv1alpha1.StoragePoolStatus {
+	status.StorageCount = len(storages)
+	status.ReadyStorageCount = 0
+	status.Capacity = 0
+	status.Allocated = 0
+	status.Allocatable = 0
+	status.LargestAllocatable = 0
+
+	states := make([]dwsstatus.State, len(storages))
+	for i, storage := range storages {
+		states[i] = dwsstatus.State(storage.Data.Status)
+		if states[i] != dwsstatus.Ready {
+			continue
+		}
+
+		status.Capacity += storage.Data.Capacity
+		status.Allocated += storage.Data.Allocated
+
+		allocatable := storage.Data.Capacity - storage.Data.Allocated
+		if allocatable < 0 {
+			allocatable = 0
+		}
+
+		status.Allocatable += allocatable
+		if allocatable > status.LargestAllocatable {
+			status.LargestAllocatable = allocatable
+		}
+	}
+
+	status.ReadyStorageCount = dwsstatus.Summarize(states, dwsstatus.Policy{}).Ready
+
+	status.State = dwsv1alpha1.StoragePoolUnavailable
+	if status.ReadyStorageCount > 0 {
+		status.State = dwsv1alpha1.StoragePoolReady
+	}
+
+	return status
+}
+
+// storagePoolMapFunc requeues the pools a Storage resource is labeled as a member of
+func storagePoolMapFunc(o client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+	for key := range o.GetLabels() {
+		if name := strings.TrimPrefix(key, dwsv1alpha1.StoragePoolLabelPrefix); name != key && name != "" {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: o.GetNamespace()}})
+		}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *StoragePoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// A Storage resource removed from a pool no longer has the label, so label changes
+	// requeue the pools from both the old and new labels
+	storageHandler := handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			addRequests(q, storagePoolMapFunc(e.Object))
+		},
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			addRequests(q, storagePoolMapFunc(e.ObjectOld))
+			addRequests(q, storagePoolMapFunc(e.ObjectNew))
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			addRequests(q, storagePoolMapFunc(e.Object))
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dwsv1alpha1.StoragePool{}).
+		Watches(&source.Kind{Type: &dwsv1alpha1.Storage{}}, storageHandler).
+		Complete(metrics.CountReconciles("StoragePool", r))
+}
+
+func addRequests(q workqueue.RateLimitingInterface, requests []reconcile.Request) {
+	for _, request := range requests {
+		q.Add(request)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+var _ = Describe("StoragePool Controller Test", func() {
+
+	var (
+		pool     *dwsv1alpha1.StoragePool
+		storages []*dwsv1alpha1.Storage
+	)
+
+	BeforeEach(func() {
+		pool = &dwsv1alpha1.StoragePool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: dwsv1alpha1.StoragePoolSpec{
+				PoolID:      "default",
+				Units:       "bytes",
+				Granularity: "1",
+			},
+		}
+
+		storages = []*dwsv1alpha1.Storage{}
+	})
+
+	AfterEach(func() {
+		for _, storage := range storages {
+			Expect(k8sClient.Delete(context.TODO(), storage)).To(Succeed())
+		}
+
+		Expect(k8sClient.Delete(context.TODO(), pool)).To(Succeed())
+	})
+
+	createStorage := func(capacity int64, allocated int64, status string) *dwsv1alpha1.Storage {
+		storage := &dwsv1alpha1.Storage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+				Labels:    map[string]string{dwsv1alpha1.StoragePoolLabel(pool.Name): "true"},
+			},
+			Data: dwsv1alpha1.StorageData{
+				Capacity:  capacity,
+				Allocated: allocated,
+				Status:    status,
+			},
+		}
+
+		Expect(k8sClient.Create(context.TODO(), storage)).To(Succeed())
+		storages = append(storages, storage)
+
+		return storage
+	}
+
+	It("Aggregates the capacity of the Storage resources in the pool", func() {
+		Expect(k8sClient.Create(context.TODO(), pool)).To(Succeed())
+
+		createStorage(1000, 100, "Ready")
+		createStorage(2000, 500, "Ready")
+		createStorage(4000, 0, "Offline")
+
+		Eventually(func(g Gomega) dwsv1alpha1.StoragePoolStatus {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(pool), pool)).To(Succeed())
+			status := *pool.Status.DeepCopy()
+			status.Conditions = nil
+			return status
+		}).Should(Equal(dwsv1alpha1.StoragePoolStatus{
+			State:              dwsv1alpha1.StoragePoolReady,
+			StorageCount:       3,
+			ReadyStorageCount:  2,
+			Capacity:           3000,
+			Allocated:          600,
+			Allocatable:        2400,
+			LargestAllocatable: 1500,
+		}))
+		Expect(meta.IsStatusConditionTrue(pool.Status.Conditions, dwsv1alpha1.ConditionReady)).To(BeTrue())
+	})
+
+	It("Updates the pool when a Storage resource leaves the pool", func() {
+		Expect(k8sClient.Create(context.TODO(), pool)).To(Succeed())
+
+		storage := createStorage(1000, 0, "Ready")
+
+		Eventually(func(g Gomega) string {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(pool), pool)).To(Succeed())
+			return pool.Status.State
+		}).Should(Equal(dwsv1alpha1.StoragePoolReady))
+
+		storage.SetLabels(map[string]string{})
+		Expect(k8sClient.Update(context.TODO(), storage)).To(Succeed())
+
+		Eventually(func(g Gomega) int {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(pool), pool)).To(Succeed())
+			return pool.Status.StorageCount
+		}).Should(Equal(0))
+		Expect(pool.Status.State).To(Equal(dwsv1alpha1.StoragePoolUnavailable))
+		Expect(meta.IsStatusConditionFalse(pool.Status.Conditions, dwsv1alpha1.ConditionReady)).To(BeTrue())
+	})
+})
@@ -0,0 +1,264 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+const (
+	// fieldManagerSystemConfiguration is the field manager that owns the status fields applied
+	// by the SystemConfiguration controller
+	fieldManagerSystemConfiguration = "dws-systemconfiguration-controller"
+
+	// nodeServiceAccountName is the ServiceAccount the mount-daemon runs as in each node's
+	// namespace. "clientmount bootstrap" requests tokens for this name.
+	nodeServiceAccountName = "clientmount"
+
+	// nodeRoleName is the Role and RoleBinding that let the mount-daemon manage the
+	// ClientMounts in its node's namespace
+	nodeRoleName = "dws-clientmount"
+
+	// nodeClusterRoleName is the ClusterRole for the cluster scoped resources the mount-daemon
+	// reads. It is bound to each node's ServiceAccount with a ClusterRoleBinding named
+	// nodeClusterRoleName-<node>.
+	nodeClusterRoleName = "dws-clientmount-node"
+)
+
+// SystemConfigurationReconciler reconciles a SystemConfiguration object. It creates the
+// Namespace, ServiceAccount, Role, and RoleBinding the mount-daemon needs for each compute
+// node, so large sites don't have to maintain those manifests by hand. The Namespace of a
+// node that's removed from the SystemConfiguration is left in place since it may still hold
+// ClientMounts, but the node's ClusterRoleBinding is deleted.
+type SystemConfigurationReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *kruntime.Scheme
+}
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=systemconfigurations,verbs=get;list;watch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=systemconfigurations/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountnodes,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountnodes/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountprofiles,verbs=get
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+func (r *SystemConfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	systemConfiguration := &dwsv1alpha1.SystemConfiguration{}
+	if err := r.Get(ctx, req.NamespacedName, systemConfiguration); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !systemConfiguration.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.SystemConfigurationStatus](systemConfiguration)
+	defer func() { err = statusUpdater.CloseWithStatusApply(ctx, r.Client, fieldManagerSystemConfiguration, err) }()
+
+	if err := r.createOrUpdateNodeClusterRole(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	nodes := map[string]bool{}
+	for _, computeNode := range systemConfiguration.Spec.ComputeNodes {
+		nodes[computeNode.Name] = true
+		if err := r.createOrUpdateNode(ctx, systemConfiguration, computeNode.Name); err != nil {
+			systemConfiguration.Status.Ready = false
+			return ctrl.Result{}, dwsv1alpha1.NewResourceError("could not create resources for node "+computeNode.Name, err)
+		}
+	}
+
+	// Remove the cluster wide access of nodes that are no longer in the SystemConfiguration
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := r.List(ctx, clusterRoleBindings, dwsv1alpha1.MatchingOwner(systemConfiguration)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for i := range clusterRoleBindings.Items {
+		clusterRoleBinding := &clusterRoleBindings.Items[i]
+		if len(clusterRoleBinding.Subjects) != 0 && nodes[clusterRoleBinding.Subjects[0].Namespace] {
+			continue
+		}
+
+		r.Log.Info("Removing cluster access for node", "name", clusterRoleBinding.Name)
+		if err := r.Delete(ctx, clusterRoleBinding); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	systemConfiguration.Status.Ready = true
+
+	return ctrl.Result{}, nil
+}
+
+// createOrUpdateNodeClusterRole creates the ClusterRole shared by every node's ServiceAccount
+func (r *SystemConfigurationReconciler) createOrUpdateNodeClusterRole(ctx context.Context) error {
+	clusterRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: nodeClusterRoleName}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, clusterRole, func() error {
+		clusterRole.Rules = []rbacv1.PolicyRule{
+			{
+				// Cordon checks read the node's Namespace
+				APIGroups: []string{""},
+				Resources: []string{"namespaces"},
+				Verbs:     []string{"get"},
+			},
+			{
+				// The node's OS information is reported in the Storage resources
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"storages"},
+				Verbs:     []string{"get", "list", "update"},
+			},
+			{
+				// Mounts read the settings of their ClientMountProfile
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"clientmountprofiles"},
+				Verbs:     []string{"get"},
+			},
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// createOrUpdateNode creates the Namespace, ServiceAccount, Role, RoleBinding, and
+// ClusterRoleBinding for a compute node
+func (r *SystemConfigurationReconciler) createOrUpdateNode(ctx context.Context, systemConfiguration *dwsv1alpha1.SystemConfiguration, node string) error {
+	// Only create the Namespace. An administrator may have labeled it, for example to
+	// cordon the node, and those labels must be left alone.
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: node}}
+	dwsv1alpha1.AddOwnerLabels(namespace, systemConfiguration)
+	if err := r.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: nodeServiceAccountName, Namespace: node}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, serviceAccount, func() error {
+		dwsv1alpha1.AddOwnerLabels(serviceAccount, systemConfiguration)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: nodeRoleName, Namespace: node}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, role, func() error {
+		dwsv1alpha1.AddOwnerLabels(role, systemConfiguration)
+		role.Rules = []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"clientmounts"},
+				Verbs:     []string{"get", "list", "watch", "update", "patch"},
+			},
+			{
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"clientmounts/status"},
+				Verbs:     []string{"get", "update", "patch"},
+			},
+			{
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"clientmounts/finalizers"},
+				Verbs:     []string{"update"},
+			},
+			{
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"clientmountnodes"},
+				Verbs:     []string{"get", "create"},
+			},
+			{
+				APIGroups: []string{dwsv1alpha1.GroupVersion.Group},
+				Resources: []string{"clientmountnodes/status"},
+				Verbs:     []string{"get", "update", "patch"},
+			},
+			{
+				// Credentials for the mounts are only read from the node's namespace
+				APIGroups: []string{""},
+				Resources: []string{"secrets"},
+				Verbs:     []string{"get"},
+			},
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: nodeServiceAccountName, Namespace: node}}
+
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: nodeRoleName, Namespace: node}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, roleBinding, func() error {
+		dwsv1alpha1.AddOwnerLabels(roleBinding, systemConfiguration)
+		roleBinding.Subjects = subjects
+
+		// The role reference can't be changed once the RoleBinding is created
+		if roleBinding.CreationTimestamp.IsZero() {
+			roleBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: nodeRoleName}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: nodeClusterRoleName + "-" + node}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, clusterRoleBinding, func() error {
+		dwsv1alpha1.AddOwnerLabels(clusterRoleBinding, systemConfiguration)
+		clusterRoleBinding.Subjects = subjects
+
+		if clusterRoleBinding.CreationTimestamp.IsZero() {
+			clusterRoleBinding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: nodeClusterRoleName}
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SystemConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&dwsv1alpha1.SystemConfiguration{}).
+		Complete(metrics.CountReconciles("SystemConfiguration", r))
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+var _ = Describe("SystemConfiguration Controller Test", func() {
+
+	var systemConfiguration *dwsv1alpha1.SystemConfiguration
+	var node string
+
+	BeforeEach(func() {
+		node = "compute-" + uuid.NewString()[0:8]
+		systemConfiguration = &dwsv1alpha1.SystemConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      uuid.NewString()[0:8],
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: dwsv1alpha1.SystemConfigurationSpec{
+				ComputeNodes: []dwsv1alpha1.SystemConfigurationComputeNode{{Name: node}},
+			},
+		}
+
+		Expect(k8sClient.Create(context.TODO(), systemConfiguration)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.Delete(context.TODO(), systemConfiguration)).To(Succeed())
+	})
+
+	It("Creates the resources for each compute node", func() {
+		Eventually(func(g Gomega) bool {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(systemConfiguration), systemConfiguration)).To(Succeed())
+			return systemConfiguration.Status.Ready
+		}).Should(BeTrue())
+
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: node}, &corev1.Namespace{})).To(Succeed())
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: nodeServiceAccountName, Namespace: node}, &corev1.ServiceAccount{})).To(Succeed())
+
+		role := &rbacv1.Role{}
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: nodeRoleName, Namespace: node}, role)).To(Succeed())
+		Expect(role.Rules).ToNot(BeEmpty())
+
+		roleBinding := &rbacv1.RoleBinding{}
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: nodeRoleName, Namespace: node}, roleBinding)).To(Succeed())
+		Expect(roleBinding.Subjects).To(ConsistOf(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: nodeServiceAccountName, Namespace: node}))
+
+		clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: nodeClusterRoleName + "-" + node}, clusterRoleBinding)).To(Succeed())
+	})
+
+	It("Removes the cluster access of a node that's removed", func() {
+		clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+		Eventually(func() error {
+			return k8sClient.Get(context.TODO(), client.ObjectKey{Name: nodeClusterRoleName + "-" + node}, clusterRoleBinding)
+		}).Should(Succeed())
+
+		Eventually(func(g Gomega) {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(systemConfiguration), systemConfiguration)).To(Succeed())
+			systemConfiguration.Spec.ComputeNodes = nil
+			g.Expect(k8sClient.Update(context.TODO(), systemConfiguration)).To(Succeed())
+		}).Should(Succeed())
+
+		Eventually(func() error {
+			return k8sClient.Get(context.TODO(), client.ObjectKey{Name: nodeClusterRoleName + "-" + node}, clusterRoleBinding)
+		}).ShouldNot(Succeed())
+
+		// The namespace may still hold ClientMounts, so it's left in place
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKey{Name: node}, &corev1.Namespace{})).To(Succeed())
+	})
+})
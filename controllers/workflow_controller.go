@@ -29,12 +29,16 @@ import (
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kruntime "k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
 	"github.com/HewlettPackard/dws/controllers/metrics"
@@ -64,6 +68,7 @@ type WorkflowReconciler struct {
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=workflows/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=workflows/finalizers,verbs=update
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=computes,verbs=get;create;list;watch;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts,verbs=get;list;watch;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -78,8 +83,6 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 	log := r.Log.WithValues("Workflow", req.NamespacedName)
 	log.Info("Reconciling Workflow")
 
-	metrics.DwsReconcilesTotal.Inc()
-
 	// Fetch the Workflow workflow
 	workflow := &dwsv1alpha1.Workflow{}
 	if err := r.Get(ctx, req.NamespacedName, workflow); err != nil {
@@ -91,6 +94,7 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 	// of the workflow.
 	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.WorkflowStatus](workflow)
 	defer func() { err = statusUpdater.CloseWithUpdate(ctx, r, err) }()
+	defer func() { setWorkflowConditions(workflow) }()
 
 	// Check if the object is being deleted
 	if !workflow.GetDeletionTimestamp().IsZero() {
@@ -169,6 +173,32 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 		}
 	}
 
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := r.List(ctx, clientMounts, dwsv1alpha1.MatchingWorkflow(workflow)); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// A workflow torn down in a hurry has its mounts removed in a hurry too
+	if workflow.Spec.Hurry {
+		if err := r.hurryClientMounts(ctx, clientMounts.Items); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Publish the job environment variables and the readiness of the mounts so the WLM can
+	// read them from the workflow
+	publishClientMountEnv(workflow, clientMounts.Items)
+	workflow.Status.ClientMounts = dwsv1alpha1.SummarizeClientMounts(clientMounts.Items)
+
+	// A fatal error in one of the workflow's resources stops the workflow in the error
+	// state so the WLM can end the job. Teardown is never stopped so the job can be
+	// cleaned up.
+	if workflow.Spec.DesiredState != dwsv1alpha1.StateTeardown {
+		if escalateFatalErrors(workflow, clientMounts.Items) {
+			return ctrl.Result{}, nil
+		}
+	}
+
 	// If the workflow has already been marked as complete for this state, then
 	// we don't need to check the drivers. The drivers can't transition from complete
 	// to not complete
@@ -212,6 +242,84 @@ func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 	return ctrl.Result{}, nil
 }
 
+// hurryClientMounts sets the hurry flag on the ClientMounts that don't have it yet
+func (r *WorkflowReconciler) hurryClientMounts(ctx context.Context, clientMounts []dwsv1alpha1.ClientMount) error {
+	for i := range clientMounts {
+		clientMount := &clientMounts[i]
+		if clientMount.Spec.Hurry {
+			continue
+		}
+
+		patch := client.MergeFrom(clientMount.DeepCopy())
+		clientMount.Spec.Hurry = true
+		if err := r.Patch(ctx, clientMount, patch); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+	}
+
+	return nil
+}
+
+// setWorkflowConditions sets the status conditions of the workflow for the current state.
+// A driver reporting an error doesn't stop the workflow, so the error is recoverable. An
+// error escalated from one of the workflow's resources is fatal.
+func setWorkflowConditions(workflow *dwsv1alpha1.Workflow) {
+	var resourceError *dwsv1alpha1.ResourceErrorInfo
+	if workflow.Status.Status == dwsv1alpha1.StatusError {
+		resourceError = dwsv1alpha1.NewResourceError(workflow.Status.Message, nil)
+		if meta.IsStatusConditionTrue(workflow.Status.Conditions, dwsv1alpha1.ConditionFatal) {
+			resourceError.WithFatal()
+		}
+	}
+
+	dwsv1alpha1.SetReadyConditions(&workflow.Status.Conditions, workflow.Generation, workflow.Status.Ready, resourceError)
+}
+
+// publishClientMountEnv adds the job environment variables from the status of the workflow's
+// ClientMounts to the workflow's environment. The variables set by the drivers are kept
+// unless a ClientMount publishes the same name.
+func publishClientMountEnv(workflow *dwsv1alpha1.Workflow, clientMounts []dwsv1alpha1.ClientMount) {
+	for _, clientMount := range clientMounts {
+		for name, value := range clientMount.Status.Env {
+			if workflow.Status.Env == nil {
+				workflow.Status.Env = map[string]string{}
+			}
+
+			workflow.Status.Env[name] = value
+		}
+	}
+}
+
+// escalateFatalErrors sets the Fatal condition and the error status of the workflow if one
+// of its ClientMounts has a fatal error. It returns whether the workflow has a fatal error.
+func escalateFatalErrors(workflow *dwsv1alpha1.Workflow, clientMounts []dwsv1alpha1.ClientMount) bool {
+	for _, clientMount := range clientMounts {
+		source := "ClientMount " + clientMount.Namespace + "/" + clientMount.Name
+		dwsv1alpha1.SetFatalCondition(&workflow.Status.Conditions, workflow.Generation, source, clientMount.Status.FatalError())
+	}
+
+	condition := meta.FindStatusCondition(workflow.Status.Conditions, dwsv1alpha1.ConditionFatal)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return false
+	}
+
+	workflow.Status.Ready = false
+	workflow.Status.Status = dwsv1alpha1.StatusError
+	workflow.Status.Message = condition.Message
+
+	return true
+}
+
+// clientMountMapFunc maps a ClientMount to the workflow named by its workflow labels
+func clientMountMapFunc(o client.Object) []reconcile.Request {
+	workflow, found := dwsv1alpha1.WorkflowFromLabels(o)
+	if !found {
+		return []reconcile.Request{}
+	}
+
+	return []reconcile.Request{{NamespacedName: workflow}}
+}
+
 func (r *WorkflowReconciler) createComputes(ctx context.Context, wf *dwsv1alpha1.Workflow, name string, log logr.Logger) (*dwsv1alpha1.Computes, error) {
 
 	computes := &dwsv1alpha1.Computes{
@@ -279,5 +387,6 @@ func (r *WorkflowReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		WithOptions(controller.Options{MaxConcurrentReconciles: maxReconciles}).
 		For(&dwsv1alpha1.Workflow{}).
 		Owns(&dwsv1alpha1.Computes{}).
-		Complete(r)
+		Watches(&source.Kind{Type: &dwsv1alpha1.ClientMount{}}, handler.EnqueueRequestsFromMapFunc(clientMountMapFunc)).
+		Complete(metrics.CountReconciles("Workflow", r))
 }
@@ -78,6 +78,44 @@ var _ = Describe("Workflow Controller Test", func() {
 		Expect(k8sClient.Update(context.TODO(), wf)).ToNot(Succeed())
 	})
 
+	It("Publishes the job environment of the workflow's ClientMounts", func() {
+		Expect(k8sClient.Create(context.TODO(), wf)).To(Succeed())
+
+		clientMount := &dwsv1alpha1.ClientMount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      wf.Name,
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: dwsv1alpha1.ClientMountSpec{
+				Node:         "compute-0",
+				DesiredState: dwsv1alpha1.ClientMountStateMounted,
+				Mounts: []dwsv1alpha1.ClientMountInfo{{
+					MountPath:  "/mnt/scratch",
+					Device:     dwsv1alpha1.ClientMountDevice{Type: dwsv1alpha1.ClientMountDeviceTypeReference},
+					Type:       "none",
+					TargetType: "directory",
+					EnvName:    "DW_JOB_scratch",
+				}},
+			},
+		}
+		dwsv1alpha1.AddWorkflowLabels(clientMount, wf)
+		Expect(k8sClient.Create(context.TODO(), clientMount)).To(Succeed())
+		DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(context.TODO(), clientMount))).To(Succeed()) })
+
+		clientMount.Status.Mounts = []dwsv1alpha1.ClientMountInfoStatus{{State: dwsv1alpha1.ClientMountStateMounted, Ready: true}}
+		clientMount.UpdateEnv()
+		Expect(k8sClient.Status().Update(context.TODO(), clientMount)).To(Succeed())
+
+		Eventually(func(g Gomega) map[string]string {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(wf), wf)).To(Succeed())
+			return wf.Status.Env
+		}).Should(HaveKeyWithValue("DW_JOB_scratch", "/mnt/scratch"))
+
+		Expect(wf.Status.ClientMounts).ToNot(BeNil())
+		Expect(wf.Status.ClientMounts.Total).To(Equal(1))
+		Expect(wf.Status.ClientMounts.AllReady).To(BeTrue())
+	})
+
 	It("Creates workflow, goes to teardown with hurry flag", func() {
 		Expect(k8sClient.Create(context.TODO(), wf)).To(Succeed())
 
@@ -90,4 +128,45 @@ var _ = Describe("Workflow Controller Test", func() {
 		wf.Spec.Hurry = true
 		Expect(k8sClient.Update(context.TODO(), wf)).To(Succeed())
 	})
+
+	It("Hurries the workflow's ClientMounts in a hurried teardown", func() {
+		Expect(k8sClient.Create(context.TODO(), wf)).To(Succeed())
+
+		clientMount := &dwsv1alpha1.ClientMount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      wf.Name,
+				Namespace: corev1.NamespaceDefault,
+			},
+			Spec: dwsv1alpha1.ClientMountSpec{
+				Node:         "compute-0",
+				DesiredState: dwsv1alpha1.ClientMountStateMounted,
+				Mounts: []dwsv1alpha1.ClientMountInfo{{
+					MountPath:  "/mnt/scratch",
+					Device:     dwsv1alpha1.ClientMountDevice{Type: dwsv1alpha1.ClientMountDeviceTypeReference},
+					Type:       "none",
+					TargetType: "directory",
+				}},
+			},
+		}
+		dwsv1alpha1.AddWorkflowLabels(clientMount, wf)
+		Expect(k8sClient.Create(context.TODO(), clientMount)).To(Succeed())
+		DeferCleanup(func() { Expect(client.IgnoreNotFound(k8sClient.Delete(context.TODO(), clientMount))).To(Succeed()) })
+
+		Eventually(func(g Gomega) string {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(wf), wf)).To(Succeed())
+			return wf.Status.Status
+		}).Should(Equal(dwsv1alpha1.StatusCompleted))
+
+		Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(clientMount), clientMount)).To(Succeed())
+		Expect(clientMount.Spec.Hurry).To(BeFalse())
+
+		wf.Spec.DesiredState = dwsv1alpha1.StateTeardown
+		wf.Spec.Hurry = true
+		Expect(k8sClient.Update(context.TODO(), wf)).To(Succeed())
+
+		Eventually(func(g Gomega) bool {
+			g.Expect(k8sClient.Get(context.TODO(), client.ObjectKeyFromObject(clientMount), clientMount)).To(Succeed())
+			return clientMount.Spec.Hurry
+		}).Should(BeTrue())
+	})
 })
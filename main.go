@@ -17,9 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
 	"runtime"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -53,11 +56,33 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var orphanGracePeriod time.Duration
+	var finalizerGracePeriod time.Duration
+	var expiryWarningPeriod time.Duration
+	var manageNodeNamespaces bool
+	var shard controllers.Shard
+	var cacheOptions controllers.CacheOptions
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&orphanGracePeriod, "clientmount-orphan-grace-period", 5*time.Minute,
+		"How long a ClientMount must be orphaned before it's deleted.")
+	flag.DurationVar(&finalizerGracePeriod, "clientmount-finalizer-grace-period", 10*time.Minute,
+		"How long a deleted ClientMount in a deleted namespace holds its finalizer before it's removed.")
+	flag.DurationVar(&expiryWarningPeriod, "clientmount-expiry-warning-period", 10*time.Minute,
+		"How long before a ClientMount expires that a warning event is sent. No warning is sent if 0.")
+	flag.BoolVar(&manageNodeNamespaces, "manage-node-namespaces", false,
+		"Create the namespace, ServiceAccount, and RBAC the mount-daemon needs for each compute node in the SystemConfiguration.")
+	flag.IntVar(&shard.Index, "shard-index", 0,
+		"The shard of the ClientMount namespaces handled by this replica. Only shard 0 runs the other controllers.")
+	flag.IntVar(&shard.Count, "shard-count", 1,
+		"The number of replicas the ClientMount namespaces are split between. Each replica needs its own --shard-index.")
+	flag.BoolVar(&cacheOptions.StripManagedFields, "cache-strip-managed-fields", false,
+		"Drop the managed fields of the cached objects to shrink the memory of the informers on large systems.")
+	flag.StringVar(&cacheOptions.ClientMountSelector, "clientmount-label-selector", "",
+		"Label selector for the ClientMounts this replica caches and reconciles. Every ClientMount is cached if empty.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -68,37 +93,141 @@ func main() {
 
 	setupLog.Info("GOMAXPROCS", "value", runtime.GOMAXPROCS(0))
 
+	if err := shard.Validate(); err != nil {
+		setupLog.Error(err, "invalid shard")
+		os.Exit(1)
+	}
+
+	// Each shard elects its own leader so the replicas of different shards run side by side
+	leaderElectionID := "a08857a2.cray.hpe.com"
+	if shard.Sharded() {
+		leaderElectionID = fmt.Sprintf("shard-%d.%s", shard.Index, leaderElectionID)
+	}
+
+	newCache, err := cacheOptions.NewCacheFunc()
+	if err != nil {
+		setupLog.Error(err, "invalid cache options")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
 		Port:                   9443,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "a08857a2.cray.hpe.com",
+		LeaderElectionID:       leaderElectionID,
+		NewCache:               newCache,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.WorkflowReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Workflow"),
-		Scheme: mgr.GetScheme(),
+	// The controllers that aren't sharded only run in the first shard
+	if shard.Primary() {
+		if err = (&controllers.WorkflowReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("Workflow"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Workflow")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.DWDirectiveRuleReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("DWDirectiveRule"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "DWDirectiveRule")
+			os.Exit(1)
+		}
+
+		if err = (&controllers.StoragePoolReconciler{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("StoragePool"),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "StoragePool")
+			os.Exit(1)
+		}
+
+		if manageNodeNamespaces {
+			if err = (&controllers.SystemConfigurationReconciler{
+				Client: mgr.GetClient(),
+				Log:    ctrl.Log.WithName("controllers").WithName("SystemConfiguration"),
+				Scheme: mgr.GetScheme(),
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "SystemConfiguration")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if err = (&controllers.ClientMountJanitorReconciler{
+		Client:               mgr.GetClient(),
+		Log:                  ctrl.Log.WithName("controllers").WithName("ClientMountJanitor"),
+		Scheme:               mgr.GetScheme(),
+		OrphanGracePeriod:    orphanGracePeriod,
+		FinalizerGracePeriod: finalizerGracePeriod,
+		Shard:                shard,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClientMountJanitor")
+		os.Exit(1)
+	}
+
+	if err = (&controllers.ClientMountExpiryReconciler{
+		Client:        mgr.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClientMountExpiry"),
+		Scheme:        mgr.GetScheme(),
+		Recorder:      mgr.GetEventRecorderFor("clientmount-expiry"),
+		WarningPeriod: expiryWarningPeriod,
+		Shard:         shard,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Workflow")
+		setupLog.Error(err, "unable to create controller", "controller", "ClientMountExpiry")
 		os.Exit(1)
 	}
 
 	if os.Getenv("ENVIRONMENT") == "kind" {
 		if err = (&controllers.ClientMountReconciler{
-			Client: mgr.GetClient(),
-			Log:    ctrl.Log.WithName("controllers").WithName("ClientMount"),
-			Scheme: mgr.GetScheme(),
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ClientMount"),
+			Scheme:   mgr.GetScheme(),
+			Shard:    shard,
+			Recorder: mgr.GetEventRecorderFor("clientmount"),
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Workflow")
 			os.Exit(1)
 		}
+
+		if shard.Primary() {
+			if err = (&controllers.DirectiveBreakdownReconciler{
+				Client: mgr.GetClient(),
+				Log:    ctrl.Log.WithName("controllers").WithName("DirectiveBreakdown"),
+				Scheme: mgr.GetScheme(),
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "DirectiveBreakdown")
+				os.Exit(1)
+			}
+
+			if err = (&controllers.DataMovementReconciler{
+				Client: mgr.GetClient(),
+				Log:    ctrl.Log.WithName("controllers").WithName("DataMovement"),
+				Scheme: mgr.GetScheme(),
+				Mover:  controllers.NoopDataMover{},
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "DataMovement")
+				os.Exit(1)
+			}
+		}
+	}
+
+	if shard.Primary() {
+		if err = controllers.AddClientMountMetrics(context.Background(), mgr.GetCache()); err != nil {
+			setupLog.Error(err, "unable to add ClientMount metrics")
+			os.Exit(1)
+		}
 	}
 
 	if err = (&dwsv1alpha1.Workflow{}).SetupWebhookWithManager(mgr); err != nil {
@@ -106,6 +235,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&dwsv1alpha1.ClientMount{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ClientMount")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
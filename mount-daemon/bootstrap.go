@@ -0,0 +1,222 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+type bootstrapOptions struct {
+	kubeconfig     string
+	namespace      string
+	serviceAccount string
+	tokenFile      string
+	certFile       string
+	expiration     time.Duration
+	rotate         bool
+}
+
+// bootstrap requests a token for the node's ServiceAccount using a bootstrap credential and
+// writes the token and the cluster CA certificate to the files the daemon reads. With
+// --rotate it keeps running and requests a new token before the current one expires. The
+// daemon rereads the token file, so a rotated token is picked up without a restart.
+//
+//	clientmount bootstrap --bootstrap-kubeconfig /etc/dws/bootstrap.kubeconfig --rotate
+func (service *Service) bootstrap(args []string) (string, error) {
+	opts := bootstrapOptions{
+		namespace:      os.Getenv("NODE_NAME"),
+		serviceAccount: "clientmount",
+		tokenFile:      os.Getenv("DWS_CLIENT_MOUNT_SERVICE_TOKEN_FILE"),
+		certFile:       os.Getenv("DWS_CLIENT_MOUNT_SERVICE_CERT_FILE"),
+		expiration:     24 * time.Hour,
+	}
+
+	flags := flag.NewFlagSet("bootstrap", flag.ContinueOnError)
+	flags.StringVar(&opts.kubeconfig, "bootstrap-kubeconfig", opts.kubeconfig, "Path to a kubeconfig with a credential allowed to create tokens for the node's ServiceAccount")
+	flags.StringVar(&opts.namespace, "node-name", opts.namespace, "Name of this compute resource. The node's ServiceAccount is in the namespace of the same name")
+	flags.StringVar(&opts.serviceAccount, "service-account", opts.serviceAccount, "Name of the node's ServiceAccount")
+	flags.StringVar(&opts.tokenFile, "service-token-file", opts.tokenFile, "Path the DWS client mount service token is written to")
+	flags.StringVar(&opts.certFile, "service-cert-file", opts.certFile, "Path the DWS client mount service certificate is written to")
+	flags.DurationVar(&opts.expiration, "token-expiration", opts.expiration, "Requested lifetime of the token. The API server may shorten it")
+	flags.BoolVar(&opts.rotate, "rotate", opts.rotate, "Keep running and request a new token before the current one expires")
+
+	zapOptions := zap.Options{
+		Development: true,
+	}
+	zapOptions.BindFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return "Bootstrap", err
+	}
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOptions)))
+	log := ctrl.Log.WithName("bootstrap")
+
+	if len(opts.kubeconfig) == 0 {
+		return "Bootstrap", fmt.Errorf("bootstrap kubeconfig not defined")
+	}
+
+	if len(opts.namespace) == 0 {
+		return "Bootstrap", fmt.Errorf("node name not defined")
+	}
+
+	if len(opts.tokenFile) == 0 || len(opts.certFile) == 0 {
+		return "Bootstrap", fmt.Errorf("DWS client mount service token and certificate files not defined")
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", opts.kubeconfig)
+	if err != nil {
+		return "Bootstrap", fmt.Errorf("could not load bootstrap kubeconfig '%s': %w", opts.kubeconfig, err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "Bootstrap", err
+	}
+
+	if err := writeBootstrapCert(config, opts.certFile); err != nil {
+		return "Bootstrap", err
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+
+	for {
+		expires, err := requestToken(ctx, clientset, opts)
+		if err != nil {
+			if !opts.rotate {
+				return "Bootstrap", err
+			}
+
+			// Keep trying while the current token is still valid
+			log.Error(err, "Could not rotate token")
+			expires = time.Now().Add(time.Minute)
+		} else {
+			log.Info("Wrote token", "path", opts.tokenFile, "serviceAccount", opts.namespace+"/"+opts.serviceAccount, "expires", expires.String())
+		}
+
+		if !opts.rotate {
+			return "Bootstrapped", nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "Exited", nil
+		case <-time.After(rotationDelay(time.Now(), expires)):
+		}
+	}
+}
+
+// requestToken creates a token for the node's ServiceAccount with the TokenRequest API and
+// writes it to the token file. It returns the time the token expires.
+func requestToken(ctx context.Context, clientset kubernetes.Interface, opts bootstrapOptions) (time.Time, error) {
+	expirationSeconds := int64(opts.expiration.Seconds())
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+
+	tokenRequest, err := clientset.CoreV1().ServiceAccounts(opts.namespace).CreateToken(ctx, opts.serviceAccount, tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not request token for ServiceAccount '%s/%s': %w", opts.namespace, opts.serviceAccount, err)
+	}
+
+	if err := writeFileAtomic(opts.tokenFile, []byte(tokenRequest.Status.Token), 0600); err != nil {
+		return time.Time{}, err
+	}
+
+	return tokenRequest.Status.ExpirationTimestamp.Time, nil
+}
+
+// rotationDelay returns how long to wait before rotating a token. The token is rotated
+// once 80% of its remaining lifetime has passed so the daemon always has a valid token.
+func rotationDelay(now time.Time, expires time.Time) time.Duration {
+	delay := expires.Sub(now) * 8 / 10
+	if delay < 10*time.Second {
+		delay = 10 * time.Second
+	}
+
+	return delay
+}
+
+// writeBootstrapCert writes the cluster CA certificate from the bootstrap kubeconfig to the
+// certificate file
+func writeBootstrapCert(config *rest.Config, certFile string) error {
+	data := config.TLSClientConfig.CAData
+	if len(data) == 0 {
+		if len(config.TLSClientConfig.CAFile) == 0 {
+			return fmt.Errorf("bootstrap kubeconfig has no certificate authority")
+		}
+
+		var err error
+		data, err = os.ReadFile(config.TLSClientConfig.CAFile)
+		if err != nil {
+			return fmt.Errorf("could not read certificate authority '%s': %w", config.TLSClientConfig.CAFile, err)
+		}
+	}
+
+	return writeFileAtomic(certFile, data, 0644)
+}
+
+// writeFileAtomic replaces the file so a reader never sees a partially written file
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("could not write '%s': %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-logr/logr"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/HewlettPackard/dws/mount-daemon/controllers"
+)
+
+// daemonConfig is the content of the daemon's config file. Every field is optional, and a
+// field that isn't set keeps the value given on the command line. For example:
+//
+//	logLevel: 2
+//	mock: false
+//	retryDelay: 30s
+//	commandTimeout: 5m
+type daemonConfig struct {
+	// LogLevel is the logr verbosity (e.g., 1 logs the commands run on the host)
+	LogLevel *int `json:"logLevel,omitempty"`
+
+	Mock           *bool            `json:"mock,omitempty"`
+	RetryDelay     *metav1.Duration `json:"retryDelay,omitempty"`
+	CommandTimeout *metav1.Duration `json:"commandTimeout,omitempty"`
+}
+
+// configReloader applies the config file on top of the command line options. The config
+// file is read at startup and again each time the daemon receives SIGHUP, so settings can
+// be changed without restarting the daemon and interrupting the mounts in progress.
+type configReloader struct {
+	path string
+
+	// base holds the settings and log level from the command line
+	base      controllers.Settings
+	baseLevel zapcore.Level
+
+	settings *controllers.RuntimeSettings
+	level    uberzap.AtomicLevel
+}
+
+func newConfigReloader(path string, base controllers.Settings, level uberzap.AtomicLevel) *configReloader {
+	return &configReloader{
+		path:      path,
+		base:      base,
+		baseLevel: level.Level(),
+		settings:  controllers.NewRuntimeSettings(base),
+		level:     level,
+	}
+}
+
+// load reads the config file and applies it. The current settings are left unchanged if
+// the config file is invalid.
+func (c *configReloader) load() error {
+	if len(c.path) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("could not read config file '%s': %w", c.path, err)
+	}
+
+	config := daemonConfig{}
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return fmt.Errorf("could not parse config file '%s': %w", c.path, err)
+	}
+
+	settings := c.base
+	level := c.baseLevel
+
+	if config.LogLevel != nil {
+		if *config.LogLevel < 0 || *config.LogLevel > 127 {
+			return fmt.Errorf("config file '%s' log level %d is out of range", c.path, *config.LogLevel)
+		}
+		level = zapcore.Level(-*config.LogLevel)
+	}
+
+	if config.Mock != nil {
+		settings.Mock = *config.Mock
+	}
+
+	if config.RetryDelay != nil {
+		if config.RetryDelay.Duration <= 0 {
+			return fmt.Errorf("config file '%s' retry delay must be positive", c.path)
+		}
+		settings.RetryDelay = config.RetryDelay.Duration
+	}
+
+	if config.CommandTimeout != nil {
+		if config.CommandTimeout.Duration < 0 {
+			return fmt.Errorf("config file '%s' command timeout must not be negative", c.path)
+		}
+		settings.CommandTimeout = config.CommandTimeout.Duration
+	}
+
+	c.settings.Set(settings)
+	c.level.SetLevel(level)
+
+	return nil
+}
+
+// handleReloadSignal reloads the config file each time the daemon receives SIGHUP
+func (c *configReloader) handleReloadSignal(ctx context.Context, log logr.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+
+		if err := c.load(); err != nil {
+			log.Error(err, "Could not reload config, keeping the current settings")
+			continue
+		}
+
+		settings := c.settings.Get()
+		log.Info("Reloaded config", "path", c.path, "logLevel", -int(c.level.Level()), "mock", settings.Mock,
+			"retryDelay", settings.RetryDelay.String(), "commandTimeout", settings.CommandTimeout.String())
+	}
+}
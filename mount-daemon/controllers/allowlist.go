@@ -0,0 +1,596 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/command"
+)
+
+// protectedDirs are the directories of the node's own software and configuration. The
+// privileged helper won't mount on, remove, or change anything under them, even if a mount
+// root is configured over one of them.
+var protectedDirs = []string{"/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/proc", "/sbin", "/sys", "/usr"}
+
+// privilegedOptions are the mount options that let the files of a mount gain privileges on
+// the node. "defaults" includes suid and dev.
+var privilegedOptions = []string{"suid", "dev", "defaults"}
+
+// formatDevicePatterns are the device paths the reconciler resolves for the devices it
+// formats and uses as swap: LVM logical volumes, multipath and device mapper devices, RBD
+// images, and the by-id links of block devices. The device dirs of the kernel and udev are
+// excluded from the /dev/<vg>/<lv> form.
+var formatDevicePatterns = []string{"/dev/*/*", "/dev/mapper/*", "/dev/rbd[0-9]*", "/dev/rbd/*/*", "/dev/disk/by-id/*"}
+
+// kernelDeviceDirs are the directories under /dev that aren't LVM volume groups
+var kernelDeviceDirs = []string{"block", "bus", "char", "cpu", "disk", "dri", "fd", "hugepages", "input", "md", "mqueue", "net", "pts", "rbd", "shm", "snd", "vfio"}
+
+// helperMountTypes are the file system types the privileged helper mounts, with the check
+// of the device for each type. The source of a bind mount, which is mounted as type none,
+// has no check here since it's held to the same paths as a mount target.
+var helperMountTypes = map[dwsv1alpha1.FileSystemType]argCheck{
+	dwsv1alpha1.FileSystemTypeXFS:    checkDevicePath,
+	dwsv1alpha1.FileSystemTypeExt4:   checkDevicePath,
+	dwsv1alpha1.FileSystemTypeGFS2:   checkDevicePath,
+	dwsv1alpha1.FileSystemTypeLustre: checkRemoteDevice,
+	dwsv1alpha1.FileSystemTypeNFS:    checkRemoteDevice,
+	dwsv1alpha1.FileSystemTypeCeph:   checkRemoteDevice,
+	dwsv1alpha1.FileSystemTypeTmpfs:  checkWord,
+	dwsv1alpha1.FileSystemTypeNone:   nil,
+}
+
+// HelperConfig limits what the privileged helper does for the ClientMount reconciler
+type HelperConfig struct {
+	// MountRoots are the directories the mounts, swap files, and bind mount sources are
+	// under. The helper only mounts on, creates, and removes paths below one of them, and
+	// none of the elements of those paths may be a symbolic link.
+	MountRoots []string
+
+	// FormatDevices are patterns (see filepath.Match) of the devices the helper formats and
+	// uses as swap in addition to the device paths the reconciler resolves, such as
+	// "/dev/sd*" for the block devices found by UUID, label, or WWN.
+	FormatDevices []string
+
+	// HookDir is the directory the site hooks are run from. Hooks aren't run if empty.
+	HookDir string
+
+	// AllowPrivilegedOptions lets the mounts use the suid, dev, and defaults options
+	AllowPrivilegedOptions bool
+}
+
+// helperChecks are the argument checks that depend on the helper's configuration
+type helperChecks struct {
+	mountRoots             []string
+	formatDevices          []string
+	allowPrivilegedOptions bool
+
+	// root is the directory the paths are looked up under when checking them for symbolic
+	// links. It's the root of a process in the mount namespace the command is run in, or
+	// empty for the mount namespace of the helper.
+	root string
+}
+
+// HelperAllowlist returns the commands the privileged helper runs for the ClientMount
+// reconciler and the checks of their arguments. The arguments are limited to the forms the
+// reconciler uses, so a ClientMount spec can't smuggle extra options or paths outside the
+// mount roots into a privileged command.
+func HelperAllowlist(config HelperConfig) command.Allowlist {
+	h := &helperChecks{formatDevices: config.FormatDevices, allowPrivilegedOptions: config.AllowPrivilegedOptions}
+	for _, root := range config.MountRoots {
+		h.mountRoots = append(h.mountRoots, filepath.Clean(root))
+	}
+
+	allowlist := h.allowlist()
+	if config.HookDir != "" {
+		allowlist["env"] = checkHookArgs(config.HookDir)
+	}
+
+	allowlist["nsenter"] = h.checkNsenterArgs
+
+	return allowlist
+}
+
+// allowlist returns the commands of the helper that are checked the same way in every
+// mount namespace
+func (h *helperChecks) allowlist() command.Allowlist {
+	return command.Allowlist{
+		"mount":  h.checkMountArgs,
+		"umount": oneOf(exactArgs(h.checkTargetPath), exactArgs(literal("--lazy"), h.checkTargetPath)),
+		"mkdir":  exactArgs(literal("-p"), h.checkTargetPath),
+		"touch":  exactArgs(h.checkTargetPath),
+		"chmod":  exactArgs(checkMode, h.checkTargetPath),
+		"rmdir":  exactArgs(h.checkTargetPath),
+		"rm":     h.checkRemoveArgs,
+
+		"vgchange": oneOf(
+			exactArgs(literal("--activate"), literal("y", "n", "ey", "sy"), checkWord),
+			exactArgs(literal("--lockstart", "--lockstop"), checkWord),
+		),
+		"lvs":        exactArgs(literal("--noheadings"), literal("--separator"), literal(" ")),
+		"pvs":        exactArgs(literal("--noheadings"), literal("--separator"), literal(" "), literal("-o"), literal("vg_name")),
+		"lvm":        exactArgs(literal("version")),
+		"lvmlockctl": exactArgs(literal("--info")),
+		"dmsetup":    exactArgs(literal("table"), checkWord),
+
+		"blkid":     exactArgs(literal("-U", "-L"), checkWord),
+		"readlink":  exactArgs(literal("-e"), checkDevicePath),
+		"wipefs":    exactArgs(literal("--no-act"), literal("--noheadings"), literal("--output"), literal("TYPE"), h.checkFormatDevice),
+		"mkfs.xfs":  h.checkFormatArgs,
+		"mkfs.ext4": h.checkFormatArgs,
+		"mkfs.gfs2": h.checkFormatArgs,
+
+		"mkswap":    exactArgs(h.checkSwapPath),
+		"swapoff":   exactArgs(h.checkSwapPath),
+		"fallocate": exactArgs(literal("--length"), checkNumber, h.checkTargetPath),
+		"swapon": oneOf(
+			exactArgs(literal("--show=NAME"), literal("--noheadings")),
+			exactArgs(h.checkSwapPath),
+			exactArgs(literal("--options"), h.checkOptions, h.checkSwapPath),
+		),
+
+		"multipathd": oneOf(
+			exactArgs(literal("add", "remove"), literal("map"), checkWord),
+			exactArgs(literal("show"), literal("maps", "paths"), literal("raw"), literal("format"), literal("%n %w", "%w %t")),
+		),
+		"lnetctl":             exactArgs(literal("ping"), checkWord),
+		"corosync-quorumtool": exactArgs(literal("-s")),
+		"dlm_tool":            exactArgs(literal("status")),
+		"systemctl":           exactArgs(literal("reload"), literal("autofs")),
+
+		"rbd": oneOf(
+			exactArgs(literal("device"), literal("list"), literal("--format"), literal("json")),
+			exactArgs(literal("device"), literal("unmap"), checkDevicePath),
+			exactArgs(literal("device"), literal("map"), checkWord),
+			exactArgs(literal("device"), literal("map"), checkWord, literal("--id"), checkWord),
+		),
+	}
+}
+
+// argCheck checks a single argument
+type argCheck func(arg string) error
+
+// exactArgs checks that there's one argument for each check and that each passes its check
+func exactArgs(checks ...argCheck) command.ArgsValidator {
+	return func(args []string) error {
+		if len(args) != len(checks) {
+			return fmt.Errorf("expected %d arguments, not %d", len(checks), len(args))
+		}
+
+		for i, check := range checks {
+			if err := check(args[i]); err != nil {
+				return fmt.Errorf("argument %d: %w", i+1, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// oneOf accepts the arguments if any of the validators does
+func oneOf(validators ...command.ArgsValidator) command.ArgsValidator {
+	return func(args []string) error {
+		var err error
+		for _, validate := range validators {
+			if err = validate(args); err == nil {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("arguments %q don't match any allowed form: %w", args, err)
+	}
+}
+
+// literal accepts only the given values
+func literal(values ...string) argCheck {
+	return func(arg string) error {
+		for _, value := range values {
+			if arg == value {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("'%s' is not one of %q", arg, values)
+	}
+}
+
+// checkWord accepts a non-empty argument that can't be taken for an option
+func checkWord(arg string) error {
+	if arg == "" || strings.HasPrefix(arg, "-") {
+		return fmt.Errorf("'%s' is empty or an option", arg)
+	}
+
+	if strings.ContainsAny(arg, " \t\n") {
+		return fmt.Errorf("'%s' contains white space", arg)
+	}
+
+	return nil
+}
+
+// checkNumber accepts a decimal number
+func checkNumber(arg string) error {
+	if arg == "" || strings.Trim(arg, "0123456789") != "" {
+		return fmt.Errorf("'%s' is not a number", arg)
+	}
+
+	return nil
+}
+
+// checkMode accepts an octal file mode of permission bits only, which are the modes
+// getTargetMode allows. The setuid, setgid, and sticky bits are refused.
+func checkMode(arg string) error {
+	if arg == "" || len(arg) > 4 || strings.Trim(arg, "01234567") != "" {
+		return fmt.Errorf("'%s' is not an octal mode", arg)
+	}
+
+	if mode, _ := strconv.ParseUint(arg, 8, 32); mode > 0777 {
+		return fmt.Errorf("mode '%s' has more than the permission bits", arg)
+	}
+
+	return nil
+}
+
+// checkOptions accepts a comma separated list of mount options. The options that give the
+// files of the mount privileges are refused unless the helper allows them.
+func (h *helperChecks) checkOptions(arg string) error {
+	if err := checkWord(arg); err != nil {
+		return err
+	}
+
+	for _, option := range strings.Split(arg, ",") {
+		// A mount helper option would run a program of the spec's choosing
+		if strings.HasPrefix(option, "helper=") {
+			return fmt.Errorf("option '%s' is not allowed", option)
+		}
+
+		if !h.allowPrivilegedOptions {
+			for _, privileged := range privilegedOptions {
+				if option == privileged {
+					return fmt.Errorf("option '%s' is not allowed", option)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkCleanPath accepts an absolute path with no "." or ".." elements
+func checkCleanPath(arg string) error {
+	if err := checkWord(arg); err != nil {
+		return err
+	}
+
+	if !filepath.IsAbs(arg) || filepath.Clean(arg) != arg {
+		return fmt.Errorf("'%s' is not a clean absolute path", arg)
+	}
+
+	return nil
+}
+
+// checkTargetPath accepts a path a mount may use, which is a path below one of the mount
+// roots and outside the protected directories. A mount root itself isn't accepted, so it
+// can't be mounted over or removed. The path may not go through a symbolic link, since a
+// link made in a mounted file system could send the command outside the mount roots.
+func (h *helperChecks) checkTargetPath(arg string) error {
+	if err := checkCleanPath(arg); err != nil {
+		return err
+	}
+
+	for _, dir := range protectedDirs {
+		if arg == dir || strings.HasPrefix(arg, dir+"/") {
+			return fmt.Errorf("'%s' is under protected directory %s", arg, dir)
+		}
+	}
+
+	for _, root := range h.mountRoots {
+		if root != "/" && strings.HasPrefix(arg, root+"/") {
+			return h.checkNoSymlinks(arg)
+		}
+	}
+
+	return fmt.Errorf("'%s' is not below a mount root %q", arg, h.mountRoots)
+}
+
+// checkNoSymlinks checks that no element of a clean absolute path is a symbolic link. The
+// elements are looked up under the root of the mount namespace, and the check stops at the
+// first element that doesn't exist.
+func (h *helperChecks) checkNoSymlinks(arg string) error {
+	path := h.root
+	for _, element := range strings.Split(strings.TrimPrefix(arg, "/"), "/") {
+		path += "/" + element
+
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not check '%s' for symbolic links: %w", arg, err)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("'%s' goes through the symbolic link %s", arg, strings.TrimPrefix(path, h.root))
+		}
+	}
+
+	return nil
+}
+
+// checkDevicePath accepts a device under /dev
+func checkDevicePath(arg string) error {
+	if err := checkCleanPath(arg); err != nil {
+		return err
+	}
+
+	if !strings.HasPrefix(arg, "/dev/") {
+		return fmt.Errorf("'%s' is not a device", arg)
+	}
+
+	return nil
+}
+
+// checkFormatDevice accepts a device the reconciler formats or uses as swap, which would
+// destroy its data. Only the device paths the reconciler resolves and the configured
+// devices are accepted, so the node's own disks can't be named.
+func (h *helperChecks) checkFormatDevice(arg string) error {
+	if err := checkDevicePath(arg); err != nil {
+		return err
+	}
+
+	for _, pattern := range formatDevicePatterns {
+		if matched, _ := filepath.Match(pattern, arg); !matched {
+			continue
+		}
+
+		if pattern == "/dev/*/*" && isKernelDeviceDir(strings.Split(arg, "/")[2]) {
+			continue
+		}
+
+		return nil
+	}
+
+	for _, pattern := range h.formatDevices {
+		if matched, _ := filepath.Match(pattern, arg); matched {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("'%s' is not a device that may be formatted", arg)
+}
+
+// isKernelDeviceDir returns whether a directory under /dev is one of the kernelDeviceDirs
+func isKernelDeviceDir(dir string) bool {
+	for _, kernelDir := range kernelDeviceDirs {
+		if dir == kernelDir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkSwapPath accepts a swap device or a swap file
+func (h *helperChecks) checkSwapPath(arg string) error {
+	if checkDevicePath(arg) == nil {
+		return h.checkFormatDevice(arg)
+	}
+
+	return h.checkTargetPath(arg)
+}
+
+// checkRemoteDevice accepts a network file system source of the form [server]:/[path]
+func checkRemoteDevice(arg string) error {
+	if err := checkWord(arg); err != nil {
+		return err
+	}
+
+	if !strings.Contains(arg, ":/") {
+		return fmt.Errorf("'%s' is not a network file system source", arg)
+	}
+
+	return nil
+}
+
+// checkMountArgs accepts the mount table query, a propagation change, and the mount of one
+// of the allowed file system types
+func (h *helperChecks) checkMountArgs(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	if strings.HasPrefix(args[0], "--make-") {
+		return exactArgs(literal(
+			"--make-"+string(dwsv1alpha1.ClientMountPropagationPrivate),
+			"--make-"+string(dwsv1alpha1.ClientMountPropagationShared),
+			"--make-"+string(dwsv1alpha1.ClientMountPropagationSlave),
+		), h.checkTargetPath)(args)
+	}
+
+	if len(args) != 4 && len(args) != 6 {
+		return fmt.Errorf("expected 4 or 6 arguments, not %d", len(args))
+	}
+
+	if args[0] != "-t" {
+		return fmt.Errorf("expected the file system type first")
+	}
+
+	checkDevice, found := helperMountTypes[dwsv1alpha1.FileSystemType(args[1])]
+	if !found {
+		return fmt.Errorf("file system type '%s' is not allowed", args[1])
+	}
+
+	if checkDevice == nil {
+		checkDevice = h.checkTargetPath
+	}
+
+	checks := []argCheck{checkDevice, h.checkTargetPath}
+	if len(args) == 6 {
+		checks = append(checks, literal("-o"), h.checkOptions)
+	}
+
+	return exactArgs(checks...)(args[2:])
+}
+
+// checkRemoveArgs accepts the removal of a file or a directory tree below a mount root
+func (h *helperChecks) checkRemoveArgs(args []string) error {
+	if len(args) == 2 {
+		return exactArgs(literal("-rf"), h.checkTargetPath)(args)
+	}
+
+	return exactArgs(h.checkTargetPath)(args)
+}
+
+// checkFormatArgs accepts the format options of a ClientMount followed by the device. The
+// options can't name another device since only the last argument may be a path.
+func (h *helperChecks) checkFormatArgs(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a device")
+	}
+
+	for _, arg := range args[:len(args)-1] {
+		if strings.HasPrefix(arg, "/") {
+			return fmt.Errorf("option '%s' is a path", arg)
+		}
+	}
+
+	return h.checkFormatDevice(args[len(args)-1])
+}
+
+// checkHookArgs accepts the DWS_ environment settings and a hook under hookDir
+func checkHookArgs(hookDir string) command.ArgsValidator {
+	return func(args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("expected a hook")
+		}
+
+		for _, arg := range args[:len(args)-1] {
+			if !strings.HasPrefix(arg, "DWS_") || !strings.Contains(arg, "=") {
+				return fmt.Errorf("'%s' is not a DWS_ environment setting", arg)
+			}
+		}
+
+		hook := args[len(args)-1]
+		if err := checkCleanPath(hook); err != nil {
+			return err
+		}
+
+		if !strings.HasPrefix(hook, filepath.Clean(hookDir)+"/") {
+			return fmt.Errorf("'%s' is not under the hook directory", hook)
+		}
+
+		return nil
+	}
+}
+
+// checkNsenterArgs accepts a command from the allowlist run in a mount namespace. Only the
+// commands that act on the mount target are run in another mount namespace, and their
+// paths are checked for symbolic links under the root of a process in the namespace.
+func (h *helperChecks) checkNsenterArgs(args []string) error {
+	if len(args) < 3 || !strings.HasPrefix(args[0], "--mount=") || args[1] != "--" {
+		return fmt.Errorf("expected --mount=[path] -- [command]")
+	}
+
+	namespace := strings.TrimPrefix(args[0], "--mount=")
+	if err := checkNamespacePath(namespace); err != nil {
+		return err
+	}
+
+	if !namespacedCommands[args[2]] {
+		return fmt.Errorf("command '%s' is not run in a mount namespace", args[2])
+	}
+
+	root, err := namespaceRoot(namespace)
+	if err != nil {
+		return err
+	}
+
+	namespaced := *h
+	namespaced.root = root
+
+	return namespaced.allowlist().Check(args[2], args[3:])
+}
+
+// namespacePatterns are the forms of the mount namespace files: the namespace of a process,
+// and a bind mount of a namespace made under /run (e.g., /run/mntns/job-1)
+var namespacePatterns = []string{"/proc/[0-9]*/ns/mnt", "/run/*ns/*"}
+
+// checkNamespacePath accepts a mount namespace file
+func checkNamespacePath(arg string) error {
+	if err := checkCleanPath(arg); err != nil {
+		return err
+	}
+
+	for _, pattern := range namespacePatterns {
+		if matched, _ := filepath.Match(pattern, arg); matched {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("'%s' is not a mount namespace file matching %q", arg, namespacePatterns)
+}
+
+// namespaceRoot returns the root directory of a process in a mount namespace, through
+// which the helper can look up the paths of the namespace
+func namespaceRoot(namespace string) (string, error) {
+	elements := strings.Split(namespace, "/")
+	if len(elements) == 5 && elements[1] == "proc" && checkNumber(elements[2]) == nil && elements[3] == "ns" && elements[4] == "mnt" {
+		return "/proc/" + elements[2] + "/root", nil
+	}
+
+	pid, err := namespaceProcess(namespace)
+	if err != nil {
+		return "", err
+	}
+
+	return "/proc/" + pid + "/root", nil
+}
+
+// namespaceProcess finds a process in the mount namespace of a namespace file, such as a
+// bind mount of the namespace made by a container runtime
+func namespaceProcess(namespace string) (string, error) {
+	var nsStat syscall.Stat_t
+	if err := syscall.Stat(namespace, &nsStat); err != nil {
+		return "", fmt.Errorf("could not find mount namespace '%s': %w", namespace, err)
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if checkNumber(entry.Name()) != nil {
+			continue
+		}
+
+		var stat syscall.Stat_t
+		if err := syscall.Stat("/proc/"+entry.Name()+"/ns/mnt", &stat); err == nil && stat.Dev == nsStat.Dev && stat.Ino == nsStat.Ino {
+			return entry.Name(), nil
+		}
+	}
+
+	return "", fmt.Errorf("no process is in mount namespace '%s'", namespace)
+}
@@ -0,0 +1,382 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testHelperChecks() *helperChecks {
+	return &helperChecks{mountRoots: []string{"/mnt", "/lus"}}
+}
+
+func TestExactArgs(t *testing.T) {
+	validate := exactArgs(literal("-p"), checkWord)
+
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"-p", "vg0"}, true},
+		{[]string{"-p"}, false},
+		{[]string{"-p", "vg0", "extra"}, false},
+		{[]string{"-q", "vg0"}, false},
+		{[]string{"-p", "--all"}, false},
+		{[]string{"-p", ""}, false},
+	}
+
+	for _, test := range tests {
+		if err := validate(test.args); (err == nil) != test.valid {
+			t.Errorf("exactArgs(%q) returned %v, expected valid %t", test.args, err, test.valid)
+		}
+	}
+}
+
+func TestCheckTargetPath(t *testing.T) {
+	h := testHelperChecks()
+
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"/mnt/job-1", true},
+		{"/mnt/job-1/scratch", true},
+		{"/lus/global/projects", true},
+		{"/mnt", false},
+		{"/", false},
+		{"/var", false},
+		{"/root", false},
+		{"/home/user", false},
+		{"/tmp/job-1", false},
+		{"/mntx/job-1", false},
+		{"/mnt/../var", false},
+		{"/mnt/job-1/", false},
+		{"mnt/job-1", false},
+		{"-rf", false},
+		{"/mnt/job 1", false},
+	}
+
+	for _, test := range tests {
+		if err := h.checkTargetPath(test.path); (err == nil) != test.valid {
+			t.Errorf("checkTargetPath(%s) returned %v, expected valid %t", test.path, err, test.valid)
+		}
+	}
+
+	// The protected directories are refused even under a mount root
+	h.mountRoots = []string{"/"}
+	for _, path := range []string{"/var/lib", "/etc/passwd"} {
+		if err := h.checkTargetPath(path); err == nil {
+			t.Errorf("checkTargetPath(%s) accepted a path with / as the mount root", path)
+		}
+	}
+
+	// Nothing is accepted without a mount root
+	h.mountRoots = nil
+	if err := h.checkTargetPath("/mnt/job-1"); err == nil {
+		t.Errorf("checkTargetPath accepted a path without a mount root")
+	}
+}
+
+func TestCheckTargetPathSymlinks(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "mnt", "job-1", "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("/etc", filepath.Join(root, "mnt", "job-1", "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(root, "mnt", "job-1"), filepath.Join(root, "mnt", "job-2")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The paths are looked up under root as they would be under the root of a namespace
+	h := testHelperChecks()
+	h.root = root
+
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"/mnt/job-1", true},
+		{"/mnt/job-1/dir", true},
+		{"/mnt/job-1/dir/new/deeper", true},
+		{"/mnt/job-1/link", false},
+		{"/mnt/job-1/link/cron.d", false},
+		{"/mnt/job-2", false},
+		{"/mnt/job-2/dir", false},
+	}
+
+	for _, test := range tests {
+		if err := h.checkTargetPath(test.path); (err == nil) != test.valid {
+			t.Errorf("checkTargetPath(%s) returned %v, expected valid %t", test.path, err, test.valid)
+		}
+	}
+}
+
+func TestCheckMode(t *testing.T) {
+	tests := []struct {
+		mode  string
+		valid bool
+	}{
+		{"755", true},
+		{"0755", true},
+		{"644", true},
+		{"777", true},
+		{"0", true},
+		{"4755", false},
+		{"2755", false},
+		{"1777", false},
+		{"7777", false},
+		{"00755", false},
+		{"788", false},
+		{"u+s", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if err := checkMode(test.mode); (err == nil) != test.valid {
+			t.Errorf("checkMode(%s) returned %v, expected valid %t", test.mode, err, test.valid)
+		}
+	}
+}
+
+func TestCheckFormatArgs(t *testing.T) {
+	h := testHelperChecks()
+
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"/dev/vg0/lv0"}, true},
+		{[]string{"-f", "/dev/vg0/lv0"}, true},
+		{[]string{"-m", "reflink=1", "/dev/mapper/mpatha"}, true},
+		{[]string{"/dev/rbd0"}, true},
+		{[]string{"/dev/rbd/pool/image"}, true},
+		{[]string{"/dev/disk/by-id/wwn-0x5000c500a1b2c3d4"}, true},
+		{[]string{"/dev/sda"}, false},
+		{[]string{"/dev/nvme0n1p2"}, false},
+		{[]string{"/dev/md/root"}, false},
+		{[]string{"/dev/disk/by-path/pci-0000:00:1f.2-ata-1"}, false},
+		{[]string{"/dev/block/8:0"}, false},
+		{[]string{"/dev/vg0/../sda"}, false},
+		{[]string{"/dev/vg0/lv0", "/dev/sda"}, false},
+		{[]string{"/mnt/job-1/image"}, false},
+		{[]string{}, false},
+	}
+
+	for _, test := range tests {
+		if err := h.checkFormatArgs(test.args); (err == nil) != test.valid {
+			t.Errorf("checkFormatArgs(%q) returned %v, expected valid %t", test.args, err, test.valid)
+		}
+	}
+
+	// The configured devices are accepted along with the ones the reconciler resolves
+	h.formatDevices = []string{"/dev/sd[b-z]"}
+	if err := h.checkFormatArgs([]string{"/dev/sdb"}); err != nil {
+		t.Errorf("checkFormatArgs refused a configured device: %v", err)
+	}
+	if err := h.checkFormatArgs([]string{"/dev/sda"}); err == nil {
+		t.Errorf("checkFormatArgs accepted a device that isn't configured")
+	}
+}
+
+func TestNamespaceRoot(t *testing.T) {
+	if root, err := namespaceRoot("/proc/123/ns/mnt"); err != nil || root != "/proc/123/root" {
+		t.Errorf("namespaceRoot returned %s, %v for the namespace of a process", root, err)
+	}
+
+	// A namespace file that isn't under a process is found through a process in the namespace
+	if _, err := namespaceRoot("/proc/self/ns/mnt"); err != nil {
+		t.Errorf("namespaceRoot did not find a process in the namespace: %v", err)
+	}
+
+	for _, namespace := range []string{"/proc/self/ns/net", "/run/missing/ns/mnt", fmt.Sprintf("/proc/%d/ns/net", os.Getpid())} {
+		if _, err := namespaceRoot(namespace); err == nil {
+			t.Errorf("namespaceRoot(%s) found the root of a namespace", namespace)
+		}
+	}
+}
+
+func TestCheckNamespacePath(t *testing.T) {
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"/proc/100/ns/mnt", true},
+		{"/run/mntns/job-1", true},
+		{"/run/netns/job-1", true},
+		{"/proc/self/ns/mnt", false},
+		{"/proc/100/ns/net", false},
+		{"/proc/100/root", false},
+		{"/run/mntns/../../etc/shadow", false},
+		{"/run/mntns/a/b", false},
+		{"/run/lock/job-1", false},
+		{"/mnt/job-1/ns", false},
+		{"/etc/shadow", false},
+		{"run/mntns/job-1", false},
+	}
+
+	for _, test := range tests {
+		if err := checkNamespacePath(test.path); (err == nil) != test.valid {
+			t.Errorf("checkNamespacePath(%s) returned %v, expected valid %t", test.path, err, test.valid)
+		}
+	}
+}
+
+func TestCheckRemoveArgs(t *testing.T) {
+	h := testHelperChecks()
+
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"/mnt/job-1/swapfile"}, true},
+		{[]string{"-rf", "/mnt/job-1"}, true},
+		{[]string{"-rf", "/mnt"}, false},
+		{[]string{"-rf", "/var"}, false},
+		{[]string{"-rf", "/"}, false},
+		{[]string{"-rf", "/mnt/../var"}, false},
+		{[]string{"-r", "/mnt/job-1"}, false},
+		{[]string{"-rf", "/mnt/job-1", "/mnt/job-2"}, false},
+		{[]string{}, false},
+	}
+
+	for _, test := range tests {
+		if err := h.checkRemoveArgs(test.args); (err == nil) != test.valid {
+			t.Errorf("checkRemoveArgs(%q) returned %v, expected valid %t", test.args, err, test.valid)
+		}
+	}
+}
+
+func TestCheckOptions(t *testing.T) {
+	h := testHelperChecks()
+
+	tests := []struct {
+		options    string
+		valid      bool
+		privileged bool
+	}{
+		{"noatime", true, true},
+		{"nosuid,nodev,ro", true, true},
+		{"size=4g", true, true},
+		{"suid", false, true},
+		{"noatime,dev", false, true},
+		{"defaults", false, true},
+		{"helper=/tmp/evil", false, false},
+		{"-o", false, false},
+		{"ro rw", false, false},
+	}
+
+	for _, test := range tests {
+		if err := h.checkOptions(test.options); (err == nil) != test.valid {
+			t.Errorf("checkOptions(%s) returned %v, expected valid %t", test.options, err, test.valid)
+		}
+	}
+
+	// The privileged options are accepted when the helper allows them
+	h.allowPrivilegedOptions = true
+	for _, test := range tests {
+		if err := h.checkOptions(test.options); (err == nil) != test.privileged {
+			t.Errorf("checkOptions(%s) returned %v with privileged options allowed, expected valid %t", test.options, err, test.privileged)
+		}
+	}
+}
+
+func TestCheckMountArgs(t *testing.T) {
+	h := testHelperChecks()
+
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{}, true},
+		{[]string{"-t", "xfs", "/dev/vg0/lv0", "/mnt/job-1"}, true},
+		{[]string{"-t", "lustre", "10.1.1.1@tcp:/lus", "/mnt/job-1", "-o", "flock"}, true},
+		{[]string{"-t", "tmpfs", "tmpfs", "/mnt/job-1", "-o", "size=4g,nosuid,nodev"}, true},
+		{[]string{"-t", "none", "/lus/global/projects", "/mnt/job-1", "-o", "bind"}, true},
+		{[]string{"--make-private", "/mnt/job-1"}, true},
+		{[]string{"-t", "none", "/root", "/mnt/job-1", "-o", "bind"}, false},
+		{[]string{"-t", "xfs", "/dev/vg0/lv0", "/var"}, false},
+		{[]string{"-t", "xfs", "/mnt/image", "/mnt/job-1"}, false},
+		{[]string{"-t", "xfs", "/dev/vg0/lv0", "/mnt/job-1", "-o", "suid"}, false},
+		{[]string{"-t", "xfs", "/dev/vg0/lv0", "/mnt/job-1", "--bind", "flock"}, false},
+		{[]string{"-t", "btrfs", "/dev/sda", "/mnt/job-1"}, false},
+		{[]string{"/dev/vg0/lv0", "/mnt/job-1", "-t", "xfs"}, false},
+		{[]string{"-t", "lustre", "10.1.1.1@tcp", "/mnt/job-1"}, false},
+		{[]string{"--make-rshared", "/mnt/job-1"}, false},
+		{[]string{"--make-private", "/"}, false},
+		{[]string{"-t", "xfs", "/dev/vg0/lv0"}, false},
+	}
+
+	for _, test := range tests {
+		if err := h.checkMountArgs(test.args); (err == nil) != test.valid {
+			t.Errorf("checkMountArgs(%q) returned %v, expected valid %t", test.args, err, test.valid)
+		}
+	}
+}
+
+func TestHelperAllowlist(t *testing.T) {
+	allowlist := HelperAllowlist(HelperConfig{MountRoots: []string{"/mnt/"}, HookDir: "/etc/dws/hooks"})
+
+	tests := []struct {
+		command string
+		args    []string
+		valid   bool
+	}{
+		{"mkdir", []string{"-p", "/mnt/job-1"}, true},
+		{"rm", []string{"-rf", "/mnt/job-1"}, true},
+		{"umount", []string{"--lazy", "/mnt/job-1"}, true},
+		{"swapon", []string{"--options", "pri=5", "/mnt/job-1/swapfile"}, true},
+		{"swapon", []string{"/dev/vg0/swap"}, true},
+		{"mkfs.xfs", []string{"-f", "/dev/vg0/lv0"}, true},
+		{"wipefs", []string{"--no-act", "--noheadings", "--output", "TYPE", "/dev/mapper/mpatha"}, true},
+		{"env", []string{"DWS_MOUNT_PATH=/mnt/job-1", "/etc/dws/hooks/pre-mount/lustre"}, true},
+		{"nsenter", []string{"--mount=/proc/100/ns/mnt", "--", "mkdir", "-p", "/mnt/job-1"}, true},
+		{"rm", []string{"-rf", "/var"}, false},
+		{"rmdir", []string{"/mnt"}, false},
+		{"chmod", []string{"4755", "/root/.ssh"}, false},
+		{"chmod", []string{"4755", "/mnt/job-1"}, false},
+		{"mkfs.xfs", []string{"-f", "/dev/sda"}, false},
+		{"wipefs", []string{"--no-act", "--noheadings", "--output", "TYPE", "/dev/sda"}, false},
+		{"mkswap", []string{"/dev/sda2"}, false},
+		{"env", []string{"PATH=/tmp", "/etc/dws/hooks/pre-mount/lustre"}, false},
+		{"env", []string{"/tmp/hook"}, false},
+		{"nsenter", []string{"--mount=/proc/100/ns/mnt", "--", "rm", "-rf", "/home"}, false},
+		{"nsenter", []string{"--mount=/etc/shadow", "--", "mkdir", "-p", "/mnt/job-1"}, false},
+		{"nsenter", []string{"--mount=/mnt/job-1/ns", "--", "mkdir", "-p", "/mnt/job-1"}, false},
+		{"nsenter", []string{"--mount=/proc/100/ns/mnt", "--", "lvs", "--noheadings", "--separator", " "}, false},
+		{"sh", []string{"-c", "reboot"}, false},
+	}
+
+	for _, test := range tests {
+		if err := allowlist.Check(test.command, test.args); (err == nil) != test.valid {
+			t.Errorf("Check(%s %q) returned %v, expected valid %t", test.command, test.args, err, test.valid)
+		}
+	}
+
+	// The hooks aren't run without a hook directory
+	allowlist = HelperAllowlist(HelperConfig{MountRoots: []string{"/mnt"}})
+	if err := allowlist.Check("env", []string{"/etc/dws/hooks/pre-mount/lustre"}); err == nil {
+		t.Errorf("Hook was allowed without a hook directory")
+	}
+}
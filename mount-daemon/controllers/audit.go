@@ -0,0 +1,175 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/HewlettPackard/dws/utils/command"
+)
+
+// AuditEntry is a single record in the audit log describing a command run on the host
+type AuditEntry struct {
+	Time        time.Time `json:"time"`
+	ClientMount string    `json:"clientMount,omitempty"`
+	Command     string    `json:"command"`
+	Args        []string  `json:"args,omitempty"`
+	ExitCode    int       `json:"exitCode"`
+	DurationMs  int64     `json:"durationMs"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AuditLog is an append-only log of the commands run on the host. Each entry is written
+// as a single line of JSON. The log file is rotated when it grows past the maximum size.
+type AuditLog struct {
+	mu sync.Mutex
+
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewAuditLog opens the audit log at path for appending. The log is rotated once it
+// reaches maxSizeMB megabytes, keeping up to maxBackups rotated files named path.1,
+// path.2, etc. A maxSizeMB of zero disables rotation.
+func NewAuditLog(path string, maxSizeMB int, maxBackups int) (*AuditLog, error) {
+	l := &AuditLog{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: maxBackups,
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *AuditLog) open() error {
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open audit log '%s': %w", l.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not stat audit log '%s': %w", l.path, err)
+	}
+
+	l.file = file
+	l.size = info.Size()
+
+	return nil
+}
+
+// rotate closes the current log file, shifts the backups, and opens a new log file
+func (l *AuditLog) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	if l.maxBackups > 0 {
+		for i := l.maxBackups - 1; i > 0; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		}
+
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	} else {
+		if err := os.Remove(l.path); err != nil {
+			return err
+		}
+	}
+
+	return l.open()
+}
+
+// Record writes an entry to the audit log
+func (l *AuditLog) Record(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("could not rotate audit log '%s': %w", l.path, err)
+		}
+	}
+
+	n, err := l.file.Write(line)
+	l.size += int64(n)
+
+	return err
+}
+
+// Close closes the audit log file
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
+
+// newAuditEntry builds the audit entry for a command that finished running
+func newAuditEntry(ctx context.Context, start time.Time, name string, args []string, err error) AuditEntry {
+	entry := AuditEntry{
+		Time:        start,
+		ClientMount: auditClientMount(ctx),
+		Command:     name,
+		Args:        args,
+		DurationMs:  time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+		entry.ExitCode = command.ExitCode(err)
+	}
+
+	return entry
+}
+
+type auditContextKey struct{}
+
+// withAuditClientMount returns a context that records the name of the ClientMount
+// responsible for any commands run with it
+func withAuditClientMount(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, name)
+}
+
+func auditClientMount(ctx context.Context) string {
+	name, _ := ctx.Value(auditContextKey{}).(string)
+	return name
+}
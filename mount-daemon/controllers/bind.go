@@ -0,0 +1,67 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// getBindDevice returns the path that's bind mounted for a "none" device after checking
+// that the file system it's in is mounted. The file system is managed outside of DWS, so
+// one that isn't mounted yet is retried rather than mounted.
+func (r *ClientMountReconciler) getBindDevice(ctx context.Context, none *dwsv1alpha1.ClientMountDeviceNone) (string, error) {
+	if none == nil {
+		return "", dwsv1alpha1.NewResourceError("Missing none device information", nil).WithFatal()
+	}
+
+	if err := none.Validate(); err != nil {
+		return "", dwsv1alpha1.NewResourceError("", err).WithFatal()
+	}
+
+	if r.mock() {
+		return none.Device(), nil
+	}
+
+	output, err := r.run(ctx, "mount")
+	if err != nil {
+		return "", dwsv1alpha1.NewResourceError(output, err)
+	}
+
+	// Each line of the mount table is of the form "[device] on [path] type [type] ([options])"
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[2] != none.MountPoint {
+			continue
+		}
+
+		if none.FileSystemType != "" && fields[4] != string(none.FileSystemType) {
+			return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("File system at '%s' is mounted as type %s, not %s", none.MountPoint, fields[4], none.FileSystemType), nil).
+				WithUserMessage("Client has a different file system mounted").WithFatal()
+		}
+
+		return none.Device(), nil
+	}
+
+	return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("No file system is mounted at '%s'", none.MountPoint), nil).WithUserMessage("Client does not have the file system mounted")
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// getBlockDevice resolves a block device to its /dev path. A file system UUID or label is
+// looked up with blkid, and a WWN is resolved through its /dev/disk/by-id link.
+func (r *ClientMountReconciler) getBlockDevice(ctx context.Context, block *dwsv1alpha1.ClientMountDeviceBlock) (string, error) {
+	if block == nil {
+		return "", dwsv1alpha1.NewResourceError("Missing block device information", nil).WithFatal()
+	}
+
+	if err := block.Validate(); err != nil {
+		return "", dwsv1alpha1.NewResourceError("", err).WithFatal()
+	}
+
+	var id string
+	var args []string
+
+	switch {
+	case block.Path != "":
+		return block.Path, nil
+	case block.UUID != "":
+		id, args = "UUID "+block.UUID, []string{"blkid", "-U", block.UUID}
+	case block.Label != "":
+		id, args = "label "+block.Label, []string{"blkid", "-L", block.Label}
+	case block.WWN != "":
+		id, args = "WWN "+block.WWN, []string{"readlink", "-e", filepath.Join("/dev/disk/by-id", "wwn-"+block.WWN)}
+	}
+
+	if r.mock() {
+		return "/dev/disk/by-id/mock-" + strings.ReplaceAll(id, " ", "-"), nil
+	}
+
+	// A device that isn't found may not have been presented to the client yet, so the
+	// mount is retried
+	output, err := r.run(ctx, args[0], args[1:]...)
+	device := strings.TrimSpace(output)
+	if err != nil || device == "" {
+		return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("Could not find the block device with %s", id), err).WithUserMessage("Client could not find block device")
+	}
+
+	return device, nil
+}
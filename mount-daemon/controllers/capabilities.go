@@ -0,0 +1,116 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountnodes,verbs=get;create
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountnodes/status,verbs=get;update;patch
+
+// Capabilities are the parts of the ClientMount spec this mount-daemon supports. They're
+// reported in the node's ClientMountNode so the ClientMount webhook can reject mounts an
+// older mount-daemon would fail on. Add new device and file system types here when the
+// mount-daemon learns to mount them, and new features when it learns to handle them.
+var Capabilities = dwsv1alpha1.ClientMountCapabilities{
+	DeviceTypes: []dwsv1alpha1.ClientMountDeviceType{
+		dwsv1alpha1.ClientMountDeviceTypeLustre,
+		dwsv1alpha1.ClientMountDeviceTypeLVM,
+		dwsv1alpha1.ClientMountDeviceTypeTmpfs,
+		dwsv1alpha1.ClientMountDeviceTypeSwapFile,
+		dwsv1alpha1.ClientMountDeviceTypeMultipath,
+		dwsv1alpha1.ClientMountDeviceTypeNFS,
+		dwsv1alpha1.ClientMountDeviceTypeBlock,
+		dwsv1alpha1.ClientMountDeviceTypeCephFS,
+		dwsv1alpha1.ClientMountDeviceTypeRBD,
+		dwsv1alpha1.ClientMountDeviceTypeNone,
+	},
+	FileSystemTypes: []dwsv1alpha1.FileSystemType{
+		dwsv1alpha1.FileSystemTypeLustre,
+		dwsv1alpha1.FileSystemTypeXFS,
+		dwsv1alpha1.FileSystemTypeExt4,
+		dwsv1alpha1.FileSystemTypeGFS2,
+		dwsv1alpha1.FileSystemTypeSwap,
+		dwsv1alpha1.FileSystemTypeTmpfs,
+		dwsv1alpha1.FileSystemTypeNFS,
+		dwsv1alpha1.FileSystemTypeCeph,
+		dwsv1alpha1.FileSystemTypeNone,
+	},
+	Features: []dwsv1alpha1.ClientMountFeature{
+		dwsv1alpha1.ClientMountFeatureLustreSquash,
+	},
+}
+
+// capabilityReporter returns a runnable that reports the mount-daemon's version and
+// capabilities in the node's ClientMountNode when the daemon starts. The report is retried
+// until it succeeds.
+func (r *ClientMountReconciler) capabilityReporter(node string) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		for {
+			err := r.reportCapabilities(ctx, node)
+			if err == nil {
+				return nil
+			}
+
+			r.Log.Error(err, "Could not report mount-daemon capabilities", "node", node)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(r.Settings.Get().RetryDelay):
+			}
+		}
+	}
+}
+
+// reportCapabilities creates the node's ClientMountNode if it doesn't exist and updates its
+// status with the mount-daemon's version and capabilities
+func (r *ClientMountReconciler) reportCapabilities(ctx context.Context, node string) error {
+	clientMountNode := &dwsv1alpha1.ClientMountNode{}
+	key := client.ObjectKey{Name: node, Namespace: node}
+	if err := r.APIReader.Get(ctx, key, clientMountNode); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		clientMountNode = &dwsv1alpha1.ClientMountNode{ObjectMeta: metav1.ObjectMeta{Name: node, Namespace: node}}
+		if err := r.Create(ctx, clientMountNode); err != nil {
+			return err
+		}
+	}
+
+	now := metav1.Now()
+	clientMountNode.Status = dwsv1alpha1.ClientMountNodeStatus{
+		DaemonVersion: r.DaemonVersion,
+		Capabilities:  *Capabilities.DeepCopy(),
+		LastReported:  &now,
+	}
+
+	return r.Status().Update(ctx, clientMountNode)
+}
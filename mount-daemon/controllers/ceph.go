@@ -0,0 +1,145 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// rbdMapping is an entry in the output of "rbd device list --format json"
+type rbdMapping struct {
+	Pool   string `json:"pool"`
+	Image  string `json:"name"`
+	Device string `json:"device"`
+}
+
+// getCephFSOptions returns the CephFS specific mount options
+func getCephFSOptions(cephfs *dwsv1alpha1.ClientMountDeviceCephFS) []string {
+	if cephfs == nil {
+		return nil
+	}
+
+	options := []string{}
+	if cephfs.User != "" {
+		options = append(options, "name="+cephfs.User)
+	}
+
+	if cephfs.FileSystem != "" {
+		options = append(options, "fs="+cephfs.FileSystem)
+	}
+
+	return options
+}
+
+// mapRBDImage maps the RBD image to a block device if it isn't already mapped and returns
+// the path of the device
+func (r *ClientMountReconciler) mapRBDImage(ctx context.Context, rbd *dwsv1alpha1.ClientMountDeviceRBD) (string, error) {
+	if rbd == nil {
+		return "", dwsv1alpha1.NewResourceError("Missing RBD device information", nil).WithFatal()
+	}
+
+	if r.mock() {
+		return filepath.Join("/dev/rbd", rbd.Pool, rbd.Image), nil
+	}
+
+	mapping, err := r.findRBDMapping(ctx, rbd)
+	if err != nil {
+		return "", err
+	}
+
+	if mapping != nil {
+		return mapping.Device, nil
+	}
+
+	args := []string{"device", "map", rbd.ImageSpec()}
+	if rbd.User != "" {
+		args = append(args, "--id", rbd.User)
+	}
+
+	// The image may not be created yet or the monitors may be unreachable, so the mount
+	// is retried
+	output, err := r.run(ctx, "rbd", args...)
+	if err != nil {
+		return "", dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not map RBD image")
+	}
+
+	// The image was only recorded in dry-run mode, so there's no device yet. The udev link
+	// stands in for it in the plan.
+	if dryRun(ctx) != nil {
+		return filepath.Join("/dev/rbd", rbd.Pool, rbd.Image), nil
+	}
+
+	return strings.TrimSpace(output), nil
+}
+
+// unmapRBDImage unmaps the RBD image after unmounting if it's mapped
+func (r *ClientMountReconciler) unmapRBDImage(ctx context.Context, rbd *dwsv1alpha1.ClientMountDeviceRBD) error {
+	if rbd == nil || r.mock() {
+		return nil
+	}
+
+	mapping, err := r.findRBDMapping(ctx, rbd)
+	if err != nil {
+		return err
+	}
+
+	if mapping == nil {
+		return nil
+	}
+
+	output, err := r.run(ctx, "rbd", "device", "unmap", mapping.Device)
+	if err != nil {
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not unmap RBD image")
+	}
+
+	return nil
+}
+
+// findRBDMapping returns the mapping of the RBD image, or nil if the image isn't mapped
+func (r *ClientMountReconciler) findRBDMapping(ctx context.Context, rbd *dwsv1alpha1.ClientMountDeviceRBD) (*rbdMapping, error) {
+	output, err := r.run(ctx, "rbd", "device", "list", "--format", "json")
+	if err != nil {
+		return nil, dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not list RBD devices")
+	}
+
+	return parseRBDMappings(output, rbd), nil
+}
+
+// parseRBDMappings returns the mapping of the RBD image from the JSON output of
+// "rbd device list", or nil if it isn't listed
+func parseRBDMappings(output string, rbd *dwsv1alpha1.ClientMountDeviceRBD) *rbdMapping {
+	mappings := []rbdMapping{}
+	if err := json.Unmarshal([]byte(output), &mappings); err != nil {
+		return nil
+	}
+
+	for i := range mappings {
+		if mappings[i].Pool == rbd.Pool && mappings[i].Image == rbd.Image {
+			return &mappings[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// CheckpointEntry is the last state a ClientMount reached on the node
+type CheckpointEntry struct {
+	// UID is the UID of the ClientMount, so a ClientMount that's recreated with the same
+	// name doesn't match
+	UID types.UID `json:"uid"`
+
+	// Generation is the generation of the ClientMount spec that was applied
+	Generation int64 `json:"generation"`
+
+	// State is the desired state all the mounts reached
+	State dwsv1alpha1.ClientMountState `json:"state"`
+
+	// Mounts is the number of mounts in the ClientMount
+	Mounts int `json:"mounts"`
+
+	// Time is when the state was reached
+	Time time.Time `json:"time"`
+}
+
+// Checkpoint records the ClientMounts whose mounts all reached their desired state in a
+// local file. After a restart, a ClientMount whose status doesn't show the state it reached
+// (e.g., the status update was lost when the daemon stopped) is made ready from the
+// checkpoint once its mounts are checked, rather than going through the mounts again. A nil
+// Checkpoint does nothing.
+type Checkpoint struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]CheckpointEntry
+}
+
+// NewCheckpoint returns a Checkpoint kept in the file at path, loaded with the entries
+// already in the file. A Checkpoint without entries is returned with the error if the file
+// can't be read.
+func NewCheckpoint(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, entries: map[string]CheckpointEntry{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+
+		return c, err
+	}
+
+	entries := map[string]CheckpointEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return c, err
+	}
+
+	c.entries = entries
+	return c, nil
+}
+
+// Matches returns whether the checkpoint shows that the mounts of the ClientMount reached
+// the desired state of its current spec
+func (c *Checkpoint) Matches(key string, clientMount *dwsv1alpha1.ClientMount) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	return ok &&
+		entry.UID == clientMount.UID &&
+		entry.Generation == clientMount.Generation &&
+		entry.State == clientMount.Spec.DesiredState &&
+		entry.Mounts == len(clientMount.Spec.Mounts)
+}
+
+// Record updates the checkpoint with a ClientMount after a reconcile. Only a ClientMount
+// with all its mounts ready is kept. The file is only written if the entry changed.
+func (c *Checkpoint) Record(key string, clientMount *dwsv1alpha1.ClientMount) error {
+	if c == nil {
+		return nil
+	}
+
+	ready := !clientMount.Spec.DryRun && clientMount.Status.Error == nil && len(clientMount.Status.Mounts) == len(clientMount.Spec.Mounts)
+	for _, mount := range clientMount.Status.Mounts {
+		ready = ready && mount.Ready && mount.State == clientMount.Spec.DesiredState
+	}
+
+	if !ready {
+		return c.Remove(key)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if ok && entry.UID == clientMount.UID && entry.Generation == clientMount.Generation && entry.State == clientMount.Spec.DesiredState {
+		return nil
+	}
+
+	c.entries[key] = CheckpointEntry{
+		UID:        clientMount.UID,
+		Generation: clientMount.Generation,
+		State:      clientMount.Spec.DesiredState,
+		Mounts:     len(clientMount.Spec.Mounts),
+		Time:       time.Now().UTC(),
+	}
+
+	return c.write()
+}
+
+// Remove drops a ClientMount from the checkpoint
+func (c *Checkpoint) Remove(key string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		return nil
+	}
+
+	delete(c.entries, key)
+	return c.write()
+}
+
+// write replaces the checkpoint file. The lock must be held.
+func (c *Checkpoint) write() error {
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(c.path, append(data, '\n'))
+}
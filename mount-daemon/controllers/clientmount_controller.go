@@ -23,135 +23,406 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	toolsrecord "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/controllers/metrics"
+	"github.com/HewlettPackard/dws/utils/clientmount"
+	"github.com/HewlettPackard/dws/utils/command"
 	"github.com/HewlettPackard/dws/utils/updater"
 )
 
 // ClientMountReconciler reconciles a ClientMount object
 type ClientMountReconciler struct {
 	client.Client
-	Mock   bool
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Settings *RuntimeSettings
+	Runner   command.Runner
+	Audit    *AuditLog
+	InFlight *InFlightOperations
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+
+	// RedactDevices hides device paths from the log for sites with strict log policies
+	RedactDevices bool
+
+	// LNetPrecheck pings the Lustre MGS NIDs with lnetctl before mounting
+	LNetPrecheck bool
+
+	// GFS2Precheck checks the corosync quorum and the DLM fencing state before mounting gfs2
+	GFS2Precheck bool
+
+	// ShutdownGracePeriod is how long a running host command may continue after the
+	// daemon is asked to shut down before it's killed
+	ShutdownGracePeriod time.Duration
+
+	// StartupDelay is how long after the daemon starts that the ClientMounts are first
+	// reconciled. It's usually a NodeJitter, so after a restart of the whole system the
+	// nodes don't all go to the lock manager and the Lustre MGS at once.
+	StartupDelay time.Duration
+
+	// reconcileAfter is the time the startup delay ends
+	reconcileAfter time.Time
+
+	// APIReader reads resources that aren't cached, such as the node's namespace
+	// which may be cordoned. Cordoning is ignored if nil.
+	APIReader client.Reader
+
+	// OrphanMountRoot is the directory under which the ClientMounts mount their file
+	// systems. Anything mounted under it that no ClientMount describes is unmounted when
+	// the daemon starts. The scan is disabled if empty.
+	OrphanMountRoot string
+
+	// AutofsMapDir is the autofs master map directory (e.g., /etc/auto.master.d) that the
+	// automount entries for NFS mounts are written to. Automount is refused if empty.
+	AutofsMapDir string
+
+	// MockLVM is the fake LVM the LVM commands run against in mock mode. The LVM devices
+	// are assumed to be ready in mock mode if it's nil.
+	MockLVM *MockLVM
+
+	// HookDir is the directory of the site hooks run before mounting and after unmounting.
+	// Hooks aren't run if empty.
+	HookDir string
+
+	// SecretDir is the directory of the credential files written from the Secrets of the
+	// mounts. It should be on a tmpfs so the files don't outlive a reboot.
+	SecretDir string
+
+	// PrivilegedHelper is set when the Runner sends the commands to the privileged helper
+	// and the daemon runs without privileges. The mount targets are then created and
+	// removed with commands too. The credential files and the autofs maps are still
+	// written by the daemon, so their directories must be writable by it.
+	PrivilegedHelper bool
+
+	// Prober checks that the mounted file systems respond and sets the Degraded condition
+	// of the mounts. Mounts aren't probed if nil.
+	Prober *MountProber
+
+	// UsageInterval is the interval between the reports of the space and inodes used on the
+	// mounted file systems. The usage is read by the probe, so it isn't reported without a
+	// Prober. Not reported if 0.
+	UsageInterval time.Duration
+
+	// LVMReleaseTimeout is how long after a ClientMount is deleted the node may take to
+	// release its LVM devices before the ClientMount gets a fatal error. The finalizer is
+	// held until they're released. The release isn't checked if 0.
+	LVMReleaseTimeout time.Duration
+
+	// LVM serializes the LVM commands and pauses them after repeated failures. LVM
+	// commands aren't limited if nil.
+	LVM *LVMGuard
+
+	// NodeStatus writes a summary of the ClientMounts to a file for node health checks. No
+	// file is written if nil.
+	NodeStatus *NodeStatus
+
+	// Checkpoint records the ClientMounts that reached their desired state in a local file,
+	// so their status can be restored after a restart without going through the mounts
+	// again. Not recorded if nil.
+	Checkpoint *Checkpoint
+
+	// NodeName is the name of the node in the compute access lists of the Storage resources
+	NodeName string
+
+	// NodeInfoInterval is the interval between reports of the node's OS information to the
+	// Storage resources that list the node as a compute. Not reported if 0.
+	NodeInfoInterval time.Duration
+
+	// ListPageSize is the number of resources read at a time by the lists that bypass the
+	// cache. Not paged if 0.
+	ListPageSize int64
+
+	// Recorder emits the warning events for ClientMounts released without unmounting. No
+	// events are emitted if nil, as in standalone mode.
+	Recorder toolsrecord.EventRecorder
+
+	// DaemonVersion is the version of the mount-daemon reported with its capabilities in the
+	// node's ClientMountNode. The capabilities aren't reported if empty.
+	DaemonVersion string
 }
 
 const (
-	// finalizerClientMount defines the key used for the finalizer
-	finalizerClientMount = "dws.cray.hpe.com/client_mount"
+	// fieldManagerClientMount is the field manager that owns the status fields applied by
+	// the mount-daemon. It's different from the cluster-side controller's field manager so
+	// the two don't conflict.
+	fieldManagerClientMount = "dws-mount-daemon"
+
+	// redacted replaces a device path in the log when device redaction is enabled
+	redacted = "<redacted>"
+
+	// maxStatusErrorLength is the number of bytes of the error message kept in the status.
+	// The message may hold the output of a mount helper, which could otherwise push the
+	// resource past the etcd object size limit.
+	maxStatusErrorLength = 4096
 )
 
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmounts/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
 func (r *ClientMountReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	// Wait for the node's turn after startup
+	if wait := time.Until(r.reconcileAfter); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
 	log := r.Log.WithValues("ClientMount", req.NamespacedName)
+	ctx = withAuditClientMount(ctx, req.NamespacedName.String())
+	ctx = withLVSCache(ctx)
+
 	clientMount := &dwsv1alpha1.ClientMount{}
 	if err := r.Get(ctx, req.NamespacedName, clientMount); err != nil {
+		if apierrors.IsNotFound(err) {
+			metrics.DwsMountsReady.DeleteLabelValues(req.NamespacedName.String())
+
+			if err := r.NodeStatus.Remove(req.NamespacedName.String()); err != nil {
+				log.Error(err, "Could not write node status file")
+			}
+
+			if err := r.Checkpoint.Remove(req.NamespacedName.String()); err != nil {
+				log.Error(err, "Could not write checkpoint file")
+			}
+		}
+
 		// ignore not-found errors, since they can't be fixed by an immediate
 		// requeue (we'll need to wait for a new notification), and we can get them
 		// on deleted requests.
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// Create a status updater that handles the call to r.Status().Update() if any of the fields
-	// in clientMount.Status{} change
-	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.ClientMountStatus](clientMount)
-	defer func() { err = statusUpdater.CloseWithStatusUpdate(ctx, r, err) }()
+	// Record the final status, after the deferred status updates below
+	defer func() {
+		metrics.DwsMountsReady.WithLabelValues(req.NamespacedName.String()).Set(float64(clientMount.Status.ReadyCount))
 
-	// Handle cleanup if the resource is being deleted
-	if !clientMount.GetDeletionTimestamp().IsZero() {
-		if !controllerutil.ContainsFinalizer(clientMount, finalizerClientMount) {
-			return ctrl.Result{}, nil
+		if err := r.NodeStatus.Record(req.NamespacedName.String(), clientMount); err != nil {
+			log.Error(err, "Could not write node status file")
 		}
 
-		// Unmount everything before removing the finalizer
-		log.Info("Unmounting all file systems due to resource deletion")
-		if err := r.unmountAll(ctx, clientMount); err != nil {
-			return ctrl.Result{}, err
+		if err := r.Checkpoint.Record(req.NamespacedName.String(), clientMount); err != nil {
+			log.Error(err, "Could not write checkpoint file")
 		}
+	}()
 
-		controllerutil.RemoveFinalizer(clientMount, finalizerClientMount)
-		if err := r.Update(ctx, clientMount); err != nil {
-			return ctrl.Result{}, err
-		}
+	// Create a status updater that applies clientMount.Status{} with server-side apply if any
+	// of the fields change
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.ClientMountStatus](clientMount)
+	defer func() {
+		statusCtx, cancel := statusContext(ctx)
+		defer cancel()
 
-		return ctrl.Result{}, nil
-	}
+		err = statusUpdater.CloseWithStatusApply(statusCtx, r.Client, fieldManagerClientMount, err)
+	}()
 
-	// Create the status section if it doesn't exist yet
-	if len(clientMount.Status.Mounts) != len(clientMount.Spec.Mounts) {
-		clientMount.Status.Mounts = make([]dwsv1alpha1.ClientMountInfoStatus, len(clientMount.Spec.Mounts))
-	}
+	core := &clientmount.Core{Client: r.Client, Log: log, Actuator: r, Recorder: r.Recorder}
+	return core.Reconcile(ctx, clientMount)
+}
 
-	// Initialize the status section if the desired state doesn't match the status state
-	if clientMount.Status.Mounts[0].State != clientMount.Spec.DesiredState {
-		for i := 0; i < len(clientMount.Status.Mounts); i++ {
-			clientMount.Status.Mounts[i].State = clientMount.Spec.DesiredState
-			clientMount.Status.Mounts[i].Ready = false
-		}
+// Release unmounts the file systems of a deleted ClientMount and waits for the node to let
+// go of its LVM devices before the finalizer is removed
+func (r *ClientMountReconciler) Release(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	log := r.Log.WithValues("ClientMount", client.ObjectKeyFromObject(clientMount))
 
-		return ctrl.Result{}, nil
+	// Unmount everything before removing the finalizer
+	log.Info("Unmounting all file systems due to resource deletion")
+	if err := r.unmountAll(ctx, clientMount); err != nil {
+		return ctrl.Result{}, err
 	}
 
-	// Add finalizer if it doesn't exist
-	if !controllerutil.ContainsFinalizer(clientMount, finalizerClientMount) {
-		controllerutil.AddFinalizer(clientMount, finalizerClientMount)
-		if err := r.Update(ctx, clientMount); err != nil {
-			return ctrl.Result{Requeue: true}, nil
+	// The storage driver may tear down the volume groups once the finalizer is removed,
+	// so the node must let go of them first
+	if r.LVMReleaseTimeout != 0 {
+		if err := r.releaseLVM(ctx, clientMount); err != nil {
+			resourceError := dwsv1alpha1.NewResourceError("LVM devices were not released", err).WithUserMessage("client could not release storage")
+			if time.Since(clientMount.GetDeletionTimestamp().Time) > r.LVMReleaseTimeout {
+				resourceError.WithFatal()
+			}
+
+			clientMount.Status.Error = reportError(log, resourceError, clientMount.Status.Error)
+			return ctrl.Result{RequeueAfter: r.Settings.Get().RetryDelay}, nil
 		}
+	}
+
+	return ctrl.Result{}, nil
+}
 
-		return ctrl.Result{}, nil
+// Actuate mounts or unmounts the file systems of the ClientMount to reach its desired state
+func (r *ClientMountReconciler) Actuate(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	key := client.ObjectKeyFromObject(clientMount)
+	log := r.Log.WithValues("ClientMount", key)
+
+	// The mounts reached the desired state before the daemon restarted, but the status
+	// doesn't show it
+	if !clientMount.Spec.DryRun && clientMount.Status.ReadyCount != len(clientMount.Spec.Mounts) && r.Checkpoint.Matches(key.String(), clientMount) {
+		if r.restoreFromCheckpoint(ctx, clientMount) {
+			log.Info("Restored the status from the checkpoint", "state", clientMount.Spec.DesiredState)
+			return r.usageResult(clientMount), nil
+		}
 	}
 
+	previousError := clientMount.Status.Error
 	clientMount.Status.Error = nil
+	clientMount.Status.DryRunCommands = nil
+
+	// In dry-run mode the commands that change the node are reported in the status rather
+	// than run. Nothing is mounted or unmounted, so none of the mounts are ready.
+	mountCtx := ctx
+	if clientMount.Spec.DryRun {
+		plan := &dryRunPlan{}
+		mountCtx = withDryRun(ctx, plan)
+
+		defer func() {
+			clientMount.Status.DryRunCommands = plan.commands
+			for i := range clientMount.Status.Mounts {
+				clientMount.Status.Mounts[i].Ready = false
+			}
+			clientMount.Status.UpdateReadyCount()
+		}()
+	}
 
 	if clientMount.Spec.DesiredState == dwsv1alpha1.ClientMountStateMounted {
-		err := r.mountAll(ctx, clientMount)
+		err := r.mountAll(mountCtx, clientMount)
 		if err != nil {
-			resourceError := dwsv1alpha1.NewResourceError("Mount failed", err)
-			log.Info(resourceError.Error())
-
-			clientMount.Status.Error = resourceError
-			return ctrl.Result{RequeueAfter: time.Second * time.Duration(10)}, nil
+			clientMount.Status.Error = reportError(log, dwsv1alpha1.NewResourceError("Mount failed", err), previousError)
+			return ctrl.Result{RequeueAfter: r.retryDelay(ctx, clientMount)}, nil
 		}
+
+		return r.usageResult(clientMount), nil
 	} else if clientMount.Spec.DesiredState == dwsv1alpha1.ClientMountStateUnmounted {
-		err := r.unmountAll(ctx, clientMount)
+		err := r.unmountAll(mountCtx, clientMount)
 		if err != nil {
-			resourceError := dwsv1alpha1.NewResourceError("Unmount failed", err)
-			log.Info(resourceError.Error())
-
-			clientMount.Status.Error = resourceError
-			return ctrl.Result{RequeueAfter: time.Second * time.Duration(10)}, nil
+			clientMount.Status.Error = reportError(log, dwsv1alpha1.NewResourceError("Unmount failed", err), previousError)
+			return ctrl.Result{RequeueAfter: r.retryDelay(ctx, clientMount)}, nil
 		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// usageResult returns the result that requeues a mounted ClientMount so its mounts are
+// probed again to refresh their usage
+func (r *ClientMountReconciler) usageResult(clientMount *dwsv1alpha1.ClientMount) ctrl.Result {
+	if r.UsageInterval == 0 || r.Prober == nil || clientMount.Spec.DryRun || clientMount.Spec.DesiredState != dwsv1alpha1.ClientMountStateMounted {
+		return ctrl.Result{}
+	}
+
+	return ctrl.Result{RequeueAfter: r.UsageInterval}
+}
+
+// usageStale returns whether the reported usage of a mount should be replaced by the usage
+// just read. The status update of a report starts another reconcile, so a report younger
+// than half the interval is kept to keep the reports from following each other.
+func (r *ClientMountReconciler) usageStale(reported *dwsv1alpha1.ClientMountUsage, usage *dwsv1alpha1.ClientMountUsage) bool {
+	if reported == nil || usage == nil {
+		return true
+	}
+
+	return time.Since(reported.LastReported.Time) >= r.UsageInterval/2
+}
+
+// restoreFromCheckpoint marks all the mounts ready if each one is still in the desired
+// state. It returns false, leaving the status alone, if any of them isn't, so the mounts are
+// gone through as usual.
+func (r *ClientMountReconciler) restoreFromCheckpoint(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) bool {
+	for _, mount := range clientMount.Spec.Mounts {
+		// An automount isn't mounted until it's accessed
+		if isAutomount(mount) {
+			return false
+		}
+
+		active, err := r.isActive(ctx, mount)
+		if err != nil || active != (clientMount.Spec.DesiredState == dwsv1alpha1.ClientMountStateMounted) {
+			return false
+		}
+	}
+
+	for i := range clientMount.Status.Mounts {
+		clientMount.Status.Mounts[i].Ready = true
+	}
+	clientMount.Status.Error = nil
+	clientMount.Status.UpdateReadyCount()
+
+	return true
+}
+
+// reportError logs a mount or unmount failure and returns it for the status. The first
+// occurrence of an error is logged in full. An error that repeats the previous error is
+// logged as a summary so a persistent failure retried every few seconds doesn't flood the
+// log.
+func reportError(log logr.Logger, resourceError *dwsv1alpha1.ResourceErrorInfo, previous *dwsv1alpha1.ResourceErrorInfo) *dwsv1alpha1.ResourceErrorInfo {
+	fullMessage := resourceError.Error()
+
+	resourceError.DebugMessage = command.Truncate(resourceError.DebugMessage, maxStatusErrorLength)
+	if resourceError.Repeat(previous, metav1.Now()) {
+		log.Info("Error repeated", "error", resourceError.DebugMessage, "count", resourceError.Count, "firstSeen", resourceError.FirstSeen.Time)
+	} else {
+		log.Info(fullMessage)
+	}
+
+	return resourceError
+}
+
 // unmountAll unmounts all the file systems listed in the spec.Mounts list
 func (r *ClientMountReconciler) unmountAll(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) error {
 	log := r.Log.WithValues("ClientMount", types.NamespacedName{Name: clientMount.Name, Namespace: clientMount.Namespace})
 
+	if clientMount.Spec.Hurry {
+		ctx = withHurry(ctx)
+	}
+
+	// Unmount in the reverse of the mount order. The mounts are still unmounted in the
+	// reverse of the listed order if the dependencies are invalid.
+	order, err := clientMount.Spec.MountOrder()
+	if err != nil {
+		order = make([]int, len(clientMount.Spec.Mounts))
+		for i := range order {
+			order[i] = i
+		}
+	}
+
 	var firstError error = nil
-	for i, mount := range clientMount.Spec.Mounts {
-		err := r.unmount(ctx, mount, log)
+	for n := len(order) - 1; n >= 0; n-- {
+		i := order[n]
+		mount := clientMount.Spec.Mounts[i]
+
+		// Leave the status of the mounts that weren't attempted alone
+		if err := checkShutdown(ctx); err != nil {
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
+
+		// A file system can't be unmounted while a mount that depends on it is still there
+		if dependent := unreadyDependent(clientMount, i); dependent != "" {
+			if firstError == nil {
+				firstError = fmt.Errorf("mount '%s' is waiting for '%s' to be unmounted", mount.MountPath, dependent)
+			}
+			clientMount.Status.Mounts[i].Ready = false
+			continue
+		}
+
+		unmountCtx, mount, err := r.withProfile(ctx, mount, false, log)
+		if err == nil {
+			err = r.unmount(unmountCtx, mount, log)
+		}
 		if err != nil {
 			if firstError == nil {
 				firstError = err
@@ -159,41 +430,85 @@ func (r *ClientMountReconciler) unmountAll(ctx context.Context, clientMount *dws
 			clientMount.Status.Mounts[i].Ready = false
 		} else {
 			clientMount.Status.Mounts[i].Ready = true
+			clientMount.Status.Mounts[i].Usage = nil
+			meta.RemoveStatusCondition(&clientMount.Status.Mounts[i].Conditions, dwsv1alpha1.ConditionDegraded)
 		}
 	}
+	clientMount.Status.UpdateReadyCount()
 
 	return firstError
 }
 
 // unmount unmounts a single mount point described in the ClientMountInfo object
 func (r *ClientMountReconciler) unmount(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, log logr.Logger) error {
-	state, err := r.checkMount(clientMountInfo.MountPath)
+	if clientMountInfo.Type.IsSwap() {
+		return r.deactivateSwap(ctx, clientMountInfo, log)
+	}
+
+	if isAutomount(clientMountInfo) {
+		if err := r.removeAutomount(ctx, clientMountInfo); err != nil {
+			return err
+		}
+	}
+
+	ctx = withMountNamespace(ctx, clientMountInfo.MountNamespace)
+
+	state, err := r.checkMount(ctx, clientMountInfo.MountPath)
 	if err != nil {
 		return err
 	}
 
 	if state == dwsv1alpha1.ClientMountStateMounted {
+		// A lazy unmount detaches the mount even if it's busy, leaving the file system to
+		// be cleaned up when its last user goes away
+		args := []string{clientMountInfo.MountPath}
+		if hurry(ctx) {
+			args = append([]string{"--lazy"}, args...)
+		}
 
-		output, err := r.run("umount " + clientMountInfo.MountPath)
+		output, err := r.run(ctx, "umount", args...)
 		if err != nil {
-			log.Info("Could not unmount file system", "mount path", clientMountInfo.MountPath, "Error output", output)
+			log.Info("Could not unmount file system", "mountPath", clientMountInfo.MountPath, "output", output)
 			return err
 		}
 	}
 
+	if err := r.removeSecretFiles(ctx, clientMountInfo); err != nil {
+		log.Error(err, "Could not remove credential files", "mountPath", clientMountInfo.MountPath)
+		return err
+	}
+
 	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeLVM {
-		if err := r.configureLVMDevice(clientMountInfo.Device.LVM, false, clientMountInfo.Type == "gfs2"); err != nil {
-			log.Error(err, "Could not deactivate LVM volume", "mount path", clientMountInfo.MountPath)
+		if err := r.configureLVMDevice(ctx, clientMountInfo.Device.LVM, false, clientMountInfo.Device.LVM.ActivationModeFor(clientMountInfo.Type)); err != nil {
+			log.Error(err, "Could not deactivate LVM volume", "mountPath", clientMountInfo.MountPath)
+			return err
+		}
+	}
+
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeMultipath {
+		if err := r.removeMultipathMap(ctx, clientMountInfo.Device.Multipath); err != nil {
+			log.Error(err, "Could not remove multipath map", "mountPath", clientMountInfo.MountPath)
+			return err
+		}
+	}
+
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeRBD {
+		if err := r.unmapRBDImage(ctx, clientMountInfo.Device.RBD); err != nil {
+			log.Error(err, "Could not unmap RBD image", "mountPath", clientMountInfo.MountPath)
 			return err
 		}
 	}
 
-	// Remove the mount directory. It's not a big deal if this fails, so we just log a failure and don't return it
-	if err := r.rmdir(clientMountInfo.MountPath); err != nil {
-		log.Error(err, "Unable to remove mount directory", "Path", clientMountInfo.MountPath)
+	// Remove the mount target. It's not a big deal if this fails, so we just log a failure and don't return it
+	if err := r.cleanupTarget(ctx, clientMountInfo); err != nil {
+		log.Error(err, "Unable to remove mount target", "mountPath", clientMountInfo.MountPath)
 	}
 
-	log.Info("Unmounted file system", "mount path", clientMountInfo.MountPath)
+	if err := r.runHooks(ctx, hookPostUnmount, clientMountInfo); err != nil {
+		return err
+	}
+
+	log.Info("Unmounted file system", "mountPath", clientMountInfo.MountPath)
 	return nil
 }
 
@@ -201,9 +516,71 @@ func (r *ClientMountReconciler) unmount(ctx context.Context, clientMountInfo dws
 func (r *ClientMountReconciler) mountAll(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) error {
 	log := r.Log.WithValues("ClientMount", types.NamespacedName{Name: clientMount.Name, Namespace: clientMount.Namespace})
 
+	// Refuse the mounts rather than letting them shadow another ClientMount's mounts
+	if err := r.checkMountConflicts(ctx, clientMount); err != nil {
+		for i := range clientMount.Status.Mounts {
+			clientMount.Status.Mounts[i].Ready = false
+		}
+		clientMount.Status.UpdateReadyCount()
+
+		return err
+	}
+
+	// A cordoned node keeps the mounts it has but refuses new ones. The mounts are retried
+	// until the node is uncordoned.
+	if err := r.checkCordon(ctx, clientMount.Namespace); err != nil {
+		for i, mount := range clientMount.Spec.Mounts {
+			active, activeErr := r.isActive(ctx, mount)
+			clientMount.Status.Mounts[i].Ready = active && activeErr == nil
+		}
+		clientMount.Status.UpdateReadyCount()
+
+		log.Info("Refusing new mounts", "reason", err.Error())
+		return err
+	}
+
+	order, err := clientMount.Spec.MountOrder()
+	if err != nil {
+		for i := range clientMount.Status.Mounts {
+			clientMount.Status.Mounts[i].Ready = false
+		}
+		clientMount.Status.UpdateReadyCount()
+
+		return dwsv1alpha1.NewResourceError("Invalid mount dependencies", err).WithUserMessage("invalid mount dependencies").WithFatal()
+	}
+
 	var firstError error = nil
-	for i, mount := range clientMount.Spec.Mounts {
-		err := r.mount(ctx, mount, log)
+	for n, i := range order {
+		mount := clientMount.Spec.Mounts[i]
+
+		if clientMount.Status.Mounts[i].Plan == nil {
+			clientMount.Status.Mounts[i].Plan = &dwsv1alpha1.ClientMountPlan{}
+		}
+		clientMount.Status.Mounts[i].Plan.Order = n
+
+		// Leave the status of the mounts that weren't attempted alone
+		if err := checkShutdown(ctx); err != nil {
+			if firstError == nil {
+				firstError = err
+			}
+			continue
+		}
+
+		if dependency := unreadyDependency(clientMount, i); dependency != "" {
+			if firstError == nil {
+				firstError = fmt.Errorf("mount '%s' is waiting for '%s' to be mounted", mount.MountPath, dependency)
+			}
+			clientMount.Status.Mounts[i].Ready = false
+			continue
+		}
+
+		mountCtx, mount, err := r.withProfile(ctx, mount, true, log)
+		if err == nil {
+			mountCtx, err = r.withSecret(mountCtx, clientMount.Namespace, mount)
+		}
+		if err == nil {
+			err = r.mount(withPlan(mountCtx, clientMount.Status.Mounts[i].Plan), mount, log)
+		}
 		if err != nil {
 			if firstError == nil {
 				firstError = err
@@ -211,17 +588,70 @@ func (r *ClientMountReconciler) mountAll(ctx context.Context, clientMount *dwsv1
 			clientMount.Status.Mounts[i].Ready = false
 		} else {
 			clientMount.Status.Mounts[i].Ready = true
+
+			// A stale mount is still mounted, so it's reported rather than failed
+			if r.Prober != nil {
+				usage, probeErr := r.probeMount(ctx, mount)
+				if probeErr != nil {
+					log.Info("Mounted file system is not responding", "mountPath", mount.MountPath, "error", probeErr.Error())
+				}
+				dwsv1alpha1.SetDegradedCondition(&clientMount.Status.Mounts[i].Conditions, clientMount.Generation, probeErr)
+
+				if r.UsageInterval != 0 && r.usageStale(clientMount.Status.Mounts[i].Usage, usage) {
+					clientMount.Status.Mounts[i].Usage = usage
+				}
+			}
 		}
 	}
+	clientMount.Status.UpdateReadyCount()
 
 	return firstError
 }
 
+// unreadyDependency returns the mount path of a dependency of mount i that isn't ready, or
+// an empty string if all the dependencies are ready
+func unreadyDependency(clientMount *dwsv1alpha1.ClientMount, i int) string {
+	for _, dependency := range clientMount.Spec.Mounts[i].DependsOn {
+		for j, mount := range clientMount.Spec.Mounts {
+			if mount.MountPath == dependency && !clientMount.Status.Mounts[j].Ready {
+				return dependency
+			}
+		}
+	}
+
+	return ""
+}
+
+// unreadyDependent returns the mount path of a mount that depends on mount i and isn't
+// ready, or an empty string if all the dependents are ready
+func unreadyDependent(clientMount *dwsv1alpha1.ClientMount, i int) string {
+	mountPath := clientMount.Spec.Mounts[i].MountPath
+	for j, mount := range clientMount.Spec.Mounts {
+		for _, dependency := range mount.DependsOn {
+			if dependency == mountPath && !clientMount.Status.Mounts[j].Ready {
+				return mount.MountPath
+			}
+		}
+	}
+
+	return ""
+}
+
 // mount mounts a single mount point described in the ClientMountInfo object
 func (r *ClientMountReconciler) mount(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, log logr.Logger) error {
+	if clientMountInfo.Type.IsSwap() {
+		return r.activateSwap(ctx, clientMountInfo, log)
+	}
+
+	// autofs creates the mount path and mounts the export on first access
+	if isAutomount(clientMountInfo) {
+		return r.writeAutomount(ctx, clientMountInfo, log)
+	}
+
+	ctx = withMountNamespace(ctx, clientMountInfo.MountNamespace)
 
 	// Check whether the file system is already mounted
-	state, err := r.checkMount(clientMountInfo.MountPath)
+	state, err := r.checkMount(ctx, clientMountInfo.MountPath)
 	if err != nil {
 		return err
 	}
@@ -231,75 +661,205 @@ func (r *ClientMountReconciler) mount(ctx context.Context, clientMountInfo dwsv1
 		return nil
 	}
 
-	device, err := r.getDevice(clientMountInfo)
+	if clientMountInfo.Type == dwsv1alpha1.FileSystemTypeGFS2 && r.GFS2Precheck {
+		if err := r.checkGFS2Cluster(ctx); err != nil {
+			return err
+		}
+	}
+
+	device, err := r.getDevice(ctx, clientMountInfo)
+	if err != nil {
+		return err
+	}
+
+	if err := r.formatDevice(ctx, clientMountInfo, device, log); err != nil {
+		return err
+	}
+
+	mode, err := getTargetMode(clientMountInfo)
 	if err != nil {
 		return err
 	}
 
-	// Create the mount file or directory
+	// Create the mount file or directory. A device target already exists.
 	switch clientMountInfo.TargetType {
-	case "directory":
-		if err := r.mkdir(clientMountInfo.MountPath); err != nil {
-			log.Error(err, "Could not create mount directory", "mount path", clientMountInfo.MountPath, "device", device)
+	case dwsv1alpha1.TargetTypeDirectory:
+		if err := r.mkdir(ctx, clientMountInfo.MountPath); err != nil {
+			log.Error(err, "Could not create mount directory", "mountPath", clientMountInfo.MountPath, "device", r.redact(device))
+			return err
+		}
+
+		// MkdirAll is subject to the umask and leaves existing directories alone, so set the mode explicitly
+		if err := r.chmod(ctx, clientMountInfo.MountPath, mode); err != nil {
+			log.Error(err, "Could not set mount directory mode", "mountPath", clientMountInfo.MountPath, "mode", mode.String())
 			return err
 		}
-	case "file":
+	case dwsv1alpha1.TargetTypeFile:
 		// Create the parent directory and then the file
-		if err := r.mkdir(filepath.Dir(clientMountInfo.MountPath)); err != nil {
-			log.Error(err, "Could not create mount parent directory", "mount path", clientMountInfo.MountPath, "device", device)
+		if err := r.mkdir(ctx, filepath.Dir(clientMountInfo.MountPath)); err != nil {
+			log.Error(err, "Could not create mount parent directory", "mountPath", clientMountInfo.MountPath, "device", r.redact(device))
 			return err
 		}
 
-		if err := r.createFile(clientMountInfo.MountPath); err != nil {
-			log.Error(err, "Could not create mount file", "mount path", clientMountInfo.MountPath, "device", device)
+		if err := r.createFile(ctx, clientMountInfo.MountPath); err != nil {
+			log.Error(err, "Could not create mount file", "mountPath", clientMountInfo.MountPath, "device", r.redact(device))
 			return err
 		}
+
+		if err := r.chmod(ctx, clientMountInfo.MountPath, mode); err != nil {
+			log.Error(err, "Could not set mount file mode", "mountPath", clientMountInfo.MountPath, "mode", mode.String())
+			return err
+		}
+	}
+
+	if err := r.runHooks(ctx, hookPreMount, clientMountInfo); err != nil {
+		return err
 	}
 
 	// Run the mount command
-	mountCmd := "mount -t " + clientMountInfo.Type + " " + device + " " + clientMountInfo.MountPath
-	if clientMountInfo.Options != "" {
-		mountCmd = mountCmd + " -o " + clientMountInfo.Options
+	mountArgs := []string{"-t", string(clientMountInfo.Type), device, clientMountInfo.MountPath}
+	secretOptions, err := r.secretOptions(ctx, clientMountInfo)
+	if err != nil {
+		return err
+	}
+
+	options := getMountOptions(clientMountInfo)
+	if len(secretOptions) != 0 {
+		if options != "" {
+			secretOptions = append([]string{options}, secretOptions...)
+		}
+		options = strings.Join(secretOptions, ",")
 	}
 
-	output, err := r.run(mountCmd)
+	if options != "" {
+		mountArgs = append(mountArgs, "-o", options)
+	}
+
+	recordPlan(ctx, device, options)
+
+	output, err := r.run(ctx, "mount", mountArgs...)
 	if err != nil {
-		log.Info("Could not mount file system", "mount path", clientMountInfo.MountPath, "device", device, "Error output", output)
+		log.Info("Could not mount file system", "mountPath", clientMountInfo.MountPath, "device", r.redact(device), "output", output)
 		return err
 	}
 
-	log.Info("Mounted file system", "Mount path", clientMountInfo.MountPath, "device", device)
+	if err := r.setPropagation(ctx, clientMountInfo); err != nil {
+		log.Error(err, "Could not set mount propagation", "mountPath", clientMountInfo.MountPath, "propagation", clientMountInfo.Propagation)
+		return err
+	}
+
+	log.Info("Mounted file system", "mountPath", clientMountInfo.MountPath, "device", r.redact(device))
 
 	return nil
 }
 
 // getDevice builds the device string for the mount command. This is dependent on the type of file
-func (r *ClientMountReconciler) getDevice(clientMountInfo dwsv1alpha1.ClientMountInfo) (string, error) {
+func (r *ClientMountReconciler) getDevice(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) (string, error) {
 	switch clientMountInfo.Device.Type {
 	case dwsv1alpha1.ClientMountDeviceTypeLustre:
-		device := clientMountInfo.Device.Lustre.MgsAddresses + ":/" + clientMountInfo.Device.Lustre.FileSystemName
+		device, err := getLustreDevice(clientMountInfo.Device.Lustre)
+		if err != nil {
+			return "", err
+		}
+
+		if r.LNetPrecheck {
+			if err := r.checkLNet(ctx, clientMountInfo.Device.Lustre); err != nil {
+				return "", err
+			}
+		}
 
 		return device, nil
 	case dwsv1alpha1.ClientMountDeviceTypeLVM:
-		if err := r.configureLVMDevice(clientMountInfo.Device.LVM, true, clientMountInfo.Type == "gfs2"); err != nil {
+		if err := r.configureLVMDevice(ctx, clientMountInfo.Device.LVM, true, clientMountInfo.Device.LVM.ActivationModeFor(clientMountInfo.Type)); err != nil {
 			return "", err
 		}
 
 		return filepath.Join("/dev", clientMountInfo.Device.LVM.VolumeGroup, clientMountInfo.Device.LVM.LogicalVolume), nil
+	case dwsv1alpha1.ClientMountDeviceTypeTmpfs:
+		return "tmpfs", nil
+	case dwsv1alpha1.ClientMountDeviceTypeSwapFile:
+		if err := r.createSwapFile(ctx, clientMountInfo.Device.SwapFile); err != nil {
+			return "", err
+		}
+
+		return clientMountInfo.Device.SwapFile.Path, nil
+	case dwsv1alpha1.ClientMountDeviceTypeMultipath:
+		return r.getMultipathDevice(ctx, clientMountInfo.Device.Multipath)
+	case dwsv1alpha1.ClientMountDeviceTypeNFS:
+		if clientMountInfo.Device.NFS == nil {
+			return "", dwsv1alpha1.NewResourceError("Missing NFS device information", nil).WithFatal()
+		}
+
+		return clientMountInfo.Device.NFS.Device(), nil
+	case dwsv1alpha1.ClientMountDeviceTypeBlock:
+		return r.getBlockDevice(ctx, clientMountInfo.Device.Block)
+	case dwsv1alpha1.ClientMountDeviceTypeCephFS:
+		if clientMountInfo.Device.CephFS == nil {
+			return "", dwsv1alpha1.NewResourceError("Missing CephFS device information", nil).WithFatal()
+		}
+
+		return clientMountInfo.Device.CephFS.Device(), nil
+	case dwsv1alpha1.ClientMountDeviceTypeRBD:
+		return r.mapRBDImage(ctx, clientMountInfo.Device.RBD)
+	case dwsv1alpha1.ClientMountDeviceTypeNone:
+		return r.getBindDevice(ctx, clientMountInfo.Device.None)
 	}
 
 	return "", fmt.Errorf("Invalid device type")
 }
 
-// configureLVMDevice will configure the provided LVM device with the desired activate/deactivate option
-func (r *ClientMountReconciler) configureLVMDevice(lvm *dwsv1alpha1.ClientMountDeviceLVM, activate bool, shared bool) error {
-	output, err := r.run(fmt.Sprintf("lvs --noheadings --separator ' '"))
-	if err != nil {
-		return err
+// getMountOptions builds the option string for the mount command. Device specific options
+// come before the options from the ClientMountInfo.
+func getMountOptions(clientMountInfo dwsv1alpha1.ClientMountInfo) string {
+	options := []string{}
+
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeTmpfs && clientMountInfo.Device.Tmpfs != nil {
+		if clientMountInfo.Device.Tmpfs.Size != "" {
+			options = append(options, "size="+clientMountInfo.Device.Tmpfs.Size)
+		}
 	}
 
-	if r.Mock {
-		return nil
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeLustre && clientMountInfo.Device.Lustre != nil && clientMountInfo.Device.Lustre.Squash != nil {
+		options = append(options, clientMountInfo.Device.Lustre.Squash.Options()...)
+	}
+
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeNFS {
+		options = append(options, getNFSOptions(clientMountInfo.Device.NFS)...)
+	}
+
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeCephFS {
+		options = append(options, getCephFSOptions(clientMountInfo.Device.CephFS)...)
+	}
+
+	if clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeNone {
+		options = append(options, "bind")
+	}
+
+	if clientMountInfo.Options != "" {
+		options = append(options, clientMountInfo.Options)
+	}
+
+	return strings.Join(options, ",")
+}
+
+// configureLVMDevice will configure the provided LVM device with the desired activate/deactivate option.
+// The lock manager is started for the VG before an exclusive or shared activation and stopped after
+// the deactivation.
+func (r *ClientMountReconciler) configureLVMDevice(ctx context.Context, lvm *dwsv1alpha1.ClientMountDeviceLVM, activate bool, mode dwsv1alpha1.ClientMountLVMActivationMode) error {
+	// In mock mode the LVM commands run against the fake LVM, if there is one, with the
+	// device from the spec added to it
+	if r.mock() {
+		if r.MockLVM == nil {
+			return nil
+		}
+
+		r.MockLVM.add(lvm)
+		invalidateLVS(ctx)
+	}
+
+	output, err := r.listLVs(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Parse the lvs output. Example with headings:
@@ -322,33 +882,43 @@ func (r *ClientMountReconciler) configureLVMDevice(lvm *dwsv1alpha1.ClientMountD
 
 		// Check the 5th letter of the attributes map to see if the LV is activated
 		isActive := string(fields[2][4]) == "a"
+		locked := mode != dwsv1alpha1.ClientMountLVMActivationModeLocal
 		if activate && !isActive {
 
-			sharedOption := ""
 			// Start lock if needed
-			if shared {
-				output, err := r.run(fmt.Sprintf("vgchange --lockstart %s", lvm.VolumeGroup))
+			if locked {
+				output, err := r.runLVM(ctx, "vgchange", "--lockstart", lvm.VolumeGroup)
+				invalidateLVS(ctx)
 				if err != nil {
 					return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not access storage").WithFatal()
 				}
+			}
 
-				sharedOption = "s" // activate with shared option
+			activateOption := "y"
+			switch mode {
+			case dwsv1alpha1.ClientMountLVMActivationModeShared:
+				activateOption = "sy"
+			case dwsv1alpha1.ClientMountLVMActivationModeExclusive:
+				activateOption = "ey"
 			}
 
 			// Activate the LV if needed
-			output, err := r.run(fmt.Sprintf("vgchange --activate %sy %s", sharedOption, lvm.VolumeGroup))
+			output, err := r.runLVM(ctx, "vgchange", "--activate", activateOption, lvm.VolumeGroup)
+			invalidateLVS(ctx)
 			if err != nil {
 				return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not access storage").WithFatal()
 			}
 
 		} else if !activate && isActive {
-			output, err := r.run(fmt.Sprintf("vgchange --activate n %s", lvm.VolumeGroup))
+			output, err := r.runLVM(ctx, "vgchange", "--activate", "n", lvm.VolumeGroup)
+			invalidateLVS(ctx)
 			if err != nil {
 				return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not release storage").WithFatal()
 			}
 
-			if shared {
-				output, err := r.run(fmt.Sprintf("vgchange --lockstop %s", lvm.VolumeGroup))
+			if locked {
+				output, err := r.runLVM(ctx, "vgchange", "--lockstop", lvm.VolumeGroup)
+				invalidateLVS(ctx)
 				if err != nil {
 					return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not release storage").WithFatal()
 				}
@@ -365,8 +935,8 @@ func (r *ClientMountReconciler) configureLVMDevice(lvm *dwsv1alpha1.ClientMountD
 }
 
 // checkMount checks whether a file system is mounted at the path specified in "mountPath"
-func (r *ClientMountReconciler) checkMount(mountPath string) (dwsv1alpha1.ClientMountState, error) {
-	output, err := r.run("mount")
+func (r *ClientMountReconciler) checkMount(ctx context.Context, mountPath string) (dwsv1alpha1.ClientMountState, error) {
+	output, err := r.run(ctx, "mount")
 	if err != nil {
 		return dwsv1alpha1.ClientMountStateUnmounted, dwsv1alpha1.NewResourceError(output, err)
 	}
@@ -383,43 +953,289 @@ func (r *ClientMountReconciler) checkMount(mountPath string) (dwsv1alpha1.Client
 	return dwsv1alpha1.ClientMountStateUnmounted, nil
 }
 
-func (r *ClientMountReconciler) createFile(path string) error {
-	if r.Mock {
-		r.Log.Info("Touch file", "Path", path)
+// getTargetMode returns the permission mode for the mount target from the create options
+func getTargetMode(clientMountInfo dwsv1alpha1.ClientMountInfo) (os.FileMode, error) {
+	if clientMountInfo.CreateOptions == nil || clientMountInfo.CreateOptions.Mode == "" {
+		if clientMountInfo.TargetType == dwsv1alpha1.TargetTypeFile {
+			return 0644, nil
+		}
+
+		return 0755, nil
+	}
+
+	mode, err := strconv.ParseUint(clientMountInfo.CreateOptions.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, dwsv1alpha1.NewResourceError(fmt.Sprintf("Invalid mode '%s'", clientMountInfo.CreateOptions.Mode), err).WithUserMessage("invalid mount target mode").WithFatal()
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// cleanupTarget removes the mount target after unmounting according to the create options.
+// Removing a target that's already gone is not an error.
+func (r *ClientMountReconciler) cleanupTarget(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) error {
+	options := clientMountInfo.CreateOptions
+	if options == nil {
+		options = &dwsv1alpha1.ClientMountCreateOptions{}
+	}
+
+	if options.PreserveOnUnmount || !clientMountInfo.TargetType.IsCreated() {
+		return nil
+	}
+
+	if !options.RecursiveCleanup {
+		if err := r.rmdir(ctx, clientMountInfo.MountPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	// Never recursively remove a path that still has a file system mounted on it. That
+	// would delete the contents of the file system. In dry-run mode the umount was only
+	// recorded, so the file system is expected to still be mounted.
+	if dryRun(ctx) == nil {
+		state, err := r.checkMount(ctx, clientMountInfo.MountPath)
+		if err != nil {
+			return err
+		}
+
+		if state == dwsv1alpha1.ClientMountStateMounted {
+			return fmt.Errorf("mount target '%s' is still mounted", clientMountInfo.MountPath)
+		}
+	}
+
+	return r.removeAll(ctx, clientMountInfo.MountPath)
+}
+
+// fileCommands returns whether the file operations on the mount targets are run as
+// commands rather than by the daemon, either because they're in another mount namespace
+// or because the daemon doesn't have the privileges
+func (r *ClientMountReconciler) fileCommands(ctx context.Context) bool {
+	return mountNamespace(ctx) != nil || r.PrivilegedHelper
+}
+
+func (r *ClientMountReconciler) createFile(ctx context.Context, path string) error {
+	if r.fileCommands(ctx) {
+		_, err := r.run(ctx, "touch", path)
+		return err
+	}
+
+	if record(ctx, "touch", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Touch file", "path", path)
 		return nil
 	}
 
 	return os.WriteFile(path, []byte(""), 0644)
 }
 
-func (r *ClientMountReconciler) rmdir(path string) error {
-	if r.Mock {
-		r.Log.Info("rmdir", "Path", path)
+func (r *ClientMountReconciler) removeFile(ctx context.Context, path string) error {
+	if r.fileCommands(ctx) {
+		_, err := r.run(ctx, "rm", path)
+		return err
+	}
+
+	if record(ctx, "rm", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Remove file", "path", path)
+		return nil
+	}
+
+	return os.Remove(path)
+}
+
+func (r *ClientMountReconciler) chmod(ctx context.Context, path string, mode os.FileMode) error {
+	if r.fileCommands(ctx) {
+		_, err := r.run(ctx, "chmod", strconv.FormatUint(uint64(mode.Perm()), 8), path)
+		return err
+	}
+
+	if record(ctx, "chmod", strconv.FormatUint(uint64(mode.Perm()), 8), path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Chmod", "path", path, "mode", mode.String())
+		return nil
+	}
+
+	return os.Chmod(path, mode)
+}
+
+func (r *ClientMountReconciler) rmdir(ctx context.Context, path string) error {
+	if r.fileCommands(ctx) {
+		_, err := r.run(ctx, "rmdir", path)
+		return err
+	}
+
+	if record(ctx, "rmdir", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("rmdir", "path", path)
 		return nil
 	}
 
 	return os.Remove(path)
 }
 
-func (r *ClientMountReconciler) mkdir(path string) error {
-	if r.Mock {
-		r.Log.Info("Mkdir", "Path", path)
+func (r *ClientMountReconciler) removeAll(ctx context.Context, path string) error {
+	if r.fileCommands(ctx) {
+		_, err := r.run(ctx, "rm", "-rf", path)
+		return err
+	}
+
+	if record(ctx, "rm", "-rf", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Remove all", "path", path)
+		return nil
+	}
+
+	return os.RemoveAll(path)
+}
+
+func (r *ClientMountReconciler) mkdir(ctx context.Context, path string) error {
+	if r.fileCommands(ctx) {
+		_, err := r.run(ctx, "mkdir", "-p", path)
+		return err
+	}
+
+	if record(ctx, "mkdir", "-p", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Mkdir", "path", path)
 		return nil
 	}
 
 	return os.MkdirAll(path, 0755)
 }
 
-// run runs a command on the host OS and returns the output as a string.
-func (r *ClientMountReconciler) run(c string) (string, error) {
-	if r.Mock {
-		r.Log.Info("Run", "Command", c)
+// run runs a command on the host OS and returns the output as a string. The command
+// is recorded in the audit log if one is configured. The command line is logged at V(1)
+// and the full output at V(2).
+func (r *ClientMountReconciler) run(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = nsenter(ctx, name, args...)
+
+	// The arguments are only shown with the credential values replaced
+	shownArgs := redactSecrets(ctx, args)
+	commandLine := strings.Join(append([]string{name}, r.redactArgs(shownArgs)...), " ")
+
+	if !isQuery(name, args...) && record(ctx, name, shownArgs...) {
 		return "", nil
 	}
 
-	output, err := exec.Command("bash", "-c", c).Output()
+	if r.mock() {
+		r.Log.Info("Run", "command", commandLine)
+		if r.MockLVM != nil && r.MockLVM.handles(name) {
+			return r.MockLVM.run(name, args...)
+		}
+
+		return "", nil
+	}
+
+	log := r.Log.WithValues("command", name)
+	if clientMount := auditClientMount(ctx); clientMount != "" {
+		log = log.WithValues("ClientMount", clientMount)
+	}
+
+	log.V(1).Info("Running command", "commandLine", commandLine)
+
+	start := time.Now()
+
+	if r.InFlight != nil {
+		id := r.InFlight.begin(InFlightOperation{ClientMount: auditClientMount(ctx), Command: name, Args: shownArgs, Start: start})
+		defer r.InFlight.end(id)
+	}
+
+	commandCtx, cancel := r.commandContext(ctx)
+	defer cancel()
 
-	return string(output), err
+	timeout := r.Settings.Get().CommandTimeout
+	if profile := mountProfile(ctx); profile != nil && profile.CommandTimeout != nil {
+		timeout = profile.CommandTimeout.Duration
+	}
+
+	if timeout != 0 {
+		var cancelTimeout context.CancelFunc
+		commandCtx, cancelTimeout = context.WithTimeout(commandCtx, timeout)
+		defer cancelTimeout()
+	}
+
+	result, err := r.Runner.Run(commandCtx, name, args...)
+	if result == nil {
+		result = &command.Result{ExitCode: command.ExitCode(err)}
+	}
+
+	if err != nil {
+		if commandCtx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("command '%s' timed out: %w", name, err)
+		} else if commandCtx.Err() == context.Canceled {
+			err = fmt.Errorf("command '%s' was killed at shutdown: %w", name, err)
+		} else if stderr := strings.TrimSpace(result.Stderr); stderr != "" {
+			err = fmt.Errorf("%w: %s", err, stderr)
+		}
+
+		if result.OutputFile != "" {
+			err = fmt.Errorf("%w (full output in %s on the node)", err, result.OutputFile)
+		}
+	}
+
+	log.V(1).Info("Command finished", "durationMs", result.Duration.Milliseconds(), "exitCode", result.ExitCode, "attempts", result.Attempts)
+	log.V(2).Info("Command output", "output", r.redact(result.Stdout), "truncated", result.Truncated)
+
+	if r.Audit != nil {
+		if auditErr := r.Audit.Record(newAuditEntry(ctx, start, name, shownArgs, err)); auditErr != nil {
+			log.Error(auditErr, "Could not write audit log entry")
+		}
+	}
+
+	return result.Stdout, err
+}
+
+// mock returns whether the client mount operations are skipped
+func (r *ClientMountReconciler) mock() bool {
+	return r.Settings.Get().Mock
+}
+
+// redact hides a device path from the log if device redaction is enabled
+func (r *ClientMountReconciler) redact(device string) string {
+	if r.RedactDevices {
+		return redacted
+	}
+
+	return device
+}
+
+// redactArgs hides the command arguments that look like device paths (block devices and
+// Lustre MGS NIDs) if device redaction is enabled
+func (r *ClientMountReconciler) redactArgs(args []string) []string {
+	if !r.RedactDevices {
+		return args
+	}
+
+	redactedArgs := make([]string, len(args))
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "/dev/") || strings.Contains(arg, "@") {
+			redactedArgs[i] = redacted
+		} else {
+			redactedArgs[i] = arg
+		}
+	}
+
+	return redactedArgs
 }
 
 func filterByNonRabbitNamespacePrefixForTest() predicate.Predicate {
@@ -430,6 +1246,11 @@ func filterByNonRabbitNamespacePrefixForTest() predicate.Predicate {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClientMountReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.reconcileAfter = time.Now().Add(r.StartupDelay)
+	if r.StartupDelay != 0 {
+		r.Log.Info("Delaying the first reconcile", "delay", r.StartupDelay.String())
+	}
+
 	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&dwsv1alpha1.ClientMount{})
 
@@ -437,5 +1258,23 @@ func (r *ClientMountReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		builder = builder.WithEventFilter(filterByNonRabbitNamespacePrefixForTest())
 	}
 
-	return builder.Complete(r)
+	if r.OrphanMountRoot != "" {
+		if err := mgr.Add(r.orphanScanner(mgr.GetCache())); err != nil {
+			return err
+		}
+	}
+
+	if r.NodeInfoInterval != 0 && r.APIReader != nil {
+		if err := mgr.Add(r.nodeInfoReporter(r.NodeName, r.NodeInfoInterval)); err != nil {
+			return err
+		}
+	}
+
+	if r.DaemonVersion != "" && r.APIReader != nil {
+		if err := mgr.Add(r.capabilityReporter(r.NodeName)); err != nil {
+			return err
+		}
+	}
+
+	return builder.Complete(metrics.CountReconciles("ClientMount", r))
 }
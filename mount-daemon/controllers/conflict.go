@@ -0,0 +1,115 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// mountPaths returns the cleaned mount paths of a ClientMount. Swap doesn't use a mount path.
+func mountPaths(clientMount *dwsv1alpha1.ClientMount) []string {
+	paths := []string{}
+	for _, mount := range clientMount.Spec.Mounts {
+		if mount.Type.IsSwap() {
+			continue
+		}
+
+		paths = append(paths, filepath.Clean(mount.MountPath))
+	}
+
+	return paths
+}
+
+// pathsOverlap returns true if the paths are the same or one is below the other
+func pathsOverlap(a string, b string) bool {
+	if a == b {
+		return true
+	}
+
+	return strings.HasPrefix(a, strings.TrimSuffix(b, "/")+"/") || strings.HasPrefix(b, strings.TrimSuffix(a, "/")+"/")
+}
+
+// mountedBefore returns true if ClientMount a takes precedence over ClientMount b for a
+// conflicting mount path. The older ClientMount wins so a later mount can't shadow an
+// earlier one.
+func mountedBefore(a *dwsv1alpha1.ClientMount, b *dwsv1alpha1.ClientMount) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+}
+
+// checkMountConflicts refuses a ClientMount that requests the same mount path as, or a path
+// overlapping with, another mount on this node. Overlapping paths within the ClientMount
+// itself are also refused.
+func (r *ClientMountReconciler) checkMountConflicts(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) error {
+	paths := mountPaths(clientMount)
+
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			if pathsOverlap(paths[i], paths[j]) {
+				return dwsv1alpha1.NewResourceError(fmt.Sprintf("Mount paths '%s' and '%s' overlap", paths[i], paths[j]), nil).
+					WithUserMessage("conflicting mount paths").WithFatal()
+			}
+		}
+	}
+
+	// The mount-daemon manager only watches the namespace for this node, so this lists
+	// the ClientMounts for this node
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := r.List(ctx, clientMounts, client.InNamespace(clientMount.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range clientMounts.Items {
+		other := &clientMounts.Items[i]
+
+		if other.Name == clientMount.Name && other.Namespace == clientMount.Namespace {
+			continue
+		}
+
+		if other.Spec.Node != clientMount.Spec.Node || other.Spec.DesiredState != dwsv1alpha1.ClientMountStateMounted {
+			continue
+		}
+
+		if !mountedBefore(other, clientMount) {
+			continue
+		}
+
+		for _, path := range paths {
+			for _, otherPath := range mountPaths(other) {
+				if pathsOverlap(path, otherPath) {
+					return dwsv1alpha1.NewResourceError(fmt.Sprintf("Mount path '%s' conflicts with mount path '%s' of ClientMount %s/%s", path, otherPath, other.Namespace, other.Name), nil).
+						WithUserMessage("mount path already in use on node").WithFatal()
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get
+
+// checkCordon returns an error if the node's namespace is cordoned. The namespace is read
+// directly from the API server since the daemon doesn't cache cluster scoped resources.
+func (r *ClientMountReconciler) checkCordon(ctx context.Context, node string) error {
+	if r.APIReader == nil {
+		return nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := r.APIReader.Get(ctx, client.ObjectKey{Name: node}, namespace); err != nil {
+		return dwsv1alpha1.NewResourceError("Could not get node namespace", err)
+	}
+
+	if cordoned, reason := dwsv1alpha1.IsNodeCordoned(namespace); cordoned {
+		return dwsv1alpha1.NewResourceError(fmt.Sprintf("Node '%s' is cordoned: %s", node, reason), nil).WithUserMessage("Compute node is cordoned for maintenance")
+	}
+
+	return nil
+}
+
+// isActive returns whether a mount is already mounted, or for swap, already activated
+func (r *ClientMountReconciler) isActive(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) (bool, error) {
+	if clientMountInfo.Type.IsSwap() {
+		device, err := r.getSwapDevice(clientMountInfo)
+		if err != nil {
+			return false, err
+		}
+
+		return r.checkSwap(ctx, device)
+	}
+
+	state, err := r.checkMount(withMountNamespace(ctx, clientMountInfo.MountNamespace), clientMountInfo.MountPath)
+	if err != nil {
+		return false, err
+	}
+
+	return state == dwsv1alpha1.ClientMountStateMounted, nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"strings"
+)
+
+// dryRunPlan collects the commands that would be run for a ClientMount in dry-run mode
+type dryRunPlan struct {
+	commands []string
+}
+
+type dryRunKey struct{}
+
+// withDryRun returns a context that records the commands that change the node in the plan
+// rather than running them
+func withDryRun(ctx context.Context, plan *dryRunPlan) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, plan)
+}
+
+// dryRun returns the plan if the context is in dry-run mode, otherwise nil
+func dryRun(ctx context.Context) *dryRunPlan {
+	plan, _ := ctx.Value(dryRunKey{}).(*dryRunPlan)
+	return plan
+}
+
+// record adds a command to the plan if the context is in dry-run mode. It returns true if
+// the command was recorded and must not be run.
+func record(ctx context.Context, command string, args ...string) bool {
+	plan := dryRun(ctx)
+	if plan == nil {
+		return false
+	}
+
+	plan.commands = append(plan.commands, strings.Join(append([]string{command}, args...), " "))
+	return true
+}
+
+// isQuery returns whether a command only reads the state of the node. Queries are still
+// run in dry-run mode so the plan reflects what's already mounted or active.
+func isQuery(command string, args ...string) bool {
+	switch command {
+	case "lvs", "blkid", "readlink":
+		return true
+	case "mount":
+		return len(args) == 0
+	case "swapon":
+		return len(args) != 0 && strings.HasPrefix(args[0], "--show")
+	case "multipathd":
+		return len(args) != 0 && args[0] == "show"
+	case "lnetctl":
+		return len(args) != 0 && args[0] == "ping"
+	case "wipefs":
+		return len(args) != 0 && args[0] == "--no-act"
+	case "dmsetup":
+		return len(args) != 0 && args[0] == "table"
+	case "lvmlockctl":
+		return len(args) != 0 && args[0] == "--info"
+	case "rbd":
+		return len(args) > 1 && args[0] == "device" && args[1] == "list"
+	case "nsenter":
+		for i, arg := range args {
+			if arg == "--" && i+1 < len(args) {
+				return isQuery(args[i+1], args[i+2:]...)
+			}
+		}
+	}
+
+	return false
+}
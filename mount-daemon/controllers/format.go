@@ -0,0 +1,79 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// formatDevice creates the file system on the device if the mount asks for it. Only a blank
+// device is formatted. Any existing signature (file system, partition table, LVM, RAID, etc.)
+// is left alone so data is never destroyed.
+func (r *ClientMountReconciler) formatDevice(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, device string, log logr.Logger) error {
+	if clientMountInfo.Format == nil {
+		return nil
+	}
+
+	if !clientMountInfo.Type.IsFormattable() {
+		return dwsv1alpha1.NewResourceError(fmt.Sprintf("Formatting is not supported for file system type '%s'", clientMountInfo.Type), nil).WithFatal()
+	}
+
+	switch clientMountInfo.Device.Type {
+	case dwsv1alpha1.ClientMountDeviceTypeLVM, dwsv1alpha1.ClientMountDeviceTypeMultipath, dwsv1alpha1.ClientMountDeviceTypeBlock, dwsv1alpha1.ClientMountDeviceTypeRBD:
+	default:
+		return dwsv1alpha1.NewResourceError(fmt.Sprintf("Formatting is not supported for device type '%s'", clientMountInfo.Device.Type), nil).WithFatal()
+	}
+
+	// wipefs lists the signatures on the device without changing anything
+	output, err := r.run(ctx, "wipefs", "--no-act", "--noheadings", "--output", "TYPE", device)
+	if err != nil {
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not check device for existing data")
+	}
+
+	signatures := strings.Fields(output)
+	if len(signatures) == 1 && signatures[0] == string(clientMountInfo.Type) {
+		log.Info("Device already formatted", "device", r.redact(device), "type", clientMountInfo.Type)
+		return nil
+	}
+
+	if len(signatures) != 0 {
+		return dwsv1alpha1.NewResourceError(fmt.Sprintf("Device has existing signatures '%s'", strings.Join(signatures, ",")), nil).WithUserMessage("Client found existing data on device").WithFatal()
+	}
+
+	// The options are split into arguments at spaces, as they were when the command line
+	// was run through a shell
+	args := append(strings.Fields(clientMountInfo.Format.Options), device)
+
+	output, err = r.run(ctx, "mkfs."+string(clientMountInfo.Type), args...)
+	if err != nil {
+		log.Info("Could not format device", "device", r.redact(device), "output", output)
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not format device")
+	}
+
+	log.Info("Formatted device", "device", r.redact(device), "type", clientMountInfo.Type)
+
+	return nil
+}
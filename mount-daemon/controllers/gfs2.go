@@ -0,0 +1,96 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// checkGFS2Cluster checks that the node is a healthy member of the cluster before mounting
+// gfs2. A gfs2 mount hangs until DLM can join the lockspace, which never happens if the
+// cluster has lost quorum or is waiting to fence a node.
+func (r *ClientMountReconciler) checkGFS2Cluster(ctx context.Context) error {
+	output, err := r.run(ctx, "corosync-quorumtool", "-s")
+
+	// corosync-quorumtool exits with an error when the cluster isn't quorate, so look at
+	// the output before the error
+	quorate, found := parseQuorate(output)
+	if !found {
+		return dwsv1alpha1.NewResourceError("Could not get the corosync quorum status: "+output, err).WithUserMessage("Client gfs2 cluster state is unknown")
+	}
+
+	if !quorate {
+		return dwsv1alpha1.NewResourceError("The corosync cluster is not quorate", nil).WithUserMessage("Client gfs2 cluster is not quorate")
+	}
+
+	output, err = r.run(ctx, "dlm_tool", "status")
+	if err != nil {
+		return dwsv1alpha1.NewResourceError("Could not get the DLM status: "+output, err).WithUserMessage("Client gfs2 cluster state is unknown")
+	}
+
+	if err := checkDLMStatus(output); err != nil {
+		return dwsv1alpha1.NewResourceError("", err).WithUserMessage("Client gfs2 cluster is fencing a node")
+	}
+
+	return nil
+}
+
+// parseQuorate returns the "Quorate:" field of the corosync-quorumtool output and whether
+// it was found
+func parseQuorate(output string) (bool, bool) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "Quorate:" {
+			return fields[1] == "Yes", true
+		}
+	}
+
+	return false, false
+}
+
+// checkDLMStatus checks the dlm_tool status output for a cluster that's not quorate from
+// DLM's point of view or a fence operation that's in progress. The output looks like:
+//
+//	cluster nodeid 1 quorate 1 ring seq 8 8
+//	daemon now 1234 fence_pid 0
+//	node 1 M add 13 rem 0 fail 0 fence 0 at 0 0
+func checkDLMStatus(output string) error {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		for i := 0; i+1 < len(fields); i++ {
+			switch {
+			case fields[0] == "cluster" && fields[i] == "quorate" && fields[i+1] != "1":
+				return fmt.Errorf("DLM reports the cluster is not quorate")
+			case fields[0] == "daemon" && fields[i] == "fence_pid" && fields[i+1] != "0":
+				return fmt.Errorf("DLM is waiting for fence agent pid %s to finish", fields[i+1])
+			}
+		}
+	}
+
+	return nil
+}
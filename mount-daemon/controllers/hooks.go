@@ -0,0 +1,131 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// Hook phases. The hooks for a phase are the executable files in the subdirectory of the
+// hook directory named for the phase. They're run in lexical order. A mount with a
+// ClientMountProfile that names a hook set runs the hooks under that subdirectory of the
+// hook directory instead.
+const (
+	hookPreMount    = "pre-mount"
+	hookPostUnmount = "post-unmount"
+)
+
+// runHooks runs the site hooks for a phase. The mount is described to the hooks in DWS_
+// environment variables. The hooks are run again when a mount or unmount is retried, so
+// they must be idempotent. The first hook that fails stops the mount or unmount.
+func (r *ClientMountReconciler) runHooks(ctx context.Context, phase string, clientMountInfo dwsv1alpha1.ClientMountInfo) error {
+	if r.HookDir == "" {
+		return nil
+	}
+
+	dir := r.HookDir
+	if profile := mountProfile(ctx); profile != nil {
+		if profile.SkipHooks {
+			return nil
+		}
+
+		if profile.HookSet != "" {
+			dir = filepath.Join(r.HookDir, profile.HookSet)
+		}
+	}
+
+	hooks, err := listHooks(filepath.Join(dir, phase))
+	if err != nil {
+		return dwsv1alpha1.NewResourceError("Could not list the "+phase+" hooks", err)
+	}
+
+	env := hookEnv(ctx, phase, clientMountInfo)
+	for _, hook := range hooks {
+		output, err := r.run(ctx, "env", append(env, hook)...)
+		if err != nil {
+			return dwsv1alpha1.NewResourceError("Hook "+hook+" failed: "+output, err).WithUserMessage("Site " + phase + " hook failed")
+		}
+	}
+
+	return nil
+}
+
+// listHooks returns the paths of the executable files in a hook directory. A missing
+// directory has no hooks.
+func listHooks(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	hooks := []string{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		hooks = append(hooks, filepath.Join(dir, entry.Name()))
+	}
+
+	return hooks, nil
+}
+
+// hookEnv returns the env arguments that describe the mount to a hook
+func hookEnv(ctx context.Context, phase string, clientMountInfo dwsv1alpha1.ClientMountInfo) []string {
+	vars := [][2]string{
+		{"DWS_HOOK_PHASE", phase},
+		{"DWS_CLIENTMOUNT", auditClientMount(ctx)},
+		{"DWS_MOUNT_PATH", clientMountInfo.MountPath},
+		{"DWS_MOUNT_TYPE", string(clientMountInfo.Type)},
+		{"DWS_MOUNT_OPTIONS", getMountOptions(clientMountInfo)},
+		{"DWS_TARGET_TYPE", string(clientMountInfo.TargetType)},
+		{"DWS_DEVICE_TYPE", string(clientMountInfo.Device.Type)},
+		{"DWS_COMPUTE", clientMountInfo.Compute},
+		{"DWS_ENV_NAME", clientMountInfo.EnvName},
+	}
+
+	if ns := clientMountInfo.MountNamespace; ns != nil {
+		vars = append(vars, [2]string{"DWS_MOUNT_NAMESPACE", ns.NamespacePath()})
+	}
+
+	if lvm := clientMountInfo.Device.LVM; lvm != nil {
+		vars = append(vars, [2]string{"DWS_LVM_VOLUME_GROUP", lvm.VolumeGroup}, [2]string{"DWS_LVM_LOGICAL_VOLUME", lvm.LogicalVolume})
+	}
+
+	if lustre := clientMountInfo.Device.Lustre; lustre != nil {
+		vars = append(vars, [2]string{"DWS_LUSTRE_FS_NAME", lustre.FileSystemName})
+	}
+
+	env := []string{}
+	for _, v := range vars {
+		env = append(env, v[0]+"="+v[1])
+	}
+
+	return env
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// InFlightOperation describes a host command that's currently running
+type InFlightOperation struct {
+	ClientMount string
+	Command     string
+	Args        []string
+	Start       time.Time
+}
+
+// InFlightOperations tracks the host commands that are currently running so they
+// can be reported when debugging a hung reconciler
+type InFlightOperations struct {
+	mu         sync.Mutex
+	next       uint64
+	operations map[uint64]InFlightOperation
+}
+
+// NewInFlightOperations returns an empty operation tracker
+func NewInFlightOperations() *InFlightOperations {
+	return &InFlightOperations{operations: map[uint64]InFlightOperation{}}
+}
+
+// begin records the start of an operation and returns the id used to end it
+func (o *InFlightOperations) begin(operation InFlightOperation) uint64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.next++
+	o.operations[o.next] = operation
+
+	return o.next
+}
+
+// end removes a finished operation
+func (o *InFlightOperations) end(id uint64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.operations, id)
+}
+
+// List returns the running operations, oldest first
+func (o *InFlightOperations) List() []InFlightOperation {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	operations := make([]InFlightOperation, 0, len(o.operations))
+	for _, operation := range o.operations {
+		operations = append(operations, operation)
+	}
+
+	sort.Slice(operations, func(i, j int) bool { return operations[i].Start.Before(operations[j].Start) })
+
+	return operations
+}
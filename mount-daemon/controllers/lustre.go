@@ -0,0 +1,111 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// nidMatcher matches an LNet NID of the form [address]@[lnet] (e.g., "10.1.1.1@o2ib" or "mgs1@tcp0")
+var nidMatcher = regexp.MustCompile(`^[A-Za-z0-9._\-]+@[a-z]+[0-9]*$`)
+
+// fsNameMatcher matches a Lustre file system name
+var fsNameMatcher = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,8}$`)
+
+// getLustreDevice builds the device string for mounting a Lustre file system. The NIDs of
+// an MGS node are separated by commas and the failover MGS nodes are separated by colons
+// (e.g., "mgs1@o2ib,mgs1@tcp:mgs2@o2ib:/fs").
+func getLustreDevice(lustre *dwsv1alpha1.ClientMountDeviceLustre) (string, error) {
+	if lustre == nil {
+		return "", dwsv1alpha1.NewResourceError("Missing Lustre device information", nil).WithFatal()
+	}
+
+	if !fsNameMatcher.MatchString(lustre.FileSystemName) {
+		return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("Invalid Lustre file system name '%s'", lustre.FileSystemName), nil).WithFatal()
+	}
+
+	mgsNodes, err := getLustreMgsNodes(lustre)
+	if err != nil {
+		return "", err
+	}
+
+	nodes := []string{}
+	for _, node := range mgsNodes {
+		nodes = append(nodes, strings.Join(node, ","))
+	}
+
+	return strings.Join(nodes, ":") + ":/" + lustre.FileSystemName, nil
+}
+
+// getLustreMgsNodes returns the validated NIDs of each MGS node. The structured MgsNodes
+// list is used if present, otherwise the MgsAddresses string is parsed.
+func getLustreMgsNodes(lustre *dwsv1alpha1.ClientMountDeviceLustre) ([][]string, error) {
+	mgsNodes := [][]string{}
+
+	if len(lustre.MgsNodes) != 0 {
+		for _, node := range lustre.MgsNodes {
+			mgsNodes = append(mgsNodes, node.NIDs)
+		}
+	} else {
+		for _, node := range strings.Split(lustre.MgsAddresses, ":") {
+			mgsNodes = append(mgsNodes, strings.Split(node, ","))
+		}
+	}
+
+	for _, nids := range mgsNodes {
+		if len(nids) == 0 {
+			return nil, dwsv1alpha1.NewResourceError("Lustre MGS node has no NIDs", nil).WithFatal()
+		}
+
+		for _, nid := range nids {
+			if !nidMatcher.MatchString(nid) {
+				return nil, dwsv1alpha1.NewResourceError(fmt.Sprintf("Invalid Lustre MGS NID '%s'", nid), nil).WithFatal()
+			}
+		}
+	}
+
+	return mgsNodes, nil
+}
+
+// checkLNet checks that at least one MGS node can be reached through LNet before mounting so
+// an unreachable MGS fails quickly rather than hanging in the mount command
+func (r *ClientMountReconciler) checkLNet(ctx context.Context, lustre *dwsv1alpha1.ClientMountDeviceLustre) error {
+	mgsNodes, err := getLustreMgsNodes(lustre)
+	if err != nil {
+		return err
+	}
+
+	var output string
+	for _, nids := range mgsNodes {
+		for _, nid := range nids {
+			output, err = r.run(ctx, "lnetctl", "ping", nid)
+			if err == nil {
+				return nil
+			}
+		}
+	}
+
+	return dwsv1alpha1.NewResourceError("No Lustre MGS node is reachable: "+output, err).WithUserMessage("Client could not reach Lustre file system")
+}
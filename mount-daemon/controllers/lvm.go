@@ -0,0 +1,161 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// LVMGuard limits the number of LVM commands that run at the same time on the node and
+// pauses LVM commands for a cool-down period after repeated failures. Concurrent vgchange
+// commands from many ClientMounts can deadlock lvmlockd.
+type LVMGuard struct {
+	slots chan struct{}
+
+	mu               sync.Mutex
+	failureThreshold int
+	cooldown         time.Duration
+	failures         int
+	pausedUntil      time.Time
+}
+
+// NewLVMGuard returns an LVMGuard that runs up to concurrency LVM commands at once. LVM
+// commands are paused for the cool-down period after failureThreshold consecutive
+// failures. The circuit breaker is disabled if failureThreshold is 0.
+func NewLVMGuard(concurrency int, failureThreshold int, cooldown time.Duration) *LVMGuard {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &LVMGuard{
+		slots:            make(chan struct{}, concurrency),
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+	}
+}
+
+// acquire waits for a free slot. It returns an error if LVM commands are paused or the
+// context is done while waiting.
+func (g *LVMGuard) acquire(ctx context.Context) error {
+	if err := g.checkPaused(); err != nil {
+		return err
+	}
+
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return dwsv1alpha1.NewResourceError("Timed out waiting to run LVM command", ctx.Err())
+	}
+}
+
+// release frees the slot and records the result of the command
+func (g *LVMGuard) release(err error) {
+	<-g.slots
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err == nil {
+		g.failures = 0
+		return
+	}
+
+	g.failures++
+	if g.failureThreshold > 0 && g.failures >= g.failureThreshold {
+		g.pausedUntil = time.Now().Add(g.cooldown)
+		g.failures = 0
+	}
+}
+
+// checkPaused returns a recoverable error if the circuit breaker is open
+func (g *LVMGuard) checkPaused() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if time.Now().Before(g.pausedUntil) {
+		return dwsv1alpha1.NewResourceError(fmt.Sprintf("LVM commands paused after %d consecutive failures until %s", g.failureThreshold, g.pausedUntil.Format(time.RFC3339)), nil).WithUserMessage("Client storage operations are paused")
+	}
+
+	return nil
+}
+
+// runLVM runs an LVM command through the node's LVMGuard if there is one
+func (r *ClientMountReconciler) runLVM(ctx context.Context, command string, args ...string) (string, error) {
+	if r.LVM == nil || dryRun(ctx) != nil {
+		return r.run(ctx, command, args...)
+	}
+
+	if err := r.LVM.acquire(ctx); err != nil {
+		return "", err
+	}
+
+	output, err := r.run(ctx, command, args...)
+	r.LVM.release(err)
+
+	return output, err
+}
+
+// lvsCache holds the output of lvs for the duration of a reconcile, so the logical volumes
+// are only scanned once for all the mounts in a ClientMount
+type lvsCache struct {
+	output string
+	valid  bool
+}
+
+type lvsCacheKey struct{}
+
+// withLVSCache returns a context that caches the output of lvs
+func withLVSCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, lvsCacheKey{}, &lvsCache{})
+}
+
+// invalidateLVS drops the cached lvs output. It's called after any command that changes
+// the state of the logical volumes.
+func invalidateLVS(ctx context.Context) {
+	if cache, _ := ctx.Value(lvsCacheKey{}).(*lvsCache); cache != nil {
+		cache.valid = false
+	}
+}
+
+// listLVs returns the output of lvs, using the cached output if the context has any
+func (r *ClientMountReconciler) listLVs(ctx context.Context) (string, error) {
+	cache, _ := ctx.Value(lvsCacheKey{}).(*lvsCache)
+	if cache != nil && cache.valid {
+		return cache.output, nil
+	}
+
+	output, err := r.runLVM(ctx, "lvs", "--noheadings", "--separator", " ")
+	if err != nil {
+		return output, err
+	}
+
+	if cache != nil {
+		cache.output = output
+		cache.valid = true
+	}
+
+	return output, nil
+}
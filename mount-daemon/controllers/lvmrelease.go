@@ -0,0 +1,164 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// releaseLVM checks that the node has let go of the LVM devices of a deleted ClientMount
+// after its mounts are unmounted: the device-mapper devices of the logical volumes are
+// gone and the lvmlockd lockspaces of the volume groups are stopped. A lockspace that's
+// still started after its logical volumes are gone is stopped. The lockspace of a volume
+// group that another ClientMount on the node still uses is left alone.
+func (r *ClientMountReconciler) releaseLVM(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) error {
+	devices := []*dwsv1alpha1.ClientMountDeviceLVM{}
+	lockspaces := map[string]bool{}
+	for _, mount := range clientMount.Spec.Mounts {
+		if mount.Device.Type != dwsv1alpha1.ClientMountDeviceTypeLVM || mount.Device.LVM == nil {
+			continue
+		}
+
+		devices = append(devices, mount.Device.LVM)
+		if mount.Device.LVM.ActivationModeFor(mount.Type) != dwsv1alpha1.ClientMountLVMActivationModeLocal {
+			lockspaces[mount.Device.LVM.VolumeGroup] = true
+		}
+	}
+
+	if len(devices) == 0 {
+		return nil
+	}
+
+	problems := []string{}
+	held := map[string]bool{}
+	for _, lvm := range devices {
+		exists, err := r.deviceMapperExists(ctx, lvm)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			problems = append(problems, fmt.Sprintf("logical volume %s/%s is still active", lvm.VolumeGroup, lvm.LogicalVolume))
+			held[lvm.VolumeGroup] = true
+		}
+	}
+
+	if len(lockspaces) != 0 {
+		inUse, err := r.volumeGroupsInUse(ctx, clientMount)
+		if err != nil {
+			return err
+		}
+
+		started, err := r.startedLockspaces(ctx)
+		if err != nil {
+			return err
+		}
+
+		for vg := range lockspaces {
+			if !started[vg] || inUse[vg] || held[vg] {
+				continue
+			}
+
+			if output, err := r.runLVM(ctx, "vgchange", "--lockstop", vg); err != nil {
+				problems = append(problems, fmt.Sprintf("lockspace of volume group %s could not be stopped: %s", vg, strings.TrimSpace(output)))
+			}
+		}
+	}
+
+	if len(problems) != 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// deviceMapperExists returns whether the device-mapper device of a logical volume exists
+func (r *ClientMountReconciler) deviceMapperExists(ctx context.Context, lvm *dwsv1alpha1.ClientMountDeviceLVM) (bool, error) {
+	// device-mapper doubles the dashes in the names, so the dash between them is unambiguous
+	name := strings.ReplaceAll(lvm.VolumeGroup, "-", "--") + "-" + strings.ReplaceAll(lvm.LogicalVolume, "-", "--")
+
+	output, err := r.run(ctx, "dmsetup", "table", name)
+	if err != nil {
+		if strings.Contains(output, "Device does not exist") {
+			return false, nil
+		}
+
+		return false, dwsv1alpha1.NewResourceError("Could not check device-mapper device "+name+": "+output, err)
+	}
+
+	return true, nil
+}
+
+// startedLockspaces returns the volume groups with a started lvmlockd lockspace. The
+// lvmlockctl --info output has a line for each lockspace, such as:
+//
+//	info=ls ls_name=lvm_vg0 vg_name=vg0 vg_uuid=... vg_sysid=... vg_args=... lm_type=sanlock
+func (r *ClientMountReconciler) startedLockspaces(ctx context.Context) (map[string]bool, error) {
+	output, err := r.runLVM(ctx, "lvmlockctl", "--info")
+	if err != nil {
+		return nil, dwsv1alpha1.NewResourceError("Could not list lvmlockd lockspaces: "+output, err)
+	}
+
+	started := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "info=ls" {
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "vg_name=") {
+				started[strings.TrimPrefix(field, "vg_name=")] = true
+			}
+		}
+	}
+
+	return started, nil
+}
+
+// volumeGroupsInUse returns the volume groups used by the other ClientMounts on the node
+// that aren't being deleted
+func (r *ClientMountReconciler) volumeGroupsInUse(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (map[string]bool, error) {
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := r.List(ctx, clientMounts, client.InNamespace(clientMount.Namespace)); err != nil {
+		return nil, err
+	}
+
+	inUse := map[string]bool{}
+	for _, other := range clientMounts.Items {
+		if other.UID == clientMount.UID || !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		for _, mount := range other.Spec.Mounts {
+			if mount.Device.LVM != nil {
+				inUse[mount.Device.LVM.VolumeGroup] = true
+			}
+		}
+	}
+
+	return inUse, nil
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// MockLVM is a fake LVM used in mock mode so the activation logic runs against a
+// deterministic device tree rather than the node's real lvs output. The volume groups and
+// logical volumes are created from the ClientMount specs as they're reconciled. The lvs,
+// vgchange, lvmlockctl, and dmsetup commands change and report the state of the fake volume
+// groups like the real commands do, including the lvmlockd lock that exclusive and shared
+// activations need.
+type MockLVM struct {
+	mu  sync.Mutex
+	vgs map[string]*mockVG
+}
+
+// mockVG is the state of a fake volume group
+type mockVG struct {
+	lvs map[string]bool
+
+	// activation is the vgchange activation option the VG was activated with ("y", "ey",
+	// or "sy"), or empty if the VG isn't active
+	activation string
+
+	// lockStarted is true between a vgchange --lockstart and --lockstop
+	lockStarted bool
+}
+
+// NewMockLVM returns a MockLVM with no volume groups
+func NewMockLVM() *MockLVM {
+	return &MockLVM{vgs: map[string]*mockVG{}}
+}
+
+// add creates the volume group and logical volume of the device if they don't exist
+func (m *MockLVM) add(lvm *dwsv1alpha1.ClientMountDeviceLVM) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vg, found := m.vgs[lvm.VolumeGroup]
+	if !found {
+		vg = &mockVG{lvs: map[string]bool{}}
+		m.vgs[lvm.VolumeGroup] = vg
+	}
+
+	vg.lvs[lvm.LogicalVolume] = true
+}
+
+// handles returns whether the command is one the fake LVM runs
+func (m *MockLVM) handles(command string) bool {
+	return command == "lvs" || command == "vgchange" || command == "lvmlockctl" || command == "dmsetup"
+}
+
+// run runs a fake lvs, vgchange, lvmlockctl, or dmsetup command
+func (m *MockLVM) run(command string, args ...string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch command {
+	case "lvs":
+		return m.lvs(), nil
+	case "lvmlockctl":
+		return m.lockspaces(), nil
+	case "dmsetup":
+		return m.dmsetupTable(args...)
+	}
+
+	if len(args) < 2 {
+		return "", fmt.Errorf("unsupported vgchange arguments %v", args)
+	}
+
+	name := args[len(args)-1]
+	vg, found := m.vgs[name]
+	if !found {
+		return "", fmt.Errorf("Volume group \"%s\" not found", name)
+	}
+
+	switch args[0] {
+	case "--lockstart":
+		vg.lockStarted = true
+	case "--lockstop":
+		if vg.activation != "" {
+			return "", fmt.Errorf("VG %s stop failed: LVs must first be deactivated", name)
+		}
+		vg.lockStarted = false
+	case "--activate":
+		switch option := args[1]; option {
+		case "n":
+			vg.activation = ""
+		case "y":
+			vg.activation = option
+		case "ey", "sy":
+			if !vg.lockStarted {
+				return "", fmt.Errorf("VG %s lock failed: lockspace is not started", name)
+			}
+			vg.activation = option
+		default:
+			return "", fmt.Errorf("unsupported activation option %s", option)
+		}
+	default:
+		return "", fmt.Errorf("unsupported vgchange arguments %v", args)
+	}
+
+	return "", nil
+}
+
+// lvs returns the logical volumes in the format of "lvs --noheadings", sorted so the output
+// is deterministic
+func (m *MockLVM) lvs() string {
+	lines := []string{}
+	for vgName, vg := range m.vgs {
+		attr := "-wi-------"
+		if vg.activation != "" {
+			attr = "-wi-a-----"
+		}
+
+		for lvName := range vg.lvs {
+			lines = append(lines, fmt.Sprintf("  %s %s %s 1.00g", lvName, vgName, attr))
+		}
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// lockspaces returns the started lockspaces in the format of "lvmlockctl --info"
+func (m *MockLVM) lockspaces() string {
+	lines := []string{}
+	for vgName, vg := range m.vgs {
+		if vg.lockStarted {
+			lines = append(lines, fmt.Sprintf("info=ls ls_name=lvm_%s vg_name=%s lm_type=sanlock", vgName, vgName))
+		}
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// dmsetupTable returns the device-mapper table of an active logical volume like
+// "dmsetup table", or fails like it does if the device doesn't exist
+func (m *MockLVM) dmsetupTable(args ...string) (string, error) {
+	if len(args) != 2 || args[0] != "table" {
+		return "", fmt.Errorf("unsupported dmsetup arguments %v", args)
+	}
+
+	for vgName, vg := range m.vgs {
+		if vg.activation == "" {
+			continue
+		}
+
+		for lvName := range vg.lvs {
+			if strings.ReplaceAll(vgName, "-", "--")+"-"+strings.ReplaceAll(lvName, "-", "--") == args[1] {
+				return "0 2097152 linear 8:16 2048\n", nil
+			}
+		}
+	}
+
+	return "Device does not exist.\nCommand failed.\n", fmt.Errorf("exit status 1")
+}
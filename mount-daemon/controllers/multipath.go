@@ -0,0 +1,172 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// multipathMap describes a multipath map reported by multipathd
+type multipathMap struct {
+	name        string
+	wwid        string
+	activePaths int
+}
+
+// getMultipathDevice returns the /dev/mapper path of a multipath device once it has enough
+// active paths. The map is added first if the client manages it.
+func (r *ClientMountReconciler) getMultipathDevice(ctx context.Context, multipath *dwsv1alpha1.ClientMountDeviceMultipath) (string, error) {
+	if multipath == nil || (multipath.WWID == "" && multipath.Alias == "") {
+		return "", dwsv1alpha1.NewResourceError("Missing multipath WWID or alias", nil).WithFatal()
+	}
+
+	if r.mock() {
+		return filepath.Join("/dev/mapper", multipathID(multipath)), nil
+	}
+
+	mpath, err := r.findMultipathMap(ctx, multipath)
+	if err != nil {
+		return "", err
+	}
+
+	if mpath == nil {
+		if !multipath.ManageMap {
+			return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("Multipath map '%s' not found", multipathID(multipath)), nil).WithUserMessage("Client could not find multipath device")
+		}
+
+		output, err := r.run(ctx, "multipathd", "add", "map", multipathID(multipath))
+		if err != nil {
+			return "", dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not add multipath map")
+		}
+
+		// The map was only recorded in dry-run mode, so there are no paths to check
+		if dryRun(ctx) != nil {
+			return filepath.Join("/dev/mapper", multipathID(multipath)), nil
+		}
+
+		mpath, err = r.findMultipathMap(ctx, multipath)
+		if err != nil {
+			return "", err
+		}
+
+		if mpath == nil {
+			return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("Multipath map '%s' not found after adding it", multipathID(multipath)), nil).WithUserMessage("Client could not find multipath device")
+		}
+	}
+
+	minPaths := multipath.MinPaths
+	if minPaths < 1 {
+		minPaths = 1
+	}
+
+	// Paths can take a while to come up after the storage is presented to the client.
+	// The error is recoverable, so the mount is retried.
+	if mpath.activePaths < minPaths {
+		return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("Multipath map '%s' has %d active paths, waiting for %d", mpath.name, mpath.activePaths, minPaths), nil).WithUserMessage("Client is waiting for multipath device paths")
+	}
+
+	return filepath.Join("/dev/mapper", mpath.name), nil
+}
+
+// removeMultipathMap removes the multipath map after unmounting if the client manages it
+func (r *ClientMountReconciler) removeMultipathMap(ctx context.Context, multipath *dwsv1alpha1.ClientMountDeviceMultipath) error {
+	if multipath == nil || !multipath.ManageMap || r.mock() {
+		return nil
+	}
+
+	mpath, err := r.findMultipathMap(ctx, multipath)
+	if err != nil {
+		return err
+	}
+
+	if mpath == nil {
+		return nil
+	}
+
+	output, err := r.run(ctx, "multipathd", "remove", "map", mpath.name)
+	if err != nil {
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not remove multipath map")
+	}
+
+	return nil
+}
+
+// findMultipathMap returns the multipath map matching the WWID or alias, or nil if there's
+// no such map
+func (r *ClientMountReconciler) findMultipathMap(ctx context.Context, multipath *dwsv1alpha1.ClientMountDeviceMultipath) (*multipathMap, error) {
+	output, err := r.run(ctx, "multipathd", "show", "maps", "raw", "format", "%n %w")
+	if err != nil {
+		return nil, dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not list multipath maps")
+	}
+
+	var mpath *multipathMap
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		if (multipath.WWID != "" && fields[1] == multipath.WWID) || (multipath.WWID == "" && fields[0] == multipath.Alias) {
+			mpath = &multipathMap{name: fields[0], wwid: fields[1]}
+			break
+		}
+	}
+
+	if mpath == nil {
+		return nil, nil
+	}
+
+	output, err = r.run(ctx, "multipathd", "show", "paths", "raw", "format", "%w %t")
+	if err != nil {
+		return nil, dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not list multipath paths")
+	}
+
+	mpath.activePaths = countActivePaths(output, mpath.wwid)
+
+	return mpath, nil
+}
+
+// countActivePaths counts the paths of a map that device-mapper reports as active given the
+// "%w %t" (WWID and dm state) output of "multipathd show paths"
+func countActivePaths(output string, wwid string) int {
+	count := 0
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == wwid && fields[1] == "active" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// multipathID returns the identifier used to refer to the multipath map
+func multipathID(multipath *dwsv1alpha1.ClientMountDeviceMultipath) string {
+	if multipath.WWID != "" {
+		return multipath.WWID
+	}
+
+	return multipath.Alias
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+type mountNamespaceKey struct{}
+
+// namespacedCommands are the commands that act on the mount table or the mount target.
+// They're run in the mount namespace of the mount. Device commands (LVM, multipath, mkfs)
+// are always run in the mount namespace of the daemon.
+var namespacedCommands = map[string]bool{
+	"mount":  true,
+	"umount": true,
+	"mkdir":  true,
+	"touch":  true,
+	"chmod":  true,
+	"rmdir":  true,
+	"rm":     true,
+}
+
+// withMountNamespace returns a context that runs the mount commands in the mount namespace.
+// The context is returned unchanged if ns is nil.
+func withMountNamespace(ctx context.Context, ns *dwsv1alpha1.ClientMountNamespace) context.Context {
+	if ns == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, mountNamespaceKey{}, ns)
+}
+
+// mountNamespace returns the mount namespace of the context, or nil for the mount namespace
+// of the daemon
+func mountNamespace(ctx context.Context) *dwsv1alpha1.ClientMountNamespace {
+	ns, _ := ctx.Value(mountNamespaceKey{}).(*dwsv1alpha1.ClientMountNamespace)
+	return ns
+}
+
+// nsenter returns the command and arguments that run a command in the mount namespace of
+// the context. Commands that aren't namespaced are returned unchanged.
+func nsenter(ctx context.Context, name string, args ...string) (string, []string) {
+	ns := mountNamespace(ctx)
+	if ns == nil || !namespacedCommands[name] {
+		return name, args
+	}
+
+	return "nsenter", append([]string{"--mount=" + ns.NamespacePath(), "--", name}, args...)
+}
+
+// setPropagation sets the propagation of the mount point if the mount asks for one
+func (r *ClientMountReconciler) setPropagation(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) error {
+	if clientMountInfo.Propagation == "" {
+		return nil
+	}
+
+	output, err := r.run(ctx, "mount", "--make-"+string(clientMountInfo.Propagation), clientMountInfo.MountPath)
+	if err != nil {
+		return dwsv1alpha1.NewResourceError("Could not set mount propagation: "+output, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// isAutomount returns whether the mount is handed to autofs rather than mounted directly
+func isAutomount(clientMountInfo dwsv1alpha1.ClientMountInfo) bool {
+	return clientMountInfo.Device.Type == dwsv1alpha1.ClientMountDeviceTypeNFS &&
+		clientMountInfo.Device.NFS != nil && clientMountInfo.Device.NFS.Automount
+}
+
+// getNFSOptions returns the NFS specific mount options
+func getNFSOptions(nfs *dwsv1alpha1.ClientMountDeviceNFS) []string {
+	if nfs == nil || nfs.Version == "" {
+		return nil
+	}
+
+	return []string{"vers=" + nfs.Version}
+}
+
+// automountFiles returns the paths of the master map fragment and the direct map for a
+// mount path. The master map fragment is read by autofs from the auto.master.d directory
+// and points at the direct map, which holds the single entry for the mount.
+func (r *ClientMountReconciler) automountFiles(mountPath string) (string, string) {
+	h := fnv.New32a()
+	h.Write([]byte(mountPath))
+	name := fmt.Sprintf("dws-%08x", h.Sum32())
+
+	return filepath.Join(r.AutofsMapDir, name+".autofs"), filepath.Join(r.AutofsMapDir, name+".map")
+}
+
+// writeAutomount writes the autofs map entry for an NFS mount and reloads autofs. The
+// export is mounted by autofs when the mount path is first accessed.
+func (r *ClientMountReconciler) writeAutomount(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, log logr.Logger) error {
+	if r.AutofsMapDir == "" {
+		return dwsv1alpha1.NewResourceError("NFS automount requested without an autofs map directory", nil).WithUserMessage("Automount is not configured on the compute node").WithFatal()
+	}
+
+	if clientMountInfo.MountNamespace != nil {
+		return dwsv1alpha1.NewResourceError("NFS automount is not supported in a mount namespace", nil).WithUserMessage("Automount is not supported in a mount namespace").WithFatal()
+	}
+
+	masterFile, mapFile := r.automountFiles(clientMountInfo.MountPath)
+
+	options := "-fstype=nfs"
+	if mountOptions := getMountOptions(clientMountInfo); mountOptions != "" {
+		options += "," + mountOptions
+	}
+
+	entry := fmt.Sprintf("%s %s %s\n", clientMountInfo.MountPath, options, clientMountInfo.Device.NFS.Device())
+	recordPlan(ctx, clientMountInfo.Device.NFS.Device(), options)
+
+	if err := r.writeFile(ctx, mapFile, entry); err != nil {
+		return dwsv1alpha1.NewResourceError("Could not write the autofs map "+mapFile, err)
+	}
+
+	if err := r.writeFile(ctx, masterFile, fmt.Sprintf("/- %s\n", mapFile)); err != nil {
+		return dwsv1alpha1.NewResourceError("Could not write the autofs master map fragment "+masterFile, err)
+	}
+
+	if output, err := r.run(ctx, "systemctl", "reload", "autofs"); err != nil {
+		return dwsv1alpha1.NewResourceError("Could not reload autofs: "+output, err)
+	}
+
+	log.Info("Wrote automount entry", "mountPath", clientMountInfo.MountPath, "map", mapFile)
+	return nil
+}
+
+// removeAutomount removes the autofs map entry for an NFS mount and reloads autofs so it
+// drops the trigger at the mount path
+func (r *ClientMountReconciler) removeAutomount(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) error {
+	if r.AutofsMapDir == "" {
+		return nil
+	}
+
+	masterFile, mapFile := r.automountFiles(clientMountInfo.MountPath)
+	for _, file := range []string{masterFile, mapFile} {
+		if err := r.removeFile(ctx, file); err != nil && !os.IsNotExist(err) {
+			return dwsv1alpha1.NewResourceError("Could not remove the autofs map "+file, err)
+		}
+	}
+
+	if output, err := r.run(ctx, "systemctl", "reload", "autofs"); err != nil {
+		return dwsv1alpha1.NewResourceError("Could not reload autofs: "+output, err)
+	}
+
+	return nil
+}
+
+// writeFile writes a file on the node
+func (r *ClientMountReconciler) writeFile(ctx context.Context, path string, contents string) error {
+	if record(ctx, "write", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Write file", "path", path, "contents", contents)
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(contents), 0644)
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/HewlettPackard/dws/utils/command"
+)
+
+// NodeControllerConfig is what the daemon shares with its node controllers
+type NodeControllerConfig struct {
+	// NodeName is the name of the node and the namespace of its ClientMounts
+	NodeName string
+
+	// Runner runs commands on the node
+	Runner command.Runner
+
+	// APIReader reads resources outside the node's namespace, which the manager's cache
+	// doesn't hold
+	APIReader client.Reader
+
+	// Settings are the daemon's runtime settings, which may be reloaded
+	Settings *RuntimeSettings
+
+	// ReportInterval is the interval between the reports of the controllers that report
+	// on the node periodically
+	ReportInterval time.Duration
+
+	// ListPageSize is the number of resources read at a time by the lists that bypass the
+	// cache. Not paged if 0.
+	ListPageSize int64
+
+	Log logr.Logger
+}
+
+// NodeController is a controller that runs in the daemon alongside the ClientMount
+// reconciler, sharing its manager and credentials. It lets node-resident functionality be
+// added without another daemon and service token.
+type NodeController interface {
+	SetupWithManager(mgr ctrl.Manager) error
+}
+
+// NodeControllerFactory builds a node controller
+type NodeControllerFactory func(config NodeControllerConfig) (NodeController, error)
+
+var (
+	nodeControllersLock sync.Mutex
+	nodeControllers     = map[string]NodeControllerFactory{}
+)
+
+// RegisterNodeController makes a node controller available to be enabled by name. It's
+// called from an init function, so a controller can be left out of a build with a build tag
+// on its file.
+func RegisterNodeController(name string, factory NodeControllerFactory) {
+	nodeControllersLock.Lock()
+	defer nodeControllersLock.Unlock()
+
+	if _, found := nodeControllers[name]; found {
+		panic(fmt.Sprintf("node controller '%s' is already registered", name))
+	}
+
+	nodeControllers[name] = factory
+}
+
+// NodeControllers returns the names of the registered node controllers in order
+func NodeControllers() []string {
+	nodeControllersLock.Lock()
+	defer nodeControllersLock.Unlock()
+
+	names := make([]string, 0, len(nodeControllers))
+	for name := range nodeControllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// SetupNodeControllers builds the named node controllers and adds them to the manager. The
+// config's Log is named for each controller.
+func SetupNodeControllers(mgr ctrl.Manager, names []string, config NodeControllerConfig) error {
+	log := config.Log
+	for _, name := range names {
+		nodeControllersLock.Lock()
+		factory, found := nodeControllers[name]
+		nodeControllersLock.Unlock()
+
+		if !found {
+			return fmt.Errorf("unknown node controller '%s', the controllers in this build are %v", name, NodeControllers())
+		}
+
+		config.Log = log.WithName(name)
+		controller, err := factory(config)
+		if err != nil {
+			return fmt.Errorf("could not create node controller '%s': %w", name, err)
+		}
+
+		if err := controller.SetupWithManager(mgr); err != nil {
+			return fmt.Errorf("could not set up node controller '%s': %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=storages,verbs=get;list;update
+
+const (
+	kernelReleasePath = "/proc/sys/kernel/osrelease"
+	lustreVersionPath = "/sys/module/lustre/version"
+	procModulesPath   = "/proc/modules"
+)
+
+// nodeInfoReporter returns a runnable that reports the node's OS information in the compute
+// access list of every Storage resource the node is attached to. The information is reported
+// when the daemon starts and then every interval.
+func (r *ClientMountReconciler) nodeInfoReporter(node string, interval time.Duration) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		for {
+			if err := r.reportNodeInfo(ctx, node); err != nil {
+				// The ClientMounts can still be managed, so the report failing isn't fatal
+				r.Log.Error(err, "Could not report node information", "node", node)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	}
+}
+
+// reportNodeInfo updates the OS information for the node in the Storage resources that list
+// it as a compute. Storage resources are updated only if the information changed.
+func (r *ClientMountReconciler) reportNodeInfo(ctx context.Context, node string) error {
+	info, err := r.getNodeOSInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	return dwsv1alpha1.Storages(r.APIReader).ListPages(ctx, r.ListPageSize, func(storages []dwsv1alpha1.Storage) error {
+		for _, storage := range storages {
+			key := client.ObjectKeyFromObject(&storage)
+			if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+				storage := &dwsv1alpha1.Storage{}
+				if err := r.APIReader.Get(ctx, key, storage); err != nil {
+					return client.IgnoreNotFound(err)
+				}
+
+				if !setNodeOSInfo(storage.Data.Access.Computes, node, info) {
+					return nil
+				}
+
+				return r.Update(ctx, storage)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// setNodeOSInfo sets the OS information of the node in the list of computes. It returns true
+// if the node was found and its information changed.
+func setNodeOSInfo(computes []dwsv1alpha1.Node, node string, info dwsv1alpha1.NodeOSInfo) bool {
+	for i := range computes {
+		if computes[i].Name != node {
+			continue
+		}
+
+		// Compare the information without the report time so the Storage resource isn't
+		// updated every interval
+		if current := computes[i].OSInfo; current != nil {
+			previous := *current
+			previous.LastReported = info.LastReported
+			if reflect.DeepEqual(previous, info) {
+				return false
+			}
+		}
+
+		computes[i].OSInfo = info.DeepCopy()
+		return true
+	}
+
+	return false
+}
+
+// getNodeOSInfo collects the kernel, Lustre, and LVM versions and the loaded file system
+// kernel modules. Software that isn't installed is left empty.
+func (r *ClientMountReconciler) getNodeOSInfo(ctx context.Context) (dwsv1alpha1.NodeOSInfo, error) {
+	now := metav1.Now()
+	info := dwsv1alpha1.NodeOSInfo{LastReported: &now}
+
+	release, err := os.ReadFile(kernelReleasePath)
+	if err != nil {
+		return info, err
+	}
+	info.KernelVersion = strings.TrimSpace(string(release))
+
+	if version, err := os.ReadFile(lustreVersionPath); err == nil {
+		info.LustreVersion = strings.TrimSpace(string(version))
+	}
+
+	// The first line of "lvm version" is of the form:
+	//   LVM version:     2.03.14(2)-RHEL8 (2021-10-20)
+	if output, err := r.run(ctx, "lvm", "version"); err == nil {
+		line, _, _ := strings.Cut(output, "\n")
+		if _, version, found := strings.Cut(line, ":"); found {
+			info.LVMVersion = strings.TrimSpace(version)
+		}
+	}
+
+	modules, err := os.Open(procModulesPath)
+	if err != nil {
+		return info, err
+	}
+	defer modules.Close()
+
+	wanted := map[string]bool{}
+	for _, required := range dwsv1alpha1.FileSystemKernelModules {
+		for _, module := range required {
+			wanted[module] = true
+		}
+	}
+
+	scanner := bufio.NewScanner(modules)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 0 && wanted[fields[0]] {
+			info.KernelModules = append(info.KernelModules, fields[0])
+		}
+	}
+
+	sort.Strings(info.KernelModules)
+
+	return info, scanner.Err()
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// NodeStatusReport is the node status file written by the daemon. Node health frameworks
+// (e.g., a Slurm HealthCheckProgram) read it to check the daemon without Kubernetes access.
+type NodeStatusReport struct {
+	// Node is the name of the node
+	Node string `json:"node"`
+
+	// Healthy is false if any ClientMount has an error
+	Healthy bool `json:"healthy"`
+
+	// ClientMounts is the number of ClientMounts for the node
+	ClientMounts int `json:"clientMounts"`
+
+	// Mounts is the number of mounts described by the ClientMounts
+	Mounts int `json:"mounts"`
+
+	// ReadyMounts is the number of mounts that have reached their desired state
+	ReadyMounts int `json:"readyMounts"`
+
+	// Failures lists the ClientMounts that have an error
+	Failures []NodeStatusFailure `json:"failures"`
+
+	// LastReconcile is the time a ClientMount was last reconciled
+	LastReconcile *time.Time `json:"lastReconcile,omitempty"`
+
+	// UpdateTime is the time the file was written
+	UpdateTime time.Time `json:"updateTime"`
+}
+
+// NodeStatusFailure is a ClientMount with an error
+type NodeStatusFailure struct {
+	// ClientMount is the namespace/name of the ClientMount
+	ClientMount string `json:"clientMount"`
+
+	// Error is the debug message of the ClientMount's error
+	Error string `json:"error"`
+}
+
+type nodeClientMountStatus struct {
+	mounts int
+	ready  int
+	err    string
+}
+
+// NodeStatus keeps a summary of the ClientMounts reconciled by the daemon and writes it to a
+// file as JSON after each reconcile. The file is replaced atomically, so a reader never sees
+// a partial file. A nil NodeStatus does nothing.
+type NodeStatus struct {
+	path string
+	node string
+
+	mu            sync.Mutex
+	clientMounts  map[string]nodeClientMountStatus
+	lastReconcile *time.Time
+}
+
+// NewNodeStatus returns a NodeStatus that writes the status of the node to path
+func NewNodeStatus(path string, node string) *NodeStatus {
+	return &NodeStatus{
+		path:         path,
+		node:         node,
+		clientMounts: map[string]nodeClientMountStatus{},
+	}
+}
+
+// Record updates the summary with the status of a ClientMount after a reconcile, and writes
+// the status file
+func (s *NodeStatus) Record(key string, clientMount *dwsv1alpha1.ClientMount) error {
+	if s == nil {
+		return nil
+	}
+
+	status := nodeClientMountStatus{mounts: len(clientMount.Spec.Mounts)}
+	for _, mount := range clientMount.Status.Mounts {
+		if mount.Ready {
+			status.ready++
+		}
+	}
+
+	if clientMount.Status.Error != nil {
+		status.err = clientMount.Status.Error.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clientMounts[key] = status
+	return s.write()
+}
+
+// Remove drops a ClientMount that no longer exists from the summary, and writes the status file
+func (s *NodeStatus) Remove(key string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.clientMounts, key)
+	return s.write()
+}
+
+// report builds the status report. The lock must be held.
+func (s *NodeStatus) report(now time.Time) NodeStatusReport {
+	report := NodeStatusReport{
+		Node:          s.node,
+		Healthy:       true,
+		ClientMounts:  len(s.clientMounts),
+		Failures:      []NodeStatusFailure{},
+		LastReconcile: s.lastReconcile,
+		UpdateTime:    now,
+	}
+
+	for key, status := range s.clientMounts {
+		report.Mounts += status.mounts
+		report.ReadyMounts += status.ready
+
+		if status.err != "" {
+			report.Healthy = false
+			report.Failures = append(report.Failures, NodeStatusFailure{ClientMount: key, Error: status.err})
+		}
+	}
+
+	sort.Slice(report.Failures, func(i, j int) bool { return report.Failures[i].ClientMount < report.Failures[j].ClientMount })
+
+	return report
+}
+
+// write replaces the status file with the current report. The lock must be held.
+func (s *NodeStatus) write() error {
+	now := time.Now().UTC()
+	s.lastReconcile = &now
+
+	data, err := json.MarshalIndent(s.report(now), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(s.path, append(data, '\n'))
+}
+
+// replaceFile atomically replaces the file at path with data, so a reader never sees a
+// partial file
+func replaceFile(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// orphanScanner returns a runnable that unmounts the orphaned mounts once the cache has
+// synced. A ClientMount that's deleted while the daemon isn't running loses its finalizer
+// only if it's forced, so the mounts it described are left behind on the node.
+func (r *ClientMountReconciler) orphanScanner(informers cache.Cache) manager.RunnableFunc {
+	return func(ctx context.Context) error {
+		if !informers.WaitForCacheSync(ctx) {
+			return nil
+		}
+
+		ctx = withAuditClientMount(ctx, "orphan-scan")
+		if err := r.unmountOrphans(ctx); err != nil {
+			// The daemon can still manage the ClientMounts, so the scan failing isn't fatal
+			r.Log.Error(err, "Could not unmount orphaned mounts", "mountRoot", r.OrphanMountRoot)
+		}
+
+		return nil
+	}
+}
+
+// unmountOrphans unmounts the file systems mounted under the orphan mount root that don't
+// belong to any ClientMount in the node's namespace
+func (r *ClientMountReconciler) unmountOrphans(ctx context.Context) error {
+	owned, err := r.ownedMountPaths(ctx)
+	if err != nil {
+		return err
+	}
+
+	mountPaths, err := r.listMounts(ctx)
+	if err != nil {
+		return err
+	}
+
+	orphans := []string{}
+	root := filepath.Clean(r.OrphanMountRoot)
+	for _, mountPath := range mountPaths {
+		if !strings.HasPrefix(mountPath, root+string(filepath.Separator)) || owned[mountPath] {
+			continue
+		}
+
+		orphans = append(orphans, mountPath)
+	}
+
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	// The reconciler runs while the scan does, so a ClientMount created since the mount
+	// paths were collected may have mounted one of the paths
+	owned, err = r.ownedMountPaths(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Unmount nested mounts before the mounts they're under
+	sort.Slice(orphans, func(i, j int) bool { return len(orphans[i]) > len(orphans[j]) })
+
+	var firstError error
+	for _, mountPath := range orphans {
+		if owned[mountPath] {
+			continue
+		}
+
+		r.Log.Info("Unmounting orphaned mount", "mountPath", mountPath)
+
+		output, err := r.run(ctx, "umount", mountPath)
+		if err != nil {
+			r.Log.Info("Could not unmount orphaned mount", "mountPath", mountPath, "output", output)
+			if firstError == nil {
+				firstError = fmt.Errorf("could not unmount orphaned mount '%s': %w", mountPath, err)
+			}
+		}
+	}
+
+	return firstError
+}
+
+// ownedMountPaths returns the set of mount paths described by the ClientMounts in the
+// node's namespace
+func (r *ClientMountReconciler) ownedMountPaths(ctx context.Context) (map[string]bool, error) {
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := r.List(ctx, clientMounts); err != nil {
+		return nil, err
+	}
+
+	owned := map[string]bool{}
+	for _, clientMount := range clientMounts.Items {
+		for _, mount := range clientMount.Spec.Mounts {
+			owned[filepath.Clean(mount.MountPath)] = true
+		}
+	}
+
+	return owned, nil
+}
+
+// listMounts returns the mount points in the node's mount table
+func (r *ClientMountReconciler) listMounts(ctx context.Context) ([]string, error) {
+	output, err := r.run(ctx, "mount")
+	if err != nil {
+		return nil, dwsv1alpha1.NewResourceError(output, err)
+	}
+
+	mountPaths := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		// [device] on [mount path] type [type] ([options])
+		fields := strings.Fields(line)
+		if len(fields) >= 3 {
+			mountPaths = append(mountPaths, filepath.Clean(fields[2]))
+		}
+	}
+
+	return mountPaths, nil
+}
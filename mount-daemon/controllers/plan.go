@@ -0,0 +1,45 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+type planKey struct{}
+
+// withPlan returns a context that records how the mount is carried out in the plan
+func withPlan(ctx context.Context, plan *dwsv1alpha1.ClientMountPlan) context.Context {
+	return context.WithValue(ctx, planKey{}, plan)
+}
+
+// recordPlan sets the resolved device and options in the plan of the mount the context is
+// for. The values from the Secret of the mount are replaced in the options.
+func recordPlan(ctx context.Context, device string, options string) {
+	plan, _ := ctx.Value(planKey{}).(*dwsv1alpha1.ClientMountPlan)
+	if plan == nil {
+		return
+	}
+
+	plan.Device = device
+	plan.Options = redactSecrets(ctx, []string{options})[0]
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// MountProber checks that mounted file systems respond. A file system that has lost its
+// servers (e.g., Lustre or NFS) stays in the mount table, but statfs on it fails or hangs.
+// A hung statfs can't be interrupted, so the probe is abandoned after the timeout and the
+// path isn't probed again until the earlier statfs returns.
+type MountProber struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]bool
+}
+
+// NewMountProber returns a MountProber that waits up to timeout for statfs to return
+func NewMountProber(timeout time.Duration) *MountProber {
+	return &MountProber{
+		timeout: timeout,
+		pending: map[string]bool{},
+	}
+}
+
+// probe runs statfs on the path and returns the result. It returns an error if statfs fails
+// or doesn't return within the timeout.
+func (p *MountProber) probe(path string) (*syscall.Statfs_t, error) {
+	p.mu.Lock()
+	if p.pending[path] {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("an earlier statfs of '%s' has not returned", path)
+	}
+	p.pending[path] = true
+	p.mu.Unlock()
+
+	type result struct {
+		stat *syscall.Statfs_t
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		stat := &syscall.Statfs_t{}
+		err := syscall.Statfs(path, stat)
+
+		p.mu.Lock()
+		delete(p.pending, path)
+		p.mu.Unlock()
+
+		done <- result{stat, err}
+	}()
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	select {
+	case result := <-done:
+		if result.err != nil {
+			return nil, fmt.Errorf("statfs of '%s' failed: %w", path, result.err)
+		}
+		return result.stat, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("statfs of '%s' did not return within %s", path, p.timeout)
+	}
+}
+
+// probeMount checks that a mounted file system responds and returns its usage. Swap, mounts
+// in another mount namespace, and mounts in mock and dry-run mode aren't probed, and have no
+// usage.
+func (r *ClientMountReconciler) probeMount(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) (*dwsv1alpha1.ClientMountUsage, error) {
+	if r.Prober == nil || r.mock() || dryRun(ctx) != nil {
+		return nil, nil
+	}
+
+	if clientMountInfo.Type.IsSwap() || clientMountInfo.MountNamespace != nil || isAutomount(clientMountInfo) {
+		return nil, nil
+	}
+
+	stat, err := r.Prober.probe(clientMountInfo.MountPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return usageFromStatfs(stat), nil
+}
+
+// usageFromStatfs converts the statfs result for a file system to its usage
+func usageFromStatfs(stat *syscall.Statfs_t) *dwsv1alpha1.ClientMountUsage {
+	blockSize := int64(stat.Bsize)
+
+	return &dwsv1alpha1.ClientMountUsage{
+		TotalBytes:     int64(stat.Blocks) * blockSize,
+		UsedBytes:      int64(stat.Blocks-stat.Bfree) * blockSize,
+		AvailableBytes: int64(stat.Bavail) * blockSize,
+		TotalInodes:    int64(stat.Files),
+		FreeInodes:     int64(stat.Ffree),
+		LastReported:   metav1.Now(),
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=dws.cray.hpe.com,resources=clientmountprofiles,verbs=get
+
+type profileKey struct{}
+
+// withProfile returns a context with the settings of the mount's ClientMountProfile and a
+// copy of the mount with the options of the profile added. A mount without a profile is
+// returned as is. A missing profile fails a mount so the file system isn't mounted without
+// the site's settings, but an unmount goes ahead with the client's settings so a deleted
+// profile doesn't leave the file system mounted.
+func (r *ClientMountReconciler) withProfile(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, mounting bool, log logr.Logger) (context.Context, dwsv1alpha1.ClientMountInfo, error) {
+	if clientMountInfo.Profile == "" {
+		return ctx, clientMountInfo, nil
+	}
+
+	profile, err := r.getProfile(ctx, clientMountInfo.Profile)
+	if err != nil {
+		if mounting {
+			return ctx, clientMountInfo, err
+		}
+
+		log.Info("Unmounting without the mount profile", "mountPath", clientMountInfo.MountPath, "error", err.Error())
+		return ctx, clientMountInfo, nil
+	}
+
+	return context.WithValue(ctx, profileKey{}, &profile.Spec), profile.Apply(clientMountInfo), nil
+}
+
+// mountProfile returns the settings of the ClientMountProfile of the mount the context is
+// for, or nil if the mount doesn't have a profile
+func mountProfile(ctx context.Context) *dwsv1alpha1.ClientMountProfileSpec {
+	profile, _ := ctx.Value(profileKey{}).(*dwsv1alpha1.ClientMountProfileSpec)
+	return profile
+}
+
+// getProfile reads a ClientMountProfile from the API server. Profiles aren't cached since a
+// node only reads the few its mounts use.
+func (r *ClientMountReconciler) getProfile(ctx context.Context, name string) (*dwsv1alpha1.ClientMountProfile, error) {
+	if r.APIReader == nil {
+		return nil, dwsv1alpha1.NewResourceError("Mount profiles can't be read without the API server", nil).WithUserMessage("mount profile '" + name + "' is not available on the client").WithFatal()
+	}
+
+	profile := &dwsv1alpha1.ClientMountProfile{}
+	if err := r.APIReader.Get(ctx, client.ObjectKey{Name: name}, profile); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, dwsv1alpha1.NewResourceError("ClientMountProfile "+name+" not found", err).WithUserMessage("mount profile '" + name + "' not found")
+		}
+
+		return nil, dwsv1alpha1.NewResourceError("Could not get ClientMountProfile "+name, err)
+	}
+
+	return profile, nil
+}
+
+// retryDelay returns the delay before retrying a ClientMount that failed. It's the longest
+// of the client's retry delay and the retry delays of the profiles the mounts use.
+func (r *ClientMountReconciler) retryDelay(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) time.Duration {
+	delay := r.Settings.Get().RetryDelay
+
+	names := map[string]bool{}
+	for _, mount := range clientMount.Spec.Mounts {
+		if mount.Profile == "" || names[mount.Profile] {
+			continue
+		}
+		names[mount.Profile] = true
+
+		profile, err := r.getProfile(ctx, mount.Profile)
+		if err != nil {
+			continue
+		}
+
+		if profile.Spec.RetryDelay != nil && profile.Spec.RetryDelay.Duration > delay {
+			delay = profile.Spec.RetryDelay.Duration
+		}
+	}
+
+	return delay
+}
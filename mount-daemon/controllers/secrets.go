@@ -0,0 +1,207 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// mountSecret is the Secret of the mount a context is for
+type mountSecret struct {
+	ref  *dwsv1alpha1.ClientMountSecretRef
+	data map[string][]byte
+}
+
+type secretKey struct{}
+
+// withSecret returns a context with the Secret named by the SecretRef of the mount. The
+// Secret is read from the namespace of the ClientMount each time the mount is attempted, so
+// a rotated credential is used by the next mount. The values never leave the context other
+// than in the mount command and the credential files.
+func (r *ClientMountReconciler) withSecret(ctx context.Context, namespace string, clientMountInfo dwsv1alpha1.ClientMountInfo) (context.Context, error) {
+	ref := clientMountInfo.SecretRef
+	if ref == nil {
+		return ctx, nil
+	}
+
+	if r.APIReader == nil {
+		return ctx, dwsv1alpha1.NewResourceError("Secrets can't be read without the API server", nil).WithUserMessage("mount credentials are not available on the client").WithFatal()
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.APIReader.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctx, dwsv1alpha1.NewResourceError("Secret "+namespace+"/"+ref.Name+" not found", err).WithUserMessage("mount credentials '" + ref.Name + "' not found")
+		}
+
+		return ctx, dwsv1alpha1.NewResourceError("Could not get Secret "+namespace+"/"+ref.Name, err)
+	}
+
+	for _, option := range ref.Options {
+		if _, found := secret.Data[option.Key]; !found {
+			return ctx, dwsv1alpha1.NewResourceError(fmt.Sprintf("Secret %s/%s has no key '%s'", namespace, ref.Name, option.Key), nil).WithUserMessage("mount credentials '" + ref.Name + "' are incomplete")
+		}
+
+		if option.File && clientMountInfo.MountNamespace != nil {
+			return ctx, dwsv1alpha1.NewResourceError("Credential files can't be used with a mount namespace", nil).WithUserMessage("mount credentials '" + ref.Name + "' can't be written in the mount namespace").WithFatal()
+		}
+	}
+
+	return context.WithValue(ctx, secretKey{}, &mountSecret{ref: ref, data: secret.Data}), nil
+}
+
+// secretOptions returns the mount options set from the Secret of the mount. The values of the
+// file options are written to credential files first.
+func (r *ClientMountReconciler) secretOptions(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) ([]string, error) {
+	secret, _ := ctx.Value(secretKey{}).(*mountSecret)
+	if secret == nil {
+		return nil, nil
+	}
+
+	options := []string{}
+	for _, option := range secret.ref.Options {
+		value := secret.data[option.Key]
+		if !option.File {
+			options = append(options, option.Option+"="+string(value))
+			continue
+		}
+
+		path := r.secretFilePath(ctx, clientMountInfo, option.Option)
+		if err := r.writeSecretFile(ctx, path, value); err != nil {
+			return nil, dwsv1alpha1.NewResourceError("Could not write credential file for option "+option.Option, err).WithUserMessage("could not write mount credentials")
+		}
+
+		options = append(options, option.Option+"="+path)
+	}
+
+	return options, nil
+}
+
+// removeSecretFiles removes the credential files of a mount once it's unmounted
+func (r *ClientMountReconciler) removeSecretFiles(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo) error {
+	if clientMountInfo.SecretRef == nil {
+		return nil
+	}
+
+	for _, option := range clientMountInfo.SecretRef.Options {
+		if !option.File {
+			continue
+		}
+
+		path := r.secretFilePath(ctx, clientMountInfo, option.Option)
+		if record(ctx, "rm", "-f", path) {
+			continue
+		}
+
+		if r.mock() {
+			r.Log.Info("Remove credential file", "path", path)
+			continue
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// secretFilePath returns the path of the credential file for an option of a mount. The name
+// is a hash of the ClientMount, the mount path, and the option, so it doesn't reveal anything
+// about the mount and stays the same until the mount is removed.
+func (r *ClientMountReconciler) secretFilePath(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, option string) string {
+	hash := fnv.New64a()
+	hash.Write([]byte(auditClientMount(ctx) + "\x00" + clientMountInfo.MountPath + "\x00" + option))
+
+	return filepath.Join(r.SecretDir, fmt.Sprintf("%016x", hash.Sum64()))
+}
+
+// writeSecretFile writes a credential file that only root can read. Only the path is
+// recorded in dry-run mode and logged in mock mode.
+func (r *ClientMountReconciler) writeSecretFile(ctx context.Context, path string, value []byte) error {
+	if record(ctx, "write", path) {
+		return nil
+	}
+
+	if r.mock() {
+		r.Log.Info("Write credential file", "path", path)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	// The mode of an existing file isn't changed by the open
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Write(value); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// redactSecrets replaces the values from the Secret of the mount in the command arguments,
+// so they aren't written to the log, the audit log, the dry-run plan, or the in-flight
+// operations
+func redactSecrets(ctx context.Context, args []string) []string {
+	secret, _ := ctx.Value(secretKey{}).(*mountSecret)
+	if secret == nil {
+		return args
+	}
+
+	redactedArgs := make([]string, len(args))
+	copy(redactedArgs, args)
+	for _, option := range secret.ref.Options {
+		value := string(secret.data[option.Key])
+		if option.File || value == "" {
+			continue
+		}
+
+		for i := range redactedArgs {
+			redactedArgs[i] = strings.ReplaceAll(redactedArgs[i], value, redacted)
+		}
+	}
+
+	return redactedArgs
+}
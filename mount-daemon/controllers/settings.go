@@ -0,0 +1,75 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"sync"
+	"time"
+)
+
+// Settings are the reconciler options that can be changed while the daemon is running
+type Settings struct {
+	// Mock skips all the client mount operations
+	Mock bool
+
+	// RetryDelay is the delay before retrying a mount or unmount that failed
+	RetryDelay time.Duration
+
+	// CommandTimeout is the time a host command may run before it's killed. Zero
+	// means no timeout.
+	CommandTimeout time.Duration
+}
+
+// DefaultSettings are the settings used when none are given
+var DefaultSettings = Settings{
+	RetryDelay: 10 * time.Second,
+}
+
+// RuntimeSettings holds the current Settings. The settings can be replaced at any time
+// without restarting the daemon.
+type RuntimeSettings struct {
+	mu       sync.RWMutex
+	settings Settings
+}
+
+// NewRuntimeSettings returns a RuntimeSettings holding the initial settings
+func NewRuntimeSettings(settings Settings) *RuntimeSettings {
+	return &RuntimeSettings{settings: settings}
+}
+
+// Get returns the current settings
+func (s *RuntimeSettings) Get() Settings {
+	if s == nil {
+		return DefaultSettings
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.settings
+}
+
+// Set replaces the current settings
+func (s *RuntimeSettings) Set(settings Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings = settings
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// The reconcile context is canceled as soon as the daemon is asked to shut down. Killing a
+// mount or unmount command part way through can leave the node in a state that's hard to
+// recover from, so the host commands and the final status update run under contexts that
+// outlive the reconcile context by the shutdown grace period.
+
+// statusUpdateTimeout bounds the final status update made after the daemon is asked to
+// shut down
+const statusUpdateTimeout = 10 * time.Second
+
+// commandContext returns the context a host command runs under. It's canceled once the
+// shutdown grace period has passed after ctx is done, or when the returned cancel function
+// is called.
+func (r *ClientMountReconciler) commandContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	commandCtx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case <-commandCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+
+		timer := time.NewTimer(r.ShutdownGracePeriod)
+		defer timer.Stop()
+
+		select {
+		case <-commandCtx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+
+	return commandCtx, cancel
+}
+
+// statusContext returns the context used to write the status at the end of a reconcile.
+// The status is still written when the daemon is shutting down.
+func statusContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(context.Background(), statusUpdateTimeout)
+}
+
+// checkShutdown returns an error if the daemon is shutting down so no new mount or unmount
+// is started. The operation is retried when the daemon restarts.
+func checkShutdown(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return dwsv1alpha1.NewResourceError("Client mount daemon is shutting down", nil)
+	}
+
+	return nil
+}
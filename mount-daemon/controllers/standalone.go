@@ -0,0 +1,325 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// standaloneReconcileLimit is the number of times a ClientMount is reconciled in a row while
+// each reconcile changes it. The watch that would trigger the next reconcile in a cluster
+// doesn't exist in standalone mode.
+const standaloneReconcileLimit = 10
+
+// FileClient is a client for ClientMounts described by YAML files, for running the daemon
+// without a Kubernetes API (e.g., to validate compute node hardware before the management
+// cluster exists). Each file in the spec directory named [name].yaml holds a ClientMount.
+// The ClientMount, with its finalizers and status, is written to [name].yaml in the status
+// directory after each change. A ClientMount whose spec file is removed is deleted, and
+// its status file is removed once the daemon removes its finalizer.
+//
+// Only the methods used by ClientMountReconciler are implemented.
+type FileClient struct {
+	client.Client
+
+	specDir   string
+	statusDir string
+	namespace string
+	scheme    *runtime.Scheme
+
+	mutex   sync.Mutex
+	changed bool
+}
+
+// NewFileClient returns a client for the ClientMounts in specDir. The ClientMounts are put
+// in namespace, which is the node's name.
+func NewFileClient(specDir string, statusDir string, namespace string, scheme *runtime.Scheme) *FileClient {
+	return &FileClient{specDir: specDir, statusDir: statusDir, namespace: namespace, scheme: scheme}
+}
+
+func (c *FileClient) Scheme() *runtime.Scheme { return c.scheme }
+
+func (c *FileClient) Status() client.StatusWriter { return &fileStatusWriter{c: c} }
+
+// Get returns the ClientMount in the spec file with the status and finalizers from its
+// status file
+func (c *FileClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	clientMount, ok := obj.(*dwsv1alpha1.ClientMount)
+	if !ok {
+		return errors.New("standalone mode only supports ClientMount resources")
+	}
+
+	current, err := c.read(key.Name)
+	if err != nil {
+		return err
+	}
+
+	*clientMount = *current
+	return nil
+}
+
+// List returns the ClientMounts with a spec or status file
+func (c *FileClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	clientMounts, ok := list.(*dwsv1alpha1.ClientMountList)
+	if !ok {
+		return errors.New("standalone mode only supports ClientMount resources")
+	}
+
+	names, err := c.names()
+	if err != nil {
+		return err
+	}
+
+	clientMounts.Items = []dwsv1alpha1.ClientMount{}
+	for _, name := range names {
+		clientMount, err := c.read(name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return err
+		}
+
+		clientMounts.Items = append(clientMounts.Items, *clientMount)
+	}
+
+	return nil
+}
+
+// Update records the finalizers of the ClientMount. The rest of the spec comes from the spec
+// file and isn't changed.
+func (c *FileClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current, err := c.read(obj.GetName())
+	if err != nil {
+		return err
+	}
+
+	current.Finalizers = obj.GetFinalizers()
+	return c.write(current)
+}
+
+type fileStatusWriter struct {
+	client.StatusWriter
+	c *FileClient
+}
+
+// Patch records the status applied by the status updater
+func (w *fileStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.c.mutex.Lock()
+	defer w.c.mutex.Unlock()
+
+	applied, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return errors.New("standalone mode only supports applying the status")
+	}
+
+	current, err := w.c.read(obj.GetName())
+	if err != nil {
+		return err
+	}
+
+	status, _, _ := unstructured.NestedMap(applied.Object, "status")
+	current.Status = dwsv1alpha1.ClientMountStatus{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(status, &current.Status); err != nil {
+		return err
+	}
+
+	return w.c.write(current)
+}
+
+// Changed returns whether a ClientMount was written since the last call
+func (c *FileClient) Changed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	changed := c.changed
+	c.changed = false
+
+	return changed
+}
+
+// names returns the names of the ClientMounts with a spec or status file. The lock must be
+// held.
+func (c *FileClient) names() ([]string, error) {
+	unique := map[string]bool{}
+	for _, dir := range []string{c.specDir, c.statusDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && strings.HasSuffix(name, ".yaml") && !strings.HasPrefix(name, ".") {
+				unique[strings.TrimSuffix(name, ".yaml")] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// read returns the ClientMount from its spec file, with the finalizers and status from its
+// status file. A ClientMount with a status file but no spec file is being deleted. The lock
+// must be held.
+func (c *FileClient) read(name string) (*dwsv1alpha1.ClientMount, error) {
+	notFound := apierrors.NewNotFound(dwsv1alpha1.GroupVersion.WithResource("clientmounts").GroupResource(), name)
+
+	recorded, err := readClientMount(filepath.Join(c.statusDir, name+".yaml"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	clientMount, err := readClientMount(filepath.Join(c.specDir, name+".yaml"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if recorded == nil || len(recorded.Finalizers) == 0 {
+			return nil, notFound
+		}
+
+		if recorded.DeletionTimestamp.IsZero() {
+			now := metav1.Now()
+			recorded.DeletionTimestamp = &now
+		}
+
+		return recorded, nil
+	}
+
+	// The spec file doesn't go through the webhook, so the defaults are filled in here
+	clientMount.Name = name
+	clientMount.Namespace = c.namespace
+	clientMount.Default()
+
+	clientMount.Status = dwsv1alpha1.ClientMountStatus{}
+	if recorded != nil {
+		clientMount.Finalizers = recorded.Finalizers
+		clientMount.Status = recorded.Status
+	}
+
+	return clientMount, nil
+}
+
+// write records the ClientMount in its status file. The status file of a deleted
+// ClientMount is removed when it has no finalizers left. The lock must be held.
+func (c *FileClient) write(clientMount *dwsv1alpha1.ClientMount) error {
+	c.changed = true
+	path := filepath.Join(c.statusDir, clientMount.Name+".yaml")
+
+	if !clientMount.DeletionTimestamp.IsZero() && len(clientMount.Finalizers) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	data, err := yaml.Marshal(clientMount)
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(path, data)
+}
+
+func readClientMount(path string) (*dwsv1alpha1.ClientMount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	clientMount := &dwsv1alpha1.ClientMount{}
+	if err := yaml.UnmarshalStrict(data, clientMount); err != nil {
+		return nil, err
+	}
+
+	return clientMount, nil
+}
+
+// RunStandalone reconciles the ClientMounts described by the files of c until the context
+// is canceled. The reconciler must use c as its client. The files are scanned every
+// interval, and a ClientMount is reconciled again right away while each reconcile changes
+// it, since there's no watch to trigger the next reconcile.
+func (r *ClientMountReconciler) RunStandalone(ctx context.Context, c *FileClient, interval time.Duration) error {
+	for {
+		clientMounts := &dwsv1alpha1.ClientMountList{}
+		if err := c.List(ctx, clientMounts); err != nil {
+			r.Log.Error(err, "Could not read the ClientMount files")
+		}
+
+		for _, clientMount := range clientMounts.Items {
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: clientMount.Name, Namespace: clientMount.Namespace}}
+
+			for i := 0; i < standaloneReconcileLimit; i++ {
+				c.Changed()
+				if _, err := r.Reconcile(ctx, request); err != nil {
+					r.Log.Error(err, "Reconcile failed", "ClientMount", request.NamespacedName)
+					break
+				}
+
+				if !c.Changed() {
+					break
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// NodeJitter returns a delay between 0 and window that's fixed for a node. It's derived
+// from a hash of the node name, so the nodes of a large system are spread over the window
+// without coordinating, and a node gets the same delay every time it restarts.
+func NodeJitter(node string, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(node))
+
+	return time.Duration(hash.Sum64() % uint64(window))
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/status"
+)
+
+func init() {
+	RegisterNodeController("storage-reporter", newStorageReporter)
+}
+
+// storageReporter reports the node's view of the storage its ClientMounts use in the compute
+// access list of every Storage resource the node is attached to. It checks that the Lustre
+// MGS NIDs answer an LNet ping and that the physical volumes of the LVM volume groups are
+// visible, so the cluster can see a compute that lost its connection to the storage before a
+// mount fails.
+type storageReporter struct {
+	NodeControllerConfig
+	client client.Client
+}
+
+func newStorageReporter(config NodeControllerConfig) (NodeController, error) {
+	if config.ReportInterval == 0 {
+		return nil, fmt.Errorf("the storage reporter needs a report interval")
+	}
+
+	return &storageReporter{NodeControllerConfig: config}, nil
+}
+
+// SetupWithManager adds the reporter to the manager
+func (s *storageReporter) SetupWithManager(mgr ctrl.Manager) error {
+	s.client = mgr.GetClient()
+
+	return mgr.Add(manager.RunnableFunc(s.run))
+}
+
+// run reports the storage accessibility when the daemon starts and then every interval
+func (s *storageReporter) run(ctx context.Context) error {
+	for {
+		if s.Settings != nil && s.Settings.Get().Mock {
+			s.Log.V(1).Info("Not checking the storage in mock mode")
+		} else if err := s.report(ctx); err != nil {
+			s.Log.Error(err, "Could not report storage accessibility", "node", s.NodeName)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.ReportInterval):
+		}
+	}
+}
+
+// report checks the storage targets of the node's ClientMounts and updates the node's entry
+// in the Storage resources that list it as a compute. Storage resources are updated only if
+// the result changed.
+func (s *storageReporter) report(ctx context.Context) error {
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := s.APIReader.List(ctx, clientMounts, client.InNamespace(s.NodeName)); err != nil {
+		return err
+	}
+
+	accessibility := s.checkTargets(ctx, clientMounts.Items)
+
+	return dwsv1alpha1.Storages(s.APIReader).ListPages(ctx, s.ListPageSize, func(storages []dwsv1alpha1.Storage) error {
+		for _, storage := range storages {
+			key := client.ObjectKeyFromObject(&storage)
+			if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+				storage := &dwsv1alpha1.Storage{}
+				if err := s.APIReader.Get(ctx, key, storage); err != nil {
+					return client.IgnoreNotFound(err)
+				}
+
+				if !setNodeAccessibility(storage.Data.Access.Computes, s.NodeName, accessibility) {
+					return nil
+				}
+
+				return s.client.Update(ctx, storage)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkTargets checks each Lustre MGS NID and LVM volume group used by the ClientMounts once
+func (s *storageReporter) checkTargets(ctx context.Context, clientMounts []dwsv1alpha1.ClientMount) dwsv1alpha1.NodeStorageAccessibility {
+	nids := map[string]bool{}
+	volumeGroups := map[string]bool{}
+
+	for _, clientMount := range clientMounts {
+		for _, mount := range clientMount.Spec.Mounts {
+			if lustre := mount.Device.Lustre; lustre != nil {
+				mgsNodes, err := getLustreMgsNodes(lustre)
+				if err != nil {
+					continue
+				}
+
+				for _, node := range mgsNodes {
+					for _, nid := range node {
+						nids[nid] = true
+					}
+				}
+			}
+
+			if lvm := mount.Device.LVM; lvm != nil && lvm.VolumeGroup != "" {
+				volumeGroups[lvm.VolumeGroup] = true
+			}
+		}
+	}
+
+	targets := []dwsv1alpha1.NodeStorageTarget{}
+	for nid := range nids {
+		target := dwsv1alpha1.NodeStorageTarget{Type: dwsv1alpha1.NodeStorageTargetLustreMGS, Name: nid, Reachable: true}
+		if output, err := s.runCheck(ctx, "lnetctl", "ping", nid); err != nil {
+			target.Reachable = false
+			target.Message = strings.TrimSpace(output + " " + err.Error())
+		}
+
+		targets = append(targets, target)
+	}
+
+	if len(volumeGroups) != 0 {
+		visible, err := s.visibleVolumeGroups(ctx)
+		for volumeGroup := range volumeGroups {
+			target := dwsv1alpha1.NodeStorageTarget{Type: dwsv1alpha1.NodeStorageTargetLVMVolumeGroup, Name: volumeGroup, Reachable: visible[volumeGroup]}
+			if err != nil {
+				target.Message = "Could not list the physical volumes: " + err.Error()
+			} else if !target.Reachable {
+				target.Message = "No physical volumes of the volume group are visible"
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Type != targets[j].Type {
+			return targets[i].Type < targets[j].Type
+		}
+
+		return targets[i].Name < targets[j].Name
+	})
+
+	now := metav1.Now()
+	states := make([]status.State, len(targets))
+	for i, target := range targets {
+		states[i] = status.FromReady(target.Reachable, status.Degraded)
+	}
+
+	return dwsv1alpha1.NodeStorageAccessibility{Status: string(status.Worst(states...)), Targets: targets, LastReported: &now}
+}
+
+// visibleVolumeGroups returns the volume groups that have a physical volume on the node
+func (s *storageReporter) visibleVolumeGroups(ctx context.Context) (map[string]bool, error) {
+	output, err := s.runCheck(ctx, "pvs", "--noheadings", "--separator", " ", "-o", "vg_name")
+	if err != nil {
+		return nil, err
+	}
+
+	visible := map[string]bool{}
+	for _, volumeGroup := range strings.Fields(output) {
+		visible[volumeGroup] = true
+	}
+
+	return visible, nil
+}
+
+// runCheck runs a check command with the daemon's command timeout
+func (s *storageReporter) runCheck(ctx context.Context, name string, args ...string) (string, error) {
+	if s.Settings != nil {
+		if timeout := s.Settings.Get().CommandTimeout; timeout != 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+	}
+
+	result, err := s.Runner.Run(ctx, name, args...)
+	if result == nil {
+		return "", err
+	}
+
+	return result.Stdout, err
+}
+
+// setNodeAccessibility sets the accessibility of the node in the list of computes. It returns
+// true if the node was found and its accessibility changed.
+func setNodeAccessibility(computes []dwsv1alpha1.Node, node string, accessibility dwsv1alpha1.NodeStorageAccessibility) bool {
+	for i := range computes {
+		if computes[i].Name != node {
+			continue
+		}
+
+		// Compare without the report time so the Storage resource isn't updated every
+		// interval
+		if current := computes[i].Accessibility; current != nil {
+			previous := *current
+			previous.LastReported = accessibility.LastReported
+			if reflect.DeepEqual(previous, accessibility) {
+				return false
+			}
+		}
+
+		computes[i].Accessibility = accessibility.DeepCopy()
+		return true
+	}
+
+	return false
+}
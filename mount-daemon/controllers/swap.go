@@ -0,0 +1,175 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// activateSwap formats and activates the swap space described in the ClientMountInfo object.
+// The swap space is either an LVM logical volume or a swap file created on the client.
+func (r *ClientMountReconciler) activateSwap(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, log logr.Logger) error {
+	device, err := r.getSwapDevice(clientMountInfo)
+	if err != nil {
+		return err
+	}
+
+	active, err := r.checkSwap(ctx, device)
+	if err != nil {
+		return err
+	}
+
+	if active {
+		log.Info("Swap already active", "device", r.redact(device))
+		return nil
+	}
+
+	// getDevice activates the LVM volume or creates the swap file
+	if _, err := r.getDevice(ctx, clientMountInfo); err != nil {
+		return err
+	}
+
+	output, err := r.run(ctx, "mkswap", device)
+	if err != nil {
+		log.Info("Could not format swap space", "device", r.redact(device), "output", output)
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not format swap space")
+	}
+
+	swaponArgs := []string{}
+	if clientMountInfo.Options != "" {
+		swaponArgs = append(swaponArgs, "--options", clientMountInfo.Options)
+	}
+
+	recordPlan(ctx, device, clientMountInfo.Options)
+
+	output, err = r.run(ctx, "swapon", append(swaponArgs, device)...)
+	if err != nil {
+		log.Info("Could not activate swap space", "device", r.redact(device), "output", output)
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not activate swap space")
+	}
+
+	log.Info("Activated swap space", "device", r.redact(device))
+
+	return nil
+}
+
+// deactivateSwap deactivates the swap space described in the ClientMountInfo object and
+// releases the underlying storage
+func (r *ClientMountReconciler) deactivateSwap(ctx context.Context, clientMountInfo dwsv1alpha1.ClientMountInfo, log logr.Logger) error {
+	device, err := r.getSwapDevice(clientMountInfo)
+	if err != nil {
+		return err
+	}
+
+	active, err := r.checkSwap(ctx, device)
+	if err != nil {
+		return err
+	}
+
+	if active {
+		output, err := r.run(ctx, "swapoff", device)
+		if err != nil {
+			log.Info("Could not deactivate swap space", "device", r.redact(device), "output", output)
+			return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not deactivate swap space")
+		}
+	}
+
+	switch clientMountInfo.Device.Type {
+	case dwsv1alpha1.ClientMountDeviceTypeLVM:
+		if err := r.configureLVMDevice(ctx, clientMountInfo.Device.LVM, false, clientMountInfo.Device.LVM.ActivationModeFor(clientMountInfo.Type)); err != nil {
+			log.Error(err, "Could not deactivate LVM volume", "device", r.redact(device))
+			return err
+		}
+	case dwsv1alpha1.ClientMountDeviceTypeSwapFile:
+		if err := r.removeFile(ctx, device); err != nil && !os.IsNotExist(err) {
+			log.Error(err, "Could not remove swap file", "device", r.redact(device))
+			return err
+		}
+	}
+
+	log.Info("Deactivated swap space", "device", r.redact(device))
+
+	return nil
+}
+
+// getSwapDevice returns the path of the swap device without activating or creating it
+func (r *ClientMountReconciler) getSwapDevice(clientMountInfo dwsv1alpha1.ClientMountInfo) (string, error) {
+	switch clientMountInfo.Device.Type {
+	case dwsv1alpha1.ClientMountDeviceTypeLVM:
+		return filepath.Join("/dev", clientMountInfo.Device.LVM.VolumeGroup, clientMountInfo.Device.LVM.LogicalVolume), nil
+	case dwsv1alpha1.ClientMountDeviceTypeSwapFile:
+		return clientMountInfo.Device.SwapFile.Path, nil
+	}
+
+	return "", dwsv1alpha1.NewResourceError(fmt.Sprintf("Invalid device type '%s' for swap", clientMountInfo.Device.Type), nil).WithFatal()
+}
+
+// createSwapFile allocates the swap file if it doesn't already exist
+func (r *ClientMountReconciler) createSwapFile(ctx context.Context, swapFile *dwsv1alpha1.ClientMountDeviceSwapFile) error {
+	if !r.mock() {
+		if _, err := os.Stat(swapFile.Path); err == nil {
+			return nil
+		}
+	}
+
+	if err := r.mkdir(ctx, filepath.Dir(swapFile.Path)); err != nil {
+		return err
+	}
+
+	// Swap files can't have holes, so the blocks are allocated up front
+	output, err := r.run(ctx, "fallocate", "--length", strconv.FormatInt(swapFile.Size, 10), swapFile.Path)
+	if err != nil {
+		return dwsv1alpha1.NewResourceError(output, err).WithUserMessage("Client could not create swap file")
+	}
+
+	return r.chmod(ctx, swapFile.Path, 0600)
+}
+
+// checkSwap checks whether the swap device is active
+func (r *ClientMountReconciler) checkSwap(ctx context.Context, device string) (bool, error) {
+	output, err := r.run(ctx, "swapon", "--show=NAME", "--noheadings")
+	if err != nil {
+		return false, dwsv1alpha1.NewResourceError(output, err)
+	}
+
+	// swapon lists the resolved device (e.g., /dev/dm-3) rather than the LVM path
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		resolved = device
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		if name == device || name == resolved {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/rest"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// credentialReloader is the transport for the API server requests. It's rebuilt from the
+// service token and certificate files when either file changes, so rotated credentials are
+// used without restarting the daemon.
+type credentialReloader struct {
+	host      string
+	tokenFile string
+	certFile  string
+	log       logr.Logger
+
+	mutex     sync.RWMutex
+	token     []byte
+	cert      []byte
+	transport http.RoundTripper
+}
+
+func newCredentialReloader(host string, tokenFile string, certFile string, log logr.Logger) (*credentialReloader, error) {
+	c := &credentialReloader{
+		host:      host,
+		tokenFile: tokenFile,
+		certFile:  certFile,
+		log:       log,
+	}
+
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// RoundTrip sends the request with the current credentials
+func (c *credentialReloader) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mutex.RLock()
+	transport := c.transport
+	c.mutex.RUnlock()
+
+	return transport.RoundTrip(req)
+}
+
+// reload reads the token and certificate files and rebuilds the transport if either
+// changed. The current transport is kept if the files can't be read or are invalid, since
+// they may be in the middle of being rotated. It returns true if the transport was rebuilt.
+func (c *credentialReloader) reload() (bool, error) {
+	token, err := os.ReadFile(c.tokenFile)
+	if err != nil {
+		return false, fmt.Errorf("DWS client mount service token failed to read")
+	}
+
+	cert, err := os.ReadFile(c.certFile)
+	if err != nil {
+		return false, fmt.Errorf("DWS client mount service certificate failed to read")
+	}
+
+	c.mutex.RLock()
+	unchanged := bytes.Equal(token, c.token) && bytes.Equal(cert, c.cert)
+	c.mutex.RUnlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	if _, err := certutil.NewPoolFromBytes(cert); err != nil {
+		return false, fmt.Errorf("DWS client mount service certificate invalid")
+	}
+
+	transport, err := rest.TransportFor(&rest.Config{
+		Host:            c.host,
+		TLSClientConfig: rest.TLSClientConfig{CAData: cert},
+		BearerToken:     string(bytes.TrimSpace(token)),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	c.mutex.Lock()
+	previous := c.transport
+	c.token, c.cert, c.transport = token, cert, transport
+	c.mutex.Unlock()
+
+	// Connections made with the old certificate are closed once their requests finish
+	if closer, ok := previous.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+
+	return true, nil
+}
+
+// monitor checks the token and certificate files for changes every interval until the
+// context is done
+func (c *credentialReloader) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := c.reload()
+		if err != nil {
+			c.log.Error(err, "Could not reload the service credentials", "tokenFile", c.tokenFile, "certFile", c.certFile)
+		} else if reloaded {
+			c.log.Info("Reloaded the service credentials", "tokenFile", c.tokenFile, "certFile", c.certFile)
+		}
+	}
+}
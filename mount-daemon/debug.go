@@ -0,0 +1,114 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/signal"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/HewlettPackard/dws/mount-daemon/controllers"
+)
+
+// startPprofServer serves the pprof endpoints on the address until the context is done
+func startPprofServer(ctx context.Context, address string, listeners *listenerConfig, log logr.Logger) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	log.Info("Serving pprof endpoints", "address", address)
+	serve(ctx, address, mux, listeners, log)
+}
+
+// startMetricsServer serves the controller metrics on the address until the context is done.
+// The daemon serves the metrics itself rather than through the manager so the endpoint is
+// secured like the daemon's other endpoints.
+func startMetricsServer(ctx context.Context, address string, listeners *listenerConfig, log logr.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	log.Info("Serving metrics", "address", address)
+	serve(ctx, address, mux, listeners, log)
+}
+
+// serve serves the handler on a listener from the listener configuration until the context
+// is done
+func serve(ctx context.Context, address string, handler http.Handler, listeners *listenerConfig, log logr.Logger) {
+	listener, err := listeners.listen(address)
+	if err != nil {
+		log.Error(err, "Could not listen", "address", address)
+		return
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		log.Error(err, "Server failed", "address", address)
+	}
+}
+
+// handleDebugSignal dumps the goroutine stacks and the in-flight host commands to the
+// log each time the daemon receives SIGUSR1
+func handleDebugSignal(ctx context.Context, inFlight *controllers.InFlightOperations, log logr.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+
+		for _, operation := range inFlight.List() {
+			log.Info("In-flight operation", "ClientMount", operation.ClientMount,
+				"Command", strings.Join(append([]string{operation.Command}, operation.Args...), " "),
+				"Running", time.Since(operation.Start).String())
+		}
+
+		stacks := &bytes.Buffer{}
+		if err := runtimepprof.Lookup("goroutine").WriteTo(stacks, 2); err != nil {
+			log.Error(err, "Could not dump goroutine stacks")
+			continue
+		}
+
+		log.Info("Goroutine stacks\n" + stacks.String())
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/client-go/rest"
+)
+
+// endpointSelector directs the requests made by the daemon to one of several API server
+// endpoints. The endpoints are health checked and requests fail over to the first healthy
+// endpoint in the list, failing back to a preferred endpoint once it's healthy again.
+// Informers reconnect on their own once their watch breaks, so a failover only costs the
+// requests that were in flight on the failed endpoint.
+type endpointSelector struct {
+	mu sync.RWMutex
+
+	// endpoints is the list of API server "host:port" endpoints in order of preference
+	endpoints []string
+	current   int
+
+	interval time.Duration
+	timeout  time.Duration
+	log      logr.Logger
+}
+
+func newEndpointSelector(endpoints []string, interval time.Duration, log logr.Logger) *endpointSelector {
+	return &endpointSelector{
+		endpoints: endpoints,
+		interval:  interval,
+		timeout:   5 * time.Second,
+		log:       log,
+	}
+}
+
+// currentEndpoint returns the endpoint requests are sent to
+func (s *endpointSelector) currentEndpoint() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.endpoints[s.current]
+}
+
+// setCurrent switches requests to the endpoint at index
+func (s *endpointSelector) setCurrent(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != index {
+		s.log.Info("Switching API server endpoint", "from", s.endpoints[s.current], "to", s.endpoints[index])
+		s.current = index
+	}
+}
+
+// failover moves to the next endpoint after a request to the failed endpoint couldn't be
+// completed. The health check will fail back to a preferred endpoint once it recovers.
+func (s *endpointSelector) failover(failed string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may already have moved off the failed endpoint
+	if s.endpoints[s.current] != failed {
+		return
+	}
+
+	next := (s.current + 1) % len(s.endpoints)
+	s.log.Info("API server endpoint failed, failing over", "from", failed, "to", s.endpoints[next])
+	s.current = next
+}
+
+// wrap is a rest.Config WrapTransport function that sends each request to the current endpoint
+func (s *endpointSelector) wrap(rt http.RoundTripper) http.RoundTripper {
+	return &endpointRoundTripper{selector: s, rt: rt}
+}
+
+type endpointRoundTripper struct {
+	selector *endpointSelector
+	rt       http.RoundTripper
+}
+
+func (e *endpointRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := e.selector.currentEndpoint()
+
+	req = req.Clone(req.Context())
+	req.URL.Host = endpoint
+	req.Host = endpoint
+
+	resp, err := e.rt.RoundTrip(req)
+	if err != nil && req.Context().Err() == nil {
+		// The request couldn't reach the API server. The caller will retry, so move the
+		// following requests to another endpoint.
+		e.selector.failover(endpoint)
+	}
+
+	return resp, err
+}
+
+// monitor health checks the endpoints until the context is done. Requests are sent to the
+// first endpoint in the list that reports it's ready.
+func (s *endpointSelector) monitor(ctx context.Context, config *rest.Config) {
+	// The health check uses the same credentials and TLS configuration as the requests
+	// but must go directly to each endpoint
+	probeConfig := rest.CopyConfig(config)
+	probeConfig.WrapTransport = nil
+	probeConfig.Timeout = s.timeout
+
+	rt, err := rest.TransportFor(probeConfig)
+	if err != nil {
+		s.log.Error(err, "Could not create API server health check transport")
+		return
+	}
+	client := &http.Client{Transport: rt, Timeout: s.timeout}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		for i, endpoint := range s.endpoints {
+			if s.healthy(ctx, client, endpoint) {
+				s.setCurrent(i)
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *endpointSelector) healthy(ctx context.Context, client *http.Client, endpoint string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+endpoint+"/readyz", nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		s.log.V(1).Info("API server endpoint health check failed", "endpoint", endpoint, "error", err.Error())
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/HewlettPackard/dws/mount-daemon/controllers"
+	"github.com/HewlettPackard/dws/utils/command"
+)
+
+// runHelper runs the daemon as the privileged helper. The helper serves the commands sent
+// by the controller on the helper socket until it's signaled to stop. The commands are run
+// without a shell and only if they're in the allowlist, so the controller can't be used to
+// run anything else on the node even if a ClientMount spec is crafted to try.
+func runHelper(opts *options) (string, error) {
+	if len(opts.helperSocket) == 0 {
+		return "Helper", fmt.Errorf("the privileged helper needs a --helper-socket")
+	}
+
+	if len(opts.helperMountRoots) == 0 {
+		return "Helper", fmt.Errorf("the privileged helper needs --helper-mount-roots")
+	}
+
+	for _, pattern := range opts.helperFormatDevices {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return "Helper", fmt.Errorf("invalid --helper-format-devices pattern '%s': %w", pattern, err)
+		}
+	}
+
+	listener, err := command.ListenHelper(opts.helperSocket, opts.helperSocketUID, opts.helperSocketGID)
+	if err != nil {
+		return "Helper", err
+	}
+
+	server := &command.HelperServer{
+		Runner: newHostRunner(opts),
+		Allowlist: controllers.HelperAllowlist(controllers.HelperConfig{
+			MountRoots:             opts.helperMountRoots,
+			FormatDevices:          opts.helperFormatDevices,
+			HookDir:                opts.hookDir,
+			AllowPrivilegedOptions: opts.helperPrivileged,
+		}),
+		Log: ctrl.Log.WithName("helper"),
+	}
+
+	setupLog.Info("Serving the privileged helper", "socket", opts.helperSocket)
+	if err := server.Serve(ctrl.SetupSignalHandler(), listener); err != nil {
+		return "Helper", err
+	}
+
+	return "Exited", nil
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-logr/logr"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// listenerConfig secures the daemon's HTTP endpoints (e.g., metrics and pprof). Compute
+// nodes sit on shared high-speed networks, so the endpoints can be served with TLS, can
+// require client certificates signed by a certificate authority (mTLS), and can refuse
+// connections from addresses outside an allow-list.
+type listenerConfig struct {
+	// certFile and keyFile are the server certificate and key. The endpoints are served
+	// without TLS if empty.
+	certFile string
+	keyFile  string
+
+	// clientCAFile is the certificate authority that signs the client certificates. Client
+	// certificates aren't required if empty. The DWS client mount service certificate can
+	// be used so clients authenticate with certificates from the cluster's authority.
+	clientCAFile string
+
+	// allowed are the networks connections are accepted from. Connections are accepted
+	// from anywhere if empty.
+	allowed []*net.IPNet
+
+	log logr.Logger
+}
+
+// cidrList is a flag.Value that collects a comma separated list of CIDRs. A plain address
+// is treated as a single host network.
+type cidrList []*net.IPNet
+
+func (l *cidrList) String() string {
+	cidrs := []string{}
+	for _, network := range *l {
+		cidrs = append(cidrs, network.String())
+	}
+
+	return strings.Join(cidrs, ",")
+}
+
+func (l *cidrList) Set(value string) error {
+	for _, cidr := range strings.Split(value, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return fmt.Errorf("invalid address '%s'", cidr)
+			}
+
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			*l = append(*l, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid CIDR '%s': %w", cidr, err)
+		}
+		*l = append(*l, network)
+	}
+
+	return nil
+}
+
+// validate checks that the options are consistent before any listener is created
+func (c *listenerConfig) validate() error {
+	if (len(c.certFile) == 0) != (len(c.keyFile) == 0) {
+		return fmt.Errorf("the endpoint TLS certificate and key must be set together")
+	}
+
+	if len(c.clientCAFile) != 0 && len(c.certFile) == 0 {
+		return fmt.Errorf("client certificates require the endpoint TLS certificate and key")
+	}
+
+	return nil
+}
+
+// listen returns a listener on the address that applies the allow-list and TLS settings
+func (c *listenerConfig) listen(address string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.allowed) != 0 {
+		listener = &allowListListener{Listener: listener, allowed: c.allowed, log: c.log}
+	}
+
+	if len(c.certFile) == 0 {
+		return listener, nil
+	}
+
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+func (c *listenerConfig) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load endpoint TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if len(c.clientCAFile) != 0 {
+		pool, err := certutil.NewPool(c.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load endpoint client certificate authority: %w", err)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// allowListListener closes the connections from addresses outside the allowed networks
+type allowListListener struct {
+	net.Listener
+	allowed []*net.IPNet
+	log     logr.Logger
+}
+
+func (l *allowListListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if l.isAllowed(conn.RemoteAddr()) {
+			return conn, nil
+		}
+
+		l.log.V(1).Info("Refused connection", "address", conn.RemoteAddr().String())
+		conn.Close()
+	}
+}
+
+func (l *allowListListener) isAllowed(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+
+	for _, network := range l.allowed {
+		if network.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+
+	return false
+}
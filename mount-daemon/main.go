@@ -20,41 +20,52 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"syscall"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"github.com/takama/daemon"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
 	kruntime "k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
-	certutil "k8s.io/client-go/util/cert"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
 	"github.com/HewlettPackard/dws/mount-daemon/controllers"
+	"github.com/HewlettPackard/dws/utils/command"
 	//+kubebuilder:scaffold:imports
 )
 
 const (
 	name        = "clientmount"
 	description = "Data Workflow Service (DWS) Client Mount Service"
+
+	// statusUpdateGrace is the time allowed after the shutdown grace period for the
+	// reconcilers to write their final status
+	statusUpdateGrace = 15 * time.Second
 )
 
 var (
 	scheme   = kruntime.NewScheme()
 	setupLog = ctrl.Log.WithName("setup")
+
+	// version is set when the daemon is built with -ldflags "-X main.version=<version>"
+	version = "dev"
 )
 
 type Service struct {
@@ -82,11 +93,17 @@ func (service *Service) Manage() (string, error) {
 			return service.Stop()
 		case "status":
 			return service.Status()
+		case "bootstrap":
+			return service.bootstrap(os.Args[2:])
 		}
 	}
 
 	opts := getOptions()
 
+	if opts.privilegedHelper {
+		return runHelper(opts)
+	}
+
 	config, err := createManager(opts)
 	if err != nil {
 		return "Create", err
@@ -98,17 +115,82 @@ func (service *Service) Manage() (string, error) {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, os.Kill, syscall.SIGTERM)
 
-	go startManager(config)
+	// The manager context is canceled by the same signals
+	ctx := ctrl.SetupSignalHandler()
+
+	done := make(chan struct{})
+	go func() {
+		if config.standalone {
+			startStandalone(ctx, config)
+		} else {
+			startManager(ctx, config)
+		}
+		close(done)
+	}()
 
 	killSignal := <-interrupt
-	setupLog.Info("Daemon was killed", "signal", killSignal)
+	setupLog.Info("Daemon was killed, waiting for in-flight operations", "signal", killSignal, "gracePeriod", config.gracePeriod.String())
+
+	// The manager waits for the reconcilers, which wait for the running commands for up
+	// to the grace period. Allow a little longer for the final status updates.
+	select {
+	case <-done:
+	case <-time.After(config.gracePeriod + statusUpdateGrace):
+		setupLog.Info("Timed out waiting for in-flight operations")
+	}
+
+	if config.audit != nil {
+		_ = config.audit.Close()
+	}
+
 	return "Exited", nil
 }
 
 type managerConfig struct {
 	config    *rest.Config
 	namespace string
-	mock      bool
+	reloader  *configReloader
+	runner    command.Runner
+	audit     *controllers.AuditLog
+	endpoints *endpointSelector
+	inFlight  *controllers.InFlightOperations
+	pprofAddr string
+	redact    bool
+	lnetCheck bool
+	gfs2Check bool
+	mountRoot string
+	hookDir   string
+	autofsDir string
+	secretDir string
+	privilege bool
+	lvmGuard  *controllers.LVMGuard
+	lvmWait   time.Duration
+	prober    *controllers.MountProber
+	usageTime time.Duration
+	mockLVM   *controllers.MockLVM
+
+	metricsAddr string
+	listeners   *listenerConfig
+
+	nodeStatus   *controllers.NodeStatus
+	checkpoint   *controllers.Checkpoint
+	nodeInfoTime time.Duration
+	pageSize     int64
+
+	credentials      *credentialReloader
+	credentialReload time.Duration
+
+	gracePeriod time.Duration
+
+	startupDelay time.Duration
+	resyncPeriod time.Duration
+
+	standalone          bool
+	standaloneDir       string
+	standaloneStatusDir string
+
+	nodeControllers []string
+	nodeReportTime  time.Duration
 }
 
 type options struct {
@@ -119,6 +201,106 @@ type options struct {
 	tokenFile string
 	certFile  string
 	mock      bool
+	mockLVM   bool
+
+	configFile     string
+	retryDelay     time.Duration
+	commandTimeout time.Duration
+	logLevel       uberzap.AtomicLevel
+
+	endpointHealthInterval time.Duration
+	credentialReload       time.Duration
+	pprofAddr              string
+	metricsAddr            string
+	endpointCertFile       string
+	endpointKeyFile        string
+	endpointClientCAFile   string
+	endpointAllowed        cidrList
+	redactDevices          bool
+	lnetPrecheck           bool
+	gfs2Precheck           bool
+	orphanMountRoot        string
+	hookDir                string
+	autofsMapDir           string
+	secretDir              string
+	privilegedHelper       bool
+	helperSocket           string
+	helperSocketUID        int
+	helperSocketGID        int
+	helperMountRoots       nameList
+	helperFormatDevices    nameList
+	helperPrivileged       bool
+	nodeStatusFile         string
+	checkpointFile         string
+	nodeInfoInterval       time.Duration
+	mountProbeTimeout      time.Duration
+	usageReportInterval    time.Duration
+	standalone             bool
+	standaloneDir          string
+	standaloneStatusDir    string
+	nodeControllers        nameList
+	nodeReportInterval     time.Duration
+	listPageSize           int64
+
+	lvmConcurrency      int
+	lvmFailureThreshold int
+	lvmCooldown         time.Duration
+	lvmReleaseTimeout   time.Duration
+
+	startupJitter time.Duration
+	resyncPeriod  time.Duration
+
+	mountCommand    string
+	umountCommand   string
+	lvsCommand      string
+	vgchangeCommand string
+	commandPath     string
+	commandLdPath   string
+	commandEnv      envList
+
+	commandRetries     int
+	commandRetryDelay  time.Duration
+	commandOutputLimit int
+	commandOutputSpool string
+
+	auditLog           string
+	auditLogMaxSize    int
+	auditLogMaxBackups int
+
+	shutdownGracePeriod time.Duration
+}
+
+// envList is a flag.Value that collects repeated "key=value" environment settings
+type envList []string
+
+func (e *envList) String() string {
+	return strings.Join(*e, ",")
+}
+
+func (e *envList) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("environment setting '%s' is not of the form key=value", value)
+	}
+
+	*e = append(*e, value)
+	return nil
+}
+
+// nameList is a flag.Value that collects a comma separated list of names
+type nameList []string
+
+func (n *nameList) String() string {
+	return strings.Join(*n, ",")
+}
+
+func (n *nameList) Set(value string) error {
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			*n = append(*n, name)
+		}
+	}
+
+	return nil
 }
 
 func getOptions() *options {
@@ -129,14 +311,106 @@ func getOptions() *options {
 		tokenFile: os.Getenv("DWS_CLIENT_MOUNT_SERVICE_TOKEN_FILE"),
 		certFile:  os.Getenv("DWS_CLIENT_MOUNT_SERVICE_CERT_FILE"),
 		mock:      false,
+
+		retryDelay:     controllers.DefaultSettings.RetryDelay,
+		commandTimeout: controllers.DefaultSettings.CommandTimeout,
+
+		endpointHealthInterval: 10 * time.Second,
+		metricsAddr:            ":8080",
+		credentialReload:       time.Minute,
+		mountProbeTimeout:      10 * time.Second,
+		standaloneDir:          "/etc/clientmount.d",
+		standaloneStatusDir:    "/var/lib/clientmount",
+		resyncPeriod:           10 * time.Hour,
+		nodeReportInterval:     time.Minute,
+		listPageSize:           500,
+		secretDir:              "/run/clientmount/secrets",
+		helperSocketUID:        -1,
+		helperSocketGID:        -1,
+
+		lvmConcurrency:      1,
+		lvmFailureThreshold: 5,
+		lvmCooldown:         time.Minute,
+
+		mountCommand:    "mount",
+		umountCommand:   "umount",
+		lvsCommand:      "lvs",
+		vgchangeCommand: "vgchange",
+
+		commandRetries:     2,
+		commandRetryDelay:  time.Second,
+		commandOutputLimit: 1024 * 1024,
+
+		auditLogMaxSize:    100,
+		auditLogMaxBackups: 5,
+
+		shutdownGracePeriod: 30 * time.Second,
 	}
 
-	flag.StringVar(&opts.host, "kubernetes-service-host", opts.host, "Kubernetes service host address")
+	flag.StringVar(&opts.host, "kubernetes-service-host", opts.host, "Kubernetes service host address. A comma separated list of [host] or [host]:[port] endpoints enables failover between them")
 	flag.StringVar(&opts.port, "kubernetes-service-port", opts.port, "Kubernetes service port number")
 	flag.StringVar(&opts.name, "node-name", opts.name, "Name of this compute resource")
+	flag.DurationVar(&opts.endpointHealthInterval, "kubernetes-endpoint-health-interval", opts.endpointHealthInterval, "Interval between health checks of the Kubernetes service endpoints when failover is enabled")
 	flag.StringVar(&opts.tokenFile, "service-token-file", opts.tokenFile, "Path to the DWS client mount service token")
 	flag.StringVar(&opts.certFile, "service-cert-file", opts.certFile, "Path to the DWS client mount service certificate")
+	flag.DurationVar(&opts.credentialReload, "service-credential-reload-interval", opts.credentialReload, "Interval between checks of the service token and certificate files for rotated credentials. Not checked if 0")
 	flag.BoolVar(&opts.mock, "mock", opts.mock, "Run in mock mode where no client mount operations take place")
+	flag.BoolVar(&opts.mockLVM, "mock-lvm", opts.mockLVM, "In mock mode, run the LVM commands against a fake LVM built from the ClientMount specs so the activation logic is exercised")
+	flag.StringVar(&opts.configFile, "config", opts.configFile, "Path to a config file whose settings override the command line. The file is reloaded on SIGHUP")
+	flag.DurationVar(&opts.retryDelay, "retry-delay", opts.retryDelay, "Delay before retrying a mount or unmount that failed")
+	flag.DurationVar(&opts.commandTimeout, "command-timeout", opts.commandTimeout, "Time a mount helper command may run before it's killed. No timeout if 0")
+	flag.BoolVar(&opts.redactDevices, "redact-device-paths", opts.redactDevices, "Hide device paths and Lustre MGS NIDs from the log. The audit log is not redacted")
+	flag.BoolVar(&opts.lnetPrecheck, "lnet-precheck", opts.lnetPrecheck, "Check that a Lustre MGS can be reached with 'lnetctl ping' before mounting")
+	flag.BoolVar(&opts.gfs2Precheck, "gfs2-precheck", opts.gfs2Precheck, "Check the corosync quorum and DLM fencing state before mounting gfs2")
+	flag.IntVar(&opts.lvmConcurrency, "lvm-concurrency", opts.lvmConcurrency, "Number of LVM commands (lvs, vgchange) that may run at the same time")
+	flag.IntVar(&opts.lvmFailureThreshold, "lvm-failure-threshold", opts.lvmFailureThreshold, "Number of consecutive LVM command failures that pause LVM commands for the cool-down. Never paused if 0")
+	flag.DurationVar(&opts.lvmCooldown, "lvm-cooldown", opts.lvmCooldown, "Time LVM commands are paused after repeated failures")
+	flag.DurationVar(&opts.lvmReleaseTimeout, "lvm-release-timeout", opts.lvmReleaseTimeout, "Time a deleted ClientMount waits for the node to release its LVM devices and lockspaces before it gets a fatal error. The finalizer is held until they're released. Not checked if 0")
+	flag.StringVar(&opts.orphanMountRoot, "orphan-mount-root", opts.orphanMountRoot, "Directory under which file systems that don't belong to any ClientMount are unmounted at startup. The scan is disabled if empty")
+	flag.StringVar(&opts.hookDir, "hook-dir", opts.hookDir, "Directory of site hooks. The executables in its pre-mount and post-unmount subdirectories are run before each mount and after each unmount with the mount described in DWS_ environment variables. No hooks are run if empty")
+	flag.StringVar(&opts.secretDir, "secret-dir", opts.secretDir, "Directory of the credential files written from the Secrets of the mounts. Only root can read the files, and they're removed when the mount is unmounted. It should be on a tmpfs")
+	flag.BoolVar(&opts.privilegedHelper, "privileged-helper", opts.privilegedHelper, "Run as the privileged helper rather than the controller. The helper only runs the allowlisted mount, LVM, and file commands the controller sends to --helper-socket")
+	flag.StringVar(&opts.helperSocket, "helper-socket", opts.helperSocket, "Unix socket of the privileged helper. The controller sends its commands to the helper so it can run without privileges. The commands are run by the controller if empty")
+	flag.IntVar(&opts.helperSocketUID, "helper-socket-uid", opts.helperSocketUID, "Owner the privileged helper gives its socket so the controller's user can connect. Only the owner can connect. Not changed if -1")
+	flag.IntVar(&opts.helperSocketGID, "helper-socket-gid", opts.helperSocketGID, "Group the privileged helper gives its socket. Not changed if -1")
+	flag.Var(&opts.helperMountRoots, "helper-mount-roots", "Comma separated list of the directories the privileged helper may mount on and create or remove paths below. The mount paths, swap files, and bind mount sources of the ClientMounts must be below one of them. Required with --privileged-helper")
+	flag.Var(&opts.helperFormatDevices, "helper-format-devices", "Comma separated list of patterns of the devices the privileged helper may format and use as swap in addition to the LVM, multipath, RBD, and /dev/disk/by-id devices (e.g., /dev/sd* for the block devices found by UUID, label, or WWN)")
+	flag.BoolVar(&opts.helperPrivileged, "helper-allow-privileged-options", opts.helperPrivileged, "Let the privileged helper mount with the suid, dev, and defaults options, which are refused otherwise")
+	flag.StringVar(&opts.autofsMapDir, "autofs-map-dir", opts.autofsMapDir, "autofs master map directory (e.g., /etc/auto.master.d) that entries for NFS mounts with automount set are written to. Automount is refused if empty")
+	flag.StringVar(&opts.nodeStatusFile, "node-status-file", opts.nodeStatusFile, "Path of a JSON file summarizing the ClientMounts on the node for node health checks. The file isn't written if empty")
+	flag.StringVar(&opts.checkpointFile, "checkpoint-file", opts.checkpointFile, "Path of a file recording the ClientMounts that reached their desired state, so their status can be restored after a restart without remounting. Not recorded if empty")
+	flag.DurationVar(&opts.startupJitter, "startup-jitter", opts.startupJitter, "Window the first reconcile after startup is delayed within, by an amount fixed for the node by a hash of its name, so a fleet-wide restart doesn't start every node's mounts at once. Not delayed if 0")
+	flag.DurationVar(&opts.resyncPeriod, "resync-period", opts.resyncPeriod, "Period the ClientMounts are reconciled again without a change. Each node's period is lengthened by up to 10% by a hash of its name so the nodes don't resync together")
+	flag.DurationVar(&opts.nodeInfoInterval, "node-info-interval", opts.nodeInfoInterval, "Interval between reports of the node's kernel, Lustre, and LVM versions to the Storage resources it's attached to. Not reported if 0")
+	flag.DurationVar(&opts.mountProbeTimeout, "mount-probe-timeout", opts.mountProbeTimeout, "Time statfs may take on a mounted file system before the mount is marked Degraded. Mounts aren't probed if 0")
+	flag.DurationVar(&opts.usageReportInterval, "usage-report-interval", opts.usageReportInterval, "Interval between the reports of the space and inodes used on the mounted file systems in the ClientMount status. The usage is read by the mount probe, so it isn't reported if --mount-probe-timeout is 0. Not reported if 0")
+	flag.BoolVar(&opts.standalone, "standalone", opts.standalone, "Run without a Kubernetes API. The ClientMounts are read from the YAML files in --standalone-dir and their status is written to --standalone-status-dir")
+	flag.StringVar(&opts.standaloneDir, "standalone-dir", opts.standaloneDir, "Directory of [name].yaml ClientMount files in standalone mode. It's scanned for changes every retry delay")
+	flag.StringVar(&opts.standaloneStatusDir, "standalone-status-dir", opts.standaloneStatusDir, "Directory the ClientMounts and their status are written to in standalone mode")
+	flag.Var(&opts.nodeControllers, "node-controllers", fmt.Sprintf("Comma separated list of the node controllers to run alongside the ClientMount reconciler. They share the daemon's service token, which must be granted their RBAC. The controllers in this build are %v", controllers.NodeControllers()))
+	flag.DurationVar(&opts.nodeReportInterval, "node-report-interval", opts.nodeReportInterval, "Interval between the reports of the node controllers that report periodically (e.g., storage-reporter)")
+	flag.Int64Var(&opts.listPageSize, "list-page-size", opts.listPageSize, "Number of Storage resources read from the API server at a time when the node reports to them, so a large system's resources aren't all held in memory. Not paged if 0")
+	flag.StringVar(&opts.pprofAddr, "pprof-bind-address", opts.pprofAddr, "The address the pprof debug endpoints bind to (e.g., localhost:6060). The endpoints are disabled if empty")
+	flag.StringVar(&opts.metricsAddr, "metrics-bind-address", opts.metricsAddr, "The address the metric endpoint binds to. The endpoint is disabled if empty or \"0\"")
+	flag.StringVar(&opts.endpointCertFile, "endpoint-tls-cert-file", opts.endpointCertFile, "Certificate used to serve the metrics and pprof endpoints with TLS. The endpoints don't use TLS if empty")
+	flag.StringVar(&opts.endpointKeyFile, "endpoint-tls-key-file", opts.endpointKeyFile, "Key for the endpoint TLS certificate")
+	flag.StringVar(&opts.endpointClientCAFile, "endpoint-client-ca-file", opts.endpointClientCAFile, "Certificate authority that must sign the client certificates for the metrics and pprof endpoints (e.g., the service certificate file). Client certificates aren't required if empty")
+	flag.Var(&opts.endpointAllowed, "endpoint-allowed-cidrs", "Comma separated list of addresses or CIDRs that may connect to the metrics and pprof endpoints. Any address may connect if empty")
+	flag.StringVar(&opts.mountCommand, "mount-command", opts.mountCommand, "Binary used to mount file systems")
+	flag.StringVar(&opts.umountCommand, "umount-command", opts.umountCommand, "Binary used to unmount file systems")
+	flag.StringVar(&opts.lvsCommand, "lvs-command", opts.lvsCommand, "Binary used to list LVM logical volumes")
+	flag.StringVar(&opts.vgchangeCommand, "vgchange-command", opts.vgchangeCommand, "Binary used to activate and deactivate LVM volume groups")
+	flag.StringVar(&opts.commandPath, "command-path", opts.commandPath, "PATH used when running mount helper commands")
+	flag.StringVar(&opts.commandLdPath, "command-ld-library-path", opts.commandLdPath, "LD_LIBRARY_PATH used when running mount helper commands")
+	flag.Var(&opts.commandEnv, "command-env", "Extra key=value environment setting used when running mount helper commands. May be repeated.")
+	flag.IntVar(&opts.commandRetries, "command-retries", opts.commandRetries, "Number of times a mount helper command is run again after a transient error such as a busy device")
+	flag.DurationVar(&opts.commandRetryDelay, "command-retry-delay", opts.commandRetryDelay, "Delay between attempts of a mount helper command that failed with a transient error")
+	flag.IntVar(&opts.commandOutputLimit, "command-output-limit", opts.commandOutputLimit, "Number of bytes of stdout and of stderr kept from a mount helper command. The output isn't limited if 0")
+	flag.StringVar(&opts.commandOutputSpool, "command-output-spool-dir", opts.commandOutputSpool, "Directory where the full output of a mount helper command is kept when it's over the output limit. The files aren't removed by the daemon. The output isn't kept if empty")
+	flag.StringVar(&opts.auditLog, "audit-log", opts.auditLog, "Path to the audit log of commands run on the host. Auditing is disabled if empty")
+	flag.IntVar(&opts.auditLogMaxSize, "audit-log-max-size", opts.auditLogMaxSize, "Size in megabytes at which the audit log is rotated. Rotation is disabled if 0")
+	flag.DurationVar(&opts.shutdownGracePeriod, "shutdown-grace-period", opts.shutdownGracePeriod, "Time running mount helper commands may continue after the daemon is asked to stop before they're killed")
+	flag.IntVar(&opts.auditLogMaxBackups, "audit-log-max-backups", opts.auditLogMaxBackups, "Number of rotated audit logs to keep")
 
 	zapOptions := zap.Options{
 		Development: true,
@@ -145,6 +419,15 @@ func getOptions() *options {
 
 	flag.Parse()
 
+	// The log level must be an atomic level so the config file can change it while running.
+	// The zap-log-level flag always creates one.
+	logLevel, ok := zapOptions.Level.(uberzap.AtomicLevel)
+	if !ok {
+		logLevel = uberzap.NewAtomicLevelAt(zapcore.DebugLevel)
+		zapOptions.Level = logLevel
+	}
+	opts.logLevel = logLevel
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOptions)))
 
 	return &opts
@@ -153,9 +436,17 @@ func getOptions() *options {
 func createManager(opts *options) (*managerConfig, error) {
 
 	var config *rest.Config
+	var selector *endpointSelector
+	var credentials *credentialReloader
 	var err error
 
-	if len(opts.host) == 0 && len(opts.port) == 0 {
+	if opts.standalone && len(opts.nodeControllers) != 0 {
+		return nil, fmt.Errorf("node controllers can't run in standalone mode")
+	}
+
+	if opts.standalone {
+		setupLog.Info("Running standalone without a Kubernetes API", "dir", opts.standaloneDir, "statusDir", opts.standaloneStatusDir)
+	} else if len(opts.host) == 0 && len(opts.port) == 0 {
 		setupLog.Info("Using kubeconfig rest configuration")
 
 		config, err = ctrl.GetConfig()
@@ -174,40 +465,177 @@ func createManager(opts *options) (*managerConfig, error) {
 			return nil, fmt.Errorf("DWS client mount service token not defined")
 		}
 
-		token, err := ioutil.ReadFile(opts.tokenFile)
-		if err != nil {
-			return nil, fmt.Errorf("DWS client mount service token failed to read")
-		}
-
 		if len(opts.certFile) == 0 {
 			return nil, fmt.Errorf("DWS client mount service certificate file not defined")
 		}
 
-		if _, err := certutil.NewPool(opts.certFile); err != nil {
-			return nil, fmt.Errorf("DWS client mount service certificate invalid")
+		endpoints := []string{}
+		for _, host := range strings.Split(opts.host, ",") {
+			if _, _, err := net.SplitHostPort(host); err == nil {
+				endpoints = append(endpoints, host)
+			} else {
+				endpoints = append(endpoints, net.JoinHostPort(host, opts.port))
+			}
 		}
 
-		tlsClientConfig := rest.TLSClientConfig{}
-		tlsClientConfig.CAFile = opts.certFile
+		credentials, err = newCredentialReloader("https://"+endpoints[0], opts.tokenFile, opts.certFile, ctrl.Log.WithName("credentials"))
+		if err != nil {
+			return nil, err
+		}
 
+		// The transport holds the credentials so it can be rebuilt when they're rotated
 		config = &rest.Config{
-			Host:            "https://" + net.JoinHostPort(opts.host, opts.port),
-			TLSClientConfig: tlsClientConfig,
-			BearerToken:     string(token),
-			BearerTokenFile: opts.tokenFile,
+			Host:      "https://" + endpoints[0],
+			Transport: credentials,
+		}
+
+		if len(endpoints) > 1 {
+			setupLog.Info("Using API server endpoint failover", "endpoints", endpoints)
+			selector = newEndpointSelector(endpoints, opts.endpointHealthInterval, ctrl.Log.WithName("endpoints"))
+			config.WrapTransport = selector.wrap
+		}
+	}
+
+	reloader := newConfigReloader(opts.configFile, controllers.Settings{
+		Mock:           opts.mock,
+		RetryDelay:     opts.retryDelay,
+		CommandTimeout: opts.commandTimeout,
+	}, opts.logLevel)
+
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	var runner command.Runner = newHostRunner(opts)
+	if len(opts.helperSocket) != 0 {
+		setupLog.Info("Running commands through the privileged helper", "socket", opts.helperSocket)
+		runner = command.NewHelperRunner(opts.helperSocket)
+	}
+
+	var audit *controllers.AuditLog
+	if len(opts.auditLog) != 0 {
+		audit, err = controllers.NewAuditLog(opts.auditLog, opts.auditLogMaxSize, opts.auditLogMaxBackups)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	return &managerConfig{config: config, namespace: opts.name, mock: opts.mock}, nil
+	listeners := &listenerConfig{
+		certFile:     opts.endpointCertFile,
+		keyFile:      opts.endpointKeyFile,
+		clientCAFile: opts.endpointClientCAFile,
+		allowed:      opts.endpointAllowed,
+		log:          ctrl.Log.WithName("listener"),
+	}
+
+	if err := listeners.validate(); err != nil {
+		return nil, err
+	}
+
+	var nodeStatus *controllers.NodeStatus
+	if len(opts.nodeStatusFile) != 0 {
+		nodeStatus = controllers.NewNodeStatus(opts.nodeStatusFile, opts.name)
+	}
+
+	var checkpoint *controllers.Checkpoint
+	if len(opts.checkpointFile) != 0 {
+		checkpoint, err = controllers.NewCheckpoint(opts.checkpointFile)
+		if err != nil {
+			setupLog.Error(err, "Ignoring the entries in the checkpoint file", "file", opts.checkpointFile)
+		}
+	}
+
+	var prober *controllers.MountProber
+	if opts.mountProbeTimeout != 0 {
+		prober = controllers.NewMountProber(opts.mountProbeTimeout)
+	}
+
+	var mockLVM *controllers.MockLVM
+	if opts.mockLVM {
+		mockLVM = controllers.NewMockLVM()
+	}
+
+	return &managerConfig{
+		config:    config,
+		namespace: opts.name,
+		reloader:  reloader,
+		runner:    runner,
+		audit:     audit,
+		endpoints: selector,
+		inFlight:  controllers.NewInFlightOperations(),
+		pprofAddr: opts.pprofAddr,
+		redact:    opts.redactDevices,
+		lnetCheck: opts.lnetPrecheck,
+		gfs2Check: opts.gfs2Precheck,
+		mountRoot: opts.orphanMountRoot,
+		hookDir:   opts.hookDir,
+		autofsDir: opts.autofsMapDir,
+		secretDir: opts.secretDir,
+		privilege: len(opts.helperSocket) != 0,
+		lvmGuard:  controllers.NewLVMGuard(opts.lvmConcurrency, opts.lvmFailureThreshold, opts.lvmCooldown),
+		lvmWait:   opts.lvmReleaseTimeout,
+		prober:    prober,
+		usageTime: opts.usageReportInterval,
+		mockLVM:   mockLVM,
+
+		metricsAddr: opts.metricsAddr,
+		listeners:   listeners,
+
+		nodeStatus:   nodeStatus,
+		checkpoint:   checkpoint,
+		nodeInfoTime: opts.nodeInfoInterval,
+		pageSize:     opts.listPageSize,
+
+		credentials:      credentials,
+		credentialReload: opts.credentialReload,
+
+		gracePeriod: opts.shutdownGracePeriod,
+
+		startupDelay: controllers.NodeJitter(opts.name, opts.startupJitter),
+		resyncPeriod: opts.resyncPeriod + controllers.NodeJitter(opts.name, opts.resyncPeriod/10),
+
+		standalone:          opts.standalone,
+		standaloneDir:       opts.standaloneDir,
+		standaloneStatusDir: opts.standaloneStatusDir,
+
+		nodeControllers: opts.nodeControllers,
+		nodeReportTime:  opts.nodeReportInterval,
+	}, nil
 }
 
-func startManager(config *managerConfig) {
+// newHostRunner returns the runner for the mount helper commands on the host
+func newHostRunner(opts *options) *command.HostRunner {
+	runner := command.NewHostRunner().
+		WithBinary("mount", opts.mountCommand).
+		WithBinary("umount", opts.umountCommand).
+		WithBinary("lvs", opts.lvsCommand).
+		WithBinary("vgchange", opts.vgchangeCommand).
+		WithEnv("PATH", opts.commandPath).
+		WithEnv("LD_LIBRARY_PATH", opts.commandLdPath).
+		WithRetries(opts.commandRetries, opts.commandRetryDelay).
+		WithMaxOutput(opts.commandOutputLimit).
+		WithSpoolDir(opts.commandOutputSpool)
+	runner.Env = append(runner.Env, opts.commandEnv...)
+
+	return runner
+}
+
+func startManager(ctx context.Context, config *managerConfig) {
 	setupLog.Info("GOMAXPROCS", "value", runtime.GOMAXPROCS(0))
 
+	gracefulShutdownTimeout := config.gracePeriod + statusUpdateGrace
 	mgr, err := ctrl.NewManager(config.config, ctrl.Options{
 		Scheme:         scheme,
 		LeaderElection: false,
 		Namespace:      config.namespace,
+
+		// The metrics are served by startMetricsServer with the daemon's listener settings
+		MetricsBindAddress: "0",
+
+		// Give the reconcilers time to finish their commands and write their status
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
+
+		SyncPeriod: &config.resyncPeriod,
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -215,24 +643,140 @@ func startManager(config *managerConfig) {
 	}
 
 	if err = (&controllers.ClientMountReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ClientMount"),
-		Mock:   config.mock,
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClientMount"),
+		Settings: config.reloader.settings,
+		Runner:   config.runner,
+		Audit:    config.audit,
+		InFlight: config.inFlight,
+		Scheme:   mgr.GetScheme(),
+
+		RedactDevices: config.redact,
+		LNetPrecheck:  config.lnetCheck,
+		GFS2Precheck:  config.gfs2Check,
+		APIReader:     mgr.GetAPIReader(),
+
+		OrphanMountRoot:   config.mountRoot,
+		HookDir:           config.hookDir,
+		AutofsMapDir:      config.autofsDir,
+		SecretDir:         config.secretDir,
+		PrivilegedHelper:  config.privilege,
+		LVM:               config.lvmGuard,
+		LVMReleaseTimeout: config.lvmWait,
+		Prober:            config.prober,
+		UsageInterval:     config.usageTime,
+		MockLVM:           config.mockLVM,
+		NodeStatus:        config.nodeStatus,
+		Checkpoint:        config.checkpoint,
+
+		NodeName:         config.namespace,
+		NodeInfoInterval: config.nodeInfoTime,
+		ListPageSize:     config.pageSize,
+		DaemonVersion:    version,
+		Recorder:         mgr.GetEventRecorderFor("dws-mount-daemon"),
+
+		ShutdownGracePeriod: config.gracePeriod,
+		StartupDelay:        config.startupDelay,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClientMount")
 		os.Exit(1)
 	}
 
+	if err := controllers.SetupNodeControllers(mgr, config.nodeControllers, controllers.NodeControllerConfig{
+		NodeName:       config.namespace,
+		Runner:         config.runner,
+		APIReader:      mgr.GetAPIReader(),
+		Settings:       config.reloader.settings,
+		ReportInterval: config.nodeReportTime,
+		ListPageSize:   config.pageSize,
+		Log:            ctrl.Log.WithName("controllers"),
+	}); err != nil {
+		setupLog.Error(err, "unable to create node controllers")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 
+	if config.endpoints != nil {
+		go config.endpoints.monitor(ctx, config.config)
+	}
+
+	if config.credentials != nil && config.credentialReload != 0 {
+		go config.credentials.monitor(ctx, config.credentialReload)
+	}
+
+	if len(config.pprofAddr) != 0 {
+		go startPprofServer(ctx, config.pprofAddr, config.listeners, ctrl.Log.WithName("debug"))
+	}
+
+	if len(config.metricsAddr) != 0 && config.metricsAddr != "0" {
+		go startMetricsServer(ctx, config.metricsAddr, config.listeners, ctrl.Log.WithName("metrics"))
+	}
+
+	go handleDebugSignal(ctx, config.inFlight, ctrl.Log.WithName("debug"))
+	go config.reloader.handleReloadSignal(ctx, ctrl.Log.WithName("config"))
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
 
+// startStandalone runs the ClientMount reconciler against the ClientMount files in the
+// standalone directory rather than a Kubernetes API
+func startStandalone(ctx context.Context, config *managerConfig) {
+	if err := os.MkdirAll(config.standaloneStatusDir, 0755); err != nil {
+		setupLog.Error(err, "unable to create the standalone status directory")
+		os.Exit(1)
+	}
+
+	fileClient := controllers.NewFileClient(config.standaloneDir, config.standaloneStatusDir, config.namespace, scheme)
+
+	reconciler := &controllers.ClientMountReconciler{
+		Client:   fileClient,
+		Log:      ctrl.Log.WithName("controllers").WithName("ClientMount"),
+		Settings: config.reloader.settings,
+		Runner:   config.runner,
+		Audit:    config.audit,
+		InFlight: config.inFlight,
+		Scheme:   scheme,
+
+		RedactDevices: config.redact,
+		LNetPrecheck:  config.lnetCheck,
+		GFS2Precheck:  config.gfs2Check,
+
+		HookDir:           config.hookDir,
+		AutofsMapDir:      config.autofsDir,
+		SecretDir:         config.secretDir,
+		PrivilegedHelper:  config.privilege,
+		LVM:               config.lvmGuard,
+		LVMReleaseTimeout: config.lvmWait,
+		Prober:            config.prober,
+		UsageInterval:     config.usageTime,
+		MockLVM:           config.mockLVM,
+		NodeStatus:        config.nodeStatus,
+		Checkpoint:        config.checkpoint,
+
+		NodeName: config.namespace,
+
+		ShutdownGracePeriod: config.gracePeriod,
+	}
+
+	if len(config.metricsAddr) != 0 && config.metricsAddr != "0" {
+		go startMetricsServer(ctx, config.metricsAddr, config.listeners, ctrl.Log.WithName("metrics"))
+	}
+
+	go handleDebugSignal(ctx, config.inFlight, ctrl.Log.WithName("debug"))
+	go config.reloader.handleReloadSignal(ctx, ctrl.Log.WithName("config"))
+
+	setupLog.Info("starting standalone reconciler")
+	if err := reconciler.RunStandalone(ctx, fileClient, config.reloader.settings.Get().RetryDelay); err != nil {
+		setupLog.Error(err, "problem running standalone reconciler")
+		os.Exit(1)
+	}
+}
+
 func main() {
 	kindFn := func() daemon.Kind {
 		if runtime.GOOS == "darwin" {
@@ -0,0 +1,120 @@
+//go:build e2e
+// +build e2e
+
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2e
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/test/e2e/framework"
+)
+
+var _ = Describe("ClientMount Lifecycle", func() {
+
+	const timeout = 2 * time.Minute
+
+	var clientMount *dwsv1alpha1.ClientMount
+
+	// eventuallyInState waits for the daemon to move every mount to the state
+	eventuallyInState := func(state dwsv1alpha1.ClientMountState) {
+		Eventually(func(g Gomega) {
+			g.Expect(cluster.Client.Get(ctx, client.ObjectKeyFromObject(clientMount), clientMount)).To(Succeed())
+			g.Expect(framework.InState(clientMount, state)).To(BeTrue(), "status: %+v", clientMount.Status)
+		}).WithTimeout(timeout).WithPolling(time.Second).Should(Succeed())
+	}
+
+	// setDesiredState flips the desired state of the ClientMount
+	setDesiredState := func(state dwsv1alpha1.ClientMountState) {
+		Eventually(func() error {
+			if err := cluster.Client.Get(ctx, client.ObjectKeyFromObject(clientMount), clientMount); err != nil {
+				return err
+			}
+			clientMount.Spec.DesiredState = state
+			return cluster.Client.Update(ctx, clientMount)
+		}).WithTimeout(timeout).Should(Succeed())
+	}
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(cluster.Client.Delete(ctx, clientMount))).To(Succeed())
+
+		// The daemon holds a finalizer until the file systems are unmounted
+		Eventually(func() bool {
+			err := cluster.Client.Get(ctx, client.ObjectKeyFromObject(clientMount), &dwsv1alpha1.ClientMount{})
+			return apierrors.IsNotFound(err)
+		}).WithTimeout(timeout).Should(BeTrue())
+	})
+
+	It("Mounts and unmounts a tmpfs on every worker", func() {
+		for i, daemon := range daemons {
+			clientMount = framework.NewClientMount(daemon.Node, fmt.Sprintf("tmpfs-%d", i), framework.TmpfsMount("/mnt/dws-e2e/tmpfs"))
+			Expect(cluster.Client.Create(ctx, clientMount)).To(Succeed())
+
+			By("mounting on " + daemon.Node)
+			eventuallyInState(dwsv1alpha1.ClientMountStateMounted)
+
+			if daemonMode == framework.DaemonModeHost {
+				Expect(cluster.Exec(ctx, daemon.Node, "mountpoint", "/mnt/dws-e2e/tmpfs")).Error().NotTo(HaveOccurred())
+			}
+
+			By("unmounting on " + daemon.Node)
+			setDesiredState(dwsv1alpha1.ClientMountStateUnmounted)
+			eventuallyInState(dwsv1alpha1.ClientMountStateUnmounted)
+
+			Expect(cluster.Client.Delete(ctx, clientMount)).To(Succeed())
+		}
+	})
+
+	It("Mounts again after an unmount", func() {
+		daemon := daemons[0]
+		clientMount = framework.NewClientMount(daemon.Node, "remount", framework.TmpfsMount("/mnt/dws-e2e/remount"))
+		Expect(cluster.Client.Create(ctx, clientMount)).To(Succeed())
+		eventuallyInState(dwsv1alpha1.ClientMountStateMounted)
+
+		setDesiredState(dwsv1alpha1.ClientMountStateUnmounted)
+		eventuallyInState(dwsv1alpha1.ClientMountStateUnmounted)
+
+		setDesiredState(dwsv1alpha1.ClientMountStateMounted)
+		eventuallyInState(dwsv1alpha1.ClientMountStateMounted)
+	})
+
+	It("Activates and deactivates an LVM volume", func() {
+		if daemonMode != framework.DaemonModeMock {
+			Skip("LVM volumes need a loopback volume group in host mode")
+		}
+
+		daemon := daemons[0]
+		clientMount = framework.NewClientMount(daemon.Node, "lvm", framework.LVMMount("/mnt/dws-e2e/lvm", "e2e-vg", "e2e-lv"))
+		Expect(cluster.Client.Create(ctx, clientMount)).To(Succeed())
+		eventuallyInState(dwsv1alpha1.ClientMountStateMounted)
+
+		setDesiredState(dwsv1alpha1.ClientMountStateUnmounted)
+		eventuallyInState(dwsv1alpha1.ClientMountStateUnmounted)
+	})
+})
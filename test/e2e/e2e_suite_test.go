@@ -0,0 +1,104 @@
+//go:build e2e
+// +build e2e
+
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package e2e
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/HewlettPackard/dws/test/e2e/framework"
+)
+
+// The end-to-end tests run against a kind cluster whose worker nodes each run a
+// mount-daemon. Run them with "make e2e". The daemon mode is chosen with E2E_DAEMON_MODE
+// ("mock" or "host"); see framework.ClusterFromEnv for the cluster settings.
+
+var ctx context.Context
+var cancel context.CancelFunc
+var cluster *framework.Cluster
+var daemons []*framework.Daemon
+var daemonMode framework.DaemonMode
+
+func TestE2E(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "End-to-End Suite")
+}
+
+var _ = BeforeSuite(func() {
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	daemonMode = framework.DaemonModeMock
+	if mode := os.Getenv("E2E_DAEMON_MODE"); mode != "" {
+		daemonMode = framework.DaemonMode(mode)
+	}
+
+	By("starting the kind cluster")
+	cluster = framework.ClusterFromEnv()
+	Expect(cluster.Start(ctx)).To(Succeed())
+
+	By("building the mount-daemon")
+	binary, err := framework.BuildDaemon(ctx, GinkgoT().TempDir())
+	Expect(err).NotTo(HaveOccurred())
+
+	By("starting a mount-daemon on each worker node")
+	nodes, err := cluster.WorkerNodes(ctx)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(nodes).NotTo(BeEmpty())
+
+	for _, node := range nodes {
+		daemon, err := cluster.StartDaemon(ctx, binary, node, daemonMode)
+		Expect(err).NotTo(HaveOccurred())
+		daemons = append(daemons, daemon)
+	}
+})
+
+var _ = ReportAfterEach(func(report SpecReport) {
+	if !report.Failed() {
+		return
+	}
+
+	for _, daemon := range daemons {
+		if logs, err := daemon.Logs(ctx); err == nil {
+			AddReportEntry("mount-daemon log "+daemon.Node, logs)
+		}
+	}
+})
+
+var _ = AfterSuite(func() {
+	By("stopping the mount-daemons")
+	for _, daemon := range daemons {
+		Expect(daemon.Stop(ctx)).To(Succeed())
+	}
+
+	By("stopping the kind cluster")
+	if cluster != nil {
+		Expect(cluster.Stop(ctx)).To(Succeed())
+	}
+
+	cancel()
+})
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package framework
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// NewClientMount returns a ClientMount in the node's namespace that mounts the mounts
+func NewClientMount(node string, name string, mounts ...dwsv1alpha1.ClientMountInfo) *dwsv1alpha1.ClientMount {
+	return &dwsv1alpha1.ClientMount{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: node},
+		Spec: dwsv1alpha1.ClientMountSpec{
+			Node:         node,
+			DesiredState: dwsv1alpha1.ClientMountStateMounted,
+			Mounts:       mounts,
+		},
+	}
+}
+
+// TmpfsMount returns a tmpfs mount, which can be mounted in the node containers in both
+// daemon modes
+func TmpfsMount(mountPath string) dwsv1alpha1.ClientMountInfo {
+	return dwsv1alpha1.ClientMountInfo{
+		MountPath:  mountPath,
+		Type:       dwsv1alpha1.FileSystemTypeTmpfs,
+		TargetType: dwsv1alpha1.TargetTypeDirectory,
+		Device: dwsv1alpha1.ClientMountDevice{
+			Type:  dwsv1alpha1.ClientMountDeviceTypeTmpfs,
+			Tmpfs: &dwsv1alpha1.ClientMountDeviceTmpfs{Size: "16M"},
+		},
+	}
+}
+
+// LVMMount returns an xfs mount of an LVM volume
+func LVMMount(mountPath string, volumeGroup string, logicalVolume string) dwsv1alpha1.ClientMountInfo {
+	return dwsv1alpha1.ClientMountInfo{
+		MountPath:  mountPath,
+		Type:       dwsv1alpha1.FileSystemTypeXFS,
+		TargetType: dwsv1alpha1.TargetTypeDirectory,
+		Device: dwsv1alpha1.ClientMountDevice{
+			Type: dwsv1alpha1.ClientMountDeviceTypeLVM,
+			LVM: &dwsv1alpha1.ClientMountDeviceLVM{
+				DeviceType:    dwsv1alpha1.ClientMountLVMDeviceTypeNVMe,
+				VolumeGroup:   volumeGroup,
+				LogicalVolume: logicalVolume,
+			},
+		},
+	}
+}
+
+// InState returns whether the daemon has moved every mount of the ClientMount to the state
+func InState(clientMount *dwsv1alpha1.ClientMount, state dwsv1alpha1.ClientMountState) bool {
+	if len(clientMount.Status.Mounts) != len(clientMount.Spec.Mounts) {
+		return false
+	}
+
+	for _, mount := range clientMount.Status.Mounts {
+		if mount.State != state || !mount.Ready {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package framework stands up a kind cluster for the end-to-end tests. The kind worker
+// node containers stand in for compute nodes, each running a mount-daemon that the tests
+// drive through ClientMount resources.
+package framework
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// Cluster is the kind cluster the end-to-end tests run against
+type Cluster struct {
+	// Name is the name of the kind cluster
+	Name string
+
+	// Image is the kind node image. kind's default image is used if empty.
+	Image string
+
+	// Workers is the number of worker nodes created with the cluster
+	Workers int
+
+	// Keep leaves the cluster running after the tests for debugging. An existing cluster
+	// with the same name is always reused and never deleted.
+	Keep bool
+
+	// Kubeconfig is the path of the kubeconfig for the cluster. It's set by Start.
+	Kubeconfig string
+
+	// Client is a client for the core and DWS resources. It's set by Start.
+	Client client.Client
+
+	created bool
+}
+
+// ClusterFromEnv returns a cluster described by the E2E_ environment variables:
+//
+//	E2E_CLUSTER_NAME  name of the kind cluster (default "dws-e2e")
+//	E2E_NODE_IMAGE    kind node image
+//	E2E_KEEP_CLUSTER  leave the cluster running after the tests if set
+func ClusterFromEnv() *Cluster {
+	c := &Cluster{
+		Name:    "dws-e2e",
+		Image:   os.Getenv("E2E_NODE_IMAGE"),
+		Workers: 2,
+	}
+
+	if name := os.Getenv("E2E_CLUSTER_NAME"); name != "" {
+		c.Name = name
+	}
+
+	_, c.Keep = os.LookupEnv("E2E_KEEP_CLUSTER")
+
+	return c
+}
+
+// Start creates the kind cluster if it doesn't already exist and installs the DWS CRDs
+func (c *Cluster) Start(ctx context.Context) error {
+	root, err := RepoRoot()
+	if err != nil {
+		return err
+	}
+
+	exists, err := c.exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.Kubeconfig = filepath.Join(os.TempDir(), "kubeconfig-"+c.Name)
+
+	if exists {
+		if _, err := run(ctx, nil, "kind", "export", "kubeconfig", "--name", c.Name, "--kubeconfig", c.Kubeconfig); err != nil {
+			return err
+		}
+	} else {
+		config := filepath.Join(os.TempDir(), "kind-"+c.Name+".yaml")
+		if err := os.WriteFile(config, []byte(c.kindConfig()), 0644); err != nil {
+			return err
+		}
+
+		args := []string{"create", "cluster", "--name", c.Name, "--config", config, "--kubeconfig", c.Kubeconfig, "--wait", "5m"}
+		if c.Image != "" {
+			args = append(args, "--image", c.Image)
+		}
+
+		if _, err := run(ctx, nil, "kind", args...); err != nil {
+			return err
+		}
+
+		c.created = true
+	}
+
+	config, err := clientcmd.BuildConfigFromFlags("", c.Kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	c.Client, err = dwsv1alpha1.NewClient(config)
+	if err != nil {
+		return err
+	}
+
+	// Server-side apply avoids the size limit on the last-applied annotation, which the
+	// larger CRDs exceed
+	_, err = c.Kubectl(ctx, "apply", "--server-side", "-f", filepath.Join(root, "config", "crd", "bases"))
+	return err
+}
+
+// Stop deletes the kind cluster if Start created it and it isn't being kept
+func (c *Cluster) Stop(ctx context.Context) error {
+	if !c.created || c.Keep {
+		return nil
+	}
+
+	_, err := run(ctx, nil, "kind", "delete", "cluster", "--name", c.Name)
+	return err
+}
+
+// WorkerNodes returns the names of the worker nodes. The names are also the names of the
+// node containers.
+func (c *Cluster) WorkerNodes(ctx context.Context) ([]string, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.Client.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	workers := []string{}
+	for _, node := range nodes.Items {
+		if _, found := node.Labels["node-role.kubernetes.io/control-plane"]; !found {
+			workers = append(workers, node.Name)
+		}
+	}
+
+	return workers, nil
+}
+
+// Kubectl runs kubectl against the cluster
+func (c *Cluster) Kubectl(ctx context.Context, args ...string) (string, error) {
+	return run(ctx, nil, "kubectl", append([]string{"--kubeconfig", c.Kubeconfig}, args...)...)
+}
+
+// Exec runs a command in a node container
+func (c *Cluster) Exec(ctx context.Context, node string, args ...string) (string, error) {
+	return run(ctx, nil, "docker", append([]string{"exec", node}, args...)...)
+}
+
+// apiServer returns the address of the API server from inside the node containers
+func (c *Cluster) apiServer() (string, string) {
+	return c.Name + "-control-plane", "6443"
+}
+
+func (c *Cluster) exists(ctx context.Context) (bool, error) {
+	output, err := run(ctx, nil, "kind", "get", "clusters")
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range strings.Fields(output) {
+		if name == c.Name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (c *Cluster) kindConfig() string {
+	config := "kind: Cluster\napiVersion: kind.x-k8s.io/v1alpha4\nnodes:\n- role: control-plane\n"
+	for i := 0; i < c.Workers; i++ {
+		config += "- role: worker\n"
+	}
+
+	return config
+}
+
+// RepoRoot returns the root of the repository, found by looking for go.mod in the
+// parent directories of the working directory
+func RepoRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("go.mod not found above the working directory")
+		}
+		dir = parent
+	}
+}
+
+// run runs a command with stdin and returns its output. The output is included in the
+// error if the command fails.
+func run(ctx context.Context, stdin []byte, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, output)
+	}
+
+	return string(output), nil
+}
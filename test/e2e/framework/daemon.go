@@ -0,0 +1,181 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DaemonMode is how the mount-daemon in a node container handles the mounts
+type DaemonMode string
+
+const (
+	// DaemonModeMock runs the daemon in mock mode with the fake LVM. No commands are run
+	// on the node container.
+	DaemonModeMock DaemonMode = "mock"
+
+	// DaemonModeHost runs the mount commands in the node container. The kind node
+	// containers are privileged, so tmpfs mounts and loopback devices work in them.
+	DaemonModeHost DaemonMode = "host"
+)
+
+const (
+	daemonBinary = "/usr/local/bin/clientmountd"
+	daemonLog    = "/var/log/clientmountd.log"
+	daemonToken  = "/etc/dws/token"
+
+	// daemonServiceAccount is the ServiceAccount in the node's namespace that the daemon
+	// runs as, matching the name "clientmount bootstrap" requests tokens for
+	daemonServiceAccount = "clientmount"
+)
+
+// Daemon is a mount-daemon running in a worker node container
+type Daemon struct {
+	Node string
+	Mode DaemonMode
+
+	cluster *Cluster
+}
+
+// BuildDaemon builds a static mount-daemon binary in dir and returns its path
+func BuildDaemon(ctx context.Context, dir string) (string, error) {
+	root, err := RepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	binary := filepath.Join(dir, "clientmountd")
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", binary, "./mount-daemon")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("could not build the mount-daemon: %w: %s", err, output)
+	}
+
+	return binary, nil
+}
+
+// StartDaemon copies the mount-daemon binary into the node container and starts it with a
+// token for the clientmount ServiceAccount in the node's namespace. The extra args are
+// passed to the daemon.
+func (c *Cluster) StartDaemon(ctx context.Context, binary string, node string, mode DaemonMode, args ...string) (*Daemon, error) {
+	if err := c.createNodeAccount(ctx, node); err != nil {
+		return nil, err
+	}
+
+	token, err := c.Kubectl(ctx, "create", "token", daemonServiceAccount, "--namespace", node, "--duration", "24h")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.Exec(ctx, node, "mkdir", "-p", filepath.Dir(daemonToken)); err != nil {
+		return nil, err
+	}
+
+	if _, err := run(ctx, []byte(token), "docker", "exec", "-i", node, "sh", "-c", "cat > "+daemonToken); err != nil {
+		return nil, err
+	}
+
+	if _, err := run(ctx, nil, "docker", "cp", binary, node+":"+daemonBinary); err != nil {
+		return nil, err
+	}
+
+	host, port := c.apiServer()
+	daemonArgs := []string{
+		"--kubernetes-service-host=" + host,
+		"--kubernetes-service-port=" + port,
+		"--node-name=" + node,
+		"--service-token-file=" + daemonToken,
+		"--service-cert-file=/etc/kubernetes/pki/ca.crt",
+		"--metrics-bind-address=0",
+	}
+
+	if mode == DaemonModeMock {
+		daemonArgs = append(daemonArgs, "--mock", "--mock-lvm")
+	}
+
+	command := "exec " + daemonBinary
+	for _, arg := range append(daemonArgs, args...) {
+		command += " '" + arg + "'"
+	}
+	command += " >" + daemonLog + " 2>&1"
+
+	if _, err := run(ctx, nil, "docker", "exec", "-d", node, "sh", "-c", command); err != nil {
+		return nil, err
+	}
+
+	return &Daemon{Node: node, Mode: mode, cluster: c}, nil
+}
+
+// Stop stops the daemon. It's given the default shutdown grace period to finish.
+func (d *Daemon) Stop(ctx context.Context) error {
+	_, err := d.cluster.Exec(ctx, d.Node, "pkill", "-TERM", "-x", filepath.Base(daemonBinary))
+	return err
+}
+
+// Logs returns the daemon's log
+func (d *Daemon) Logs(ctx context.Context) (string, error) {
+	return d.cluster.Exec(ctx, d.Node, "cat", daemonLog)
+}
+
+// CreateLoopbackVolumeGroup creates an LVM volume group on a loopback device in the node
+// container for tests that mount LVM volumes in host mode. The node image must have lvm2
+// installed, which the default kind node image does not.
+func (c *Cluster) CreateLoopbackVolumeGroup(ctx context.Context, node string, volumeGroup string, size string) error {
+	image := "/var/lib/dws-e2e/" + volumeGroup + ".img"
+	script := fmt.Sprintf("mkdir -p /var/lib/dws-e2e && truncate -s %s %s && dev=$(losetup --find --show %s) && pvcreate $dev && vgcreate %s $dev", size, image, image, volumeGroup)
+
+	_, err := c.Exec(ctx, node, "sh", "-c", script)
+	return err
+}
+
+// createNodeAccount creates the node's namespace and the ServiceAccount the daemon runs
+// as. The account is bound to cluster-admin since the daemon's RBAC isn't what's under
+// test.
+func (c *Cluster) createNodeAccount(ctx context.Context, node string) error {
+	objects := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: node}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: daemonServiceAccount, Namespace: node}},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "dws-e2e-clientmount-" + node},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: daemonServiceAccount, Namespace: node}},
+		},
+	}
+
+	for _, obj := range objects {
+		if err := c.Client.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+
+	return nil
+}
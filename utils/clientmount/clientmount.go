@@ -0,0 +1,219 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package clientmount is the ClientMount reconciliation shared by the mount-daemon and the
+// cluster controller that stands in for it where there are no compute nodes. The Core
+// handles suspension, the finalizer, status initialization, and the transitions between
+// the desired states the same way for both. An Actuator does the mounting and unmounting:
+// the mount-daemon's runs the mount commands (or logs them in mock mode), and the
+// NoopActuator only marks the mounts ready.
+package clientmount
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/dwsowner"
+)
+
+// Finalizer is held on a ClientMount until its file systems are unmounted, as required by
+// the dwsowner convention
+const Finalizer = dwsowner.ClientMountFinalizer
+
+// Actuator mounts and unmounts the file systems of a ClientMount for the Core
+type Actuator interface {
+	// Actuate moves the mounts toward the desired state of the ClientMount. It sets the
+	// ready state of the mounts and the error in the status.
+	Actuate(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error)
+
+	// Release unmounts the file systems of a deleted ClientMount. The finalizer is removed
+	// once it returns an empty result without an error.
+	Release(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error)
+}
+
+// Core reconciles a ClientMount with an Actuator. The caller gets the ClientMount and
+// applies its status afterwards.
+type Core struct {
+	Client   client.Client
+	Log      logr.Logger
+	Actuator Actuator
+
+	// Recorder emits the warning events for ClientMounts released without unmounting. No
+	// events are emitted if nil.
+	Recorder record.EventRecorder
+}
+
+// Reconcile moves the ClientMount toward its desired state. The timing, environment, and
+// conditions in the status are updated from the mounts before it returns.
+func (c *Core) Reconcile(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	defer func() {
+		clientMount.Status.UpdateTiming()
+		clientMount.UpdateEnv()
+		clientMount.UpdateConditions()
+	}()
+
+	// A suspended ClientMount is left as it is, even if it's being deleted, until it's resumed
+	if clientMount.Spec.Suspended {
+		c.Log.V(1).Info("Reconciling is suspended")
+		return ctrl.Result{}, nil
+	}
+
+	// Handle cleanup if the resource is being deleted
+	if !clientMount.GetDeletionTimestamp().IsZero() {
+		if !controllerutil.ContainsFinalizer(clientMount, Finalizer) {
+			return ctrl.Result{}, nil
+		}
+
+		if !c.skipRelease(clientMount) {
+			if res, err := c.Actuator.Release(ctx, clientMount); err != nil || !res.IsZero() {
+				return res, err
+			}
+		}
+
+		controllerutil.RemoveFinalizer(clientMount, Finalizer)
+		if err := c.Client.Update(ctx, clientMount); err != nil {
+			return ctrl.Result{}, err
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	// The new status is written before anything is mounted
+	if InitStatus(clientMount) {
+		return ctrl.Result{}, nil
+	}
+
+	// Add finalizer if it doesn't exist
+	if !controllerutil.ContainsFinalizer(clientMount, Finalizer) {
+		controllerutil.AddFinalizer(clientMount, Finalizer)
+		if err := c.Client.Update(ctx, clientMount); err != nil {
+			return ctrl.Result{Requeue: true}, nil
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	return c.Actuator.Actuate(ctx, clientMount)
+}
+
+// skipRelease returns true if the ClientMount has a confirmed skip-unmount annotation, so its
+// finalizer is removed without releasing the mounts. An unconfirmed annotation is reported
+// and the mounts are released as usual.
+func (c *Core) skipRelease(clientMount *dwsv1alpha1.ClientMount) bool {
+	requested, confirmed := clientMount.SkipUnmount()
+	if !requested {
+		return false
+	}
+
+	if !confirmed {
+		c.Log.Info("Ignoring unconfirmed skip-unmount annotation", "annotation", dwsv1alpha1.ClientMountSkipUnmountAnnotation)
+		c.event(clientMount, "SkipUnmountNotConfirmed", fmt.Sprintf("The %s annotation must be set to the name of the ClientMount; unmounting as usual", dwsv1alpha1.ClientMountSkipUnmountAnnotation))
+		return false
+	}
+
+	c.Log.Info("Removing the finalizer without unmounting", "annotation", dwsv1alpha1.ClientMountSkipUnmountAnnotation)
+	c.event(clientMount, "UnmountSkipped", "The finalizer was removed without unmounting; the file systems are left mounted on the node")
+	return true
+}
+
+// event emits a warning event for the ClientMount if there's a recorder
+func (c *Core) event(clientMount *dwsv1alpha1.ClientMount, reason string, message string) {
+	if c.Recorder != nil {
+		c.Recorder.Event(clientMount, corev1.EventTypeWarning, reason, message)
+	}
+}
+
+// InitStatus creates the status of each mount that doesn't have one and resets the mounts
+// when the desired state changes. The status of a mount is found by its mount path, so the
+// status of the other mounts is kept when the mounts in the spec are edited. A status
+// written before the mount path was recorded is only kept if the number of mounts is the
+// same. The status of a removed mount is dropped. It returns true if any mount was reset.
+func InitStatus(clientMount *dwsv1alpha1.ClientMount) bool {
+	if len(clientMount.Spec.Mounts) == 0 {
+		if len(clientMount.Status.Mounts) != 0 {
+			clientMount.Status.Mounts = []dwsv1alpha1.ClientMountInfoStatus{}
+			clientMount.Status.UpdateReadyCount()
+		}
+
+		return false
+	}
+
+	previous := clientMount.Status.Mounts
+	byPath := map[string]dwsv1alpha1.ClientMountInfoStatus{}
+	for _, status := range previous {
+		if status.MountPath != "" {
+			byPath[status.MountPath] = status
+		}
+	}
+
+	mounts := make([]dwsv1alpha1.ClientMountInfoStatus, len(clientMount.Spec.Mounts))
+	for i, mount := range clientMount.Spec.Mounts {
+		if status, found := byPath[mount.MountPath]; found {
+			mounts[i] = *status.DeepCopy()
+		} else if len(previous) == len(mounts) && previous[i].MountPath == "" {
+			mounts[i] = previous[i]
+		}
+		mounts[i].MountPath = mount.MountPath
+	}
+	clientMount.Status.Mounts = mounts
+
+	// Reset the mounts whose state doesn't match the desired state, including the new ones
+	reset := false
+	for i := range clientMount.Status.Mounts {
+		if clientMount.Status.Mounts[i].State == clientMount.Spec.DesiredState {
+			continue
+		}
+
+		clientMount.Status.Mounts[i].State = clientMount.Spec.DesiredState
+		clientMount.Status.Mounts[i].Ready = false
+		clientMount.Status.Mounts[i].MountStarted = nil
+		reset = true
+	}
+	clientMount.Status.UpdateReadyCount()
+
+	return reset
+}
+
+// NoopActuator marks the mounts ready without mounting anything. The mounts of a dry-run
+// ClientMount are never ready, the same as with the mount-daemon.
+type NoopActuator struct{}
+
+// Actuate marks the mounts ready unless the ClientMount is a dry run
+func (NoopActuator) Actuate(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	for i := range clientMount.Status.Mounts {
+		clientMount.Status.Mounts[i].Ready = !clientMount.Spec.DryRun
+	}
+	clientMount.Status.UpdateReadyCount()
+	clientMount.Status.Error = nil
+
+	return ctrl.Result{}, nil
+}
+
+// Release has nothing to unmount
+func (NoopActuator) Release(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	return ctrl.Result{}, nil
+}
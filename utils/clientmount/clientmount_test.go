@@ -0,0 +1,272 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package clientmount
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+// updateClient counts the updates sent to it
+type updateClient struct {
+	client.Client
+	updates int
+}
+
+func (c *updateClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.updates++
+	return nil
+}
+
+// deletedClient is an updateClient for a ClientMount that's removed along with its last
+// finalizer, so the status patches sent to it aren't found
+type deletedClient struct {
+	updateClient
+	patches int
+}
+
+func (c *deletedClient) Scheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	_ = dwsv1alpha1.AddToScheme(scheme)
+	return scheme
+}
+
+func (c *deletedClient) Status() client.StatusWriter { return &deletedStatusWriter{c: c} }
+
+type deletedStatusWriter struct {
+	client.StatusWriter
+	c *deletedClient
+}
+
+func (w *deletedStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.c.patches++
+	return apierrors.NewNotFound(dwsv1alpha1.GroupVersion.WithResource("clientmounts").GroupResource(), obj.GetName())
+}
+
+// releaseActuator is a NoopActuator that holds the finalizer until it's released
+type releaseActuator struct {
+	NoopActuator
+	released bool
+}
+
+func (a *releaseActuator) Release(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	if !a.released {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func TestCoreReconcile(t *testing.T) {
+	c := &updateClient{}
+	actuator := &releaseActuator{}
+	core := &Core{Client: c, Log: logr.Discard(), Actuator: actuator}
+
+	clientMount := &dwsv1alpha1.ClientMount{
+		Spec: dwsv1alpha1.ClientMountSpec{
+			DesiredState: dwsv1alpha1.ClientMountStateMounted,
+			Mounts:       []dwsv1alpha1.ClientMountInfo{{MountPath: "/mnt/a"}, {MountPath: "/mnt/b"}},
+		},
+	}
+
+	// The status is initialized first, then the finalizer is added, then the mounts are actuated
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || len(clientMount.Status.Mounts) != 2 {
+		t.Fatalf("Status was not initialized: %v", err)
+	}
+
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || !controllerutil.ContainsFinalizer(clientMount, Finalizer) || c.updates != 1 {
+		t.Fatalf("Finalizer was not added: %v", err)
+	}
+
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || clientMount.Status.ReadyCount != 2 {
+		t.Fatalf("Mounts were not actuated: %v, ready %d", err, clientMount.Status.ReadyCount)
+	}
+
+	// A change of the desired state resets the mounts
+	clientMount.Spec.DesiredState = dwsv1alpha1.ClientMountStateUnmounted
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || clientMount.Status.ReadyCount != 0 || clientMount.Status.Mounts[0].State != dwsv1alpha1.ClientMountStateUnmounted {
+		t.Fatalf("Status was not reset: %v", err)
+	}
+
+	// A suspended ClientMount is left alone
+	clientMount.Spec.Suspended = true
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || clientMount.Status.ReadyCount != 0 {
+		t.Fatalf("Suspended ClientMount was actuated: %v", err)
+	}
+	clientMount.Spec.Suspended = false
+
+	// The finalizer is held until the actuator releases the ClientMount
+	now := metav1.Now()
+	clientMount.DeletionTimestamp = &now
+	if res, err := core.Reconcile(context.TODO(), clientMount); err != nil || !res.Requeue || !controllerutil.ContainsFinalizer(clientMount, Finalizer) {
+		t.Fatalf("Finalizer was removed before the release: %v", err)
+	}
+
+	actuator.released = true
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || controllerutil.ContainsFinalizer(clientMount, Finalizer) || c.updates != 2 {
+		t.Fatalf("Finalizer was not removed: %v", err)
+	}
+}
+
+func TestCoreReconcileDeleted(t *testing.T) {
+	c := &deletedClient{}
+	core := &Core{Client: c, Log: logr.Discard(), Actuator: NoopActuator{}}
+
+	now := metav1.Now()
+	clientMount := &dwsv1alpha1.ClientMount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test",
+			Namespace:         "default",
+			Finalizers:        []string{Finalizer},
+			DeletionTimestamp: &now,
+		},
+		Spec: dwsv1alpha1.ClientMountSpec{
+			DesiredState: dwsv1alpha1.ClientMountStateMounted,
+			Mounts:       []dwsv1alpha1.ClientMountInfo{{MountPath: "/mnt/a"}},
+		},
+	}
+
+	// Reconcile the way the controllers do, applying the status when it returns
+	reconcile := func() (err error) {
+		statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.ClientMountStatus](clientMount)
+		defer func() { err = statusUpdater.CloseWithStatusApply(context.TODO(), c, "test", err) }()
+
+		_, err = core.Reconcile(context.TODO(), clientMount)
+		return err
+	}
+
+	if err := reconcile(); err != nil {
+		t.Fatalf("Reconcile of the deleted ClientMount returned %v", err)
+	}
+
+	if controllerutil.ContainsFinalizer(clientMount, Finalizer) || c.updates != 1 {
+		t.Errorf("Finalizer was not removed")
+	}
+
+	if c.patches != 1 {
+		t.Errorf("Expected the status to be applied once, not %d times", c.patches)
+	}
+}
+
+func TestNoopActuatorDryRun(t *testing.T) {
+	clientMount := &dwsv1alpha1.ClientMount{
+		Spec:   dwsv1alpha1.ClientMountSpec{DryRun: true, Mounts: []dwsv1alpha1.ClientMountInfo{{}}},
+		Status: dwsv1alpha1.ClientMountStatus{Mounts: []dwsv1alpha1.ClientMountInfoStatus{{}}},
+	}
+
+	if _, err := (NoopActuator{}).Actuate(context.TODO(), clientMount); err != nil || clientMount.Status.Mounts[0].Ready {
+		t.Errorf("Dry-run mount was marked ready: %v", err)
+	}
+}
+
+func TestCoreSkipUnmount(t *testing.T) {
+	c := &updateClient{}
+	recorder := record.NewFakeRecorder(10)
+	core := &Core{Client: c, Log: logr.Discard(), Actuator: &releaseActuator{}, Recorder: recorder}
+
+	now := metav1.Now()
+	clientMount := &dwsv1alpha1.ClientMount{}
+	clientMount.Name = "job-1"
+	clientMount.DeletionTimestamp = &now
+	clientMount.Finalizers = []string{Finalizer}
+
+	// An unconfirmed annotation still waits for the release
+	clientMount.Annotations = map[string]string{dwsv1alpha1.ClientMountSkipUnmountAnnotation: "true"}
+	if res, err := core.Reconcile(context.TODO(), clientMount); err != nil || !res.Requeue || !controllerutil.ContainsFinalizer(clientMount, Finalizer) {
+		t.Fatalf("Finalizer was removed with an unconfirmed annotation: %v", err)
+	}
+
+	if event := <-recorder.Events; !strings.Contains(event, "SkipUnmountNotConfirmed") {
+		t.Errorf("Unexpected event %s", event)
+	}
+
+	// A confirmed annotation removes the finalizer without the release
+	clientMount.Annotations[dwsv1alpha1.ClientMountSkipUnmountAnnotation] = "job-1"
+	if _, err := core.Reconcile(context.TODO(), clientMount); err != nil || controllerutil.ContainsFinalizer(clientMount, Finalizer) || c.updates != 1 {
+		t.Fatalf("Finalizer was not removed: %v", err)
+	}
+
+	if event := <-recorder.Events; !strings.Contains(event, "Warning UnmountSkipped") {
+		t.Errorf("Unexpected event %s", event)
+	}
+}
+
+func TestInitStatus(t *testing.T) {
+	clientMount := &dwsv1alpha1.ClientMount{}
+	if InitStatus(clientMount) || clientMount.Status.Mounts != nil {
+		t.Fatalf("Status was created without mounts")
+	}
+
+	clientMount.Spec = dwsv1alpha1.ClientMountSpec{
+		DesiredState: dwsv1alpha1.ClientMountStateMounted,
+		Mounts:       []dwsv1alpha1.ClientMountInfo{{MountPath: "/mnt/a"}, {MountPath: "/mnt/b"}},
+	}
+
+	// A status written before the mount paths were recorded is kept if the mounts line up
+	clientMount.Status.Mounts = []dwsv1alpha1.ClientMountInfoStatus{
+		{State: dwsv1alpha1.ClientMountStateMounted, Ready: true},
+		{State: dwsv1alpha1.ClientMountStateMounted, Ready: true},
+	}
+	if InitStatus(clientMount) || clientMount.Status.ReadyCount != 2 || clientMount.Status.Mounts[1].MountPath != "/mnt/b" {
+		t.Fatalf("Existing status was not kept: %+v", clientMount.Status.Mounts)
+	}
+
+	// Adding a mount keeps the status of the others and resets only the new one
+	clientMount.Spec.Mounts = append([]dwsv1alpha1.ClientMountInfo{{MountPath: "/mnt/new"}}, clientMount.Spec.Mounts...)
+	if !InitStatus(clientMount) || len(clientMount.Status.Mounts) != 3 || clientMount.Status.ReadyCount != 2 {
+		t.Fatalf("Status of the other mounts was not kept: %+v", clientMount.Status.Mounts)
+	}
+
+	if status := clientMount.Status.Mounts[0]; status.MountPath != "/mnt/new" || status.Ready || status.State != dwsv1alpha1.ClientMountStateMounted {
+		t.Errorf("New mount has unexpected status %+v", status)
+	}
+
+	// Removing a mount keeps the status of the rest
+	clientMount.Status.Mounts[0].Ready = true
+	clientMount.Spec.Mounts = clientMount.Spec.Mounts[:2]
+	if InitStatus(clientMount) || len(clientMount.Status.Mounts) != 2 || clientMount.Status.Mounts[1].MountPath != "/mnt/a" || clientMount.Status.ReadyCount != 2 {
+		t.Fatalf("Status of the remaining mounts was not kept: %+v", clientMount.Status.Mounts)
+	}
+
+	// A change of the desired state resets every mount
+	clientMount.Spec.DesiredState = dwsv1alpha1.ClientMountStateUnmounted
+	if !InitStatus(clientMount) || clientMount.Status.ReadyCount != 0 || clientMount.Status.Mounts[1].State != dwsv1alpha1.ClientMountStateUnmounted {
+		t.Fatalf("Mounts were not reset: %+v", clientMount.Status.Mounts)
+	}
+
+	// Removing every mount drops their status
+	clientMount.Spec.Mounts = nil
+	if InitStatus(clientMount) || clientMount.Status.Mounts == nil || len(clientMount.Status.Mounts) != 0 || clientMount.Status.ReadyCount != 0 {
+		t.Fatalf("Status of the removed mounts was kept: %+v", clientMount.Status.Mounts)
+	}
+}
@@ -0,0 +1,380 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package command runs helper binaries (e.g., "mount" or "vgchange") on the host OS for
+// the node-side controllers. Commands that fail with a transient error are retried, and
+// the output that's kept in memory is limited. The output may be spooled to a file so the
+// full output of a chatty command is still available on the node.
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Result is the outcome of running a command
+type Result struct {
+	// Stdout and Stderr are the output of the last attempt, limited to the runner's
+	// maximum output size. Invalid UTF-8 is removed.
+	Stdout string
+	Stderr string
+
+	// Truncated is true if the output of the last attempt was cut to the maximum size
+	Truncated bool
+
+	// OutputFile is the spool file holding the full output of the last attempt. It's only
+	// set if the output was truncated and the runner has a spool directory.
+	OutputFile string
+
+	// ExitCode is the exit code of the last attempt. It's -1 if the command didn't exit
+	// normally (e.g., it couldn't be started or it was killed).
+	ExitCode int
+
+	// Duration is the time taken by all the attempts
+	Duration time.Duration
+
+	// Attempts is the number of times the command was run
+	Attempts int
+}
+
+// Runner runs a command on the host OS. The command is killed if the context is done
+// before it finishes. The Result is returned even if the command fails.
+type Runner interface {
+	Run(ctx context.Context, command string, args ...string) (*Result, error)
+}
+
+// HostRunner runs commands with a configurable binary for each command and a configurable
+// environment. The arguments are passed to the command as they are rather than through a
+// shell, so an argument can't run another command whatever it contains.
+type HostRunner struct {
+	// Binaries maps a command name to the binary run in its place. Commands that aren't
+	// in the map are resolved through PATH. The binary may be followed by arguments
+	// separated by spaces (e.g., "sudo mount"), which are run before the command's.
+	Binaries map[string]string
+
+	// Env is a list of "key=value" environment settings added to the process environment
+	// when running a command. Later entries take precedence.
+	Env []string
+
+	// Retries is the number of times a command is run again after a transient error
+	Retries int
+
+	// RetryDelay is the time between attempts
+	RetryDelay time.Duration
+
+	// MaxOutput is the number of bytes of stdout and of stderr kept from each attempt.
+	// The start and the end of the output are kept. The output isn't limited if zero.
+	MaxOutput int
+
+	// SpoolDir is a directory where the full output of an attempt is kept if it's
+	// truncated. The output isn't spooled if empty.
+	SpoolDir string
+}
+
+var _ Runner = &HostRunner{}
+
+// NewHostRunner returns a HostRunner that resolves all the commands through PATH using the
+// process environment and doesn't retry
+func NewHostRunner() *HostRunner {
+	return &HostRunner{
+		Binaries: map[string]string{},
+		Env:      []string{},
+	}
+}
+
+// WithBinary overrides the binary used for a command
+func (r *HostRunner) WithBinary(command string, binary string) *HostRunner {
+	if binary != "" && binary != command {
+		r.Binaries[command] = binary
+	}
+
+	return r
+}
+
+// WithEnv adds a "key=value" environment setting for the commands
+func (r *HostRunner) WithEnv(key string, value string) *HostRunner {
+	if value != "" {
+		r.Env = append(r.Env, key+"="+value)
+	}
+
+	return r
+}
+
+// WithRetries sets the number of retries after a transient error and the delay between them
+func (r *HostRunner) WithRetries(retries int, delay time.Duration) *HostRunner {
+	r.Retries = retries
+	r.RetryDelay = delay
+
+	return r
+}
+
+// WithMaxOutput sets the number of bytes of stdout and of stderr that are kept
+func (r *HostRunner) WithMaxOutput(maxOutput int) *HostRunner {
+	r.MaxOutput = maxOutput
+
+	return r
+}
+
+// WithSpoolDir sets the directory where the full output of a truncated command is kept
+func (r *HostRunner) WithSpoolDir(dir string) *HostRunner {
+	r.SpoolDir = dir
+
+	return r
+}
+
+// Run runs the command with the arguments. The command is run again after a transient error
+// until it succeeds, fails with another error, or runs out of retries.
+func (r *HostRunner) Run(ctx context.Context, command string, args ...string) (*Result, error) {
+	argv := []string{command}
+	if binary, found := r.Binaries[command]; found {
+		argv = strings.Fields(binary)
+	}
+
+	argv = append(argv, args...)
+	start := time.Now()
+
+	result := &Result{}
+	for {
+		err := r.runOnce(ctx, command, argv, result)
+		result.Attempts++
+		result.Duration = time.Since(start)
+
+		if err == nil || result.Attempts > r.Retries || !IsTransient(result, err) {
+			return result, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(r.RetryDelay):
+		}
+	}
+}
+
+// runOnce runs the command a single time and fills in the output and exit code
+func (r *HostRunner) runOnce(ctx context.Context, command string, argv []string, result *Result) error {
+	stdout := &limitedBuffer{max: r.MaxOutput}
+	stderr := &limitedBuffer{max: r.MaxOutput}
+
+	spool := r.createSpool(command)
+	if spool != nil {
+		stdout.tee = spool
+		stderr.tee = spool
+	}
+
+	cmd := exec.CommandContext(ctx, r.lookPath(argv[0]), argv[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if len(r.Env) != 0 {
+		cmd.Env = append(os.Environ(), r.Env...)
+	}
+
+	err := cmd.Run()
+
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+	result.ExitCode = ExitCode(err)
+	result.OutputFile = ""
+
+	if spool != nil {
+		spool.Close()
+		if result.Truncated {
+			result.OutputFile = spool.Name()
+		} else {
+			os.Remove(spool.Name())
+		}
+	}
+
+	return err
+}
+
+// lookPath resolves a command through the PATH in the runner's environment, which may not
+// be the PATH of the process. The command is returned as is if it isn't found there so the
+// process PATH is tried.
+func (r *HostRunner) lookPath(command string) string {
+	if strings.Contains(command, "/") {
+		return command
+	}
+
+	path := ""
+	for _, env := range r.Env {
+		if strings.HasPrefix(env, "PATH=") {
+			path = strings.TrimPrefix(env, "PATH=")
+		}
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+
+		candidate := filepath.Join(dir, command)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+			return candidate
+		}
+	}
+
+	return command
+}
+
+// createSpool creates a file in the spool directory for the output of an attempt. It
+// returns nil if there's no spool directory or the file can't be created, since the
+// spool is only a convenience for debugging.
+func (r *HostRunner) createSpool(command string) *os.File {
+	if r.SpoolDir == "" {
+		return nil
+	}
+
+	spool, err := os.CreateTemp(r.SpoolDir, filepath.Base(command)+"-*.out")
+	if err != nil {
+		return nil
+	}
+
+	return spool
+}
+
+// transientMessages are the messages printed for the errno classes that are worth retrying
+var transientMessages = []string{
+	"resource temporarily unavailable", // EAGAIN
+	"device or resource busy",          // EBUSY
+	"target is busy",                   // umount's EBUSY message
+}
+
+// IsTransient returns whether a failed command is likely to succeed if it's run again
+func IsTransient(result *Result, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// The command couldn't be started
+	if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EBUSY) {
+		return true
+	}
+
+	if result == nil {
+		return false
+	}
+
+	stderr := strings.ToLower(result.Stderr)
+	for _, message := range transientMessages {
+		if strings.Contains(stderr, message) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExitCode returns the exit code of a command from the error returned when running it. It's 0
+// for no error and -1 if the command didn't exit normally.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode()
+	}
+
+	return -1
+}
+
+// Truncate keeps the start and the end of s if it's longer than max bytes, with a marker
+// giving the number of bytes that were dropped between them. Invalid UTF-8, including a
+// character cut in two, is removed. s isn't limited if max is zero.
+func Truncate(s string, max int) string {
+	if max == 0 || len(s) <= max {
+		return strings.ToValidUTF8(s, "")
+	}
+
+	head := max / 2
+	tail := max - head
+
+	return joinTruncated(s[:head], len(s)-max, s[len(s)-tail:])
+}
+
+// joinTruncated joins the start and the end of a truncated output
+func joinTruncated(head string, dropped int, tail string) string {
+	return strings.ToValidUTF8(head, "") + fmt.Sprintf("\n... %d bytes truncated ...\n", dropped) + strings.ToValidUTF8(tail, "")
+}
+
+// limitedBuffer keeps the first and the last max/2 bytes written to it and drops the bytes
+// in between. It doesn't limit the output if max is zero. Everything written is also
+// copied to tee if it's set. The buffer isn't embedded so io.Copy can't bypass Write
+// through ReadFrom.
+type limitedBuffer struct {
+	head      bytes.Buffer
+	tail      []byte
+	max       int
+	dropped   int
+	truncated bool
+	tee       io.Writer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	// The spool is best effort, so a failed copy doesn't fail the command
+	if b.tee != nil {
+		_, _ = b.tee.Write(p)
+	}
+
+	if b.max == 0 {
+		return b.head.Write(p)
+	}
+
+	n := len(p)
+
+	if remaining := b.max/2 - b.head.Len(); remaining > 0 {
+		if remaining > len(p) {
+			remaining = len(p)
+		}
+
+		b.head.Write(p[:remaining])
+		p = p[remaining:]
+	}
+
+	// Keep the end of the output, dropping the oldest bytes past the start
+	b.tail = append(b.tail, p...)
+	if over := len(b.tail) - (b.max - b.max/2); over > 0 {
+		b.truncated = true
+		b.dropped += over
+		b.tail = b.tail[:copy(b.tail, b.tail[over:])]
+	}
+
+	// Report the whole write so the command isn't stopped by a short write
+	return n, nil
+}
+
+func (b *limitedBuffer) String() string {
+	if !b.truncated {
+		return strings.ToValidUTF8(b.head.String()+string(b.tail), "")
+	}
+
+	return joinTruncated(b.head.String(), b.dropped, string(b.tail))
+}
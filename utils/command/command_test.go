@@ -0,0 +1,192 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHostRunner(t *testing.T) {
+	result, err := NewHostRunner().Run(context.Background(), "echo", "hello")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Stdout != "hello\n" || result.ExitCode != 0 || result.Attempts != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	result, err = NewHostRunner().Run(context.Background(), "bash", "-c", "echo oops >&2; exit 3")
+	if err == nil {
+		t.Fatalf("Failed command did not return an error")
+	}
+	if result.Stderr != "oops\n" || result.ExitCode != 3 || ExitCode(err) != 3 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+}
+
+func TestHostRunnerBinary(t *testing.T) {
+	runner := NewHostRunner().WithBinary("greet", "printenv").WithEnv("GREETING", "hi")
+
+	result, err := runner.Run(context.Background(), "greet", "GREETING")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Stdout != "hi\n" {
+		t.Errorf("Expected the binary and environment to be used, got '%s'", result.Stdout)
+	}
+
+	// The binary may have arguments of its own
+	result, err = NewHostRunner().WithBinary("greet", "echo hello").Run(context.Background(), "greet", "world")
+	if err != nil || result.Stdout != "hello world\n" {
+		t.Errorf("Expected the binary's arguments to be used, got %+v, %v", result, err)
+	}
+}
+
+func TestHostRunnerArguments(t *testing.T) {
+	// An argument is passed as it is and can't run another command
+	marker := filepath.Join(t.TempDir(), "injected")
+	argument := "/mnt/a; touch " + marker + " $(touch " + marker + ")"
+
+	result, err := NewHostRunner().Run(context.Background(), "echo", argument, "'quoted'")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Stdout != argument+" 'quoted'\n" {
+		t.Errorf("Expected the arguments to be passed unchanged, got '%s'", result.Stdout)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Errorf("An argument ran a command")
+	}
+}
+
+func TestHostRunnerRetries(t *testing.T) {
+	// The script fails as busy on the first attempt and succeeds on the next
+	marker := filepath.Join(t.TempDir(), "attempted")
+	script := "if [ -e " + marker + " ]; then echo done; else touch " + marker + "; echo 'Device or resource busy' >&2; exit 32; fi"
+
+	result, err := NewHostRunner().WithRetries(2, time.Millisecond).Run(context.Background(), "bash", "-c", script)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Attempts != 2 || result.Stdout != "done\n" {
+		t.Errorf("Expected a second successful attempt, got %+v", result)
+	}
+
+	// Errors that aren't transient aren't retried
+	result, err = NewHostRunner().WithRetries(2, time.Millisecond).Run(context.Background(), "false")
+	if err == nil || result.Attempts != 1 {
+		t.Errorf("Expected a single failed attempt, got %+v, %v", result, err)
+	}
+
+	// Transient errors stop being retried once the retries run out
+	_ = os.Remove(marker)
+	result, _ = NewHostRunner().WithRetries(0, time.Millisecond).Run(context.Background(), "bash", "-c", script)
+	if result.Attempts != 1 || result.ExitCode != 32 {
+		t.Errorf("Expected a single failed attempt, got %+v", result)
+	}
+}
+
+func TestHostRunnerMaxOutput(t *testing.T) {
+	result, err := NewHostRunner().WithMaxOutput(4).Run(context.Background(), "echo", "0123456789")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Stdout != "01\n... 7 bytes truncated ...\n9\n" || !result.Truncated {
+		t.Errorf("Expected truncated output, got %+v", result)
+	}
+
+	result, err = NewHostRunner().WithMaxOutput(4).Run(context.Background(), "printf", "\\xff012")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Stdout != "012" || result.Truncated {
+		t.Errorf("Expected invalid UTF-8 to be removed, got %+v", result)
+	}
+}
+
+func TestHostRunnerSpool(t *testing.T) {
+	dir := t.TempDir()
+	runner := NewHostRunner().WithMaxOutput(4).WithSpoolDir(dir)
+
+	result, err := runner.Run(context.Background(), "echo", "0123456789")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	output, err := os.ReadFile(result.OutputFile)
+	if err != nil || string(output) != "0123456789\n" {
+		t.Errorf("Expected the full output in the spool file, got %q, %v", output, err)
+	}
+
+	// Output that fits isn't spooled
+	result, _ = runner.Run(context.Background(), "echo", "01")
+	if result.OutputFile != "" {
+		t.Errorf("Unexpected spool file %s", result.OutputFile)
+	}
+
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("Expected a single spool file, got %d", len(files))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if s := Truncate("0123456789", 0); s != "0123456789" {
+		t.Errorf("Unexpected truncation %q", s)
+	}
+
+	if s := Truncate("0123456789", 4); s != "01\n... 6 bytes truncated ...\n89" {
+		t.Errorf("Unexpected truncation %q", s)
+	}
+
+	// The cut multi-byte character is removed
+	if s := Truncate("aé12345678é", 4); s != "a\n... 9 bytes truncated ...\né" {
+		t.Errorf("Unexpected truncation %q", s)
+	}
+}
+
+func TestMockRunner(t *testing.T) {
+	failure := errors.New("failed")
+	runner := NewMockRunner(func(command string, args []string) (*Result, error) {
+		if command == "umount" {
+			return &Result{Stderr: "target is busy"}, failure
+		}
+
+		return &Result{Stdout: "ok"}, nil
+	})
+
+	if result, err := runner.Run(context.Background(), "mount", "/dev/sda", "/mnt"); err != nil || result.Stdout != "ok" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+
+	result, err := runner.Run(context.Background(), "umount", "/mnt")
+	if err != failure || !IsTransient(result, err) {
+		t.Errorf("Expected a transient failure, got %+v, %v", result, err)
+	}
+
+	calls := runner.Calls()
+	if len(calls) != 2 || calls[0] != "mount /dev/sda /mnt" || calls[1] != "umount /mnt" {
+		t.Errorf("Unexpected calls %v", calls)
+	}
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// ArgsValidator checks the arguments of an allowlisted command
+type ArgsValidator func(args []string) error
+
+// Allowlist maps the commands a privileged helper runs to the validators of their arguments
+type Allowlist map[string]ArgsValidator
+
+// Check returns an error if the command isn't in the allowlist or its validator refuses
+// the arguments
+func (a Allowlist) Check(command string, args []string) error {
+	validate, found := a[command]
+	if !found {
+		return fmt.Errorf("command '%s' is not allowed", command)
+	}
+
+	if validate == nil {
+		return nil
+	}
+
+	if err := validate(args); err != nil {
+		return fmt.Errorf("command '%s' is not allowed: %w", command, err)
+	}
+
+	return nil
+}
+
+// helperRequest is a command sent to the privileged helper. The arguments are the same ones
+// a Runner is given.
+type helperRequest struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// helperResponse is the outcome of a command run by the privileged helper. Result is nil if
+// the command was refused.
+type helperResponse struct {
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// HelperServer runs the commands sent to it over a unix socket if they're in its allowlist.
+// It lets the controller run without privileges while the small set of operations that need
+// them are run by a separate process that can't be asked to do anything else.
+type HelperServer struct {
+	// Runner runs the allowed commands
+	Runner Runner
+
+	// Allowlist holds the commands that may be run
+	Allowlist Allowlist
+
+	Log logr.Logger
+}
+
+// ListenHelper creates the unix socket for a HelperServer. Only the owner can connect to the
+// socket, and the owner is changed to uid and gid if they're not negative so the
+// unprivileged controller can connect. A socket left by an earlier helper is removed.
+func ListenHelper(path string, uid int, gid int) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	if uid >= 0 || gid >= 0 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+
+	return listener, nil
+}
+
+// Serve handles the connections on the listener until the context is done
+func (s *HelperServer) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+// handle runs the command sent on a connection. The command is killed if the client closes
+// the connection before it finishes.
+func (s *HelperServer) handle(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	request := helperRequest{}
+	decoder := json.NewDecoder(conn)
+	if err := decoder.Decode(&request); err != nil {
+		s.Log.Error(err, "Could not read request")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The client doesn't send anything after the request, so a read only returns when the
+	// client goes away or the connection is closed below
+	go func() {
+		_, _ = conn.Read(make([]byte, 1))
+		cancel()
+	}()
+
+	response := helperResponse{}
+	if err := s.Allowlist.Check(request.Command, request.Args); err != nil {
+		s.Log.Info("Refused command", "command", request.Command, "error", err.Error())
+		response.Error = err.Error()
+	} else {
+		result, err := s.Runner.Run(ctx, request.Command, request.Args...)
+		response.Result = result
+		if err != nil {
+			response.Error = err.Error()
+		}
+	}
+
+	if err := json.NewEncoder(conn).Encode(&response); err != nil {
+		s.Log.Error(err, "Could not write response", "command", request.Command)
+	}
+}
+
+// HelperRunner runs commands through a HelperServer listening on a unix socket. Retries and
+// binary overrides are up to the helper's runner.
+type HelperRunner struct {
+	// Socket is the path of the helper's unix socket
+	Socket string
+}
+
+var _ Runner = &HelperRunner{}
+
+// NewHelperRunner returns a HelperRunner for the helper listening on socket
+func NewHelperRunner(socket string) *HelperRunner {
+	return &HelperRunner{Socket: socket}
+}
+
+// Run sends the command to the helper and waits for its result. The connection is closed if
+// the context is done, which kills the command.
+func (r *HelperRunner) Run(ctx context.Context, command string, args ...string) (*Result, error) {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "unix", r.Socket)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the privileged helper: %w", err)
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err := json.NewEncoder(conn).Encode(&helperRequest{Command: command, Args: args}); err != nil {
+		return nil, fmt.Errorf("could not send the command to the privileged helper: %w", err)
+	}
+
+	response := helperResponse{}
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, fmt.Errorf("could not read the result from the privileged helper: %w", err)
+	}
+
+	if response.Error != "" {
+		return response.Result, errors.New(response.Error)
+	}
+
+	return response.Result, nil
+}
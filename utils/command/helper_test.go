@@ -0,0 +1,95 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+)
+
+func TestHelper(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, err := ListenHelper(filepath.Join(t.TempDir(), "helper.sock"), -1, -1)
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+
+	mock := NewMockRunner(func(command string, args []string) (*Result, error) {
+		if args[0] == "/mnt/busy" {
+			return &Result{Stderr: "target is busy"}, errors.New("exit status 32")
+		}
+
+		return &Result{Stdout: "ok"}, nil
+	})
+
+	server := &HelperServer{
+		Runner: mock,
+		Allowlist: Allowlist{
+			"umount": func(args []string) error {
+				if len(args) != 1 || filepath.Dir(args[0]) != "/mnt" {
+					return errors.New("unexpected arguments")
+				}
+				return nil
+			},
+		},
+		Log: logr.Discard(),
+	}
+
+	served := make(chan error)
+	go func() { served <- server.Serve(ctx, listener) }()
+
+	runner := NewHelperRunner(listener.Addr().String())
+
+	result, err := runner.Run(ctx, "umount", "/mnt/a")
+	if err != nil || result.Stdout != "ok" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+
+	result, err = runner.Run(ctx, "umount", "/mnt/busy")
+	if err == nil || result == nil || !IsTransient(result, err) {
+		t.Errorf("Expected the failure to be returned, got %+v, %v", result, err)
+	}
+
+	for _, args := range [][]string{{"/etc"}, {"/mnt/../etc"}, {"/mnt/a", "/mnt/b"}} {
+		if _, err := runner.Run(ctx, "umount", args...); err == nil {
+			t.Errorf("Expected umount %q to be refused", args)
+		}
+	}
+
+	if _, err := runner.Run(ctx, "reboot"); err == nil {
+		t.Errorf("Expected a command outside the allowlist to be refused")
+	}
+
+	if calls := mock.Calls(); !reflect.DeepEqual(calls, []string{"umount /mnt/a", "umount /mnt/busy"}) {
+		t.Errorf("Unexpected commands run %q", calls)
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("Serve returned unexpected error %v", err)
+	}
+}
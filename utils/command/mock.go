@@ -0,0 +1,74 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package command
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// MockHandler returns the result of a mocked command
+type MockHandler func(command string, args []string) (*Result, error)
+
+// MockRunner records the commands it's asked to run and returns the result from its
+// handler without running anything on the host. Commands succeed with no output if there's
+// no handler.
+type MockRunner struct {
+	mu sync.Mutex
+
+	handler MockHandler
+	calls   []string
+}
+
+var _ Runner = &MockRunner{}
+
+// NewMockRunner returns a MockRunner that uses handler for the results
+func NewMockRunner(handler MockHandler) *MockRunner {
+	return &MockRunner{handler: handler}
+}
+
+// Run records the command line and returns the handler's result
+func (r *MockRunner) Run(ctx context.Context, command string, args ...string) (*Result, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, strings.Join(append([]string{command}, args...), " "))
+	r.mu.Unlock()
+
+	if r.handler == nil {
+		return &Result{Attempts: 1}, nil
+	}
+
+	result, err := r.handler(command, args)
+	if result == nil {
+		result = &Result{}
+	}
+	result.Attempts = 1
+	result.ExitCode = ExitCode(err)
+
+	return result, err
+}
+
+// Calls returns the command lines that were run, in order
+func (r *MockRunner) Calls() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.calls...)
+}
@@ -0,0 +1,228 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package driver provides the scaffolding for a storage driver that works with DWS.
+//
+// A driver registers for the #DW directives it handles with a DWDirectiveRule. The rule's
+// DriverLabel is the driver's ID and its WatchStates are the workflow states the driver
+// takes part in. When a workflow is created, the workflow webhook adds an entry to the
+// workflow's status.drivers list for each directive and watch state the driver registered
+// for. When the workflow reaches one of those states, the driver does its work for the
+// directive and marks its entry completed. DWS moves the workflow to Ready once every entry
+// for the state is completed.
+//
+// A driver implements the Driver interface and runs it with a WorkflowReconciler, which finds
+// the driver's entries for the current state, calls the driver for each, and records the
+// result in the entries following the conventions the workflow webhook enforces. A driver
+// that runs its own reconciler can use the Complete, SetRunning, and SetError functions
+// directly.
+package driver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+// Driver is implemented by a storage driver to do its work for a workflow
+type Driver interface {
+	// ID returns the driver ID. It must match the DriverLabel of the driver's DWDirectiveRules.
+	ID() string
+
+	// Handle does the driver's work for the directive at index in workflow.Spec.DWDirectives
+	// in the workflow's current state (workflow.Status.State). It's called again until the
+	// result is complete. A returned error is reported in the driver's entry and the call is
+	// retried with a backoff. Handle must not update the workflow.
+	Handle(ctx context.Context, workflow *dwsv1alpha1.Workflow, index int) (Result, error)
+}
+
+// Result is the outcome of a call to Driver.Handle
+type Result struct {
+	// Complete is true when the driver is done with the directive for the current state
+	Complete bool
+
+	// Message is a user readable progress message for an incomplete result
+	Message string
+
+	// RequeueAfter is how long to wait before calling the driver again for an incomplete
+	// result. The driver is only called again when the workflow changes if it's 0.
+	RequeueAfter time.Duration
+}
+
+// PendingEntries returns the indexes of the entries in workflow.Status.Drivers that belong to
+// the driver, are for the workflow's current state, and aren't completed
+func PendingEntries(workflow *dwsv1alpha1.Workflow, driverID string) []int {
+	entries := []int{}
+	for i, entry := range workflow.Status.Drivers {
+		if entry.DriverID != driverID || entry.WatchState != workflow.Status.State || entry.Completed {
+			continue
+		}
+
+		entries = append(entries, i)
+	}
+
+	return entries
+}
+
+// Complete marks a driver entry completed. A completed entry must have the Completed status
+// and no error, and it can't change afterwards.
+func Complete(entry *dwsv1alpha1.WorkflowDriverStatus) {
+	now := metav1.NowMicro()
+
+	entry.Completed = true
+	entry.Status = dwsv1alpha1.StatusCompleted
+	entry.Message = ""
+	entry.Error = ""
+	entry.CompleteTime = &now
+}
+
+// SetRunning marks a driver entry as running with a progress message and clears any error
+func SetRunning(entry *dwsv1alpha1.WorkflowDriverStatus, message string) {
+	entry.Status = dwsv1alpha1.StatusRunning
+	entry.Message = message
+	entry.Error = ""
+}
+
+// SetError records an error in a driver entry. The user message of a ResourceErrorInfo is
+// the entry's message, which DWS rolls up into the workflow's status, and its debug message
+// is the entry's error.
+func SetError(entry *dwsv1alpha1.WorkflowDriverStatus, err error) {
+	resourceError := &dwsv1alpha1.ResourceErrorInfo{}
+	if !errors.As(err, &resourceError) {
+		resourceError = dwsv1alpha1.NewResourceError("", err)
+	}
+
+	entry.Status = dwsv1alpha1.StatusError
+	entry.Message = resourceError.UserMessage
+	entry.Error = resourceError.DebugMessage
+}
+
+// AddDirectiveBreakdown adds a reference to the DirectiveBreakdown to the workflow's
+// status.directiveBreakdowns list. It returns true if the reference was added and the
+// workflow needs to be updated.
+func AddDirectiveBreakdown(workflow *dwsv1alpha1.Workflow, breakdown *dwsv1alpha1.DirectiveBreakdown) bool {
+	reference := corev1.ObjectReference{
+		Kind:      "DirectiveBreakdown",
+		Name:      breakdown.Name,
+		Namespace: breakdown.Namespace,
+	}
+
+	for _, existing := range workflow.Status.DirectiveBreakdowns {
+		if existing == reference {
+			return false
+		}
+	}
+
+	workflow.Status.DirectiveBreakdowns = append(workflow.Status.DirectiveBreakdowns, reference)
+	return true
+}
+
+// SetDirectiveBreakdownReady sets the storage and compute breakdowns of a DirectiveBreakdown
+// and marks it ready for the WLM
+func SetDirectiveBreakdownReady(breakdown *dwsv1alpha1.DirectiveBreakdown, storage *dwsv1alpha1.StorageBreakdown, compute *dwsv1alpha1.ComputeBreakdown) {
+	breakdown.Status.Storage = storage
+	breakdown.Status.Compute = compute
+	breakdown.Status.Ready = true
+	breakdown.Status.Error = nil
+
+	dwsv1alpha1.SetReadyConditions(&breakdown.Status.Conditions, breakdown.Generation, true, nil)
+}
+
+// WorkflowReconciler calls a Driver for its pending entries in each workflow
+type WorkflowReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Driver Driver
+}
+
+// Reconcile calls the driver for each of its pending entries in the workflow's current state
+// and records the results in the entries
+func (r *WorkflowReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	workflow := &dwsv1alpha1.Workflow{}
+	if err := r.Get(ctx, req.NamespacedName, workflow); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Wait for DWS to start the desired state before doing the work for it
+	if !workflow.GetDeletionTimestamp().IsZero() || workflow.Status.State != workflow.Spec.DesiredState {
+		return ctrl.Result{}, nil
+	}
+
+	entries := PendingEntries(workflow, r.Driver.ID())
+	if len(entries) == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.WorkflowStatus](workflow)
+	defer func() { err = statusUpdater.CloseWithUpdate(ctx, r, err) }()
+
+	log := r.Log.WithValues("Workflow", req.NamespacedName, "state", workflow.Status.State)
+
+	var handleErr error
+	for _, i := range entries {
+		entry := &workflow.Status.Drivers[i]
+
+		result, err := r.Driver.Handle(ctx, workflow, entry.DWDIndex)
+		if err != nil {
+			log.Info("Driver returned an error", "index", entry.DWDIndex, "error", err.Error())
+			SetError(entry, err)
+
+			if handleErr == nil {
+				handleErr = err
+			}
+			continue
+		}
+
+		if result.Complete {
+			log.Info("Driver completed", "index", entry.DWDIndex)
+			Complete(entry)
+			continue
+		}
+
+		SetRunning(entry, result.Message)
+		if result.RequeueAfter != 0 && (res.RequeueAfter == 0 || result.RequeueAfter < res.RequeueAfter) {
+			res.RequeueAfter = result.RequeueAfter
+		}
+	}
+
+	// Retry the failed entries with a backoff
+	if handleErr != nil {
+		return ctrl.Result{}, handleErr
+	}
+
+	return res, nil
+}
+
+// SetupWithManager sets up the reconciler with the Manager. The controller is named for the
+// driver so several drivers can run in one manager.
+func (r *WorkflowReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(r.Driver.ID() + "-workflow").
+		For(&dwsv1alpha1.Workflow{}).
+		Complete(r)
+}
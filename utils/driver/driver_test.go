@@ -0,0 +1,127 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// workflowClient returns a canned workflow and records its updates
+type workflowClient struct {
+	client.Client
+	workflow *dwsv1alpha1.Workflow
+	updated  *dwsv1alpha1.Workflow
+}
+
+func (c *workflowClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.workflow.DeepCopyInto(obj.(*dwsv1alpha1.Workflow))
+	return nil
+}
+
+func (c *workflowClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.updated = obj.(*dwsv1alpha1.Workflow).DeepCopy()
+	return nil
+}
+
+// testDriver completes directive 0, is still running directive 1, and fails directive 2
+type testDriver struct{}
+
+func (d *testDriver) ID() string { return "test" }
+
+func (d *testDriver) Handle(ctx context.Context, workflow *dwsv1alpha1.Workflow, index int) (Result, error) {
+	switch index {
+	case 0:
+		return Result{Complete: true}, nil
+	case 1:
+		return Result{Message: "copying", RequeueAfter: time.Second}, nil
+	}
+
+	return Result{}, dwsv1alpha1.NewResourceError("mkfs failed", nil).WithUserMessage("could not create file system")
+}
+
+func TestWorkflowReconciler(t *testing.T) {
+	workflow := &dwsv1alpha1.Workflow{}
+	workflow.Name, workflow.Namespace = "test", "default"
+	workflow.Spec.DesiredState = dwsv1alpha1.StateSetup
+	workflow.Status.State = dwsv1alpha1.StateSetup
+	workflow.Status.Drivers = []dwsv1alpha1.WorkflowDriverStatus{
+		{DriverID: "test", DWDIndex: 0, WatchState: dwsv1alpha1.StateSetup},
+		{DriverID: "test", DWDIndex: 1, WatchState: dwsv1alpha1.StateSetup},
+		{DriverID: "test", DWDIndex: 2, WatchState: dwsv1alpha1.StateSetup},
+		{DriverID: "test", DWDIndex: 0, WatchState: dwsv1alpha1.StateTeardown},
+		{DriverID: "other", DWDIndex: 0, WatchState: dwsv1alpha1.StateSetup},
+	}
+
+	c := &workflowClient{workflow: workflow}
+	r := &WorkflowReconciler{Client: c, Log: logr.Discard(), Driver: &testDriver{}}
+
+	if entries := PendingEntries(workflow, "test"); len(entries) != 3 {
+		t.Fatalf("Expected 3 pending entries, not %v", entries)
+	}
+
+	if _, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(workflow)}); err == nil {
+		t.Errorf("Expected the driver error to be returned")
+	}
+
+	if c.updated == nil {
+		t.Fatalf("Workflow was not updated")
+	}
+
+	drivers := c.updated.Status.Drivers
+	if !drivers[0].Completed || drivers[0].Status != dwsv1alpha1.StatusCompleted || drivers[0].CompleteTime == nil {
+		t.Errorf("Entry 0 was not completed: %+v", drivers[0])
+	}
+
+	if drivers[1].Completed || drivers[1].Status != dwsv1alpha1.StatusRunning || drivers[1].Message != "copying" {
+		t.Errorf("Entry 1 is not running: %+v", drivers[1])
+	}
+
+	if drivers[2].Status != dwsv1alpha1.StatusError || drivers[2].Message != "could not create file system" || drivers[2].Error != "mkfs failed" {
+		t.Errorf("Entry 2 does not have the error: %+v", drivers[2])
+	}
+
+	for _, i := range []int{3, 4} {
+		if drivers[i].Status != "" {
+			t.Errorf("Entry %d was changed: %+v", i, drivers[i])
+		}
+	}
+}
+
+func TestAddDirectiveBreakdown(t *testing.T) {
+	workflow := &dwsv1alpha1.Workflow{}
+	breakdown := &dwsv1alpha1.DirectiveBreakdown{}
+	breakdown.Name, breakdown.Namespace = "test-0", "default"
+
+	if !AddDirectiveBreakdown(workflow, breakdown) {
+		t.Errorf("Reference was not added")
+	}
+
+	if AddDirectiveBreakdown(workflow, breakdown) || len(workflow.Status.DirectiveBreakdowns) != 1 {
+		t.Errorf("Reference was added twice")
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var keyMatcher = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// DirectiveBuilder builds a #DW directive for tools that generate job scripts or translate
+// other burst buffer syntaxes. The first error is kept and returned by Build, so the calls
+// can be chained:
+//
+//	directive, err := NewDirectiveBuilder().Command("jobdw").Arg("type", "xfs").Arg("capacity", "10GiB").Arg("name", "scratch").Build()
+type DirectiveBuilder struct {
+	args map[string]string
+	err  error
+}
+
+// NewDirectiveBuilder returns an empty directive builder
+func NewDirectiveBuilder() *DirectiveBuilder {
+	return &DirectiveBuilder{args: map[string]string{}}
+}
+
+// Command sets the command of the directive (e.g., jobdw)
+func (b *DirectiveBuilder) Command(command string) *DirectiveBuilder {
+	if b.err == nil && !keyMatcher.MatchString(command) {
+		b.err = fmt.Errorf("invalid command '%s'", command)
+	}
+
+	b.args["command"] = command
+	return b
+}
+
+// Arg adds an argument to the directive. Arguments can't be repeated, and neither the key
+// nor the value may contain white space since the directive is split on it.
+func (b *DirectiveBuilder) Arg(key string, value string) *DirectiveBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	if key == "command" || !keyMatcher.MatchString(key) {
+		b.err = fmt.Errorf("invalid argument '%s'", key)
+	} else if _, ok := b.args[key]; ok {
+		b.err = fmt.Errorf("repeated argument '%s'", key)
+	} else if value == "" || strings.ContainsAny(value, " \t\n") {
+		b.err = fmt.Errorf("invalid value '%s' for argument '%s'", value, key)
+	}
+
+	b.args[key] = value
+	return b
+}
+
+// Capacity adds a capacity argument given in bytes, formatted with FormatCapacity
+func (b *DirectiveBuilder) Capacity(bytes int64) *DirectiveBuilder {
+	capacity, err := FormatCapacity(bytes)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+
+		return b
+	}
+
+	return b.Arg("capacity", capacity)
+}
+
+// Build returns the directive in the canonical form, with the arguments sorted by key, or
+// the first error from building it
+func (b *DirectiveBuilder) Build() (string, error) {
+	if b.err != nil {
+		return "", b.err
+	}
+
+	if _, ok := b.args["command"]; !ok {
+		return "", fmt.Errorf("missing command")
+	}
+
+	return FormatArgsMap(b.args), nil
+}
+
+// BuildWithRules returns the directive like Build after validating it against the rules the
+// same way the Workflow webhook does
+func (b *DirectiveBuilder) BuildWithRules(rules []DWDirectiveRuleSpec) (string, error) {
+	directive, err := b.Build()
+	if err != nil {
+		return "", err
+	}
+
+	if err := ValidateDirectives(rules, []string{directive})[0]; err != nil {
+		return "", err
+	}
+
+	return directive, nil
+}
+
+// FormatCapacity formats a number of bytes as a directive capacity string using the unit
+// that divides it exactly with the smallest result (e.g., 1TB rather than 976562500KiB).
+// ParseCapacity reverses it.
+func FormatCapacity(bytes int64) (string, error) {
+	if bytes <= 0 {
+		return "", fmt.Errorf("invalid capacity %d", bytes)
+	}
+
+	capacity := ""
+	smallest := int64(0)
+	for unit, size := range capacityUnits {
+		if bytes%size != 0 {
+			continue
+		}
+
+		if value := bytes / size; capacity == "" || value < smallest {
+			capacity, smallest = fmt.Sprintf("%d%s", value, unit), value
+		}
+	}
+
+	if capacity == "" {
+		return "", fmt.Errorf("capacity %d is not a whole number of KB or KiB", bytes)
+	}
+
+	return capacity, nil
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"testing"
+)
+
+func TestDirectiveBuilder(t *testing.T) {
+	directive, err := NewDirectiveBuilder().Command("jobdw").Arg("type", "xfs").Capacity(10<<30).Arg("name", "scratch").Build()
+	if err != nil {
+		t.Fatalf("Build returned unexpected error %v", err)
+	}
+
+	if expected := "#DW jobdw capacity=10GiB name=scratch type=xfs"; directive != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, directive)
+	}
+
+	invalid := []*DirectiveBuilder{
+		NewDirectiveBuilder().Arg("name", "scratch"),
+		NewDirectiveBuilder().Command("job dw"),
+		NewDirectiveBuilder().Command("jobdw").Arg("name", "a").Arg("name", "b"),
+		NewDirectiveBuilder().Command("jobdw").Arg("name", "my scratch"),
+		NewDirectiveBuilder().Command("jobdw").Arg("cap=acity", "10GiB"),
+		NewDirectiveBuilder().Command("jobdw").Capacity(1000<<30 + 1),
+	}
+
+	for i, b := range invalid {
+		if directive, err := b.Build(); err == nil {
+			t.Errorf("Invalid directive %d built as '%s'", i, directive)
+		}
+	}
+
+	rules := []DWDirectiveRuleSpec{
+		{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{
+			{Key: "type", Type: "string", Pattern: "^(xfs|gfs2)$", IsRequired: true, IsValueRequired: true},
+			{Key: "name", Type: "string", IsRequired: true, IsValueRequired: true},
+		}},
+	}
+
+	if _, err := NewDirectiveBuilder().Command("jobdw").Arg("type", "xfs").Arg("name", "scratch").BuildWithRules(rules); err != nil {
+		t.Errorf("Valid directive returned error %v", err)
+	}
+
+	if _, err := NewDirectiveBuilder().Command("jobdw").Arg("type", "zfs").Arg("name", "scratch").BuildWithRules(rules); err == nil {
+		t.Errorf("Directive that breaks the rules did not return an error")
+	}
+}
+
+func TestFormatCapacity(t *testing.T) {
+	tests := map[int64]string{
+		1024:                      "1KiB",
+		10 << 30:                  "10GiB",
+		1000 * 1000 * 1000 * 1000: "1TB",
+		1536 << 20:                "1536MiB",
+		5000:                      "5KB",
+	}
+
+	for bytes, expected := range tests {
+		capacity, err := FormatCapacity(bytes)
+		if err != nil || capacity != expected {
+			t.Errorf("Expected %d to format as '%s', got '%s' %v", bytes, expected, capacity, err)
+		}
+
+		if parsed, err := ParseCapacity(capacity); err != nil || parsed != bytes {
+			t.Errorf("Capacity '%s' parsed as %d %v", capacity, parsed, err)
+		}
+	}
+
+	for _, bytes := range []int64{0, -1, 1023} {
+		if _, err := FormatCapacity(bytes); err == nil {
+			t.Errorf("Invalid capacity %d did not return an error", bytes)
+		}
+	}
+}
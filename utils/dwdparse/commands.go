@@ -0,0 +1,129 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// commandRegistry holds the rules for the directive commands registered by drivers and site
+// plugins, keyed by command and driver label
+type commandRegistry struct {
+	lock     sync.Mutex
+	commands map[string]map[string]DWDirectiveRuleSpec
+}
+
+var commands = &commandRegistry{}
+
+// RegisterCommand registers the rule for a directive command, so a driver or site plugin can
+// add a command such as "#DW container" without a DWDirectiveRule or a change to the parser.
+// It's called from an init function. The rules in the DWDirectiveRule rule sets override the
+// registered rule for the same command and driver label (see MergeRules). It panics if the
+// rule isn't valid or the command is already registered for the driver label.
+func RegisterCommand(rule DWDirectiveRuleSpec) {
+	if err := commands.register(rule); err != nil {
+		panic(err.Error())
+	}
+}
+
+// LookupCommand returns the rules registered for a command, one for each driver label
+func LookupCommand(command string) ([]DWDirectiveRuleSpec, bool) {
+	return commands.lookup(command)
+}
+
+// RegisteredCommands returns the names of the registered commands in order
+func RegisteredCommands() []string {
+	return commands.names()
+}
+
+// RegisteredRules returns the rules for all the registered commands, ordered by command and
+// driver label
+func RegisteredRules() []DWDirectiveRuleSpec {
+	rules := []DWDirectiveRuleSpec{}
+	for _, command := range commands.names() {
+		commandRules, _ := commands.lookup(command)
+		rules = append(rules, commandRules...)
+	}
+
+	return rules
+}
+
+func (r *commandRegistry) register(rule DWDirectiveRuleSpec) error {
+	if err := ValidateRuleSet(RuleSet{Name: "registered", Rules: []DWDirectiveRuleSpec{rule}}); err != nil {
+		return err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.commands == nil {
+		r.commands = map[string]map[string]DWDirectiveRuleSpec{}
+	}
+
+	if _, found := r.commands[rule.Command][rule.DriverLabel]; found {
+		return fmt.Errorf("command '%s' is already registered for driver label '%s'", rule.Command, rule.DriverLabel)
+	}
+
+	if r.commands[rule.Command] == nil {
+		r.commands[rule.Command] = map[string]DWDirectiveRuleSpec{}
+	}
+	r.commands[rule.Command][rule.DriverLabel] = *rule.DeepCopy()
+
+	return nil
+}
+
+func (r *commandRegistry) lookup(command string) ([]DWDirectiveRuleSpec, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	driverRules, found := r.commands[command]
+	if !found {
+		return nil, false
+	}
+
+	labels := make([]string, 0, len(driverRules))
+	for label := range driverRules {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	rules := make([]DWDirectiveRuleSpec, 0, len(labels))
+	for _, label := range labels {
+		rule := driverRules[label]
+		rules = append(rules, *rule.DeepCopy())
+	}
+
+	return rules, true
+}
+
+func (r *commandRegistry) names() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	names := make([]string, 0, len(r.commands))
+	for name := range r.commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
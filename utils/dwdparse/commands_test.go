@@ -0,0 +1,111 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandRegistry(t *testing.T) {
+	r := &commandRegistry{}
+
+	site := DWDirectiveRuleSpec{Command: "container", DriverLabel: "site", RuleDefs: []DWDirectiveRuleDef{{Key: "profile", Type: "string", IsRequired: true}}}
+	vendor := DWDirectiveRuleSpec{Command: "container", DriverLabel: "vendor", RuleDefs: []DWDirectiveRuleDef{{Key: "image", Type: "string"}}}
+	offload := DWDirectiveRuleSpec{Command: "copy_offload", DriverLabel: "site", RuleDefs: []DWDirectiveRuleDef{{Key: "name", Type: "string"}}}
+
+	for _, rule := range []DWDirectiveRuleSpec{vendor, offload, site} {
+		if err := r.register(rule); err != nil {
+			t.Fatalf("Register '%s' for '%s' returned unexpected error %v", rule.Command, rule.DriverLabel, err)
+		}
+	}
+
+	if names := r.names(); !reflect.DeepEqual(names, []string{"container", "copy_offload"}) {
+		t.Errorf("Unexpected registered commands %v", names)
+	}
+
+	rules, found := r.lookup("container")
+	if !found || !reflect.DeepEqual(rules, []DWDirectiveRuleSpec{site, vendor}) {
+		t.Errorf("Lookup expected the site and vendor rules, got %+v", rules)
+	}
+
+	// The registry keeps its own copy of the rules
+	rules[0].RuleDefs[0].Key = "changed"
+	if rules, _ := r.lookup("container"); rules[0].RuleDefs[0].Key != "profile" {
+		t.Errorf("Registered rule was modified through a lookup")
+	}
+
+	if _, found := r.lookup("jobdw"); found {
+		t.Errorf("Lookup found an unregistered command")
+	}
+
+	invalid := []DWDirectiveRuleSpec{
+		// Already registered for the driver label
+		site,
+		// No command
+		{DriverLabel: "site", RuleDefs: []DWDirectiveRuleDef{{Key: "name", Type: "string"}}},
+		// Unsupported type
+		{Command: "stage", DriverLabel: "site", RuleDefs: []DWDirectiveRuleDef{{Key: "name", Type: "float"}}},
+		// Duplicate key
+		{Command: "stage", DriverLabel: "site", RuleDefs: []DWDirectiveRuleDef{{Key: "name", Type: "string"}, {Key: "name", Type: "string"}}},
+	}
+
+	for _, rule := range invalid {
+		if err := r.register(rule); err == nil {
+			t.Errorf("Register '%s' for '%s' did not return an error", rule.Command, rule.DriverLabel)
+		}
+	}
+
+	if names := r.names(); len(names) != 2 {
+		t.Errorf("Invalid rules were registered: %v", names)
+	}
+}
+
+func TestRegisterCommand(t *testing.T) {
+	rule := DWDirectiveRuleSpec{Command: "test_registered", DriverLabel: "test", RuleDefs: []DWDirectiveRuleDef{
+		{Key: "name", Type: "string", IsRequired: true, IsValueRequired: true},
+	}}
+	RegisterCommand(rule)
+
+	if rules, found := LookupCommand("test_registered"); !found || len(rules) != 1 {
+		t.Fatalf("Registered command was not found")
+	}
+
+	found := false
+	for _, command := range RegisteredCommands() {
+		found = found || command == "test_registered"
+	}
+	if !found {
+		t.Errorf("Registered command is not listed in %v", RegisteredCommands())
+	}
+
+	// The registered rules validate the command like the rules from a rule set
+	errs := ValidateDirectives(RegisteredRules(), []string{"#DW test_registered name=a", "#DW test_registered", "#DW jobdw name=a"})
+	if errs[0] != nil || errs[1] == nil || errs[2] == nil {
+		t.Errorf("Unexpected validation errors %v", errs)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Registering the command again did not panic")
+		}
+	}()
+	RegisterCommand(rule)
+}
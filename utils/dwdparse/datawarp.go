@@ -0,0 +1,196 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// dataWarpOnlyArgs are the DataWarp arguments that have no equivalent. They're dropped with
+// a warning.
+var dataWarpOnlyArgs = map[string]bool{
+	"pool":                    true,
+	"optimization_strategy":   true,
+	"max_mds":                 true,
+	"modified_threshold":      true,
+	"read_ahead":              true,
+	"sync_on_close":           true,
+	"sync_to_pfs":             true,
+	"write_window_length":     true,
+	"write_window_multiplier": true,
+}
+
+// dataWarpVariables matches the DataWarp environment variables for the job's and the
+// persistent file systems
+var dataWarpVariables = regexp.MustCompile(`DW_JOB_(STRIPED|PRIVATE)\b|DW_PERSISTENT_STRIPED_`)
+
+// TranslateDataWarp translates directives written for Cray DataWarp into the current
+// directive syntax to ease the migration of existing job scripts. It returns the translated
+// directives, the warnings for each directive, and the errors for the directives that
+// can't be translated, all in the same order as the directives. Directives that don't use
+// any DataWarp syntax are returned unchanged.
+//
+// The DataWarp scratch access modes become file system types: a striped file system is
+// shared by the compute nodes, so it becomes lustre, and a private one becomes xfs. A job
+// file system without a name is named for its access mode, and $DW_JOB_STRIPED and
+// $DW_JOB_PRIVATE become $DW_JOB_striped and $DW_JOB_private to match. The storage pool
+// and the DataWarp tuning arguments have no equivalent and are dropped.
+func TranslateDataWarp(directives []string) ([]string, []Warnings, []error) {
+	translated := make([]string, len(directives))
+	warnings := make([]Warnings, len(directives))
+	errs := make([]error, len(directives))
+
+	for i, directive := range directives {
+		translated[i], warnings[i], errs[i] = translateDataWarpDirective(directive)
+	}
+
+	return translated, warnings, errs
+}
+
+// translateDataWarpDirective translates a single DataWarp directive
+func translateDataWarpDirective(directive string) (string, Warnings, error) {
+	warnings := Warnings{}
+	changed := false
+
+	fields := strings.Fields(directive)
+	if len(fields) != 0 && fields[0] == "#BB" {
+		fields[0] = "#DW"
+		warnings = append(warnings, "#BB is replaced by #DW")
+		changed = true
+	}
+
+	args, parseWarnings, err := BuildArgsMapWithOptions(strings.Join(fields, " "), ParseOptions{Duplicates: DuplicateWarn})
+	if err != nil {
+		return directive, nil, err
+	}
+	warnings = append(warnings, parseWarnings...)
+
+	// "#DW swap 10GiB" adds swap space on each compute node
+	if args["command"] == "swap" {
+		if len(args) != 2 {
+			return directive, nil, fmt.Errorf("invalid swap directive '%s'", directive)
+		}
+
+		for key := range args {
+			if key != "command" {
+				args = map[string]string{"command": "jobdw", "type": "swap", "capacity": key, "name": "swap"}
+			}
+		}
+
+		warnings = append(warnings, "swap is replaced by a jobdw directive of type swap")
+		changed = true
+	}
+
+	for _, key := range sortedKeys(args) {
+		if dataWarpOnlyArgs[key] {
+			warnings = append(warnings, fmt.Sprintf("%s=%s is dropped since it has no equivalent", key, args[key]))
+			delete(args, key)
+			changed = true
+		}
+	}
+
+	switch args["command"] {
+	case "jobdw", "create_persistent":
+		typeChanged, typeWarnings, err := translateDataWarpType(args)
+		if err != nil {
+			return directive, nil, err
+		}
+		warnings = append(warnings, typeWarnings...)
+		changed = changed || typeChanged
+	case "stage_in", "stage_out":
+		if args["type"] == "list" {
+			return directive, nil, fmt.Errorf("stage lists can't be translated: '%s'", directive)
+		}
+	}
+
+	for _, key := range sortedKeys(args) {
+		value := dataWarpVariables.ReplaceAllStringFunc(args[key], func(variable string) string {
+			if variable == "DW_PERSISTENT_STRIPED_" {
+				return "DW_PERSISTENT_"
+			}
+
+			return "DW_JOB_" + strings.ToLower(strings.TrimPrefix(variable, "DW_JOB_"))
+		})
+
+		if value != args[key] {
+			warnings = append(warnings, fmt.Sprintf("%s=%s uses the DataWarp environment variables and is replaced by %s=%s", key, args[key], key, value))
+			args[key] = value
+			changed = true
+		}
+	}
+
+	if !changed {
+		return directive, warnings, nil
+	}
+
+	return FormatArgsMap(args), warnings, nil
+}
+
+// translateDataWarpType replaces the DataWarp scratch type and access mode of a jobdw or
+// create_persistent directive with a file system type. It returns whether the arguments
+// were changed.
+func translateDataWarpType(args map[string]string) (bool, Warnings, error) {
+	warnings := Warnings{}
+
+	// Early DataWarp releases used "access" for the access mode
+	if access, ok := args["access"]; ok {
+		if _, ok := args["access_mode"]; !ok {
+			args["access_mode"] = access
+		}
+		delete(args, "access")
+	}
+
+	accessMode, hasAccessMode := args["access_mode"]
+	switch args["type"] {
+	case "scratch":
+	case "cache":
+		return false, nil, fmt.Errorf("the DataWarp cache type can't be translated; use stage_in and stage_out directives")
+	default:
+		if hasAccessMode {
+			return false, nil, fmt.Errorf("access_mode=%s can't be used with type=%s", accessMode, args["type"])
+		}
+
+		return false, nil, nil
+	}
+
+	if !hasAccessMode {
+		accessMode = "striped"
+	}
+	delete(args, "access_mode")
+
+	switch accessMode {
+	case "striped":
+		args["type"] = "lustre"
+	case "private":
+		args["type"] = "xfs"
+	default:
+		return false, nil, fmt.Errorf("access_mode=%s can't be translated; use a separate directive for each access mode", accessMode)
+	}
+	warnings = append(warnings, fmt.Sprintf("type=scratch with access_mode=%s is replaced by type=%s", accessMode, args["type"]))
+
+	if _, ok := args["name"]; !ok && args["command"] == "jobdw" {
+		args["name"] = accessMode
+		warnings = append(warnings, fmt.Sprintf("the file system is named %s; use $DW_JOB_%s in the script rather than $DW_JOB_%s", accessMode, accessMode, strings.ToUpper(accessMode)))
+	}
+
+	return true, warnings, nil
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"testing"
+)
+
+func TestTranslateDataWarp(t *testing.T) {
+	tests := []struct {
+		directive  string
+		translated string
+		invalid    bool
+	}{
+		{
+			directive:  "#DW jobdw type=scratch access_mode=striped capacity=10GiB pool=wlm_pool",
+			translated: "#DW jobdw capacity=10GiB name=striped type=lustre",
+		},
+		{
+			directive:  "#BB jobdw type=scratch access_mode=private capacity=1TiB",
+			translated: "#DW jobdw capacity=1TiB name=private type=xfs",
+		},
+		{
+			directive:  "#BB create_persistent name=shared capacity=100GiB access=striped type=scratch",
+			translated: "#DW create_persistent capacity=100GiB name=shared type=lustre",
+		},
+		{
+			directive:  "#DW stage_in type=directory source=/lus/input destination=$DW_JOB_STRIPED/input",
+			translated: "#DW stage_in destination=$DW_JOB_striped/input source=/lus/input type=directory",
+		},
+		{
+			directive:  "#DW stage_out type=file source=${DW_PERSISTENT_STRIPED_shared}/out destination=/lus/out",
+			translated: "#DW stage_out destination=/lus/out source=${DW_PERSISTENT_shared}/out type=file",
+		},
+		{
+			directive:  "#DW swap 10GiB",
+			translated: "#DW jobdw capacity=10GiB name=swap type=swap",
+		},
+		{
+			// Current directives are left as they are
+			directive:  "#DW jobdw type=xfs name=scratch capacity=10GiB",
+			translated: "#DW jobdw type=xfs name=scratch capacity=10GiB",
+		},
+		{directive: "#DW jobdw type=cache access_mode=striped pfs=/lus/scratch capacity=10GiB", invalid: true},
+		{directive: "#DW jobdw type=scratch access_mode=striped,private capacity=10GiB", invalid: true},
+		{directive: "#DW stage_in type=list source=/lus/files", invalid: true},
+	}
+
+	directives := []string{}
+	for _, test := range tests {
+		directives = append(directives, test.directive)
+	}
+
+	translated, warnings, errs := TranslateDataWarp(directives)
+	for i, test := range tests {
+		if test.invalid {
+			if errs[i] == nil {
+				t.Errorf("Directive '%s' translated as '%s'", test.directive, translated[i])
+			}
+			continue
+		}
+
+		if errs[i] != nil {
+			t.Errorf("Directive '%s' returned unexpected error %v", test.directive, errs[i])
+		} else if translated[i] != test.translated {
+			t.Errorf("Expected '%s', got '%s'", test.translated, translated[i])
+		}
+
+		if (translated[i] != test.directive) != (len(warnings[i]) != 0) {
+			t.Errorf("Directive '%s' has unexpected warnings %v", test.directive, warnings[i])
+		}
+	}
+}
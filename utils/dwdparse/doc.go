@@ -0,0 +1,61 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dwdparse parses and validates #DW directives.
+//
+// The parser has no built-in list of commands. A command is valid when a rule in one of the
+// DWDirectiveRule rule sets names it, and its arguments are checked against that rule's
+// RuleDefs. Drivers and sites register new commands by creating a DWDirectiveRule rather
+// than changing the parser. For example, a site can add "#DW container" to the vendor rules
+// with a rule set that overrides the "default" rule set (see RuleSet and MergeRules):
+//
+//	apiVersion: dws.cray.hpe.com/v1alpha1
+//	kind: DWDirectiveRule
+//	metadata:
+//	  name: site-container
+//	  labels:
+//	    dws.cray.hpe.com/ruleset.overrides: default
+//	spec:
+//	  - command: container
+//	    driverLabel: site-container
+//	    watchStates: setup,teardown
+//	    ruleDefs:
+//	      - key: profile
+//	        type: string
+//	        isRequired: true
+//	        isValueRequired: true
+//
+// A driver that owns the command watches for Workflows whose driver status entries carry
+// its driver label.
+//
+// A driver or site plugin built into the webhook can instead register the rule for its
+// command with RegisterCommand from an init function. LookupCommand and RegisteredCommands
+// report the registered commands, and RegisteredRules returns their rules to validate
+// directives with. A DWDirectiveRule rule for the same command and driver label overrides
+// the registered rule.
+//
+// Tools that generate job scripts build directives with DirectiveBuilder rather than
+// formatting the strings themselves, so the directives are in the canonical form and can
+// be checked against the rules before they're submitted.
+//
+// The package only depends on the standard library. The DWDirectiveRule resource in
+// api/v1alpha1 is built from the DWDirectiveRuleSpec type defined here, so the package must
+// not import api/v1alpha1 or a Kubernetes client. Callers that read the rules from the API
+// use the api/v1alpha1 typed client (see DWDirectiveRules) and pass the specs in.
+package dwdparse
@@ -22,22 +22,39 @@ package dwdparse
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-// DWDirectiveRuleDef defines the DWDirective parser rules
+// DWDirectiveRuleDef defines the DWDirective parser rules. Min and Max bound an integer
+// argument and are only checked when set, so a bound of zero can be expressed.
 // +kubebuilder:object:generate=true
 type DWDirectiveRuleDef struct {
 	Key             string `json:"key"`
 	Type            string `json:"type"`
 	Pattern         string `json:"pattern,omitempty"`
-	Min             int    `json:"min,omitempty"`
-	Max             int    `json:"max,omitempty"`
+	Min             *int   `json:"min,omitempty"`
+	Max             *int   `json:"max,omitempty"`
 	IsRequired      bool   `json:"isRequired,omitempty"`
 	IsValueRequired bool   `json:"isValueRequired,omitempty"`
 	UniqueWithin    string `json:"uniqueWithin,omitempty"`
+
+	// Alias is another name accepted for the argument, such as the name it had before it
+	// was renamed. The alias is replaced with the key when the directive is parsed, with
+	// a warning.
+	Alias string `json:"alias,omitempty"`
+
+	// DeprecatedSince is the rule set version the argument was deprecated in. The argument
+	// is still accepted, with a warning.
+	DeprecatedSince string `json:"deprecatedSince,omitempty"`
+
+	// RoundUpTo is the allocation granularity of a capacity argument, such as "192GiB". A
+	// capacity that isn't a multiple of it is rounded up when the Workflow is created, so
+	// the directive records the capacity that will be allocated.
+	RoundUpTo string `json:"roundUpTo,omitempty"`
 }
 
 // DWDirectiveRuleSpec defines the desired state of DWDirective
@@ -97,24 +114,63 @@ func BuildRulesMap(rule DWDirectiveRuleSpec, cmd string) (map[string]DWDirective
 	return rulesMap, nil
 }
 
-// BuildArgsMap builds a map of the DWDirective's arguments in the form: args["key"] = value
+// DuplicatePolicy is how a repeated argument in a directive is handled
+type DuplicatePolicy string
+
+const (
+	// DuplicateError rejects a directive with a repeated argument
+	DuplicateError DuplicatePolicy = "error"
+
+	// DuplicateWarn keeps the last value of a repeated argument and reports a warning
+	DuplicateWarn DuplicatePolicy = "warn"
+)
+
+// ParseOptions changes how BuildArgsMapWithOptions parses a directive
+type ParseOptions struct {
+	// Duplicates is how a repeated argument is handled. A repeated argument is an error
+	// if it's empty.
+	Duplicates DuplicatePolicy
+}
+
+// Warnings are the problems found in a directive that don't make it invalid. Validation
+// consumers can show them to the user.
+type Warnings []string
+
+// BuildArgsMap builds a map of the DWDirective's arguments in the form: args["key"] = value.
+// A repeated argument is an error.
 func BuildArgsMap(dwd string) (map[string]string, error) {
+	argsMap, _, err := BuildArgsMapWithOptions(dwd, ParseOptions{})
+	return argsMap, err
+}
+
+// BuildArgsMapWithOptions builds a map of the DWDirective's arguments like BuildArgsMap,
+// handling repeated arguments according to the options. It returns the warnings for the
+// problems that were allowed.
+func BuildArgsMapWithOptions(dwd string, options ParseOptions) (map[string]string, Warnings, error) {
 	argsMap := make(map[string]string)
+	warnings := Warnings{}
 	dwdArgs := strings.Fields(dwd)
 
 	if len(dwdArgs) == 0 {
-		return nil, fmt.Errorf("Invalid format for directive '%s'", dwd)
+		return nil, nil, fmt.Errorf("Invalid format for directive '%s'", dwd)
 	}
 
 	if dwdArgs[0] == "#DW" {
+		if len(dwdArgs) < 2 {
+			return nil, nil, fmt.Errorf("missing command in directive '%s'", dwd)
+		}
+
 		argsMap["command"] = dwdArgs[1]
 		for i := 2; i < len(dwdArgs); i++ {
 			keyValue := strings.Split(dwdArgs[i], "=")
 
-			// Don't allow repeated arguments
-			_, ok := argsMap[keyValue[0]]
-			if ok {
-				return nil, errors.New("repeated argument in directive: " + keyValue[0])
+			// Repeated arguments aren't allowed unless the options only ask for a warning
+			if previous, ok := argsMap[keyValue[0]]; ok {
+				if options.Duplicates != DuplicateWarn || keyValue[0] == "command" {
+					return nil, nil, errors.New("repeated argument in directive: " + keyValue[0])
+				}
+
+				warnings = append(warnings, fmt.Sprintf("repeated argument in directive: %s (value '%s' is replaced)", keyValue[0], previous))
 			}
 
 			if len(keyValue) == 1 {
@@ -127,9 +183,157 @@ func BuildArgsMap(dwd string) (map[string]string, error) {
 			}
 		}
 	} else {
-		return nil, errors.New("missing #DW in directive")
+		return nil, nil, errors.New("missing #DW in directive")
+	}
+	return argsMap, warnings, nil
+}
+
+// ReplaceAliases replaces the aliases of the rule's arguments in a directive with their
+// keys, keeping the order of the arguments. It returns the warnings for the aliases and the
+// deprecated arguments that are used. A directive for a different command is returned as it
+// is.
+func ReplaceAliases(rule DWDirectiveRuleSpec, dwd string) (string, Warnings, error) {
+	dwdArgs := strings.Fields(dwd)
+	if len(dwdArgs) < 2 || dwdArgs[0] != "#DW" || dwdArgs[1] != rule.Command {
+		return dwd, nil, nil
+	}
+
+	ruleDefs := map[string]DWDirectiveRuleDef{}
+	for _, ruleDef := range rule.RuleDefs {
+		ruleDefs[ruleDef.Key] = ruleDef
+		if ruleDef.Alias != "" {
+			ruleDefs[ruleDef.Alias] = ruleDef
+		}
+	}
+
+	warnings := Warnings{}
+	replaced := false
+	used := map[string]string{}
+	for i := 2; i < len(dwdArgs); i++ {
+		keyValue := strings.SplitN(dwdArgs[i], "=", 2)
+
+		ruleDef, found := ruleDefs[keyValue[0]]
+		if !found {
+			continue
+		}
+
+		if previous, found := used[ruleDef.Key]; found && previous != keyValue[0] {
+			return "", nil, fmt.Errorf("argument '%s' is repeated by its alias in directive: %s", ruleDef.Key, dwd)
+		}
+		used[ruleDef.Key] = keyValue[0]
+
+		if ruleDef.DeprecatedSince != "" {
+			warnings = append(warnings, fmt.Sprintf("argument '%s' is deprecated since rule set version %s", keyValue[0], ruleDef.DeprecatedSince))
+		}
+
+		if keyValue[0] == ruleDef.Key {
+			continue
+		}
+
+		warnings = append(warnings, fmt.Sprintf("argument '%s' is replaced by '%s'", keyValue[0], ruleDef.Key))
+		keyValue[0] = ruleDef.Key
+		dwdArgs[i] = strings.Join(keyValue, "=")
+		replaced = true
+	}
+
+	if !replaced {
+		return dwd, warnings, nil
+	}
+
+	return strings.Join(dwdArgs, " "), warnings, nil
+}
+
+// RoundCapacities rounds the capacity arguments in a directive up to the allocation
+// granularity of their rules, keeping the order of the arguments and the unit the user gave
+// where the rounded capacity is a whole number of it. It returns a warning for each capacity
+// that is changed. Aliases must already be replaced, and a capacity that can't be parsed is
+// left for validation to reject. A directive for a different command is returned as it is.
+func RoundCapacities(rule DWDirectiveRuleSpec, dwd string) (string, Warnings, error) {
+	dwdArgs := strings.Fields(dwd)
+	if len(dwdArgs) < 2 || dwdArgs[0] != "#DW" || dwdArgs[1] != rule.Command {
+		return dwd, nil, nil
+	}
+
+	granularities := map[string]int64{}
+	for _, ruleDef := range rule.RuleDefs {
+		if ruleDef.RoundUpTo == "" {
+			continue
+		}
+
+		granularity, err := ParseCapacity(ruleDef.RoundUpTo)
+		if err != nil || granularity == 0 {
+			return "", nil, fmt.Errorf("invalid roundUpTo '%s' for argument '%s'", ruleDef.RoundUpTo, ruleDef.Key)
+		}
+		granularities[ruleDef.Key] = granularity
+	}
+
+	if len(granularities) == 0 {
+		return dwd, nil, nil
 	}
-	return argsMap, nil
+
+	warnings := Warnings{}
+	for i := 2; i < len(dwdArgs); i++ {
+		keyValue := strings.SplitN(dwdArgs[i], "=", 2)
+		granularity, found := granularities[keyValue[0]]
+		if !found || len(keyValue) != 2 {
+			continue
+		}
+
+		capacity, err := ParseCapacity(keyValue[1])
+		if err != nil || capacity%granularity == 0 {
+			continue
+		}
+
+		units := capacity/granularity + 1
+		if units > math.MaxInt64/granularity {
+			return "", nil, fmt.Errorf("capacity '%s' is too large to round up to a multiple of %d bytes", keyValue[1], granularity)
+		}
+
+		rounded, err := formatCapacityLike(units*granularity, keyValue[1])
+		if err != nil {
+			return "", nil, err
+		}
+
+		warnings = append(warnings, fmt.Sprintf("argument '%s' is rounded up from %s to %s", keyValue[0], keyValue[1], rounded))
+		dwdArgs[i] = keyValue[0] + "=" + rounded
+	}
+
+	if len(warnings) == 0 {
+		return dwd, warnings, nil
+	}
+
+	return strings.Join(dwdArgs, " "), warnings, nil
+}
+
+// formatCapacityLike formats a number of bytes in the unit of another capacity string if
+// it's a whole number of that unit, and with FormatCapacity otherwise
+func formatCapacityLike(bytes int64, like string) (string, error) {
+	if matches := capacityMatcher.FindStringSubmatch(like); matches != nil {
+		if size := capacityUnits[matches[2]]; bytes%size == 0 {
+			return fmt.Sprintf("%d%s", bytes/size, matches[2]), nil
+		}
+	}
+
+	return FormatCapacity(bytes)
+}
+
+// FormatArgsMap formats a map of a DWDirective's arguments, as returned by BuildArgsMap, back
+// into a directive. The arguments are sorted by key so the result is deterministic.
+func FormatArgsMap(args map[string]string) string {
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		if key != "command" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	dwdArgs := []string{"#DW", args["command"]}
+	for _, key := range keys {
+		dwdArgs = append(dwdArgs, key+"="+args[key])
+	}
+
+	return strings.Join(dwdArgs, " ")
 }
 
 // ValidateArgs validates a map of arguments against the rules
@@ -178,11 +382,11 @@ func ValidateArgs(args map[string]string, rule DWDirectiveRuleSpec, uniqueMap ma
 				if err != nil {
 					return errors.New("invalid integer argument: " + k + "=" + v)
 				}
-				if rule.Max != 0 && i > rule.Max {
-					return errors.New("specified integer exceeds maximum " + strconv.Itoa(rule.Max) + ": " + k + "=" + v)
+				if rule.Max != nil && i > *rule.Max {
+					return errors.New("specified integer exceeds maximum " + strconv.Itoa(*rule.Max) + ": " + k + "=" + v)
 				}
-				if rule.Min != 0 && i < rule.Min {
-					return errors.New("specified integer smaller than minimum " + strconv.Itoa(rule.Min) + ": " + k + "=" + v)
+				if rule.Min != nil && i < *rule.Min {
+					return errors.New("specified integer smaller than minimum " + strconv.Itoa(*rule.Min) + ": " + k + "=" + v)
 				}
 			case "bool":
 				if rule.Pattern != "" {
@@ -234,31 +438,79 @@ func ValidateArgs(args map[string]string, rule DWDirectiveRuleSpec, uniqueMap ma
 	return nil
 }
 
+// capacityUnits maps the unit suffixes accepted for a "capacity" argument to their size in bytes
+var capacityUnits = map[string]int64{
+	"KB":  1000,
+	"KiB": 1024,
+	"MB":  1000 * 1000,
+	"MiB": 1024 * 1024,
+	"GB":  1000 * 1000 * 1000,
+	"GiB": 1024 * 1024 * 1024,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"TiB": 1024 * 1024 * 1024 * 1024,
+}
+
+var capacityMatcher = regexp.MustCompile(`^(\d+)(KiB|KB|MiB|MB|GiB|GB|TiB|TB)$`)
+
+// ParseCapacity converts a directive capacity string (e.g., "100GiB") into a number of bytes
+func ParseCapacity(capacity string) (int64, error) {
+	matches := capacityMatcher.FindStringSubmatch(capacity)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid capacity '%s'", capacity)
+	}
+
+	value, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid capacity '%s': %v", capacity, err)
+	}
+
+	multiplier := capacityUnits[matches[2]]
+	if value > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("capacity '%s' is too large", capacity)
+	}
+
+	return value * multiplier, nil
+}
+
 // ValidateDWDirective validates a set of #DW directives against a specified rule set
 func ValidateDWDirective(rule DWDirectiveRuleSpec, dwd string, uniqueMap map[string]bool, failUnknownCommand bool) (bool, error) {
+	valid, _, err := ValidateDWDirectiveWithOptions(rule, dwd, uniqueMap, failUnknownCommand, ParseOptions{})
+	return valid, err
+}
+
+// ValidateDWDirectiveWithOptions validates a #DW directive like ValidateDWDirective, parsing
+// it with the options. It returns the warnings for the directive.
+func ValidateDWDirectiveWithOptions(rule DWDirectiveRuleSpec, dwd string, uniqueMap map[string]bool, failUnknownCommand bool, options ParseOptions) (bool, Warnings, error) {
+
+	// The arguments are checked under their current names
+	dwd, aliasWarnings, err := ReplaceAliases(rule, dwd)
+	if err != nil {
+		return false, nil, err
+	}
 
 	// Build a map of the #DW commands and arguments
-	argsMap, err := BuildArgsMap(dwd)
+	argsMap, warnings, err := BuildArgsMapWithOptions(dwd, options)
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
+	warnings = append(warnings, aliasWarnings...)
 
 	// If the command doesn't match...
 	if argsMap["command"] != rule.Command {
 		// If we need to fail unknown commands, return invalid command
 		if failUnknownCommand {
-			return false, nil
+			return false, warnings, nil
 		}
 
 		// Otherwise, we may have a new command that our code doesn't yet know
 		// Don't bother checking the rest
-		return true, nil
+		return true, warnings, nil
 	}
 
 	err = ValidateArgs(argsMap, rule, uniqueMap, failUnknownCommand)
 	if err != nil {
-		return false, err
+		return false, warnings, err
 	}
 
-	return true, nil
+	return true, warnings, nil
 }
@@ -0,0 +1,126 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Directive input comes directly from user batch scripts, so the parser must never panic
+// and anything it accepts must survive a round trip through FormatArgsMap. Run the fuzzers
+// with:
+//
+//	go test ./utils/dwdparse -run=^$ -fuzz=FuzzBuildArgsMap
+//	go test ./utils/dwdparse -run=^$ -fuzz=FuzzValidateDWDirective
+//
+// Inputs that fail are saved under testdata/fuzz and replayed by a normal "go test".
+
+func addSeeds(f *testing.F) {
+	for _, tt := range dwDirectiveTests {
+		for _, directive := range tt.directiveList {
+			f.Add(directive)
+		}
+	}
+
+	for _, directive := range []string{
+		"",
+		"#DW",
+		"#DW jobdw",
+		"#DW jobdw type",
+		"#DW jobdw =",
+		"#DW jobdw type==xfs",
+		"#DW jobdw type=xfs type=xfs",
+		"#DW jobdw command=jobdw",
+		"#DW\tjobdw\ttype=xfs\n",
+		"#DW jobdw type=lustre capacity=99999999999999999999TiB name=n",
+		"#DW jobdw max_mds=-1 min_mds=99999999999999999999",
+		"#DW persistentdw name=\xff\xfe",
+		"jobdw type=xfs",
+	} {
+		f.Add(directive)
+	}
+}
+
+func FuzzBuildArgsMap(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, directive string) {
+		args, err := BuildArgsMap(directive)
+		if err != nil {
+			return
+		}
+
+		if _, found := args["command"]; !found {
+			t.Fatalf("Directive '%s' parsed without a command", directive)
+		}
+
+		// Parse then format must round trip
+		formatted := FormatArgsMap(args)
+		reparsed, err := BuildArgsMap(formatted)
+		if err != nil {
+			t.Fatalf("Formatted directive '%s' from '%s' failed to parse: %v", formatted, directive, err)
+		}
+
+		if !reflect.DeepEqual(args, reparsed) {
+			t.Fatalf("Round trip of '%s' through '%s' changed the arguments: %v != %v", directive, formatted, args, reparsed)
+		}
+
+		// Formatting is canonical
+		if FormatArgsMap(reparsed) != formatted {
+			t.Fatalf("Formatting '%s' is not stable", formatted)
+		}
+	})
+}
+
+func FuzzValidateDWDirective(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, directive string) {
+		for _, failUnknownCommand := range []bool{true, false} {
+			uniqueMap := map[string]bool{}
+			for _, rule := range dWDRules {
+				valid, err := ValidateDWDirective(rule, directive, uniqueMap, failUnknownCommand)
+				if err != nil && valid {
+					t.Fatalf("Directive '%s' reported valid with error %v", directive, err)
+				}
+			}
+		}
+
+		// Any capacity the parser accepts must be a positive number of bytes or zero
+		if args, err := BuildArgsMap(directive); err == nil {
+			if capacity, err := ParseCapacity(args["capacity"]); err == nil && capacity < 0 {
+				t.Fatalf("Capacity '%s' parsed as negative %d", args["capacity"], capacity)
+			}
+		}
+	})
+}
+
+func TestFormatArgsMap(t *testing.T) {
+	args, err := BuildArgsMap("#DW jobdw type=xfs name=test capacity=10GiB profile")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expected := "#DW jobdw capacity=10GiB name=test profile=true type=xfs"
+	if formatted := FormatArgsMap(args); formatted != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, formatted)
+	}
+}
@@ -446,3 +446,185 @@ func TestDWParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseCapacity(t *testing.T) {
+	var tests = []struct {
+		capacity string
+		bytes    int64
+		valid    bool
+	}{
+		{"1KB", 1000, true},
+		{"1KiB", 1024, true},
+		{"10MB", 10 * 1000 * 1000, true},
+		{"10MiB", 10 * 1024 * 1024, true},
+		{"100GB", 100 * 1000 * 1000 * 1000, true},
+		{"100GiB", 100 * 1024 * 1024 * 1024, true},
+		{"2TB", 2 * 1000 * 1000 * 1000 * 1000, true},
+		{"2TiB", 2 * 1024 * 1024 * 1024 * 1024, true},
+		{"0GiB", 0, true},
+		{"100", 0, false},
+		{"GiB", 0, false},
+		{"-1GiB", 0, false},
+		{"1.5GiB", 0, false},
+		{"100gib", 0, false},
+		{"99999999999999TiB", 0, false},
+	}
+
+	for _, tt := range tests {
+		bytes, err := ParseCapacity(tt.capacity)
+		if tt.valid && err != nil {
+			t.Errorf("ParseCapacity(%s): unexpected error %v", tt.capacity, err)
+		} else if !tt.valid && err == nil {
+			t.Errorf("ParseCapacity(%s): expected error", tt.capacity)
+		} else if bytes != tt.bytes {
+			t.Errorf("ParseCapacity(%s): expected %d, got %d", tt.capacity, tt.bytes, bytes)
+		}
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestIntegerBounds(t *testing.T) {
+	var tests = []struct {
+		min   *int
+		max   *int
+		value string
+		valid bool
+	}{
+		{nil, nil, "-5", true},
+		{nil, nil, "5", true},
+		{intPtr(0), nil, "0", true},
+		{intPtr(0), nil, "-1", false},
+		{nil, intPtr(0), "0", true},
+		{nil, intPtr(0), "1", false},
+		{intPtr(0), intPtr(0), "0", true},
+		{intPtr(0), intPtr(0), "1", false},
+		{intPtr(-2), intPtr(2), "-2", true},
+		{intPtr(-2), intPtr(2), "2", true},
+		{intPtr(-2), intPtr(2), "-3", false},
+		{intPtr(-2), intPtr(2), "3", false},
+		{intPtr(1), intPtr(10), "five", false},
+	}
+
+	for _, tt := range tests {
+		rule := DWDirectiveRuleSpec{
+			Command: "jobdw",
+			RuleDefs: []DWDirectiveRuleDef{
+				{Key: "count", Type: "integer", Min: tt.min, Max: tt.max, IsValueRequired: true},
+			},
+		}
+
+		args := map[string]string{"command": "jobdw", "count": tt.value}
+		err := ValidateArgs(args, rule, map[string]bool{}, true)
+		if (err == nil) != tt.valid {
+			t.Errorf("count=%s with min %v max %v: expected valid %v, got error %v", tt.value, tt.min, tt.max, tt.valid, err)
+		}
+	}
+}
+
+func TestBuildArgsMapDuplicates(t *testing.T) {
+	directive := "#DW jobdw type=xfs capacity=10GiB name=test capacity=20GiB"
+
+	if _, err := BuildArgsMap(directive); err == nil {
+		t.Errorf("Repeated argument was not rejected")
+	}
+
+	args, warnings, err := BuildArgsMapWithOptions(directive, ParseOptions{Duplicates: DuplicateWarn})
+	if err != nil {
+		t.Fatalf("Repeated argument returned error %v", err)
+	}
+	if args["capacity"] != "20GiB" {
+		t.Errorf("Expected the last capacity, got %s", args["capacity"])
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Expected a warning for the repeated argument, got %v", warnings)
+	}
+
+	if _, _, err := BuildArgsMapWithOptions("#DW jobdw command=persistentdw", ParseOptions{Duplicates: DuplicateWarn}); err == nil {
+		t.Errorf("Repeated command was not rejected")
+	}
+
+	_, warnings, err = BuildArgsMapWithOptions("#DW jobdw type=xfs capacity=10GiB name=test", ParseOptions{Duplicates: DuplicateWarn})
+	if err != nil || len(warnings) != 0 {
+		t.Errorf("Unexpected warnings %v, %v", warnings, err)
+	}
+}
+
+func TestReplaceAliases(t *testing.T) {
+	rule := DWDirectiveRuleSpec{
+		Command: "jobdw",
+		RuleDefs: []DWDirectiveRuleDef{
+			{Key: "type", Type: "string", IsRequired: true},
+			{Key: "accessmode", Type: "string", Alias: "access_mode"},
+			{Key: "pool", Type: "string", DeprecatedSince: "1.1"},
+		},
+	}
+
+	directive, warnings, err := ReplaceAliases(rule, "#DW jobdw access_mode=striped type=lustre")
+	if err != nil || directive != "#DW jobdw accessmode=striped type=lustre" || len(warnings) != 1 {
+		t.Errorf("Alias was not replaced: '%s', %v, %v", directive, warnings, err)
+	}
+
+	directive, warnings, err = ReplaceAliases(rule, "#DW jobdw type=lustre  pool=fast")
+	if err != nil || directive != "#DW jobdw type=lustre  pool=fast" || len(warnings) != 1 {
+		t.Errorf("Deprecated argument was not reported: '%s', %v, %v", directive, warnings, err)
+	}
+
+	if _, _, err := ReplaceAliases(rule, "#DW jobdw type=lustre accessmode=striped access_mode=private"); err == nil {
+		t.Errorf("Argument repeated by its alias was not rejected")
+	}
+
+	// A directive for another command is left alone
+	directive, warnings, err = ReplaceAliases(rule, "#DW persistentdw access_mode=striped")
+	if err != nil || directive != "#DW persistentdw access_mode=striped" || len(warnings) != 0 {
+		t.Errorf("Directive for another command was changed: '%s', %v, %v", directive, warnings, err)
+	}
+
+	valid, warnings, err := ValidateDWDirectiveWithOptions(rule, "#DW jobdw type=lustre access_mode=striped", map[string]bool{}, true, ParseOptions{})
+	if !valid || err != nil || len(warnings) != 1 {
+		t.Errorf("Directive with an alias was not valid: %v, %v", warnings, err)
+	}
+}
+
+func TestRoundCapacities(t *testing.T) {
+	rule := DWDirectiveRuleSpec{
+		Command: "jobdw",
+		RuleDefs: []DWDirectiveRuleDef{
+			{Key: "type", Type: "string", IsRequired: true},
+			{Key: "capacity", Type: "string", RoundUpTo: "192GiB"},
+		},
+	}
+
+	directive, warnings, err := RoundCapacities(rule, "#DW jobdw type=xfs capacity=100GiB name=scratch")
+	if err != nil || directive != "#DW jobdw type=xfs capacity=192GiB name=scratch" || len(warnings) != 1 {
+		t.Errorf("Capacity was not rounded up: '%s', %v, %v", directive, warnings, err)
+	}
+
+	// The rounded capacity isn't a whole number of TB, so it's formatted in the best unit
+	directive, _, err = RoundCapacities(rule, "#DW jobdw type=xfs capacity=1TB")
+	if err != nil || directive != "#DW jobdw type=xfs capacity=960GiB" {
+		t.Errorf("Capacity was not rounded up: '%s', %v", directive, err)
+	}
+
+	directive, warnings, err = RoundCapacities(rule, "#DW jobdw type=xfs  capacity=384GiB")
+	if err != nil || directive != "#DW jobdw type=xfs  capacity=384GiB" || len(warnings) != 0 {
+		t.Errorf("Multiple of the granularity was changed: '%s', %v, %v", directive, warnings, err)
+	}
+
+	// An invalid capacity is left for validation
+	directive, _, err = RoundCapacities(rule, "#DW jobdw type=xfs capacity=lots")
+	if err != nil || directive != "#DW jobdw type=xfs capacity=lots" {
+		t.Errorf("Invalid capacity was changed: '%s', %v", directive, err)
+	}
+
+	directive, warnings, err = RoundCapacities(rule, "#DW persistentdw capacity=100GiB")
+	if err != nil || directive != "#DW persistentdw capacity=100GiB" || len(warnings) != 0 {
+		t.Errorf("Directive for another command was changed: '%s', %v, %v", directive, warnings, err)
+	}
+
+	if _, _, err := RoundCapacities(rule, "#DW jobdw capacity=9007199120523265KiB"); err == nil {
+		t.Errorf("Capacity too large to round was not rejected")
+	}
+}
@@ -0,0 +1,276 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RuleSet is a named, versioned collection of directive rules. A rule set either provides
+// rules of its own (a base rule set) or overrides the rules of a base rule set so sites can
+// extend the vendor rules without modifying them.
+type RuleSet struct {
+	// Name of the rule set. Multiple versions of the same rule set share a name.
+	Name string
+
+	// Version of the rule set in dotted numeric form (e.g., "1.2.0"). Only the highest
+	// version of a base rule set is applicable.
+	Version string
+
+	// Overrides is the name of the base rule set this rule set overrides. Empty for a
+	// base rule set.
+	Overrides string
+
+	// Rules provided by the rule set
+	Rules []DWDirectiveRuleSpec
+}
+
+// CompareRuleSetVersions compares two dotted numeric versions and returns -1, 0, or 1 if
+// a is less than, equal to, or greater than b. Missing components are treated as zero, and
+// an empty version is lower than any other version.
+func CompareRuleSetVersions(a, b string) (int, error) {
+	if a == b {
+		return 0, nil
+	}
+	if a == "" {
+		return -1, nil
+	}
+	if b == "" {
+		return 1, nil
+	}
+
+	aParts, err := parseRuleSetVersion(a)
+	if err != nil {
+		return 0, err
+	}
+
+	bParts, err := parseRuleSetVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		if aPart < bPart {
+			return -1, nil
+		}
+		if aPart > bPart {
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func parseRuleSetVersion(version string) ([]int, error) {
+	parts := []int{}
+	for _, field := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		part, err := strconv.Atoi(field)
+		if err != nil || part < 0 {
+			return nil, fmt.Errorf("invalid rule set version '%s'", version)
+		}
+
+		parts = append(parts, part)
+	}
+
+	return parts, nil
+}
+
+// MergeRules merges the override rules into the base rules. An override rule with the same
+// command and driver label as a base rule replaces the rule definitions with matching keys
+// and adds any new keys. The watch states are replaced if the override provides them. Override
+// rules that don't match a base rule are appended. The base rules are not modified.
+func MergeRules(base []DWDirectiveRuleSpec, overrides []DWDirectiveRuleSpec) []DWDirectiveRuleSpec {
+	merged := make([]DWDirectiveRuleSpec, len(base))
+	for i := range base {
+		merged[i] = *base[i].DeepCopy()
+	}
+
+	for _, override := range overrides {
+		index := -1
+		for i := range merged {
+			if merged[i].Command == override.Command && merged[i].DriverLabel == override.DriverLabel {
+				index = i
+				break
+			}
+		}
+
+		if index == -1 {
+			merged = append(merged, *override.DeepCopy())
+			continue
+		}
+
+		rule := &merged[index]
+		if override.WatchStates != "" {
+			rule.WatchStates = override.WatchStates
+		}
+
+		for _, overrideDef := range override.RuleDefs {
+			replaced := false
+			for i := range rule.RuleDefs {
+				if rule.RuleDefs[i].Key == overrideDef.Key {
+					rule.RuleDefs[i] = overrideDef
+					replaced = true
+					break
+				}
+			}
+
+			if !replaced {
+				rule.RuleDefs = append(rule.RuleDefs, overrideDef)
+			}
+		}
+	}
+
+	return merged
+}
+
+// ApplicableRuleSets returns the rule sets that apply given a list of all the rule sets. The
+// highest version of each base rule set is selected and the rules from any rule sets that
+// override it are merged in, in order of the overriding rule set names. The result is sorted
+// by rule set name.
+func ApplicableRuleSets(ruleSets []RuleSet) ([]RuleSet, error) {
+	bases := map[string]RuleSet{}
+	overrides := map[string][]RuleSet{}
+
+	for _, ruleSet := range ruleSets {
+		if ruleSet.Overrides != "" {
+			overrides[ruleSet.Overrides] = append(overrides[ruleSet.Overrides], ruleSet)
+			continue
+		}
+
+		current, found := bases[ruleSet.Name]
+		if !found {
+			bases[ruleSet.Name] = ruleSet
+			continue
+		}
+
+		cmp, err := CompareRuleSetVersions(ruleSet.Version, current.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		if cmp == 0 {
+			return nil, fmt.Errorf("duplicate version '%s' of rule set '%s'", ruleSet.Version, ruleSet.Name)
+		}
+
+		if cmp > 0 {
+			bases[ruleSet.Name] = ruleSet
+		}
+	}
+
+	for name := range overrides {
+		if _, found := bases[name]; !found {
+			return nil, fmt.Errorf("rule set '%s' overrides unknown rule set '%s'", overrides[name][0].Name, name)
+		}
+	}
+
+	applicable := []RuleSet{}
+	for name, base := range bases {
+		siteOverrides := overrides[name]
+		sort.Slice(siteOverrides, func(i, j int) bool { return siteOverrides[i].Name < siteOverrides[j].Name })
+
+		for _, override := range siteOverrides {
+			base.Rules = MergeRules(base.Rules, override.Rules)
+		}
+
+		applicable = append(applicable, base)
+	}
+
+	sort.Slice(applicable, func(i, j int) bool { return applicable[i].Name < applicable[j].Name })
+
+	return applicable, nil
+}
+
+// ValidateRuleSet checks that the rules in a rule set are well formed
+func ValidateRuleSet(ruleSet RuleSet) error {
+	if ruleSet.Version != "" {
+		if _, err := parseRuleSetVersion(ruleSet.Version); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range ruleSet.Rules {
+		if rule.Command == "" {
+			return fmt.Errorf("rule set '%s' has a rule without a command", ruleSet.Name)
+		}
+
+		keys := map[string]bool{}
+		for _, ruleDef := range rule.RuleDefs {
+			if keys[ruleDef.Key] {
+				return fmt.Errorf("rule set '%s' command '%s' has a duplicate key '%s'", ruleSet.Name, rule.Command, ruleDef.Key)
+			}
+			keys[ruleDef.Key] = true
+		}
+
+		for _, ruleDef := range rule.RuleDefs {
+			if ruleDef.Alias != "" {
+				if keys[ruleDef.Alias] {
+					return fmt.Errorf("rule set '%s' command '%s' key '%s' has alias '%s' that is already a key or alias", ruleSet.Name, rule.Command, ruleDef.Key, ruleDef.Alias)
+				}
+				keys[ruleDef.Alias] = true
+			}
+
+			if ruleDef.DeprecatedSince != "" {
+				if _, err := parseRuleSetVersion(ruleDef.DeprecatedSince); err != nil {
+					return fmt.Errorf("rule set '%s' command '%s' key '%s' has invalid deprecatedSince: %v", ruleSet.Name, rule.Command, ruleDef.Key, err)
+				}
+			}
+
+			switch ruleDef.Type {
+			case "integer", "bool", "string":
+			default:
+				return fmt.Errorf("rule set '%s' command '%s' key '%s' has unsupported type '%s'", ruleSet.Name, rule.Command, ruleDef.Key, ruleDef.Type)
+			}
+
+			if ruleDef.RoundUpTo != "" {
+				if ruleDef.Type != "string" {
+					return fmt.Errorf("rule set '%s' command '%s' key '%s' has roundUpTo but is not a string", ruleSet.Name, rule.Command, ruleDef.Key)
+				}
+
+				if granularity, err := ParseCapacity(ruleDef.RoundUpTo); err != nil || granularity == 0 {
+					return fmt.Errorf("rule set '%s' command '%s' key '%s' has invalid roundUpTo '%s'", ruleSet.Name, rule.Command, ruleDef.Key, ruleDef.RoundUpTo)
+				}
+			}
+
+			if ruleDef.Min != nil && ruleDef.Max != nil && *ruleDef.Min > *ruleDef.Max {
+				return fmt.Errorf("rule set '%s' command '%s' key '%s' has minimum %d greater than maximum %d", ruleSet.Name, rule.Command, ruleDef.Key, *ruleDef.Min, *ruleDef.Max)
+			}
+
+			if ruleDef.Pattern != "" {
+				if _, err := regexp.Compile(ruleDef.Pattern); err != nil {
+					return fmt.Errorf("rule set '%s' command '%s' key '%s' has invalid pattern: %v", ruleSet.Name, rule.Command, ruleDef.Key, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
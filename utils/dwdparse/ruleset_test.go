@@ -0,0 +1,136 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"testing"
+)
+
+func TestCompareRuleSetVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.0", "1.0.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"2", "1.9.9", 1},
+		{"v1.1", "1.0", 1},
+		{"", "0.1", -1},
+	}
+
+	for _, test := range tests {
+		cmp, err := CompareRuleSetVersions(test.a, test.b)
+		if err != nil {
+			t.Errorf("Compare '%s' '%s' returned unexpected error %v", test.a, test.b, err)
+		}
+		if cmp != test.expected {
+			t.Errorf("Compare '%s' '%s' expected %d, got %d", test.a, test.b, test.expected, cmp)
+		}
+	}
+
+	if _, err := CompareRuleSetVersions("1.x", "1.0"); err == nil {
+		t.Errorf("Invalid version did not return an error")
+	}
+}
+
+func TestApplicableRuleSets(t *testing.T) {
+	v1 := RuleSet{Name: "vendor", Version: "1.0.0", Rules: []DWDirectiveRuleSpec{
+		{Command: "jobdw", DriverLabel: "vendor", RuleDefs: []DWDirectiveRuleDef{{Key: "type", Type: "string", Pattern: "^(xfs)$"}}},
+	}}
+
+	v2 := RuleSet{Name: "vendor", Version: "2.0.0", Rules: []DWDirectiveRuleSpec{
+		{Command: "jobdw", DriverLabel: "vendor", RuleDefs: []DWDirectiveRuleDef{
+			{Key: "type", Type: "string", Pattern: "^(xfs|lustre)$"},
+			{Key: "capacity", Type: "string"},
+		}},
+	}}
+
+	site := RuleSet{Name: "site", Version: "1.0.0", Overrides: "vendor", Rules: []DWDirectiveRuleSpec{
+		{Command: "jobdw", DriverLabel: "vendor", RuleDefs: []DWDirectiveRuleDef{
+			{Key: "type", Type: "string", Pattern: "^(xfs|lustre|gfs2)$"},
+			{Key: "profile", Type: "string"},
+		}},
+		{Command: "site_cmd", DriverLabel: "vendor", RuleDefs: []DWDirectiveRuleDef{{Key: "name", Type: "string"}}},
+	}}
+
+	ruleSets, err := ApplicableRuleSets([]RuleSet{site, v2, v1})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if len(ruleSets) != 1 || ruleSets[0].Version != "2.0.0" {
+		t.Fatalf("Expected only version 2.0.0 of the vendor rule set, got %+v", ruleSets)
+	}
+
+	rules := ruleSets[0].Rules
+	if len(rules) != 2 {
+		t.Fatalf("Expected the site command to be added, got %+v", rules)
+	}
+
+	rulesMap, _ := BuildRulesMap(rules[0], "jobdw")
+	if rulesMap["type"].Pattern != "^(xfs|lustre|gfs2)$" {
+		t.Errorf("Override did not replace the type rule: %+v", rulesMap["type"])
+	}
+	if _, found := rulesMap["capacity"]; !found {
+		t.Errorf("Base capacity rule was not kept")
+	}
+	if _, found := rulesMap["profile"]; !found {
+		t.Errorf("Override profile rule was not added")
+	}
+
+	// The base rule set must not be modified by the merge
+	if len(v2.Rules[0].RuleDefs) != 2 || v2.Rules[0].RuleDefs[0].Pattern != "^(xfs|lustre)$" {
+		t.Errorf("Base rule set was modified: %+v", v2.Rules[0])
+	}
+
+	if _, err := ApplicableRuleSets([]RuleSet{site}); err == nil {
+		t.Errorf("Override of unknown rule set did not return an error")
+	}
+
+	if _, err := ApplicableRuleSets([]RuleSet{v1, v1}); err == nil {
+		t.Errorf("Duplicate rule set version did not return an error")
+	}
+}
+
+func TestValidateRuleSet(t *testing.T) {
+	if err := ValidateRuleSet(RuleSet{Name: "test", Rules: dWDRules}); err != nil {
+		t.Errorf("Valid rule set returned error %v", err)
+	}
+
+	invalid := []RuleSet{
+		{Name: "version", Version: "one"},
+		{Name: "command", Rules: []DWDirectiveRuleSpec{{RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "string"}}}}},
+		{Name: "type", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "float"}}}}},
+		{Name: "pattern", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "string", Pattern: "^(a"}}}}},
+		{Name: "duplicate", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "string"}, {Key: "a", Type: "bool"}}}}},
+		{Name: "alias", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "string"}, {Key: "b", Type: "string", Alias: "a"}}}}},
+		{Name: "deprecated", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "string", DeprecatedSince: "next"}}}}},
+		{Name: "roundUpTo", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "string", RoundUpTo: "192"}}}}},
+		{Name: "roundUpType", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "integer", RoundUpTo: "192GiB"}}}}},
+		{Name: "bounds", Rules: []DWDirectiveRuleSpec{{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "a", Type: "integer", Min: intPtr(2), Max: intPtr(1)}}}}},
+	}
+
+	for _, ruleSet := range invalid {
+		if err := ValidateRuleSet(ruleSet); err == nil {
+			t.Errorf("Invalid rule set '%s' did not return an error", ruleSet.Name)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"fmt"
+	"sort"
+)
+
+// JSONSchemaDraft is the JSON Schema draft of the schemas built by RuleSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of JSON Schema needed to describe the arguments of a #DW
+// directive. A schema describes the argument map returned by BuildArgsMap, with the
+// "command" property fixed to the rule's command. The rule settings that have no JSON Schema
+// keyword are kept in x-dws- extensions so a rule can be rebuilt from its schema.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Const                string                 `json:"const,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+
+	DriverLabel  string `json:"x-dws-driverLabel,omitempty"`
+	WatchStates  string `json:"x-dws-watchStates,omitempty"`
+	UniqueWithin string `json:"x-dws-uniqueWithin,omitempty"`
+
+	Alias           string `json:"x-dws-alias,omitempty"`
+	DeprecatedSince string `json:"x-dws-deprecatedSince,omitempty"`
+	RoundUpTo       string `json:"x-dws-roundUpTo,omitempty"`
+}
+
+// ruleTypes maps the rule argument types to the JSON Schema types
+var ruleTypes = map[string]string{
+	"integer": "integer",
+	"bool":    "boolean",
+	"string":  "string",
+}
+
+// RuleSchema returns the JSON Schema of the arguments of the rule's directive
+func RuleSchema(rule DWDirectiveRuleSpec) (*JSONSchema, error) {
+	noAdditional := false
+	schema := &JSONSchema{
+		Schema:               JSONSchemaDraft,
+		Title:                "#DW " + rule.Command,
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{"command": {Type: "string", Const: rule.Command}},
+		Required:             []string{"command"},
+		AdditionalProperties: &noAdditional,
+		DriverLabel:          rule.DriverLabel,
+		WatchStates:          rule.WatchStates,
+	}
+
+	for _, def := range rule.RuleDefs {
+		schemaType, found := ruleTypes[def.Type]
+		if !found {
+			return nil, fmt.Errorf("unsupported value type '%s' for argument '%s'", def.Type, def.Key)
+		}
+
+		property := &JSONSchema{
+			Type:         schemaType,
+			Pattern:      def.Pattern,
+			Minimum:      def.Min,
+			Maximum:      def.Max,
+			UniqueWithin: def.UniqueWithin,
+
+			Alias:           def.Alias,
+			Deprecated:      def.DeprecatedSince != "",
+			DeprecatedSince: def.DeprecatedSince,
+			RoundUpTo:       def.RoundUpTo,
+		}
+
+		if def.IsValueRequired {
+			minLength := 1
+			property.MinLength = &minLength
+		}
+
+		if def.IsRequired {
+			schema.Required = append(schema.Required, def.Key)
+		}
+
+		schema.Properties[def.Key] = property
+	}
+
+	return schema, nil
+}
+
+// RulesSchema returns a JSON Schema that accepts the arguments of a directive matching any
+// of the rules
+func RulesSchema(rules []DWDirectiveRuleSpec) (*JSONSchema, error) {
+	schema := &JSONSchema{Schema: JSONSchemaDraft, Title: "#DW directives"}
+
+	for _, rule := range rules {
+		ruleSchema, err := RuleSchema(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule for '%s': %w", rule.Command, err)
+		}
+
+		ruleSchema.Schema = ""
+		schema.AnyOf = append(schema.AnyOf, ruleSchema)
+	}
+
+	return schema, nil
+}
+
+// RulesFromSchema rebuilds the rules from a schema built by RuleSchema or RulesSchema. The
+// argument rules are sorted by key since a schema doesn't keep their order.
+func RulesFromSchema(schema *JSONSchema) ([]DWDirectiveRuleSpec, error) {
+	if len(schema.AnyOf) == 0 {
+		rule, err := ruleFromSchema(schema)
+		if err != nil {
+			return nil, err
+		}
+
+		return []DWDirectiveRuleSpec{rule}, nil
+	}
+
+	rules := []DWDirectiveRuleSpec{}
+	for i, ruleSchema := range schema.AnyOf {
+		rule, err := ruleFromSchema(ruleSchema)
+		if err != nil {
+			return nil, fmt.Errorf("anyOf[%d]: %w", i, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func ruleFromSchema(schema *JSONSchema) (DWDirectiveRuleSpec, error) {
+	command, found := schema.Properties["command"]
+	if schema.Type != "object" || !found || command.Const == "" {
+		return DWDirectiveRuleSpec{}, fmt.Errorf("schema is not an object with a constant 'command' property")
+	}
+
+	rule := DWDirectiveRuleSpec{
+		Command:     command.Const,
+		DriverLabel: schema.DriverLabel,
+		WatchStates: schema.WatchStates,
+		RuleDefs:    []DWDirectiveRuleDef{},
+	}
+
+	required := map[string]bool{}
+	for _, key := range schema.Required {
+		required[key] = true
+	}
+
+	keys := []string{}
+	for key := range schema.Properties {
+		if key != "command" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property := schema.Properties[key]
+
+		ruleType := ""
+		for t, schemaType := range ruleTypes {
+			if schemaType == property.Type {
+				ruleType = t
+			}
+		}
+
+		if ruleType == "" {
+			return DWDirectiveRuleSpec{}, fmt.Errorf("unsupported type '%s' for property '%s'", property.Type, key)
+		}
+
+		rule.RuleDefs = append(rule.RuleDefs, DWDirectiveRuleDef{
+			Key:             key,
+			Type:            ruleType,
+			Pattern:         property.Pattern,
+			Min:             property.Minimum,
+			Max:             property.Maximum,
+			IsRequired:      required[key],
+			IsValueRequired: property.MinLength != nil && *property.MinLength > 0,
+			UniqueWithin:    property.UniqueWithin,
+			Alias:           property.Alias,
+			DeprecatedSince: property.DeprecatedSince,
+			RoundUpTo:       property.RoundUpTo,
+		})
+	}
+
+	return rule, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestRuleSchema(t *testing.T) {
+	min, max := 1, 10
+	rule := DWDirectiveRuleSpec{
+		Command:     "jobdw",
+		DriverLabel: "vendor",
+		WatchStates: "Proposal,Setup",
+		RuleDefs: []DWDirectiveRuleDef{
+			{Key: "capacity", Type: "string", Pattern: "^[0-9]+GiB$", IsRequired: true, IsValueRequired: true, RoundUpTo: "192GiB"},
+			{Key: "count", Type: "integer", Min: &min, Max: &max},
+			{Key: "name", Type: "string", IsRequired: true, UniqueWithin: "jobdw_name"},
+			{Key: "persistent", Type: "bool", Alias: "keep", DeprecatedSince: "1.2"},
+		},
+	}
+
+	schema, err := RuleSchema(rule)
+	if err != nil {
+		t.Fatalf("RuleSchema returned unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(schema.Required, []string{"command", "capacity", "name"}) {
+		t.Errorf("Unexpected required properties %v", schema.Required)
+	}
+
+	if schema.Properties["persistent"].Type != "boolean" || schema.Properties["count"].Type != "integer" {
+		t.Errorf("Unexpected property types")
+	}
+
+	// The rule is rebuilt from the JSON
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Marshal returned unexpected error %v", err)
+	}
+
+	decoded := &JSONSchema{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("Unmarshal returned unexpected error %v", err)
+	}
+
+	rules, err := RulesFromSchema(decoded)
+	if err != nil {
+		t.Fatalf("RulesFromSchema returned unexpected error %v", err)
+	}
+
+	if len(rules) != 1 || !reflect.DeepEqual(rules[0], rule) {
+		t.Errorf("Rule was not rebuilt from the schema: %+v", rules)
+	}
+
+	if _, err := RuleSchema(DWDirectiveRuleSpec{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "size", Type: "float"}}}); err == nil {
+		t.Errorf("Unsupported argument type did not return an error")
+	}
+}
+
+func TestRulesSchema(t *testing.T) {
+	rules := []DWDirectiveRuleSpec{
+		{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{{Key: "name", Type: "string"}}},
+		{Command: "copy_in", RuleDefs: []DWDirectiveRuleDef{{Key: "source", Type: "string"}}},
+	}
+
+	schema, err := RulesSchema(rules)
+	if err != nil {
+		t.Fatalf("RulesSchema returned unexpected error %v", err)
+	}
+
+	if len(schema.AnyOf) != 2 || schema.AnyOf[1].Properties["command"].Const != "copy_in" {
+		t.Errorf("Unexpected schema %+v", schema)
+	}
+
+	decoded, err := RulesFromSchema(schema)
+	if err != nil {
+		t.Fatalf("RulesFromSchema returned unexpected error %v", err)
+	}
+
+	if !reflect.DeepEqual(decoded, rules) {
+		t.Errorf("Rules were not rebuilt from the schema: %+v", decoded)
+	}
+
+	if _, err := RulesFromSchema(&JSONSchema{Type: "object"}); err == nil {
+		t.Errorf("Schema without a command did not return an error")
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ScriptDirective is a #DW directive found in a batch script
+type ScriptDirective struct {
+	// Line is the line number of the directive in the script, starting at 1
+	Line int
+
+	// Directive is the text of the directive with the surrounding white space removed
+	Directive string
+}
+
+// ExtractDirectives returns the #DW directives in a batch script
+func ExtractDirectives(r io.Reader) ([]ScriptDirective, error) {
+	return extractDirectives(r, "#DW")
+}
+
+// ExtractDataWarpDirectives returns the #DW and #BB directives in a batch script written
+// for Cray DataWarp. They can be translated with TranslateDataWarp.
+func ExtractDataWarpDirectives(r io.Reader) ([]ScriptDirective, error) {
+	return extractDirectives(r, "#DW", "#BB")
+}
+
+// extractDirectives returns the directives in a batch script that start with one of the
+// prefixes
+func extractDirectives(r io.Reader, prefixes ...string) ([]ScriptDirective, error) {
+	directives := []ScriptDirective{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 || !isPrefix(fields[0], prefixes) {
+			continue
+		}
+
+		directives = append(directives, ScriptDirective{Line: line, Directive: text})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return directives, nil
+}
+
+// isPrefix returns whether the field is one of the directive prefixes
+func isPrefix(field string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if field == prefix {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateDirectives validates a job's directives against the rules the same way the
+// Workflow webhook does: every directive must be valid for at least one rule, and
+// unsupported commands are rejected. The returned errors are in the same order as the
+// directives, with a nil entry for each valid directive.
+func ValidateDirectives(rules []DWDirectiveRuleSpec, directives []string) []error {
+	errs, _ := ValidateDirectivesWithOptions(rules, directives, ParseOptions{})
+	return errs
+}
+
+// ValidateDirectivesWithOptions validates a job's directives like ValidateDirectives,
+// parsing them with the options. It also returns the warnings for each directive, in the
+// same order as the directives.
+func ValidateDirectivesWithOptions(rules []DWDirectiveRuleSpec, directives []string, options ParseOptions) ([]error, []Warnings) {
+	uniqueMap := make(map[string]bool)
+	errs := make([]error, len(directives))
+	warnings := make([]Warnings, len(directives))
+
+	for i, directive := range directives {
+		valid := false
+		for _, rule := range rules {
+			ruleValid, ruleWarnings, err := ValidateDWDirectiveWithOptions(rule, directive, uniqueMap, true, options)
+
+			// The parse warnings are the same for every rule, but the warnings about the
+			// arguments only come from the rule for the command
+			if warnings[i] == nil || ruleValid {
+				warnings[i] = ruleWarnings
+			}
+
+			if err != nil {
+				errs[i] = err
+				break
+			}
+
+			if ruleValid {
+				valid = true
+			}
+		}
+
+		if errs[i] == nil && !valid {
+			errs[i] = fmt.Errorf("invalid directive found: '%s'", directive)
+		}
+	}
+
+	return errs, warnings
+}
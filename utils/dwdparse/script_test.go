@@ -0,0 +1,85 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"strings"
+	"testing"
+)
+
+const testScript = `#!/bin/bash
+#SBATCH --nodes=4
+#DW jobdw type=xfs capacity=10GiB name=scratch
+  #DW persistentdw name=shared
+#DWX not a directive
+echo "#DW also not a directive"
+#DW jobdw type=zfs capacity=10GiB name=bad
+srun ./app
+`
+
+func TestExtractDirectives(t *testing.T) {
+	directives, err := ExtractDirectives(strings.NewReader(testScript))
+	if err != nil {
+		t.Fatalf("Extract returned unexpected error %v", err)
+	}
+
+	expected := []ScriptDirective{
+		{Line: 3, Directive: "#DW jobdw type=xfs capacity=10GiB name=scratch"},
+		{Line: 4, Directive: "#DW persistentdw name=shared"},
+		{Line: 7, Directive: "#DW jobdw type=zfs capacity=10GiB name=bad"},
+	}
+
+	if len(directives) != len(expected) {
+		t.Fatalf("Expected %d directives, got %v", len(expected), directives)
+	}
+
+	for i := range expected {
+		if directives[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], directives[i])
+		}
+	}
+}
+
+func TestValidateDirectives(t *testing.T) {
+	rules := []DWDirectiveRuleSpec{
+		{Command: "jobdw", RuleDefs: []DWDirectiveRuleDef{
+			{Key: "type", Type: "string", Pattern: "^(xfs|gfs2)$", IsRequired: true, IsValueRequired: true},
+			{Key: "capacity", Type: "string", IsRequired: true, IsValueRequired: true},
+			{Key: "name", Type: "string", IsRequired: true, IsValueRequired: true, UniqueWithin: "jobdw_name"},
+		}},
+	}
+
+	errs := ValidateDirectives(rules, []string{
+		"#DW jobdw type=xfs capacity=10GiB name=scratch",
+		"#DW jobdw type=zfs capacity=10GiB name=bad",
+		"#DW jobdw type=gfs2 capacity=10GiB name=scratch",
+		"#DW persistentdw name=shared",
+	})
+
+	if errs[0] != nil {
+		t.Errorf("Valid directive returned error %v", errs[0])
+	}
+
+	for i := 1; i < len(errs); i++ {
+		if errs[i] == nil {
+			t.Errorf("Invalid directive %d did not return an error", i)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ViolationDuplicateName is a name used by more than one directive of the same kind
+	ViolationDuplicateName = "DuplicateName"
+
+	// ViolationUnknownReference is a $DW_JOB_[name] or $DW_PERSISTENT_[name] reference to
+	// storage that no directive in the job provides
+	ViolationUnknownReference = "UnknownReference"
+
+	// ViolationDestroyInUse is a destroy_persistent of persistent storage that the same job
+	// creates or uses
+	ViolationDestroyInUse = "DestroyInUse"
+)
+
+// Violation is a problem with how a job's directives relate to each other, rather than with
+// a single directive
+type Violation struct {
+	// Index of the directive in the job's list of directives
+	Index int
+
+	// Command of the directive
+	Command string
+
+	// Reason is one of the Violation* constants
+	Reason string
+
+	// Message describes the violation
+	Message string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("directive %d (%s): %s", v.Index, v.Command, v.Message)
+}
+
+// Violations is a list of violations that can be returned as a single error
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, len(v))
+	for i := range v {
+		messages[i] = v[i].Error()
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// storageReference matches a reference to the job storage provided by a jobdw or
+// persistentdw directive, e.g., "$DW_JOB_scratch/input" or "${DW_PERSISTENT_shared}"
+var storageReference = regexp.MustCompile(`\$\{?DW_(JOB|PERSISTENT)_([A-Za-z0-9_-]+)`)
+
+// ValidateDirectiveSet checks the relationships between a job's directives:
+//   - jobdw, persistentdw, and create_persistent names are unique for each command
+//   - $DW_JOB_[name] and $DW_PERSISTENT_[name] references, e.g., in the source or destination
+//     of stage_in and stage_out, name a jobdw or persistentdw in the job
+//   - destroy_persistent doesn't name persistent storage the job creates or uses
+//
+// Each directive should already be valid on its own (see ValidateDirectives). Directives
+// that can't be parsed are skipped. The violations are in the order of the directives.
+func ValidateDirectiveSet(directives []string) Violations {
+	violations := Violations{}
+	args := make([]map[string]string, len(directives))
+
+	// The first directive with each command and name
+	named := map[string]map[string]int{
+		"jobdw":             {},
+		"persistentdw":      {},
+		"create_persistent": {},
+	}
+
+	for i, directive := range directives {
+		argsMap, err := BuildArgsMap(directive)
+		if err != nil {
+			continue
+		}
+		args[i] = argsMap
+
+		names, found := named[argsMap["command"]]
+		if !found || argsMap["name"] == "" {
+			continue
+		}
+
+		if first, found := names[argsMap["name"]]; found {
+			violations = append(violations, Violation{
+				Index:   i,
+				Command: argsMap["command"],
+				Reason:  ViolationDuplicateName,
+				Message: fmt.Sprintf("name '%s' is already used by directive %d", argsMap["name"], first),
+			})
+			continue
+		}
+
+		names[argsMap["name"]] = i
+	}
+
+	for i, argsMap := range args {
+		if argsMap == nil {
+			continue
+		}
+
+		command := argsMap["command"]
+		for _, key := range sortedKeys(argsMap) {
+			for _, match := range storageReference.FindAllStringSubmatch(argsMap[key], -1) {
+				provider := "jobdw"
+				if match[1] == "PERSISTENT" {
+					provider = "persistentdw"
+				}
+
+				if _, found := named[provider][match[2]]; !found {
+					violations = append(violations, Violation{
+						Index:   i,
+						Command: command,
+						Reason:  ViolationUnknownReference,
+						Message: fmt.Sprintf("'%s' references '%s' but there is no %s directive named '%s'", key, strings.TrimPrefix(match[0], "$"), provider, match[2]),
+					})
+				}
+			}
+		}
+
+		if command != "destroy_persistent" {
+			continue
+		}
+
+		for _, other := range []string{"create_persistent", "persistentdw"} {
+			if j, found := named[other][argsMap["name"]]; found {
+				violations = append(violations, Violation{
+					Index:   i,
+					Command: command,
+					Reason:  ViolationDestroyInUse,
+					Message: fmt.Sprintf("persistent storage '%s' is also named by %s directive %d", argsMap["name"], other, j),
+				})
+			}
+		}
+	}
+
+	return violations
+}
+
+// sortedKeys returns the argument keys in a fixed order, so the violations are reported in
+// the same order every time
+func sortedKeys(args map[string]string) []string {
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwdparse
+
+import (
+	"testing"
+)
+
+func TestValidateDirectiveSet(t *testing.T) {
+	tests := []struct {
+		directives []string
+		reasons    []string
+	}{
+		{
+			directives: []string{
+				"#DW jobdw type=xfs capacity=10GiB name=scratch",
+				"#DW persistentdw name=shared",
+				"#DW stage_in type=directory source=/lus/input destination=$DW_JOB_scratch/input",
+				"#DW stage_out type=directory source=${DW_PERSISTENT_shared}/output destination=/lus/output",
+			},
+		},
+		{
+			directives: []string{
+				"#DW jobdw type=xfs capacity=10GiB name=scratch",
+				"#DW jobdw type=gfs2 capacity=10GiB name=scratch",
+			},
+			reasons: []string{ViolationDuplicateName},
+		},
+		{
+			// jobdw and persistentdw names are in separate environment variables
+			directives: []string{
+				"#DW jobdw type=xfs capacity=10GiB name=scratch",
+				"#DW persistentdw name=scratch",
+			},
+		},
+		{
+			directives: []string{
+				"#DW jobdw type=xfs capacity=10GiB name=scratch",
+				"#DW stage_in type=directory source=/lus/input destination=$DW_JOB_striped",
+				"#DW stage_out type=directory source=$DW_PERSISTENT_scratch destination=/lus/output",
+			},
+			reasons: []string{ViolationUnknownReference, ViolationUnknownReference},
+		},
+		{
+			directives: []string{
+				"#DW create_persistent type=lustre capacity=100GiB name=shared",
+				"#DW destroy_persistent name=shared",
+			},
+			reasons: []string{ViolationDestroyInUse},
+		},
+		{
+			directives: []string{
+				"#DW persistentdw name=shared",
+				"#DW destroy_persistent name=shared",
+				"#DW destroy_persistent name=other",
+			},
+			reasons: []string{ViolationDestroyInUse},
+		},
+		{
+			// Directives that can't be parsed are left to the per-directive validation
+			directives: []string{
+				"#DW jobdw type=xfs name=scratch name=scratch",
+				"#DW stage_in type=file source=/lus/input destination=$DW_JOB_scratch",
+			},
+			reasons: []string{ViolationUnknownReference},
+		},
+	}
+
+	for i, test := range tests {
+		violations := ValidateDirectiveSet(test.directives)
+		if len(violations) != len(test.reasons) {
+			t.Errorf("Test %d: expected %d violations, got %v", i, len(test.reasons), violations)
+			continue
+		}
+
+		for j := range violations {
+			if violations[j].Reason != test.reasons[j] {
+				t.Errorf("Test %d: expected reason %s, got %v", i, test.reasons[j], violations[j])
+			}
+		}
+	}
+}
@@ -29,6 +29,16 @@ import ()
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DWDirectiveRuleDef) DeepCopyInto(out *DWDirectiveRuleDef) {
 	*out = *in
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(int)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DWDirectiveRuleDef.
@@ -47,7 +57,9 @@ func (in *DWDirectiveRuleSpec) DeepCopyInto(out *DWDirectiveRuleSpec) {
 	if in.RuleDefs != nil {
 		in, out := &in.RuleDefs, &out.RuleDefs
 		*out = make([]DWDirectiveRuleDef, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
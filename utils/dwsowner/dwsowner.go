@@ -0,0 +1,201 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dwsowner defines the convention that guarantees ClientMounts are unmounted before
+// the storage backing them is deleted.
+//
+// A storage driver labels each ClientMount with the storage resource it mounts using
+// AddStorageLabels, and adds the UnmountFinalizer to the storage resource using
+// AddUnmountFinalizer. When the storage resource is deleted, the driver calls ReleaseStorage
+// from its reconciler until it returns true. ReleaseStorage asks the ClientMounts to unmount
+// and removes the finalizer once they have all reached the unmounted state, so the storage
+// isn't deleted out from under a mounted file system. The driver should watch ClientMounts
+// with StorageLabelMapFunc so it's reconciled as the ClientMounts unmount.
+//
+// The ClientMount controllers hold the ClientMountFinalizer on each ClientMount until its
+// file systems are unmounted, so a deleted ClientMount also counts as unmounted.
+package dwsowner
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+const (
+	// ClientMountFinalizer is held on a ClientMount by the ClientMount controller until the
+	// file systems described by the ClientMount are unmounted
+	ClientMountFinalizer = "dws.cray.hpe.com/client_mount"
+
+	// UnmountFinalizer is held on a storage resource until all the ClientMounts that mount
+	// the storage are unmounted
+	UnmountFinalizer = "dws.cray.hpe.com/unmount"
+
+	StorageKindLabel      = "dws.cray.hpe.com/storage.kind"
+	StorageNameLabel      = "dws.cray.hpe.com/storage.name"
+	StorageNamespaceLabel = "dws.cray.hpe.com/storage.namespace"
+)
+
+func storageKind(storage client.Object) string {
+	return reflect.Indirect(reflect.ValueOf(storage)).Type().Name()
+}
+
+// AddStorageLabels adds labels to a ClientMount that identify the storage resource it mounts
+func AddStorageLabels(clientMount *dwsv1alpha1.ClientMount, storage client.Object) {
+	labels := clientMount.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	labels[StorageKindLabel] = storageKind(storage)
+	labels[StorageNameLabel] = storage.GetName()
+	labels[StorageNamespaceLabel] = storage.GetNamespace()
+
+	clientMount.SetLabels(labels)
+}
+
+// MatchingStorage returns the MatchingLabels to find the ClientMounts for a storage resource
+func MatchingStorage(storage client.Object) client.MatchingLabels {
+	return client.MatchingLabels(map[string]string{
+		StorageKindLabel:      storageKind(storage),
+		StorageNameLabel:      storage.GetName(),
+		StorageNamespaceLabel: storage.GetNamespace(),
+	})
+}
+
+// AddUnmountFinalizer adds the UnmountFinalizer to a storage resource. It returns true if the
+// finalizer was added and the storage resource needs to be updated.
+func AddUnmountFinalizer(storage client.Object) bool {
+	if controllerutil.ContainsFinalizer(storage, UnmountFinalizer) {
+		return false
+	}
+
+	controllerutil.AddFinalizer(storage, UnmountFinalizer)
+	return true
+}
+
+// IsUnmounted returns true if all the file systems described by the ClientMount are unmounted
+func IsUnmounted(clientMount *dwsv1alpha1.ClientMount) bool {
+	if clientMount.Spec.DesiredState != dwsv1alpha1.ClientMountStateUnmounted {
+		return false
+	}
+
+	if len(clientMount.Status.Mounts) != len(clientMount.Spec.Mounts) {
+		return false
+	}
+
+	for _, mount := range clientMount.Status.Mounts {
+		if mount.State != dwsv1alpha1.ClientMountStateUnmounted || !mount.Ready {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UnmountClientMounts sets the desired state of all the ClientMounts for the storage resource
+// to unmounted. It returns true once all of the ClientMounts are unmounted.
+func UnmountClientMounts(ctx context.Context, c client.Client, storage client.Object) (bool, error) {
+	clientMounts := &dwsv1alpha1.ClientMountList{}
+	if err := c.List(ctx, clientMounts, MatchingStorage(storage)); err != nil {
+		return false, err
+	}
+
+	unmounted := true
+	for i := range clientMounts.Items {
+		clientMount := &clientMounts.Items[i]
+
+		if !clientMount.GetDeletionTimestamp().IsZero() {
+			// The ClientMount controller unmounts before letting the ClientMount go
+			unmounted = false
+			continue
+		}
+
+		if clientMount.Spec.DesiredState != dwsv1alpha1.ClientMountStateUnmounted {
+			clientMount.Spec.DesiredState = dwsv1alpha1.ClientMountStateUnmounted
+			if err := c.Update(ctx, clientMount); err != nil {
+				return false, client.IgnoreNotFound(err)
+			}
+		}
+
+		if !IsUnmounted(clientMount) {
+			unmounted = false
+		}
+	}
+
+	return unmounted, nil
+}
+
+// ReleaseStorage is called by a storage driver while the storage resource is being deleted. The
+// ClientMounts for the storage are unmounted and the UnmountFinalizer is removed once they are
+// all unmounted. It returns true once the finalizer is removed and the driver can continue
+// tearing down the storage.
+func ReleaseStorage(ctx context.Context, c client.Client, storage client.Object) (bool, error) {
+	if storage.GetDeletionTimestamp().IsZero() {
+		return false, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(storage, UnmountFinalizer) {
+		return true, nil
+	}
+
+	unmounted, err := UnmountClientMounts(ctx, c, storage)
+	if err != nil || !unmounted {
+		return false, err
+	}
+
+	controllerutil.RemoveFinalizer(storage, UnmountFinalizer)
+	if err := c.Update(ctx, storage); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	return true, nil
+}
+
+// StorageLabelMapFunc returns a map function that enqueues the storage resource of the same
+// kind as storage when one of its ClientMounts changes
+func StorageLabelMapFunc(storage client.Object) handler.MapFunc {
+	kind := storageKind(storage)
+
+	return func(o client.Object) []reconcile.Request {
+		labels := o.GetLabels()
+		if labels[StorageKindLabel] != kind {
+			return []reconcile.Request{}
+		}
+
+		name, exists := labels[StorageNameLabel]
+		if !exists {
+			return []reconcile.Request{}
+		}
+
+		return []reconcile.Request{
+			{NamespacedName: types.NamespacedName{
+				Name:      name,
+				Namespace: labels[StorageNamespaceLabel],
+			}},
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwsowner
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+func TestStorageLabels(t *testing.T) {
+	storage := &dwsv1alpha1.Storage{ObjectMeta: metav1.ObjectMeta{Name: "rabbit-0", Namespace: "default"}}
+	clientMount := &dwsv1alpha1.ClientMount{}
+
+	AddStorageLabels(clientMount, storage)
+
+	for key, value := range MatchingStorage(storage) {
+		if clientMount.GetLabels()[key] != value {
+			t.Errorf("Label %s expected '%s', got '%s'", key, value, clientMount.GetLabels()[key])
+		}
+	}
+
+	if clientMount.GetLabels()[StorageKindLabel] != "Storage" {
+		t.Errorf("Unexpected storage kind '%s'", clientMount.GetLabels()[StorageKindLabel])
+	}
+
+	requests := StorageLabelMapFunc(storage)(clientMount)
+	if len(requests) != 1 || requests[0].Name != "rabbit-0" || requests[0].Namespace != "default" {
+		t.Errorf("Unexpected requests %v", requests)
+	}
+
+	// A ClientMount for a different kind of storage is not mapped
+	if requests := StorageLabelMapFunc(&dwsv1alpha1.Servers{})(clientMount); len(requests) != 0 {
+		t.Errorf("Unexpected requests for a different kind %v", requests)
+	}
+}
+
+func TestIsUnmounted(t *testing.T) {
+	clientMount := &dwsv1alpha1.ClientMount{
+		Spec: dwsv1alpha1.ClientMountSpec{
+			DesiredState: dwsv1alpha1.ClientMountStateMounted,
+			Mounts:       []dwsv1alpha1.ClientMountInfo{{}, {}},
+		},
+	}
+
+	if IsUnmounted(clientMount) {
+		t.Errorf("Mounted ClientMount reported as unmounted")
+	}
+
+	clientMount.Spec.DesiredState = dwsv1alpha1.ClientMountStateUnmounted
+	if IsUnmounted(clientMount) {
+		t.Errorf("ClientMount without status reported as unmounted")
+	}
+
+	clientMount.Status.Mounts = []dwsv1alpha1.ClientMountInfoStatus{
+		{State: dwsv1alpha1.ClientMountStateUnmounted, Ready: true},
+		{State: dwsv1alpha1.ClientMountStateUnmounted, Ready: false},
+	}
+	if IsUnmounted(clientMount) {
+		t.Errorf("ClientMount with an unready mount reported as unmounted")
+	}
+
+	clientMount.Status.Mounts[1].Ready = true
+	if !IsUnmounted(clientMount) {
+		t.Errorf("Unmounted ClientMount not reported as unmounted")
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwstest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/clientmount"
+	"github.com/HewlettPackard/dws/utils/updater"
+)
+
+// fieldManagerFakeDaemon owns the ClientMount status fields applied by the fake daemon
+const fieldManagerFakeDaemon = "dws-fake-daemon"
+
+// FakeActuator is a clientmount.Actuator that marks the mounts ready without mounting
+// anything, like the NoopActuator, but can be made to fail and records what it was asked
+// to do. It's safe to change while a manager is running it.
+type FakeActuator struct {
+	mutex       sync.Mutex
+	err         *dwsv1alpha1.ResourceErrorInfo
+	holdRelease bool
+	actuated    map[types.NamespacedName]int
+	released    map[types.NamespacedName]int
+}
+
+// SetError makes Actuate report err in the status of the ClientMounts and leave the
+// mounts not ready. A nil error lets the mounts become ready again.
+func (a *FakeActuator) SetError(err *dwsv1alpha1.ResourceErrorInfo) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.err = err
+}
+
+// SetHoldRelease makes Release keep the finalizer on deleted ClientMounts until it's
+// cleared, as if the unmount were still running
+func (a *FakeActuator) SetHoldRelease(hold bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.holdRelease = hold
+}
+
+// Actuated returns the number of times Actuate was called for the ClientMount
+func (a *FakeActuator) Actuated(key types.NamespacedName) int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.actuated[key]
+}
+
+// Released returns the number of times Release was called for the ClientMount
+func (a *FakeActuator) Released(key types.NamespacedName) int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.released[key]
+}
+
+// Actuate marks the mounts ready unless an error is set or the ClientMount is a dry run
+func (a *FakeActuator) Actuate(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.actuated == nil {
+		a.actuated = map[types.NamespacedName]int{}
+	}
+	a.actuated[client.ObjectKeyFromObject(clientMount)]++
+
+	if a.err != nil {
+		for i := range clientMount.Status.Mounts {
+			clientMount.Status.Mounts[i].Ready = false
+		}
+		clientMount.Status.UpdateReadyCount()
+		clientMount.Status.Error = a.err.DeepCopy()
+
+		return ctrl.Result{}, nil
+	}
+
+	return clientmount.NoopActuator{}.Actuate(ctx, clientMount)
+}
+
+// Release has nothing to unmount. The ClientMount is requeued while the release is held.
+func (a *FakeActuator) Release(ctx context.Context, clientMount *dwsv1alpha1.ClientMount) (ctrl.Result, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.released == nil {
+		a.released = map[types.NamespacedName]int{}
+	}
+	a.released[client.ObjectKeyFromObject(clientMount)]++
+
+	if a.holdRelease {
+		return ctrl.Result{Requeue: true}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// FakeDaemon reconciles the ClientMounts of one node the way the mount-daemon does, using
+// the actuator in place of the mounts on the node. Drivers can run it in their manager to
+// see ClientMounts become ready without a node.
+type FakeDaemon struct {
+	client.Client
+	Log      logr.Logger
+	Node     string
+	Actuator clientmount.Actuator
+}
+
+// Reconcile moves a ClientMount of the node toward its desired state and applies the status
+func (r *FakeDaemon) Reconcile(ctx context.Context, req ctrl.Request) (res ctrl.Result, err error) {
+	clientMount := &dwsv1alpha1.ClientMount{}
+	if err := r.Get(ctx, req.NamespacedName, clientMount); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	statusUpdater := updater.NewStatusUpdater[*dwsv1alpha1.ClientMountStatus](clientMount)
+	defer func() { err = statusUpdater.CloseWithStatusApply(ctx, r.Client, fieldManagerFakeDaemon, err) }()
+
+	core := &clientmount.Core{Client: r.Client, Log: r.Log.WithValues("ClientMount", req.NamespacedName), Actuator: r.Actuator}
+	return core.Reconcile(ctx, clientMount)
+}
+
+// SetupWithManager sets up the fake daemon with the manager. Only the ClientMounts in the
+// namespace of the node are reconciled. A FakeActuator is used if no actuator is set.
+func (r *FakeDaemon) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Actuator == nil {
+		r.Actuator = &FakeActuator{}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("fake-daemon-" + r.Node).
+		For(&dwsv1alpha1.ClientMount{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(object client.Object) bool {
+			return object.GetNamespace() == r.Node
+		})).
+		Complete(r)
+}
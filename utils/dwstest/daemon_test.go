@@ -0,0 +1,69 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwstest
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/clientmount"
+)
+
+func TestFakeActuator(t *testing.T) {
+	actuator := &FakeActuator{}
+	clientMount := NewClientMount("compute-0", "job-1").TmpfsMount("/mnt/scratch", "").Build()
+	clientmount.InitStatus(clientMount)
+	key := types.NamespacedName{Name: "job-1", Namespace: "compute-0"}
+
+	if _, err := actuator.Actuate(context.TODO(), clientMount); err != nil || clientMount.Status.ReadyCount != 1 {
+		t.Errorf("Mounts are not ready: %v", err)
+	}
+
+	actuator.SetError(dwsv1alpha1.NewResourceError("mount failed", nil))
+	if _, err := actuator.Actuate(context.TODO(), clientMount); err != nil || clientMount.Status.ReadyCount != 0 || clientMount.Status.Error == nil {
+		t.Errorf("Error is not reported in the status: %v", err)
+	}
+
+	actuator.SetError(nil)
+	if _, err := actuator.Actuate(context.TODO(), clientMount); err != nil || clientMount.Status.Error != nil {
+		t.Errorf("Error is not cleared: %v", err)
+	}
+
+	if actuator.Actuated(key) != 3 {
+		t.Errorf("Expected 3 actuations, not %d", actuator.Actuated(key))
+	}
+
+	actuator.SetHoldRelease(true)
+	if res, _ := actuator.Release(context.TODO(), clientMount); res.IsZero() {
+		t.Errorf("Held release is not requeued")
+	}
+
+	actuator.SetHoldRelease(false)
+	if res, _ := actuator.Release(context.TODO(), clientMount); !res.IsZero() {
+		t.Errorf("Release is requeued")
+	}
+
+	if actuator.Released(key) != 2 {
+		t.Errorf("Expected 2 releases, not %d", actuator.Released(key))
+	}
+}
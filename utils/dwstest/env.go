@@ -0,0 +1,190 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dwstest helps driver repositories test against DWS. It starts an envtest API
+// server with the DWS custom resources installed, builds ClientMount and Storage fixtures,
+// and provides a fake node daemon that actuates ClientMounts without touching the node.
+package dwstest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+// dwsModule is the module path used to find the DWS configuration in the module cache
+const dwsModule = "github.com/HewlettPackard/dws"
+
+// Options configures the test environment
+type Options struct {
+	// ConfigDir is the DWS config directory holding crd/bases and webhook. It's found
+	// with FindConfigDir if empty.
+	ConfigDir string
+
+	// CRDDirectoryPaths are installed along with the DWS CRDs, such as the driver's own
+	// custom resources
+	CRDDirectoryPaths []string
+
+	// Webhooks installs the DWS webhook configuration. The webhooks are only served by a
+	// manager from NewManager.
+	Webhooks bool
+}
+
+// Environment is a running envtest API server with the DWS custom resources installed
+type Environment struct {
+	*envtest.Environment
+
+	Config  *rest.Config
+	Scheme  *runtime.Scheme
+	Client  client.Client
+	options Options
+}
+
+// Start starts the API server and installs the CRDs. Call Stop when the tests are done.
+func Start(options Options) (*Environment, error) {
+	if options.ConfigDir == "" {
+		configDir, err := FindConfigDir()
+		if err != nil {
+			return nil, err
+		}
+
+		options.ConfigDir = configDir
+	}
+
+	scheme, err := dwsv1alpha1.NewScheme()
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{
+		Environment: &envtest.Environment{
+			Scheme:                scheme,
+			CRDDirectoryPaths:     append([]string{filepath.Join(options.ConfigDir, "crd", "bases")}, options.CRDDirectoryPaths...),
+			ErrorIfCRDPathMissing: true,
+		},
+		Scheme:  scheme,
+		options: options,
+	}
+
+	if options.Webhooks {
+		env.WebhookInstallOptions.Paths = []string{filepath.Join(options.ConfigDir, "webhook")}
+	}
+
+	env.Config, err = env.Environment.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	env.Client, err = client.New(env.Config, client.Options{Scheme: scheme})
+	if err != nil {
+		env.Environment.Stop()
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// NewManager returns a manager for the environment. The scheme and webhook server settings
+// are filled in, the metrics server is disabled, and the DWS webhooks are registered if
+// the environment was started with them.
+func (e *Environment) NewManager(options ctrl.Options) (ctrl.Manager, error) {
+	if options.Scheme == nil {
+		options.Scheme = e.Scheme
+	}
+
+	if options.MetricsBindAddress == "" {
+		options.MetricsBindAddress = "0"
+	}
+
+	if e.options.Webhooks {
+		options.Host = e.WebhookInstallOptions.LocalServingHost
+		options.Port = e.WebhookInstallOptions.LocalServingPort
+		options.CertDir = e.WebhookInstallOptions.LocalServingCertDir
+	}
+
+	mgr, err := ctrl.NewManager(e.Config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.options.Webhooks {
+		if err := (&dwsv1alpha1.Workflow{}).SetupWebhookWithManager(mgr); err != nil {
+			return nil, err
+		}
+
+		if err := (&dwsv1alpha1.ClientMount{}).SetupWebhookWithManager(mgr); err != nil {
+			return nil, err
+		}
+	}
+
+	return mgr, nil
+}
+
+// FindConfigDir returns the DWS config directory. The working directory and its parents
+// are searched for this repository's config directory or a vendored copy of it, then the
+// module cache is asked for the DWS module.
+func FindConfigDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		for _, candidate := range []string{
+			filepath.Join(dir, "config"),
+			filepath.Join(dir, "vendor", filepath.FromSlash(dwsModule), "config"),
+		} {
+			if isConfigDir(candidate) {
+				return candidate, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	output, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", dwsModule).Output()
+	if err == nil {
+		candidate := filepath.Join(strings.TrimSpace(string(output)), "config")
+		if isConfigDir(candidate) {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find the DWS config directory; set Options.ConfigDir")
+}
+
+// isConfigDir returns whether dir holds the DWS CRDs
+func isConfigDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "crd", "bases", "dws.cray.hpe.com_clientmounts.yaml"))
+	return err == nil
+}
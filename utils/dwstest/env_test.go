@@ -0,0 +1,36 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwstest
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFindConfigDir(t *testing.T) {
+	configDir, err := FindConfigDir()
+	if err != nil {
+		t.Fatalf("Config directory not found: %v", err)
+	}
+
+	if filepath.Base(configDir) != "config" || !isConfigDir(configDir) {
+		t.Errorf("Unexpected config directory %s", configDir)
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwstest
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+	"github.com/HewlettPackard/dws/utils/status"
+)
+
+// ClientMountBuilder builds ClientMount fixtures. The calls can be chained:
+//
+//	clientMount := dwstest.NewClientMount("compute-0", "job-1").TmpfsMount("/mnt/scratch", "1g").Build()
+type ClientMountBuilder struct {
+	clientMount *dwsv1alpha1.ClientMount
+}
+
+// NewClientMount returns a builder for a mounted ClientMount in the namespace of the node.
+// It has no mounts until they're added.
+func NewClientMount(node string, name string) *ClientMountBuilder {
+	return &ClientMountBuilder{
+		clientMount: &dwsv1alpha1.ClientMount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: node,
+			},
+			Spec: dwsv1alpha1.ClientMountSpec{
+				Node:         node,
+				DesiredState: dwsv1alpha1.ClientMountStateMounted,
+				Mounts:       []dwsv1alpha1.ClientMountInfo{},
+			},
+		},
+	}
+}
+
+// Mount adds a mount to the ClientMount
+func (b *ClientMountBuilder) Mount(mount dwsv1alpha1.ClientMountInfo) *ClientMountBuilder {
+	b.clientMount.Spec.Mounts = append(b.clientMount.Spec.Mounts, mount)
+	return b
+}
+
+// LustreMount adds a mount of a Lustre file system
+func (b *ClientMountBuilder) LustreMount(mountPath string, fileSystemName string, mgsAddresses string) *ClientMountBuilder {
+	return b.Mount(dwsv1alpha1.ClientMountInfo{
+		MountPath:  mountPath,
+		Type:       dwsv1alpha1.FileSystemTypeLustre,
+		TargetType: dwsv1alpha1.TargetTypeDirectory,
+		Device: dwsv1alpha1.ClientMountDevice{
+			Type: dwsv1alpha1.ClientMountDeviceTypeLustre,
+			Lustre: &dwsv1alpha1.ClientMountDeviceLustre{
+				FileSystemName: fileSystemName,
+				MgsAddresses:   mgsAddresses,
+			},
+		},
+	})
+}
+
+// TmpfsMount adds a mount of a tmpfs. The kernel default size is used if size is empty.
+func (b *ClientMountBuilder) TmpfsMount(mountPath string, size string) *ClientMountBuilder {
+	return b.Mount(dwsv1alpha1.ClientMountInfo{
+		MountPath:  mountPath,
+		Type:       dwsv1alpha1.FileSystemTypeTmpfs,
+		TargetType: dwsv1alpha1.TargetTypeDirectory,
+		Device: dwsv1alpha1.ClientMountDevice{
+			Type:  dwsv1alpha1.ClientMountDeviceTypeTmpfs,
+			Tmpfs: &dwsv1alpha1.ClientMountDeviceTmpfs{Size: size},
+		},
+	})
+}
+
+// DesiredState sets the desired state of the mounts
+func (b *ClientMountBuilder) DesiredState(state dwsv1alpha1.ClientMountState) *ClientMountBuilder {
+	b.clientMount.Spec.DesiredState = state
+	return b
+}
+
+// DryRun makes the ClientMount a dry run
+func (b *ClientMountBuilder) DryRun() *ClientMountBuilder {
+	b.clientMount.Spec.DryRun = true
+	return b
+}
+
+// Labels adds labels to the ClientMount
+func (b *ClientMountBuilder) Labels(labels map[string]string) *ClientMountBuilder {
+	if b.clientMount.Labels == nil {
+		b.clientMount.Labels = map[string]string{}
+	}
+
+	for key, value := range labels {
+		b.clientMount.Labels[key] = value
+	}
+
+	return b
+}
+
+// Build returns a copy of the ClientMount, so the builder can be reused for similar fixtures
+func (b *ClientMountBuilder) Build() *dwsv1alpha1.ClientMount {
+	return b.clientMount.DeepCopy()
+}
+
+// StorageBuilder builds Storage fixtures. The calls can be chained:
+//
+//	storage := dwstest.NewStorage("rabbit-0", "default").Capacity(1<<40).Compute("compute-0", "Ready").Build()
+type StorageBuilder struct {
+	storage *dwsv1alpha1.Storage
+}
+
+// NewStorage returns a builder for a Ready NVMe Storage labeled as a DWS storage
+func NewStorage(name string, namespace string) *StorageBuilder {
+	return &StorageBuilder{
+		storage: &dwsv1alpha1.Storage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{dwsv1alpha1.StorageTypeLabel: "Rabbit"},
+			},
+			Data: dwsv1alpha1.StorageData{
+				Type:   "NVMe",
+				Status: string(status.Ready),
+				Access: dwsv1alpha1.StorageAccess{
+					Protocol: "PCIe",
+					Servers:  []dwsv1alpha1.Node{{Name: name, Status: string(status.Ready)}},
+				},
+			},
+		},
+	}
+}
+
+// Capacity sets the number of bytes the storage provides
+func (b *StorageBuilder) Capacity(bytes int64) *StorageBuilder {
+	b.storage.Data.Capacity = bytes
+	return b
+}
+
+// Status sets the overall status of the storage
+func (b *StorageBuilder) Status(status string) *StorageBuilder {
+	b.storage.Data.Status = status
+	return b
+}
+
+// Compute adds a compute node with access to the storage
+func (b *StorageBuilder) Compute(name string, status string) *StorageBuilder {
+	b.storage.Data.Access.Computes = append(b.storage.Data.Access.Computes, dwsv1alpha1.Node{Name: name, Status: status})
+	return b
+}
+
+// Device adds a physical device to the storage. Its capacity is added to the storage.
+func (b *StorageBuilder) Device(serialNumber string, capacity int64) *StorageBuilder {
+	b.storage.Data.Devices = append(b.storage.Data.Devices, dwsv1alpha1.StorageDevice{
+		Model:        "NVMe",
+		SerialNumber: serialNumber,
+		Capacity:     capacity,
+		Status:       string(status.Ready),
+	})
+	b.storage.Data.Capacity += capacity
+	return b
+}
+
+// Build returns a copy of the Storage, so the builder can be reused for similar fixtures
+func (b *StorageBuilder) Build() *dwsv1alpha1.Storage {
+	return b.storage.DeepCopy()
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dwstest
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	dwsv1alpha1 "github.com/HewlettPackard/dws/api/v1alpha1"
+)
+
+func TestClientMountBuilder(t *testing.T) {
+	builder := NewClientMount("compute-0", "job-1").
+		TmpfsMount("/mnt/scratch", "1g").
+		LustreMount("/lus/global", "global", "10.1.1.1@tcp")
+
+	clientMount := builder.Build()
+	if clientMount.Namespace != "compute-0" || clientMount.Spec.Node != "compute-0" {
+		t.Errorf("ClientMount is not in the namespace of the node: %s %s", clientMount.Namespace, clientMount.Spec.Node)
+	}
+
+	if len(clientMount.Spec.Mounts) != 2 || clientMount.Spec.DesiredState != dwsv1alpha1.ClientMountStateMounted {
+		t.Errorf("Unexpected spec %+v", clientMount.Spec)
+	}
+
+	for i := range clientMount.Spec.Mounts {
+		if err := clientMount.Spec.Mounts[i].ValidateCommandFields(field.NewPath("spec").Child("mounts").Index(i)); err != nil {
+			t.Errorf("Fixture has invalid fields: %v", err)
+		}
+	}
+
+	// Changing a built fixture doesn't change the builder
+	clientMount.Spec.Mounts = nil
+	if len(builder.DryRun().Build().Spec.Mounts) != 2 {
+		t.Errorf("Builder was changed through a built ClientMount")
+	}
+}
+
+func TestStorageBuilder(t *testing.T) {
+	storage := NewStorage("rabbit-0", "default").
+		Device("S0", 1<<30).
+		Device("S1", 1<<30).
+		Compute("compute-0", "Ready").
+		Build()
+
+	if storage.Data.Capacity != 2<<30 || len(storage.Data.Devices) != 2 {
+		t.Errorf("Unexpected capacity %d of %d devices", storage.Data.Capacity, len(storage.Data.Devices))
+	}
+
+	if len(storage.Data.Access.Computes) != 1 || storage.Data.Access.Servers[0].Name != "rabbit-0" {
+		t.Errorf("Unexpected access %+v", storage.Data.Access)
+	}
+
+	if storage.Labels[dwsv1alpha1.StorageTypeLabel] == "" {
+		t.Errorf("Storage is missing the storage type label")
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package status rolls the states of the members of a group, such as the mounts of a
+// ClientMount or the Storage resources of a pool, up into the state of the group. The states
+// are the ones the Storage resources report, plus Degraded for a group that is still usable
+// with some of its members down.
+//
+// The state of a group is the worst state of its members. A Policy can let a group with
+// enough ready members be Degraded rather than take the state of its worst member.
+package status
+
+// State is the state of a member or a group
+type State string
+
+// The states, from best to worst
+const (
+	Ready      State = "Ready"
+	Starting   State = "Starting"
+	Degraded   State = "Degraded"
+	Disabled   State = "Disabled"
+	NotPresent State = "NotPresent"
+	Offline    State = "Offline"
+	Failed     State = "Failed"
+)
+
+// severities orders the states from best to worst
+var severities = map[State]int{
+	Ready:      0,
+	Starting:   1,
+	Degraded:   2,
+	Disabled:   3,
+	NotPresent: 4,
+	Offline:    5,
+	Failed:     6,
+}
+
+// Severity returns the rank of the state, where a higher rank is worse. A state that isn't
+// known, including an empty state, ranks with Failed.
+func Severity(state State) int {
+	severity, found := severities[state]
+	if !found {
+		return severities[Failed]
+	}
+
+	return severity
+}
+
+// IsUsable returns whether a member in the state can be used, perhaps with reduced service
+func IsUsable(state State) bool {
+	return state == Ready || state == Degraded
+}
+
+// FromReady returns Ready for a member that's ready and the notReady state otherwise
+func FromReady(ready bool, notReady State) State {
+	if ready {
+		return Ready
+	}
+
+	return notReady
+}
+
+// Worst returns the worst of the states, or Ready if there are none
+func Worst(states ...State) State {
+	worst := Ready
+	for _, state := range states {
+		if Severity(state) > Severity(worst) {
+			worst = state
+		}
+	}
+
+	return worst
+}
+
+// Policy defines when a group whose worst member is down is only Degraded
+type Policy struct {
+	// MinReady is the number of ready members the group needs to be Degraded rather than
+	// take the state of its worst member. Zero means the group always takes the state of its
+	// worst member.
+	MinReady int
+}
+
+// Summary is the roll-up of the states of a group's members
+type Summary struct {
+	// Total is the number of members
+	Total int
+
+	// Ready is the number of members that are Ready
+	Ready int
+
+	// Degraded is the number of members that are Degraded
+	Degraded int
+
+	// State is the state of the group. An empty group is NotPresent.
+	State State
+}
+
+// AllReady returns whether the group has members and all of them are ready
+func (s Summary) AllReady() bool {
+	return s.Total != 0 && s.Ready == s.Total
+}
+
+// Summarize rolls the states of a group's members up into the state of the group
+func Summarize(states []State, policy Policy) Summary {
+	summary := Summary{Total: len(states)}
+	if summary.Total == 0 {
+		summary.State = NotPresent
+		return summary
+	}
+
+	for _, state := range states {
+		switch state {
+		case Ready:
+			summary.Ready++
+		case Degraded:
+			summary.Degraded++
+		}
+	}
+
+	summary.State = Worst(states...)
+	if Severity(summary.State) > Severity(Degraded) && policy.MinReady > 0 && summary.Ready >= policy.MinReady {
+		summary.State = Degraded
+	}
+
+	return summary
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import "testing"
+
+func TestSeverity(t *testing.T) {
+	order := []State{Ready, Starting, Degraded, Disabled, NotPresent, Offline, Failed}
+	for i := 1; i < len(order); i++ {
+		if Severity(order[i-1]) >= Severity(order[i]) {
+			t.Errorf("%s is not better than %s", order[i-1], order[i])
+		}
+	}
+
+	for _, state := range []State{"", "Unknown"} {
+		if Severity(state) != Severity(Failed) {
+			t.Errorf("Unknown state '%s' does not rank with Failed", state)
+		}
+	}
+}
+
+func TestIsUsable(t *testing.T) {
+	tests := []struct {
+		state  State
+		usable bool
+	}{
+		{Ready, true},
+		{Degraded, true},
+		{Starting, false},
+		{Disabled, false},
+		{NotPresent, false},
+		{Offline, false},
+		{Failed, false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if IsUsable(test.state) != test.usable {
+			t.Errorf("IsUsable(%s) is not %t", test.state, test.usable)
+		}
+	}
+}
+
+func TestFromReady(t *testing.T) {
+	if FromReady(true, Starting) != Ready {
+		t.Errorf("Ready member is not Ready")
+	}
+
+	if FromReady(false, Starting) != Starting {
+		t.Errorf("Member that isn't ready does not have the given state")
+	}
+}
+
+func TestWorst(t *testing.T) {
+	tests := []struct {
+		states []State
+		worst  State
+	}{
+		{nil, Ready},
+		{[]State{Ready}, Ready},
+		{[]State{Ready, Starting}, Starting},
+		{[]State{Starting, Degraded, Ready}, Degraded},
+		{[]State{Disabled, Degraded}, Disabled},
+		{[]State{Offline, NotPresent}, Offline},
+		{[]State{Failed, Offline, Ready}, Failed},
+		{[]State{Ready, "Unknown"}, "Unknown"},
+		{[]State{Failed, "Unknown"}, Failed},
+	}
+
+	for _, test := range tests {
+		if worst := Worst(test.states...); worst != test.worst {
+			t.Errorf("Worst of %v is %s, expected %s", test.states, worst, test.worst)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name     string
+		states   []State
+		policy   Policy
+		expected Summary
+		allReady bool
+	}{
+		{
+			name:     "empty",
+			expected: Summary{State: NotPresent},
+		},
+		{
+			name:     "all ready",
+			states:   []State{Ready, Ready},
+			expected: Summary{Total: 2, Ready: 2, State: Ready},
+			allReady: true,
+		},
+		{
+			name:     "starting",
+			states:   []State{Ready, Starting},
+			policy:   Policy{MinReady: 1},
+			expected: Summary{Total: 2, Ready: 1, State: Starting},
+		},
+		{
+			name:     "degraded member",
+			states:   []State{Ready, Degraded},
+			expected: Summary{Total: 2, Ready: 1, Degraded: 1, State: Degraded},
+		},
+		{
+			name:     "worst of",
+			states:   []State{Ready, Ready, Failed},
+			expected: Summary{Total: 3, Ready: 2, State: Failed},
+		},
+		{
+			name:     "degraded by policy",
+			states:   []State{Ready, Ready, Failed},
+			policy:   Policy{MinReady: 2},
+			expected: Summary{Total: 3, Ready: 2, State: Degraded},
+		},
+		{
+			name:     "too few ready for policy",
+			states:   []State{Ready, Offline, Failed},
+			policy:   Policy{MinReady: 2},
+			expected: Summary{Total: 3, Ready: 1, State: Failed},
+		},
+		{
+			name:     "degraded members don't count as ready",
+			states:   []State{Degraded, Degraded, Offline},
+			policy:   Policy{MinReady: 1},
+			expected: Summary{Total: 3, Degraded: 2, State: Offline},
+		},
+		{
+			name:     "none ready",
+			states:   []State{Offline, Disabled},
+			policy:   Policy{MinReady: 1},
+			expected: Summary{Total: 2, State: Offline},
+		},
+		{
+			name:     "unknown state",
+			states:   []State{Ready, ""},
+			policy:   Policy{MinReady: 1},
+			expected: Summary{Total: 2, Ready: 1, State: Degraded},
+		},
+	}
+
+	for _, test := range tests {
+		summary := Summarize(test.states, test.policy)
+		if summary != test.expected {
+			t.Errorf("%s: summary is %+v, expected %+v", test.name, summary, test.expected)
+		}
+
+		if summary.AllReady() != test.allReady {
+			t.Errorf("%s: AllReady is not %t", test.name, test.allReady)
+		}
+	}
+}
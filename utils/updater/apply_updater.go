@@ -0,0 +1,88 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package updater
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// CloseWithStatusApply will apply the resource's status with server-side apply if any of the
+// status fields have changed from the initially recorded status. The fieldManager names the
+// controller that owns the applied fields. Unlike an update, the apply doesn't depend on the
+// version of the resource, so it doesn't fail with a conflict when another controller has
+// changed the resource. The applied fields are taken over from any other field manager.
+// A resource that's being deleted is gone once its last finalizer is removed, so a
+// not-found error from the apply is ignored for it.
+func (updater *statusUpdater[S]) CloseWithStatusApply(ctx context.Context, c client.Client, fieldManager string, err error) error {
+	if reflect.DeepEqual(updater.resource.GetStatus(), updater.status) {
+		return err
+	}
+
+	applyError := ApplyStatus(ctx, c, updater.resource, updater.resource.GetStatus(), fieldManager)
+	if !updater.resource.GetDeletionTimestamp().IsZero() && len(updater.resource.GetFinalizers()) == 0 {
+		applyError = client.IgnoreNotFound(applyError)
+	}
+
+	// Do not override the original error if present
+	if err == nil {
+		return applyError
+	}
+
+	return err
+}
+
+// ApplyStatus applies the status of the resource with server-side apply. Only the name,
+// namespace, and status are sent, so the field manager doesn't take ownership of any other
+// part of the resource. The resource version of rsrc is updated from the response.
+func ApplyStatus(ctx context.Context, c client.Client, rsrc client.Object, status any, fieldManager string) error {
+	gvk, err := apiutil.GVKForObject(rsrc, c.Scheme())
+	if err != nil {
+		return err
+	}
+
+	statusMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(rsrc.GetName())
+	obj.SetNamespace(rsrc.GetNamespace())
+	obj.Object["status"] = statusMap
+
+	err = c.Status().Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership)
+	notify(rsrc, "status", err)
+	if err != nil {
+		return err
+	}
+
+	if resourceVersion := obj.GetResourceVersion(); resourceVersion != "" {
+		rsrc.SetResourceVersion(resourceVersion)
+	}
+
+	return nil
+}
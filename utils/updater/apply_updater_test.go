@@ -0,0 +1,162 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package updater
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applyClient records the status patches sent to it
+type applyClient struct {
+	client.Client
+
+	scheme   *runtime.Scheme
+	patches  []*unstructured.Unstructured
+	options  []client.PatchOptions
+	notFound bool
+}
+
+func newApplyClient() *applyClient {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypes(schema.GroupVersion{Group: "test.dws.cray.hpe.com", Version: "v1"}, &retryObject{})
+
+	return &applyClient{scheme: scheme}
+}
+
+func (c *applyClient) Scheme() *runtime.Scheme { return c.scheme }
+
+func (c *applyClient) Status() client.StatusWriter { return &applyStatusWriter{c: c} }
+
+type applyStatusWriter struct {
+	client.StatusWriter
+	c *applyClient
+}
+
+func (w *applyStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch.Type() != types.ApplyPatchType {
+		return errors.Errorf("unexpected patch type %s", patch.Type())
+	}
+
+	if w.c.notFound {
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "test"}, obj.GetName())
+	}
+
+	options := client.PatchOptions{}
+	options.ApplyOptions(opts)
+
+	w.c.patches = append(w.c.patches, obj.(*unstructured.Unstructured))
+	w.c.options = append(w.c.options, options)
+
+	obj.SetResourceVersion("2")
+	return nil
+}
+
+func TestStatusApply(t *testing.T) {
+	c := newApplyClient()
+
+	obj := &retryObject{}
+	obj.Name = "test"
+	obj.Namespace = "default"
+
+	updater := NewStatusUpdater[*retryStatus](obj)
+	obj.status.Value = "changed"
+
+	if err := updater.CloseWithStatusApply(context.TODO(), c, "test-controller", nil); err != nil {
+		t.Fatalf("Close returned unexpected error %v", err)
+	}
+
+	if len(c.patches) != 1 {
+		t.Fatalf("Expected 1 patch, not %d", len(c.patches))
+	}
+
+	patch := c.patches[0]
+	if patch.GetKind() != "retryObject" || patch.GetAPIVersion() != "test.dws.cray.hpe.com/v1" {
+		t.Errorf("Patch has unexpected type %s %s", patch.GetAPIVersion(), patch.GetKind())
+	}
+
+	if patch.GetName() != "test" || patch.GetNamespace() != "default" {
+		t.Errorf("Patch has unexpected name %s/%s", patch.GetNamespace(), patch.GetName())
+	}
+
+	value, _, _ := unstructured.NestedString(patch.Object, "status", "value")
+	if value != "changed" {
+		t.Errorf("Patch does not contain the status: %v", patch.Object)
+	}
+
+	options := c.options[0]
+	if options.FieldManager != "test-controller" || options.Force == nil || !*options.Force {
+		t.Errorf("Patch has unexpected options %+v", options)
+	}
+
+	if obj.ResourceVersion != "2" {
+		t.Errorf("Resource version was not updated from the response")
+	}
+}
+
+func TestNoStatusApply(t *testing.T) {
+	c := newApplyClient()
+
+	obj := &retryObject{}
+	updater := NewStatusUpdater[*retryStatus](obj)
+
+	err := errors.Errorf("err")
+	if applyErr := updater.CloseWithStatusApply(context.TODO(), c, "test-controller", err); applyErr != err {
+		t.Errorf("Close expected error %v, not %v", err, applyErr)
+	}
+
+	if len(c.patches) != 0 {
+		t.Errorf("Unchanged status was applied")
+	}
+}
+
+func TestStatusApplyDeleted(t *testing.T) {
+	c := newApplyClient()
+	c.notFound = true
+
+	obj := &retryObject{}
+	obj.Name = "test"
+
+	updater := NewStatusUpdater[*retryStatus](obj)
+	obj.status.Value = "changed"
+
+	// The resource still exists while it holds a finalizer
+	now := metav1.Now()
+	obj.DeletionTimestamp = &now
+	obj.Finalizers = []string{"test"}
+	if err := updater.CloseWithStatusApply(context.TODO(), c, "test-controller", nil); !apierrors.IsNotFound(err) {
+		t.Errorf("Close expected a not-found error, not %v", err)
+	}
+
+	// It's gone once the last finalizer is removed
+	obj.Finalizers = nil
+	if err := updater.CloseWithStatusApply(context.TODO(), c, "test-controller", nil); err != nil {
+		t.Errorf("Close returned unexpected error %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package updater
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RetryBackoff is the backoff used when retrying an update after a resource conflict
+var RetryBackoff wait.Backoff = retry.DefaultBackoff
+
+// UpdateWithRetry applies the mutate function to the resource and updates it. If the update
+// fails due to a resource conflict, the latest version of the resource is fetched, the mutate
+// function is applied again, and the update is retried with backoff.
+func UpdateWithRetry[T client.Object](ctx context.Context, c client.Client, rsrc T, mutate func(T) error) error {
+	return updateWithRetry(ctx, c, c, "", rsrc, mutate)
+}
+
+// StatusUpdateWithRetry is the same as UpdateWithRetry except only the status of the
+// resource is updated.
+func StatusUpdateWithRetry[T client.Object](ctx context.Context, c client.Client, rsrc T, mutate func(T) error) error {
+	return updateWithRetry(ctx, c, c.Status(), "status", rsrc, mutate)
+}
+
+func updateWithRetry[T client.Object](ctx context.Context, r client.Reader, c clientUpdater, subresource string, rsrc T, mutate func(T) error) error {
+	refetch := false
+
+	return retry.RetryOnConflict(RetryBackoff, func() error {
+		if refetch {
+			if err := r.Get(ctx, client.ObjectKeyFromObject(rsrc), rsrc); err != nil {
+				return err
+			}
+		}
+		refetch = true
+
+		if err := mutate(rsrc); err != nil {
+			return err
+		}
+
+		err := c.Update(ctx, rsrc)
+		notify(rsrc, subresource, err)
+
+		return err
+	})
+}
+
+// CloseWithUpdateRetry will attempt to update the resource if any of the status fields have
+// changed from the initially recorded status. Unlike CloseWithUpdate, a resource conflict is
+// not ignored. The status changes are applied to the latest version of the resource and the
+// update is retried.
+func (updater *statusUpdater[S]) CloseWithUpdateRetry(ctx context.Context, c client.Client, err error) error {
+	return updater.closeWithRetry(ctx, c, c, "", err)
+}
+
+// CloseWithStatusUpdateRetry will attempt to update the resource's status if any of the status
+// fields have changed from the initially recorded status. Unlike CloseWithStatusUpdate, a
+// resource conflict is not ignored. The status changes are applied to the latest version of
+// the resource and the update is retried.
+func (updater *statusUpdater[S]) CloseWithStatusUpdateRetry(ctx context.Context, c client.Client, err error) error {
+	return updater.closeWithRetry(ctx, c, c.Status(), "status", err)
+}
+
+func (updater *statusUpdater[S]) closeWithRetry(ctx context.Context, r client.Reader, c clientUpdater, subresource string, err error) error {
+	if reflect.DeepEqual(updater.resource.GetStatus(), updater.status) {
+		return err
+	}
+
+	status := updater.resource.GetStatus().DeepCopy()
+
+	updateError := updateWithRetry(ctx, r, c, subresource, updater.resource, func(rsrc resource[S]) error {
+		// Overwrite the status of the fetched resource with the status changes
+		reflect.ValueOf(rsrc.GetStatus()).Elem().Set(reflect.ValueOf(status).Elem())
+		return nil
+	})
+
+	// Do not override the original error if present
+	if err == nil {
+		return updateError
+	}
+
+	return err
+}
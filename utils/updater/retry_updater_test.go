@@ -0,0 +1,213 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package updater
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type retryObject struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta
+
+	status retryStatus
+}
+
+func (obj *retryObject) DeepCopyObject() runtime.Object {
+	out := *obj
+	return &out
+}
+
+func (obj *retryObject) GetStatus() Status[*retryStatus] {
+	return &obj.status
+}
+
+type retryStatus struct {
+	Value string
+	Other string
+}
+
+func (in *retryStatus) DeepCopy() *retryStatus {
+	out := new(retryStatus)
+	*out = *in
+	return out
+}
+
+// retryClient is a client holding a single "server side" copy of a retryObject. Updates
+// return a conflict until the configured number of conflicts has been returned.
+type retryClient struct {
+	client.Client
+
+	stored    retryObject
+	conflicts int
+	gets      int
+	updates   int
+}
+
+func (c *retryClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.gets++
+	*obj.(*retryObject) = c.stored
+	return nil
+}
+
+func (c *retryClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return c.update(obj)
+}
+
+func (c *retryClient) Status() client.StatusWriter { return &retryStatusWriter{c: c} }
+
+func (c *retryClient) update(obj client.Object) error {
+	c.updates++
+	if c.conflicts > 0 {
+		c.conflicts--
+		return apierrors.NewConflict(schema.GroupResource{Resource: "test"}, obj.GetName(), errors.Errorf("conflict"))
+	}
+
+	c.stored = *obj.(*retryObject)
+	return nil
+}
+
+type retryStatusWriter struct {
+	client.StatusWriter
+	c *retryClient
+}
+
+func (w *retryStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return w.c.update(obj)
+}
+
+func init() {
+	RetryBackoff = wait.Backoff{Steps: 3}
+}
+
+func TestStatusUpdateRetry(t *testing.T) {
+	c := &retryClient{conflicts: 2}
+	c.stored.Name = "test"
+
+	obj := &retryObject{}
+	_ = c.Get(context.TODO(), client.ObjectKey{}, obj)
+
+	updater := NewStatusUpdater[*retryStatus](obj)
+	obj.status.Value = "changed"
+
+	// Another client changes the resource before the update
+	c.stored.Annotations = map[string]string{"other": "other"}
+	c.stored.status.Other = "other"
+
+	if err := updater.CloseWithStatusUpdateRetry(context.TODO(), c, nil); err != nil {
+		t.Fatalf("Close returned unexpected error %v", err)
+	}
+
+	if c.updates != 3 {
+		t.Errorf("Expected 3 updates, not %d", c.updates)
+	}
+
+	if c.stored.status.Value != "changed" {
+		t.Errorf("Status change was not applied to the stored resource")
+	}
+
+	// The status overwrites the stored status while the rest of the
+	// resource comes from the refetched version
+	if c.stored.status.Other != "" {
+		t.Errorf("Status was not overwritten by the updater")
+	}
+
+	if c.stored.Annotations["other"] != "other" {
+		t.Errorf("Refetched resource was not used for the update")
+	}
+}
+
+func TestStatusUpdateRetryExhausted(t *testing.T) {
+	c := &retryClient{conflicts: 10}
+
+	obj := &retryObject{}
+	updater := NewStatusUpdater[*retryStatus](obj)
+	obj.status.Value = "changed"
+
+	if err := updater.CloseWithStatusUpdateRetry(context.TODO(), c, nil); !apierrors.IsConflict(err) {
+		t.Errorf("Close expected conflict error, not %v", err)
+	}
+
+	if c.updates != RetryBackoff.Steps {
+		t.Errorf("Expected %d updates, not %d", RetryBackoff.Steps, c.updates)
+	}
+}
+
+func TestStatusUpdateRetryWithError(t *testing.T) {
+	c := &retryClient{conflicts: 10}
+
+	obj := &retryObject{}
+	updater := NewStatusUpdater[*retryStatus](obj)
+	obj.status.Value = "changed"
+
+	err := errors.Errorf("err")
+	if updateErr := updater.CloseWithStatusUpdateRetry(context.TODO(), c, err); updateErr != err {
+		t.Errorf("Close expected error %v, not %v", err, updateErr)
+	}
+}
+
+func TestNoStatusUpdateRetry(t *testing.T) {
+	c := &retryClient{}
+
+	obj := &retryObject{}
+	updater := NewStatusUpdater[*retryStatus](obj)
+
+	if err := updater.CloseWithStatusUpdateRetry(context.TODO(), c, nil); err != nil {
+		t.Errorf("Close returned unexpected error %v", err)
+	}
+
+	if c.updates != 0 {
+		t.Errorf("Unchanged status was updated")
+	}
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	c := &retryClient{conflicts: 1}
+
+	obj := &retryObject{}
+	c.stored.Labels = map[string]string{"other": "other"}
+
+	err := UpdateWithRetry(context.TODO(), c, obj, func(o *retryObject) error {
+		if o.Labels == nil {
+			o.Labels = map[string]string{}
+		}
+		o.Labels["test"] = "test"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateWithRetry returned unexpected error %v", err)
+	}
+
+	if c.gets != 1 {
+		t.Errorf("Expected 1 get, not %d", c.gets)
+	}
+
+	if c.stored.Labels["test"] != "test" || c.stored.Labels["other"] != "other" {
+		t.Errorf("Mutation was not applied to the refetched resource: %v", c.stored.Labels)
+	}
+}
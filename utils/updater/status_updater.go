@@ -75,7 +75,7 @@ func NewStatusUpdater[S Status[S]](rsrc resource[S]) *statusUpdater[S] {
 // if there is a resource conflict on this version of the resource. The reconciler will
 // already have an event queued for the new version of the resource.
 func (updater *statusUpdater[S]) CloseWithUpdate(ctx context.Context, c client.Writer, err error) error {
-	return updater.close(ctx, c, err)
+	return updater.close(ctx, c, "", err)
 }
 
 // CloseWithStatusUpdate will attempt to update the resource's status if any of the status
@@ -83,19 +83,20 @@ func (updater *statusUpdater[S]) CloseWithUpdate(ctx context.Context, c client.W
 // return an error if there is a resource conflict on this version of the resource. The
 // reconciler will already have an event queued for the new version of the resource.
 func (updater *statusUpdater[S]) CloseWithStatusUpdate(ctx context.Context, c client.StatusClient, err error) error {
-	return updater.close(ctx, c.Status(), err)
+	return updater.close(ctx, c.Status(), "status", err)
 }
 
 type clientUpdater interface {
 	Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error
 }
 
-func (updater *statusUpdater[S]) close(ctx context.Context, c clientUpdater, err error) error {
+func (updater *statusUpdater[S]) close(ctx context.Context, c clientUpdater, subresource string, err error) error {
 	if !reflect.DeepEqual(updater.resource.GetStatus(), updater.status) {
 
 		// Always attempt an update to the resource even in the presence of different error, but
 		// do not override the original error if present.
 		updateError := c.Update(ctx, updater.resource)
+		notify(updater.resource, subresource, updateError)
 
 		if err == nil {
 			// Do not return an error if there is a resource conflict on this version of the resource.
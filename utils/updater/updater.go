@@ -0,0 +1,168 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package updater
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// UpdateHook is called after each update made by an updater. The subresource is "status" for
+// a status update and empty for an update of the rest of the resource. A hook can count the
+// updates and their failures for metrics.
+type UpdateHook func(obj client.Object, subresource string, err error)
+
+var (
+	hooksLock sync.RWMutex
+	hooks     []UpdateHook
+)
+
+// AddUpdateHook registers a hook that's called after each update made by an updater. Hooks
+// may be added while updaters are in use.
+func AddUpdateHook(hook UpdateHook) {
+	hooksLock.Lock()
+	defer hooksLock.Unlock()
+
+	hooks = append(hooks, hook)
+}
+
+func notify(obj client.Object, subresource string, err error) {
+	hooksLock.RLock()
+	defer hooksLock.RUnlock()
+
+	for _, hook := range hooks {
+		hook(obj, subresource, err)
+	}
+}
+
+type objectUpdater[T client.Object] struct {
+	resource T
+	original map[string]interface{}
+
+	// status is true if status changes are updated
+	status bool
+}
+
+// NewSpecUpdater returns an updater that updates the resource when it's closed if anything
+// other than its status changed, such as the spec, labels, or finalizers. Changes are found
+// with a semantic comparison that ignores the resource version and managed fields.
+func NewSpecUpdater[T client.Object](rsrc T) *objectUpdater[T] {
+	return newObjectUpdater(rsrc, false)
+}
+
+// NewUpdater returns an updater that stages both spec and status changes to the resource.
+// When it's closed, the resource is updated if anything other than its status changed, and
+// then the status is updated if it changed.
+func NewUpdater[T client.Object](rsrc T) *objectUpdater[T] {
+	return newObjectUpdater(rsrc, true)
+}
+
+func newObjectUpdater[T client.Object](rsrc T, status bool) *objectUpdater[T] {
+	return &objectUpdater[T]{
+		resource: rsrc,
+		original: comparableFields(rsrc),
+		status:   status,
+	}
+}
+
+// comparableFields returns the resource as a map without the fields that change with every update
+func comparableFields(rsrc client.Object) map[string]interface{} {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(rsrc)
+	if err != nil {
+		// A resource that can't be converted is always updated
+		return nil
+	}
+
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "resourceVersion")
+		delete(metadata, "managedFields")
+	}
+
+	return obj
+}
+
+// changes returns whether the status and the rest of the resource changed
+func (updater *objectUpdater[T]) changes() (bool, bool) {
+	current := comparableFields(updater.resource)
+	if current == nil || updater.original == nil {
+		return true, true
+	}
+
+	currentStatus, originalStatus := current["status"], updater.original["status"]
+	delete(current, "status")
+
+	original := make(map[string]interface{}, len(updater.original))
+	for key, value := range updater.original {
+		if key != "status" {
+			original[key] = value
+		}
+	}
+
+	return !equality.Semantic.DeepEqual(original, current), !equality.Semantic.DeepEqual(originalStatus, currentStatus)
+}
+
+// CloseWithUpdate updates the resource and its status if they changed. Like the status
+// updater's CloseWithUpdate, a resource conflict isn't returned since the reconciler will
+// already have an event queued for the new version of the resource.
+func (updater *objectUpdater[T]) CloseWithUpdate(ctx context.Context, c client.Client, err error) error {
+	specChanged, statusChanged := updater.changes()
+	statusChanged = statusChanged && updater.status
+
+	if !specChanged && !statusChanged {
+		return err
+	}
+
+	// The update returns the stored status, so the staged status is kept in a copy
+	staged := updater.resource.DeepCopyObject().(client.Object)
+
+	var updateError error
+	if specChanged {
+		updateError = c.Update(ctx, updater.resource)
+		notify(updater.resource, "", updateError)
+	}
+
+	if statusChanged && updateError == nil {
+		staged.SetResourceVersion(updater.resource.GetResourceVersion())
+
+		updateError = c.Status().Update(ctx, staged)
+		notify(staged, "status", updateError)
+
+		if updateError == nil {
+			reflect.ValueOf(updater.resource).Elem().Set(reflect.ValueOf(staged).Elem())
+		}
+	}
+
+	// Do not override the original error if present
+	if err != nil {
+		return err
+	}
+
+	if errors.IsConflict(updateError) {
+		return nil
+	}
+
+	return updateError
+}
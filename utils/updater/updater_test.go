@@ -0,0 +1,156 @@
+/*
+ * Copyright 2022 Hewlett Packard Enterprise Development LP
+ * Other additional copyright holders may be indicated within.
+ *
+ * The entirety of this work is licensed under the Apache License,
+ * Version 2.0 (the "License"); you may not use this file except
+ * in compliance with the License.
+ *
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package updater
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type specObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   specValue `json:"spec,omitempty"`
+	Status specValue `json:"status,omitempty"`
+}
+
+type specValue struct {
+	Value string `json:"value,omitempty"`
+}
+
+func (obj *specObject) DeepCopyObject() runtime.Object {
+	out := *obj
+	obj.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// specClient stores the spec and status updates separately like the API server does for
+// a resource with a status subresource
+type specClient struct {
+	client.Client
+
+	stored        specObject
+	updates       int
+	statusUpdates int
+}
+
+func (c *specClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.updates++
+
+	status := c.stored.Status
+	c.stored = *obj.(*specObject)
+	c.stored.Status = status
+	c.stored.ResourceVersion += "1"
+
+	*obj.(*specObject) = c.stored
+	return nil
+}
+
+func (c *specClient) Status() client.StatusWriter { return &specStatusWriter{c: c} }
+
+type specStatusWriter struct {
+	client.StatusWriter
+	c *specClient
+}
+
+func (w *specStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.c.statusUpdates++
+
+	if obj.GetResourceVersion() != w.c.stored.ResourceVersion {
+		return apierrors.NewConflict(schema.GroupResource{Resource: "test"}, obj.GetName(), errors.Errorf("conflict"))
+	}
+
+	w.c.stored.Status = obj.(*specObject).Status
+	w.c.stored.ResourceVersion += "1"
+
+	*obj.(*specObject) = w.c.stored
+	return nil
+}
+
+func TestUpdaterSpecAndStatus(t *testing.T) {
+	c := &specClient{}
+	obj := &specObject{}
+
+	updates := map[string]int{}
+	AddUpdateHook(func(obj client.Object, subresource string, err error) {
+		if _, ok := obj.(*specObject); ok && err == nil {
+			updates[subresource]++
+		}
+	})
+
+	updater := NewUpdater(obj)
+	obj.Spec.Value = "spec"
+	obj.Status.Value = "status"
+
+	if err := updater.CloseWithUpdate(context.TODO(), c, nil); err != nil {
+		t.Fatalf("Close returned unexpected error %v", err)
+	}
+
+	if c.updates != 1 || c.statusUpdates != 1 {
+		t.Errorf("Expected 1 update and 1 status update, not %d and %d", c.updates, c.statusUpdates)
+	}
+
+	if c.stored.Spec.Value != "spec" || c.stored.Status.Value != "status" {
+		t.Errorf("Changes were not stored: %+v", c.stored)
+	}
+
+	if obj.Status.Value != "status" || obj.ResourceVersion != c.stored.ResourceVersion {
+		t.Errorf("Resource was not updated from the response: %+v", obj)
+	}
+
+	if updates[""] != 1 || updates["status"] != 1 {
+		t.Errorf("Hook was not called for each update: %v", updates)
+	}
+}
+
+func TestSpecUpdaterIgnoresStatus(t *testing.T) {
+	c := &specClient{}
+	obj := &specObject{}
+
+	updater := NewSpecUpdater(obj)
+	obj.Status.Value = "status"
+	obj.ResourceVersion = "2"
+	obj.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "test"}}
+
+	if err := updater.CloseWithUpdate(context.TODO(), c, nil); err != nil {
+		t.Fatalf("Close returned unexpected error %v", err)
+	}
+
+	if c.updates != 0 || c.statusUpdates != 0 {
+		t.Errorf("Unchanged spec was updated")
+	}
+
+	obj.Labels = map[string]string{"test": "test"}
+	if err := updater.CloseWithUpdate(context.TODO(), c, nil); err != nil {
+		t.Fatalf("Close returned unexpected error %v", err)
+	}
+
+	if c.updates != 1 || c.statusUpdates != 0 {
+		t.Errorf("Expected only 1 update, not %d and %d", c.updates, c.statusUpdates)
+	}
+}